@@ -0,0 +1,418 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.34.2
+// 	protoc        (unknown)
+// source: kessel/inventory/events/v1/relationship_data.proto
+
+package v1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	structpb "google.golang.org/protobuf/types/known/structpb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// RelationshipData is the data of the redhat.inventory.resources-relationship.<relationship_type>.<operation>.v1 events.
+type RelationshipData struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Metadata     *RelationshipMetadata `protobuf:"bytes,1,opt,name=metadata,proto3" json:"metadata,omitempty"`
+	ReporterData *RelationshipReporter `protobuf:"bytes,2,opt,name=reporter_data,json=reporterData,proto3" json:"reporter_data,omitempty"`
+	// The relationship_data reported for the relationship
+	ResourceData *structpb.Struct `protobuf:"bytes,3,opt,name=resource_data,json=resourceData,proto3" json:"resource_data,omitempty"`
+}
+
+func (x *RelationshipData) Reset() {
+	*x = RelationshipData{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_kessel_inventory_events_v1_relationship_data_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RelationshipData) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RelationshipData) ProtoMessage() {}
+
+func (x *RelationshipData) ProtoReflect() protoreflect.Message {
+	mi := &file_kessel_inventory_events_v1_relationship_data_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RelationshipData.ProtoReflect.Descriptor instead.
+func (*RelationshipData) Descriptor() ([]byte, []int) {
+	return file_kessel_inventory_events_v1_relationship_data_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *RelationshipData) GetMetadata() *RelationshipMetadata {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+func (x *RelationshipData) GetReporterData() *RelationshipReporter {
+	if x != nil {
+		return x.ReporterData
+	}
+	return nil
+}
+
+func (x *RelationshipData) GetResourceData() *structpb.Struct {
+	if x != nil {
+		return x.ResourceData
+	}
+	return nil
+}
+
+type RelationshipMetadata struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// The inventory id of the relationship
+	Id               string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	RelationshipType string `protobuf:"bytes,2,opt,name=relationship_type,json=relationshipType,proto3" json:"relationship_type,omitempty"`
+	// Only set by created events
+	CreatedAt *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	// Only set by updated events
+	UpdatedAt *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	// Only set by deleted events
+	DeletedAt *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=deleted_at,json=deletedAt,proto3" json:"deleted_at,omitempty"`
+}
+
+func (x *RelationshipMetadata) Reset() {
+	*x = RelationshipMetadata{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_kessel_inventory_events_v1_relationship_data_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RelationshipMetadata) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RelationshipMetadata) ProtoMessage() {}
+
+func (x *RelationshipMetadata) ProtoReflect() protoreflect.Message {
+	mi := &file_kessel_inventory_events_v1_relationship_data_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RelationshipMetadata.ProtoReflect.Descriptor instead.
+func (*RelationshipMetadata) Descriptor() ([]byte, []int) {
+	return file_kessel_inventory_events_v1_relationship_data_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *RelationshipMetadata) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *RelationshipMetadata) GetRelationshipType() string {
+	if x != nil {
+		return x.RelationshipType
+	}
+	return ""
+}
+
+func (x *RelationshipMetadata) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *RelationshipMetadata) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+func (x *RelationshipMetadata) GetDeletedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.DeletedAt
+	}
+	return nil
+}
+
+type RelationshipReporter struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ReporterType           string `protobuf:"bytes,1,opt,name=reporter_type,json=reporterType,proto3" json:"reporter_type,omitempty"`
+	SubjectLocalResourceId string `protobuf:"bytes,2,opt,name=subject_local_resource_id,json=subjectLocalResourceId,proto3" json:"subject_local_resource_id,omitempty"`
+	ObjectLocalResourceId  string `protobuf:"bytes,3,opt,name=object_local_resource_id,json=objectLocalResourceId,proto3" json:"object_local_resource_id,omitempty"`
+	ReporterVersion        string `protobuf:"bytes,4,opt,name=reporter_version,json=reporterVersion,proto3" json:"reporter_version,omitempty"`
+	ReporterInstanceId     string `protobuf:"bytes,5,opt,name=reporter_instance_id,json=reporterInstanceId,proto3" json:"reporter_instance_id,omitempty"`
+}
+
+func (x *RelationshipReporter) Reset() {
+	*x = RelationshipReporter{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_kessel_inventory_events_v1_relationship_data_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RelationshipReporter) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RelationshipReporter) ProtoMessage() {}
+
+func (x *RelationshipReporter) ProtoReflect() protoreflect.Message {
+	mi := &file_kessel_inventory_events_v1_relationship_data_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RelationshipReporter.ProtoReflect.Descriptor instead.
+func (*RelationshipReporter) Descriptor() ([]byte, []int) {
+	return file_kessel_inventory_events_v1_relationship_data_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *RelationshipReporter) GetReporterType() string {
+	if x != nil {
+		return x.ReporterType
+	}
+	return ""
+}
+
+func (x *RelationshipReporter) GetSubjectLocalResourceId() string {
+	if x != nil {
+		return x.SubjectLocalResourceId
+	}
+	return ""
+}
+
+func (x *RelationshipReporter) GetObjectLocalResourceId() string {
+	if x != nil {
+		return x.ObjectLocalResourceId
+	}
+	return ""
+}
+
+func (x *RelationshipReporter) GetReporterVersion() string {
+	if x != nil {
+		return x.ReporterVersion
+	}
+	return ""
+}
+
+func (x *RelationshipReporter) GetReporterInstanceId() string {
+	if x != nil {
+		return x.ReporterInstanceId
+	}
+	return ""
+}
+
+var File_kessel_inventory_events_v1_relationship_data_proto protoreflect.FileDescriptor
+
+var file_kessel_inventory_events_v1_relationship_data_proto_rawDesc = []byte{
+	0x0a, 0x32, 0x6b, 0x65, 0x73, 0x73, 0x65, 0x6c, 0x2f, 0x69, 0x6e, 0x76, 0x65, 0x6e, 0x74, 0x6f,
+	0x72, 0x79, 0x2f, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x73, 0x2f, 0x76, 0x31, 0x2f, 0x72, 0x65, 0x6c,
+	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x68, 0x69, 0x70, 0x5f, 0x64, 0x61, 0x74, 0x61, 0x2e, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x12, 0x1a, 0x6b, 0x65, 0x73, 0x73, 0x65, 0x6c, 0x2e, 0x69, 0x6e, 0x76,
+	0x65, 0x6e, 0x74, 0x6f, 0x72, 0x79, 0x2e, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x73, 0x2e, 0x76, 0x31,
+	0x1a, 0x1c, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75,
+	0x66, 0x2f, 0x73, 0x74, 0x72, 0x75, 0x63, 0x74, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x1a, 0x1f,
+	0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f,
+	0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22,
+	0xf5, 0x01, 0x0a, 0x10, 0x52, 0x65, 0x6c, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x68, 0x69, 0x70,
+	0x44, 0x61, 0x74, 0x61, 0x12, 0x4c, 0x0a, 0x08, 0x6d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x30, 0x2e, 0x6b, 0x65, 0x73, 0x73, 0x65, 0x6c, 0x2e,
+	0x69, 0x6e, 0x76, 0x65, 0x6e, 0x74, 0x6f, 0x72, 0x79, 0x2e, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x73,
+	0x2e, 0x76, 0x31, 0x2e, 0x52, 0x65, 0x6c, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x68, 0x69, 0x70,
+	0x4d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x52, 0x08, 0x6d, 0x65, 0x74, 0x61, 0x64, 0x61,
+	0x74, 0x61, 0x12, 0x55, 0x0a, 0x0d, 0x72, 0x65, 0x70, 0x6f, 0x72, 0x74, 0x65, 0x72, 0x5f, 0x64,
+	0x61, 0x74, 0x61, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x30, 0x2e, 0x6b, 0x65, 0x73, 0x73,
+	0x65, 0x6c, 0x2e, 0x69, 0x6e, 0x76, 0x65, 0x6e, 0x74, 0x6f, 0x72, 0x79, 0x2e, 0x65, 0x76, 0x65,
+	0x6e, 0x74, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x52, 0x65, 0x6c, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73,
+	0x68, 0x69, 0x70, 0x52, 0x65, 0x70, 0x6f, 0x72, 0x74, 0x65, 0x72, 0x52, 0x0c, 0x72, 0x65, 0x70,
+	0x6f, 0x72, 0x74, 0x65, 0x72, 0x44, 0x61, 0x74, 0x61, 0x12, 0x3c, 0x0a, 0x0d, 0x72, 0x65, 0x73,
+	0x6f, 0x75, 0x72, 0x63, 0x65, 0x5f, 0x64, 0x61, 0x74, 0x61, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x17, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62,
+	0x75, 0x66, 0x2e, 0x53, 0x74, 0x72, 0x75, 0x63, 0x74, 0x52, 0x0c, 0x72, 0x65, 0x73, 0x6f, 0x75,
+	0x72, 0x63, 0x65, 0x44, 0x61, 0x74, 0x61, 0x22, 0x84, 0x02, 0x0a, 0x14, 0x52, 0x65, 0x6c, 0x61,
+	0x74, 0x69, 0x6f, 0x6e, 0x73, 0x68, 0x69, 0x70, 0x4d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61,
+	0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x02, 0x69, 0x64,
+	0x12, 0x2b, 0x0a, 0x11, 0x72, 0x65, 0x6c, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x68, 0x69, 0x70,
+	0x5f, 0x74, 0x79, 0x70, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x10, 0x72, 0x65, 0x6c,
+	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x68, 0x69, 0x70, 0x54, 0x79, 0x70, 0x65, 0x12, 0x39, 0x0a,
+	0x0a, 0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x64, 0x5f, 0x61, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x09, 0x63,
+	0x72, 0x65, 0x61, 0x74, 0x65, 0x64, 0x41, 0x74, 0x12, 0x39, 0x0a, 0x0a, 0x75, 0x70, 0x64, 0x61,
+	0x74, 0x65, 0x64, 0x5f, 0x61, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67,
+	0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54,
+	0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x09, 0x75, 0x70, 0x64, 0x61, 0x74, 0x65,
+	0x64, 0x41, 0x74, 0x12, 0x39, 0x0a, 0x0a, 0x64, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x64, 0x5f, 0x61,
+	0x74, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65,
+	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74,
+	0x61, 0x6d, 0x70, 0x52, 0x09, 0x64, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x64, 0x41, 0x74, 0x22, 0x8c,
+	0x02, 0x0a, 0x14, 0x52, 0x65, 0x6c, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x68, 0x69, 0x70, 0x52,
+	0x65, 0x70, 0x6f, 0x72, 0x74, 0x65, 0x72, 0x12, 0x23, 0x0a, 0x0d, 0x72, 0x65, 0x70, 0x6f, 0x72,
+	0x74, 0x65, 0x72, 0x5f, 0x74, 0x79, 0x70, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c,
+	0x72, 0x65, 0x70, 0x6f, 0x72, 0x74, 0x65, 0x72, 0x54, 0x79, 0x70, 0x65, 0x12, 0x39, 0x0a, 0x19,
+	0x73, 0x75, 0x62, 0x6a, 0x65, 0x63, 0x74, 0x5f, 0x6c, 0x6f, 0x63, 0x61, 0x6c, 0x5f, 0x72, 0x65,
+	0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x5f, 0x69, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x16, 0x73, 0x75, 0x62, 0x6a, 0x65, 0x63, 0x74, 0x4c, 0x6f, 0x63, 0x61, 0x6c, 0x52, 0x65, 0x73,
+	0x6f, 0x75, 0x72, 0x63, 0x65, 0x49, 0x64, 0x12, 0x37, 0x0a, 0x18, 0x6f, 0x62, 0x6a, 0x65, 0x63,
+	0x74, 0x5f, 0x6c, 0x6f, 0x63, 0x61, 0x6c, 0x5f, 0x72, 0x65, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65,
+	0x5f, 0x69, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x15, 0x6f, 0x62, 0x6a, 0x65, 0x63,
+	0x74, 0x4c, 0x6f, 0x63, 0x61, 0x6c, 0x52, 0x65, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x49, 0x64,
+	0x12, 0x29, 0x0a, 0x10, 0x72, 0x65, 0x70, 0x6f, 0x72, 0x74, 0x65, 0x72, 0x5f, 0x76, 0x65, 0x72,
+	0x73, 0x69, 0x6f, 0x6e, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0f, 0x72, 0x65, 0x70, 0x6f,
+	0x72, 0x74, 0x65, 0x72, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x30, 0x0a, 0x14, 0x72,
+	0x65, 0x70, 0x6f, 0x72, 0x74, 0x65, 0x72, 0x5f, 0x69, 0x6e, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65,
+	0x5f, 0x69, 0x64, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x12, 0x72, 0x65, 0x70, 0x6f, 0x72,
+	0x74, 0x65, 0x72, 0x49, 0x6e, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x49, 0x64, 0x42, 0x76, 0x0a,
+	0x2a, 0x6f, 0x72, 0x67, 0x2e, 0x70, 0x72, 0x6f, 0x6a, 0x65, 0x63, 0x74, 0x5f, 0x6b, 0x65, 0x73,
+	0x73, 0x65, 0x6c, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x69, 0x6e, 0x76, 0x65, 0x6e, 0x74, 0x6f, 0x72,
+	0x79, 0x2e, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x73, 0x2e, 0x76, 0x31, 0x50, 0x01, 0x5a, 0x46, 0x67,
+	0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x70, 0x72, 0x6f, 0x6a, 0x65, 0x63,
+	0x74, 0x2d, 0x6b, 0x65, 0x73, 0x73, 0x65, 0x6c, 0x2f, 0x69, 0x6e, 0x76, 0x65, 0x6e, 0x74, 0x6f,
+	0x72, 0x79, 0x2d, 0x61, 0x70, 0x69, 0x2f, 0x61, 0x70, 0x69, 0x2f, 0x6b, 0x65, 0x73, 0x73, 0x65,
+	0x6c, 0x2f, 0x69, 0x6e, 0x76, 0x65, 0x6e, 0x74, 0x6f, 0x72, 0x79, 0x2f, 0x65, 0x76, 0x65, 0x6e,
+	0x74, 0x73, 0x2f, 0x76, 0x31, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_kessel_inventory_events_v1_relationship_data_proto_rawDescOnce sync.Once
+	file_kessel_inventory_events_v1_relationship_data_proto_rawDescData = file_kessel_inventory_events_v1_relationship_data_proto_rawDesc
+)
+
+func file_kessel_inventory_events_v1_relationship_data_proto_rawDescGZIP() []byte {
+	file_kessel_inventory_events_v1_relationship_data_proto_rawDescOnce.Do(func() {
+		file_kessel_inventory_events_v1_relationship_data_proto_rawDescData = protoimpl.X.CompressGZIP(file_kessel_inventory_events_v1_relationship_data_proto_rawDescData)
+	})
+	return file_kessel_inventory_events_v1_relationship_data_proto_rawDescData
+}
+
+var file_kessel_inventory_events_v1_relationship_data_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
+var file_kessel_inventory_events_v1_relationship_data_proto_goTypes = []any{
+	(*RelationshipData)(nil),      // 0: kessel.inventory.events.v1.RelationshipData
+	(*RelationshipMetadata)(nil),  // 1: kessel.inventory.events.v1.RelationshipMetadata
+	(*RelationshipReporter)(nil),  // 2: kessel.inventory.events.v1.RelationshipReporter
+	(*structpb.Struct)(nil),       // 3: google.protobuf.Struct
+	(*timestamppb.Timestamp)(nil), // 4: google.protobuf.Timestamp
+}
+var file_kessel_inventory_events_v1_relationship_data_proto_depIdxs = []int32{
+	1, // 0: kessel.inventory.events.v1.RelationshipData.metadata:type_name -> kessel.inventory.events.v1.RelationshipMetadata
+	2, // 1: kessel.inventory.events.v1.RelationshipData.reporter_data:type_name -> kessel.inventory.events.v1.RelationshipReporter
+	3, // 2: kessel.inventory.events.v1.RelationshipData.resource_data:type_name -> google.protobuf.Struct
+	4, // 3: kessel.inventory.events.v1.RelationshipMetadata.created_at:type_name -> google.protobuf.Timestamp
+	4, // 4: kessel.inventory.events.v1.RelationshipMetadata.updated_at:type_name -> google.protobuf.Timestamp
+	4, // 5: kessel.inventory.events.v1.RelationshipMetadata.deleted_at:type_name -> google.protobuf.Timestamp
+	6, // [6:6] is the sub-list for method output_type
+	6, // [6:6] is the sub-list for method input_type
+	6, // [6:6] is the sub-list for extension type_name
+	6, // [6:6] is the sub-list for extension extendee
+	0, // [0:6] is the sub-list for field type_name
+}
+
+func init() { file_kessel_inventory_events_v1_relationship_data_proto_init() }
+func file_kessel_inventory_events_v1_relationship_data_proto_init() {
+	if File_kessel_inventory_events_v1_relationship_data_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_kessel_inventory_events_v1_relationship_data_proto_msgTypes[0].Exporter = func(v any, i int) any {
+			switch v := v.(*RelationshipData); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_kessel_inventory_events_v1_relationship_data_proto_msgTypes[1].Exporter = func(v any, i int) any {
+			switch v := v.(*RelationshipMetadata); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_kessel_inventory_events_v1_relationship_data_proto_msgTypes[2].Exporter = func(v any, i int) any {
+			switch v := v.(*RelationshipReporter); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_kessel_inventory_events_v1_relationship_data_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   3,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_kessel_inventory_events_v1_relationship_data_proto_goTypes,
+		DependencyIndexes: file_kessel_inventory_events_v1_relationship_data_proto_depIdxs,
+		MessageInfos:      file_kessel_inventory_events_v1_relationship_data_proto_msgTypes,
+	}.Build()
+	File_kessel_inventory_events_v1_relationship_data_proto = out.File
+	file_kessel_inventory_events_v1_relationship_data_proto_rawDesc = nil
+	file_kessel_inventory_events_v1_relationship_data_proto_goTypes = nil
+	file_kessel_inventory_events_v1_relationship_data_proto_depIdxs = nil
+}
@@ -19,7 +19,8 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	KesselStreamedListService_StreamedListObjects_FullMethodName = "/kessel.inventory.v1beta2.KesselStreamedListService/StreamedListObjects"
+	KesselStreamedListService_StreamedListObjects_FullMethodName  = "/kessel.inventory.v1beta2.KesselStreamedListService/StreamedListObjects"
+	KesselStreamedListService_StreamedListSubjects_FullMethodName = "/kessel.inventory.v1beta2.KesselStreamedListService/StreamedListSubjects"
 )
 
 // KesselStreamedListServiceClient is the client API for KesselStreamedListService service.
@@ -27,6 +28,7 @@ const (
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type KesselStreamedListServiceClient interface {
 	StreamedListObjects(ctx context.Context, in *StreamedListObjectsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[StreamedListObjectsResponse], error)
+	StreamedListSubjects(ctx context.Context, in *StreamedListSubjectsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[StreamedListSubjectsResponse], error)
 }
 
 type kesselStreamedListServiceClient struct {
@@ -56,11 +58,31 @@ func (c *kesselStreamedListServiceClient) StreamedListObjects(ctx context.Contex
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
 type KesselStreamedListService_StreamedListObjectsClient = grpc.ServerStreamingClient[StreamedListObjectsResponse]
 
+func (c *kesselStreamedListServiceClient) StreamedListSubjects(ctx context.Context, in *StreamedListSubjectsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[StreamedListSubjectsResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &KesselStreamedListService_ServiceDesc.Streams[1], KesselStreamedListService_StreamedListSubjects_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[StreamedListSubjectsRequest, StreamedListSubjectsResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type KesselStreamedListService_StreamedListSubjectsClient = grpc.ServerStreamingClient[StreamedListSubjectsResponse]
+
 // KesselStreamedListServiceServer is the server API for KesselStreamedListService service.
 // All implementations must embed UnimplementedKesselStreamedListServiceServer
 // for forward compatibility.
 type KesselStreamedListServiceServer interface {
 	StreamedListObjects(*StreamedListObjectsRequest, grpc.ServerStreamingServer[StreamedListObjectsResponse]) error
+	StreamedListSubjects(*StreamedListSubjectsRequest, grpc.ServerStreamingServer[StreamedListSubjectsResponse]) error
 	mustEmbedUnimplementedKesselStreamedListServiceServer()
 }
 
@@ -74,6 +96,9 @@ type UnimplementedKesselStreamedListServiceServer struct{}
 func (UnimplementedKesselStreamedListServiceServer) StreamedListObjects(*StreamedListObjectsRequest, grpc.ServerStreamingServer[StreamedListObjectsResponse]) error {
 	return status.Errorf(codes.Unimplemented, "method StreamedListObjects not implemented")
 }
+func (UnimplementedKesselStreamedListServiceServer) StreamedListSubjects(*StreamedListSubjectsRequest, grpc.ServerStreamingServer[StreamedListSubjectsResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamedListSubjects not implemented")
+}
 func (UnimplementedKesselStreamedListServiceServer) mustEmbedUnimplementedKesselStreamedListServiceServer() {
 }
 func (UnimplementedKesselStreamedListServiceServer) testEmbeddedByValue() {}
@@ -107,6 +132,17 @@ func _KesselStreamedListService_StreamedListObjects_Handler(srv interface{}, str
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
 type KesselStreamedListService_StreamedListObjectsServer = grpc.ServerStreamingServer[StreamedListObjectsResponse]
 
+func _KesselStreamedListService_StreamedListSubjects_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamedListSubjectsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(KesselStreamedListServiceServer).StreamedListSubjects(m, &grpc.GenericServerStream[StreamedListSubjectsRequest, StreamedListSubjectsResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type KesselStreamedListService_StreamedListSubjectsServer = grpc.ServerStreamingServer[StreamedListSubjectsResponse]
+
 // KesselStreamedListService_ServiceDesc is the grpc.ServiceDesc for KesselStreamedListService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -120,6 +156,11 @@ var KesselStreamedListService_ServiceDesc = grpc.ServiceDesc{
 			Handler:       _KesselStreamedListService_StreamedListObjects_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "StreamedListSubjects",
+			Handler:       _KesselStreamedListService_StreamedListSubjects_Handler,
+			ServerStreams: true,
+		},
 	},
 	Metadata: "kessel/inventory/v1beta2/streamed_list_service.proto",
 }
@@ -0,0 +1,148 @@
+package authz
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/spf13/cobra"
+
+	"github.com/project-kessel/inventory-api/cmd/common"
+	"github.com/project-kessel/inventory-api/internal/authz"
+	"github.com/project-kessel/inventory-api/internal/authz/kessel"
+	"github.com/project-kessel/inventory-api/internal/authz/local"
+	"github.com/project-kessel/inventory-api/internal/authz/verify"
+	"github.com/project-kessel/inventory-api/internal/errors"
+	"github.com/project-kessel/inventory-api/internal/middleware"
+)
+
+// NewCommand creates the parent Cobra command of the authz tooling
+func NewCommand(authzOptions *authz.Options, loggerOptions common.LoggerOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "authz",
+		Short: "Authorization tooling",
+	}
+
+	cmd.AddCommand(newVerifyCommand(authzOptions, loggerOptions))
+
+	return cmd
+}
+
+func newVerifyCommand(authzOptions *authz.Options, loggerOptions common.LoggerOptions) *cobra.Command {
+	var (
+		schemaFile string
+		output     string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "verify",
+		Short: "Verify the relations schema defines the resource types",
+		Long: `Compares the resource types of the resource schemas with the relations schema and reports the types without
+definition and the definitions without the workspace relation, or a relation declared by a reporter.
+
+The relations schema is read from --schema-file, otherwise it is the schema of the configured authorizer: relations-api
+is probed for the types and relations, the local authorizer loads its schema file.`,
+		// missing definitions are reported as errors, which are not usage errors
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			_, logger := common.InitLogger(common.GetLogLevel(), loggerOptions)
+			logHelper := log.NewHelper(log.With(logger, "subsystem", "authz"))
+			ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+			defer stop()
+
+			if output != "text" && output != "json" {
+				return fmt.Errorf("invalid output format: %s. Options are 'text' and 'json'", output)
+			}
+
+			definitions, err := relationsDefinitions(ctx, authzOptions, schemaFile, logHelper)
+			if err != nil {
+				return err
+			}
+
+			if err := middleware.PreloadAllSchemas(""); err != nil {
+				return fmt.Errorf("failed to load resource schemas: %w", err)
+			}
+
+			report, err := verify.Verify(ctx, definitions)
+			if err != nil {
+				return err
+			}
+
+			if err := printReport(cmd, report, output); err != nil {
+				return err
+			}
+			if len(report.Problems) > 0 {
+				return fmt.Errorf("the relations schema lacks %d definition(s) or relation(s) of the resource types", len(report.Problems))
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&schemaFile, "schema-file", "", "the relations schema to verify, e.g. deploy/schema.zed, instead of the schema of the configured authorizer")
+	cmd.Flags().StringVar(&output, "output", "text", "output format of the report.  Options are 'text' and 'json'.")
+
+	return cmd
+}
+
+// relationsDefinitions returns the definitions of the schema file, or of the schema of the configured authorizer
+func relationsDefinitions(ctx context.Context, authzOptions *authz.Options, schemaFile string, logger *log.Helper) (verify.Definitions, error) {
+	if schemaFile != "" {
+		schema, err := local.LoadSchema(schemaFile)
+		if err != nil {
+			return nil, err
+		}
+		return verify.FromSchema(schema), nil
+	}
+
+	if authzOptions.Authz == authz.AllowAll {
+		return nil, fmt.Errorf("the %s authorizer has no relations schema, pass the --schema-file of relations-api", authz.AllowAll)
+	}
+	if errs := authzOptions.Complete(); errs != nil {
+		return nil, errors.NewAggregate(errs)
+	}
+	if errs := authzOptions.Validate(); errs != nil {
+		return nil, errors.NewAggregate(errs)
+	}
+	authzConfig, errs := authz.NewConfig(authzOptions).Complete(ctx)
+	if errs != nil {
+		return nil, errors.NewAggregate(errs)
+	}
+
+	if authzConfig.Authz == authz.Local {
+		return verify.FromSchema(authzConfig.Local.Schema), nil
+	}
+	authorizer, err := kessel.New(ctx, authzConfig.Kessel, logger)
+	if err != nil {
+		return nil, err
+	}
+	return verify.FromRelations(authorizer), nil
+}
+
+func printReport(cmd *cobra.Command, report *verify.Report, output string) error {
+	out := cmd.OutOrStdout()
+	if output == "json" {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	}
+
+	for _, t := range report.Types {
+		status := "ok"
+		var problems []string
+		for _, problem := range report.Problems {
+			if problem.Type == t.String() {
+				problems = append(problems, problem.Message)
+			}
+		}
+		if len(problems) > 0 {
+			status = strings.Join(problems, ", ")
+		}
+		_, _ = fmt.Fprintf(out, "%s\treporters=%s\trelations=%s\t%s\n", t, strings.Join(t.Reporters, ","), strings.Join(t.Relations, ","), status)
+	}
+	_, _ = fmt.Fprintf(out, "%d type(s) verified, %d problem(s)\n", len(report.Types), len(report.Problems))
+	return nil
+}
@@ -0,0 +1,97 @@
+package consume
+
+import (
+	"context"
+	"os/signal"
+	"syscall"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/spf13/cobra"
+	"gorm.io/gorm"
+
+	"github.com/project-kessel/inventory-api/cmd/common"
+	"github.com/project-kessel/inventory-api/internal/authz"
+	"github.com/project-kessel/inventory-api/internal/consumer"
+	resourcerepo "github.com/project-kessel/inventory-api/internal/data/resources"
+	"github.com/project-kessel/inventory-api/internal/errors"
+	"github.com/project-kessel/inventory-api/internal/storage"
+)
+
+// NewCommand creates the command replicating the tuple events of the outbox into relations-api
+func NewCommand(
+	consumerOptions *consumer.Options,
+	storageOptions *storage.Options,
+	authzOptions *authz.Options,
+	loggerOptions common.LoggerOptions,
+) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "consume",
+		Short: "Replicate the tuple events of the outbox topic into relations-api",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			_, logger := common.InitLogger(common.GetLogLevel(), loggerOptions)
+			ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+			defer stop()
+
+			// configure the consumer
+			if errs := consumerOptions.Complete(); errs != nil {
+				return errors.NewAggregate(errs)
+			}
+			if errs := consumerOptions.Validate(); errs != nil {
+				return errors.NewAggregate(errs)
+			}
+			consumerConfig, err := consumer.NewConfig(consumerOptions).Complete()
+			if err != nil {
+				return err
+			}
+
+			// configure authz
+			if errs := authzOptions.Complete(); errs != nil {
+				return errors.NewAggregate(errs)
+			}
+			if errs := authzOptions.Validate(); errs != nil {
+				return errors.NewAggregate(errs)
+			}
+			authzConfig, errs := authz.NewConfig(authzOptions).Complete(ctx)
+			if errs != nil {
+				return errors.NewAggregate(errs)
+			}
+
+			// construct storage, the consistency tokens are dropped when persistence is disabled
+			var tokens consumer.TokenStore
+			var db *gorm.DB
+			if !storageOptions.DisablePersistence {
+				if errs := storageOptions.Complete(); errs != nil {
+					return errors.NewAggregate(errs)
+				}
+				if errs := storageOptions.Validate(); errs != nil {
+					return errors.NewAggregate(errs)
+				}
+				db, err = storage.New(storage.NewConfig(storageOptions).Complete(), log.NewHelper(log.With(logger, "subsystem", "storage")))
+				if err != nil {
+					return err
+				}
+				tokens = resourcerepo.New(db)
+			}
+
+			// construct authz, the local authorizer stores the tuples in the inventory database
+			authorizer, err := authz.New(ctx, authzConfig, db, log.NewHelper(log.With(logger, "subsystem", "authz")))
+			if err != nil {
+				return err
+			}
+
+			tupleConsumer, err := consumer.New(consumerConfig, authorizer, tokens, log.NewHelper(log.With(logger, "subsystem", "consumer")))
+			if err != nil {
+				return err
+			}
+			defer func() {
+				_ = tupleConsumer.Close()
+			}()
+
+			return tupleConsumer.Run(ctx)
+		},
+	}
+
+	consumerOptions.AddFlags(cmd.Flags(), "consumer")
+
+	return cmd
+}
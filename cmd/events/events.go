@@ -0,0 +1,237 @@
+package events
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"os/signal"
+	"sort"
+	"syscall"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/spf13/cobra"
+
+	"github.com/project-kessel/inventory-api/cmd/common"
+	"github.com/project-kessel/inventory-api/internal/errors"
+	"github.com/project-kessel/inventory-api/internal/eventing"
+	"github.com/project-kessel/inventory-api/internal/eventing/api"
+	"github.com/project-kessel/inventory-api/internal/eventing/kafka"
+	"github.com/project-kessel/inventory-api/internal/eventing/replay"
+	"github.com/project-kessel/inventory-api/internal/server"
+	"github.com/project-kessel/inventory-api/internal/storage"
+)
+
+// NewCommand creates the parent Cobra command of the eventing tooling
+func NewCommand(
+	options *eventing.Options,
+	storageOptions *storage.Options,
+	serverOptions *server.Options,
+	loggerOptions common.LoggerOptions,
+) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "events",
+		Short: "Eventing tooling",
+	}
+
+	cmd.AddCommand(newFlushSpoolCommand(options, loggerOptions))
+	cmd.AddCommand(newReplayCommand(options, storageOptions, serverOptions, loggerOptions))
+
+	return cmd
+}
+
+func newFlushSpoolCommand(options *eventing.Options, loggerOptions common.LoggerOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "flush-spool",
+		Short: "Send the events spooled while Kafka was unreachable",
+		// undelivered events are reported as errors, which are not usage errors
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			_, logger := common.InitLogger(common.GetLogLevel(), loggerOptions)
+			logHelper := log.NewHelper(log.With(logger, "subsystem", "eventing"))
+
+			if options.Eventer != "kafka" {
+				return fmt.Errorf("the event spool requires the kafka eventer, got %s", options.Eventer)
+			}
+			if options.Kafka.SpoolDir == "" {
+				return fmt.Errorf("the event spool is not enabled, set eventing.kafka.spool-dir")
+			}
+
+			if errs := options.Complete(); errs != nil {
+				return errors.NewAggregate(errs)
+			}
+			if errs := options.Validate(); errs != nil {
+				return errors.NewAggregate(errs)
+			}
+
+			config, err := kafka.NewConfig(options.Kafka).Complete()
+			if err != nil {
+				return err
+			}
+			// the command flushes the spool once, it doesn't need the background retries
+			config.SpoolRetryInterval = 0
+
+			manager, err := kafka.New(config, "", logHelper)
+			if stderrors.Is(err, kafka.ErrSpoolLocked) {
+				return fmt.Errorf("%w, stop the server spooling to %s before flushing it", err, options.Kafka.SpoolDir)
+			}
+			if err != nil {
+				return err
+			}
+
+			ctx := context.Background()
+			sent, flushErr := manager.FlushSpool(ctx)
+
+			// waits for the delivery reports, events failing again go back to the spool
+			if err := manager.Shutdown(ctx); err != nil {
+				return err
+			}
+			if flushErr != nil {
+				return fmt.Errorf("failed to flush the event spool after %d event(s): %w", sent, flushErr)
+			}
+
+			remaining := manager.Spool.Depth()
+			logHelper.Infof("Sent %d spooled event(s), %d left in the spool", sent, remaining)
+			if remaining > 0 {
+				return fmt.Errorf("%d event(s) could not be delivered and are still spooled", remaining)
+			}
+			return nil
+		},
+	}
+
+	return cmd
+}
+
+func newReplayCommand(
+	options *eventing.Options,
+	storageOptions *storage.Options,
+	serverOptions *server.Options,
+	loggerOptions common.LoggerOptions,
+) *cobra.Command {
+	var (
+		since, until string
+		filter       replay.Filter
+		replayer     = &replay.Replayer{Rate: 100}
+	)
+
+	cmd := &cobra.Command{
+		Use:   "replay",
+		Short: "Produce the events of the changes recorded in the resource and relationship history again",
+		Long: `Walks resource_history, then relationship_history, in the order the changes were recorded and produces
+their events through the configured eventer.  Replayed events keep the id they were first sent with, so
+consumers can drop the ones they already processed.`,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			_, logger := common.InitLogger(common.GetLogLevel(), loggerOptions)
+			logHelper := log.NewHelper(log.With(logger, "subsystem", "eventing"))
+			ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+			defer stop()
+
+			var err error
+			if filter.Since, err = parseTime("since", since); err != nil {
+				return err
+			}
+			if filter.Until, err = parseTime("until", until); err != nil {
+				return err
+			}
+			if filter.EventType != "" && filter.EventType != api.ResourceEventType && filter.EventType != api.RelationshipEventType {
+				return fmt.Errorf("event-type must be either %s or %s", api.ResourceEventType, api.RelationshipEventType)
+			}
+			if replayer.Rate < 0 {
+				return fmt.Errorf("rate must be 0 or more")
+			}
+
+			// construct storage
+			if storageOptions.DisablePersistence {
+				return fmt.Errorf("replaying events requires the resource history, persistence is disabled")
+			}
+			if errs := storageOptions.Complete(); errs != nil {
+				return errors.NewAggregate(errs)
+			}
+			if errs := storageOptions.Validate(); errs != nil {
+				return errors.NewAggregate(errs)
+			}
+			db, err := storage.New(storage.NewConfig(storageOptions).Complete(), log.NewHelper(log.With(logger, "subsystem", "storage")))
+			if err != nil {
+				return err
+			}
+			replayer.DB = db
+
+			// construct eventing, a dry run only counts the events
+			if !replayer.DryRun {
+				if errs := options.Complete(); errs != nil {
+					return errors.NewAggregate(errs)
+				}
+				if errs := options.Validate(); errs != nil {
+					return errors.NewAggregate(errs)
+				}
+				config, errs := eventing.NewConfig(options).Complete()
+				if errs != nil {
+					return errors.NewAggregate(errs)
+				}
+				// the events are sent with the source of the server that first sent them
+				manager, err := eventing.New(config, serverOptions.PublicUrl, logHelper)
+				if err != nil {
+					return err
+				}
+				replayer.Manager = manager
+			}
+
+			counts, replayErr := replayer.Replay(ctx, filter)
+
+			if replayer.Manager != nil {
+				// waits for the events still buffered by the eventer
+				if err := replayer.Manager.Shutdown(context.Background()); err != nil && replayErr == nil {
+					replayErr = err
+				}
+			}
+
+			printCounts(cmd, counts, replayer.DryRun)
+			if replayErr != nil {
+				return fmt.Errorf("failed to replay events after %d event(s): %w", counts.Total(), replayErr)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&since, "since", "", "Replay the changes recorded at or after this time (RFC3339)")
+	cmd.Flags().StringVar(&until, "until", "", "Replay the changes recorded before this time (RFC3339)")
+	cmd.Flags().StringVar(&filter.ResourceType, "resource-type", "", "Replay the changes of this resource type, and the relationships involving it")
+	cmd.Flags().StringVar(&filter.ReporterType, "reporter-type", "", "Replay the changes reported by this reporter type")
+	cmd.Flags().StringVar(&filter.OrgId, "org-id", "", "Replay the changes of this org")
+	cmd.Flags().StringVar(&filter.EventType, "event-type", "", fmt.Sprintf("Replay only the %s or the %s events", api.ResourceEventType, api.RelationshipEventType))
+	cmd.Flags().BoolVar(&replayer.DryRun, "dry-run", false, "Print the number of events that would be replayed without producing them")
+	cmd.Flags().IntVar(&replayer.Rate, "rate", replayer.Rate, "Maximum number of events produced per second, 0 disables the limit")
+	cmd.Flags().IntVar(&replayer.BatchSize, "batch-size", 500, "Number of history rows read at a time")
+
+	return cmd
+}
+
+func parseTime(name, value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid %s: %w", name, err)
+	}
+	return t, nil
+}
+
+func printCounts(cmd *cobra.Command, counts replay.Counts, dryRun bool) {
+	eventTypes := make([]string, 0, len(counts))
+	for eventType := range counts {
+		eventTypes = append(eventTypes, eventType)
+	}
+	sort.Strings(eventTypes)
+
+	out := cmd.OutOrStdout()
+	for _, eventType := range eventTypes {
+		_, _ = fmt.Fprintf(out, "%s\t%d\n", eventType, counts[eventType])
+	}
+	if dryRun {
+		_, _ = fmt.Fprintf(out, "%d event(s) would be replayed\n", counts.Total())
+	} else {
+		_, _ = fmt.Fprintf(out, "%d event(s) replayed\n", counts.Total())
+	}
+}
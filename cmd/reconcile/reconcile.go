@@ -0,0 +1,160 @@
+package reconcile
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/spf13/cobra"
+
+	"github.com/project-kessel/inventory-api/cmd/common"
+	"github.com/project-kessel/inventory-api/internal/authz"
+	"github.com/project-kessel/inventory-api/internal/errors"
+	"github.com/project-kessel/inventory-api/internal/middleware"
+	"github.com/project-kessel/inventory-api/internal/reconciler"
+	"github.com/project-kessel/inventory-api/internal/storage"
+)
+
+// NewCommand creates the command comparing the resources of the inventory database with their workspace tuples in
+// relations-api
+func NewCommand(
+	reconcilerOptions *reconciler.Options,
+	storageOptions *storage.Options,
+	authzOptions *authz.Options,
+	loggerOptions common.LoggerOptions,
+) *cobra.Command {
+	var (
+		repair          bool
+		rate, batchSize int
+		output          string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "reconcile",
+		Short: "Report, and optionally repair, the drift between the resources and their workspace tuples",
+		Long: `Pages through the resources of the inventory database and the workspace tuples of relations-api and reports
+the resources without workspace tuple (missing), the resources related to another workspace (stale) and the
+tuples of deleted resources (orphaned).  With --repair the workspace tuples are fixed to match the database.
+
+--repair, --rate and --batch-size override the reconciler configuration.`,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			_, logger := common.InitLogger(common.GetLogLevel(), loggerOptions)
+			logHelper := log.NewHelper(log.With(logger, "subsystem", "reconciler"))
+			ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+			defer stop()
+
+			if output != "text" && output != "json" {
+				return fmt.Errorf("output must be either text or json")
+			}
+
+			// configure the reconciler
+			if cmd.Flags().Changed("repair") {
+				reconcilerOptions.Repair = repair
+			}
+			if cmd.Flags().Changed("rate") {
+				reconcilerOptions.Rate = rate
+			}
+			if cmd.Flags().Changed("batch-size") {
+				reconcilerOptions.BatchSize = batchSize
+			}
+			if errs := reconcilerOptions.Complete(); errs != nil {
+				return errors.NewAggregate(errs)
+			}
+			if errs := reconcilerOptions.Validate(); errs != nil {
+				return errors.NewAggregate(errs)
+			}
+			reconcilerConfig := reconciler.NewConfig(reconcilerOptions).Complete()
+
+			// construct storage
+			if storageOptions.DisablePersistence {
+				return fmt.Errorf("reconciling requires the inventory database, persistence is disabled")
+			}
+			if errs := storageOptions.Complete(); errs != nil {
+				return errors.NewAggregate(errs)
+			}
+			if errs := storageOptions.Validate(); errs != nil {
+				return errors.NewAggregate(errs)
+			}
+			db, err := storage.New(storage.NewConfig(storageOptions).Complete(), log.NewHelper(log.With(logger, "subsystem", "storage")))
+			if err != nil {
+				return err
+			}
+
+			// construct authz
+			if authzOptions.Authz == authz.AllowAll {
+				return fmt.Errorf("reconciling requires the %s or %s authorizer", authz.Kessel, authz.Local)
+			}
+			if errs := authzOptions.Complete(); errs != nil {
+				return errors.NewAggregate(errs)
+			}
+			if errs := authzOptions.Validate(); errs != nil {
+				return errors.NewAggregate(errs)
+			}
+			authzConfig, errs := authz.NewConfig(authzOptions).Complete(ctx)
+			if errs != nil {
+				return errors.NewAggregate(errs)
+			}
+			authorizer, err := authz.New(ctx, authzConfig, db, log.NewHelper(log.With(logger, "subsystem", "authz")))
+			if err != nil {
+				return err
+			}
+
+			// the reporter configs hold the relations namespaces of the resources
+			if err := middleware.PreloadAllSchemas(""); err != nil {
+				return fmt.Errorf("failed to load resource schemas: %w", err)
+			}
+
+			r, err := reconciler.New(reconcilerConfig, db, authorizer, logHelper)
+			if err != nil {
+				return err
+			}
+			report, reconcileErr := r.Reconcile(ctx)
+
+			if err := printReport(cmd, report, output); err != nil {
+				return err
+			}
+			if reconcileErr != nil {
+				return fmt.Errorf("failed to reconcile after %d resource(s): %w", report.Resources, reconcileErr)
+			}
+			return nil
+		},
+	}
+
+	defaults := reconciler.NewOptions()
+	cmd.Flags().BoolVar(&repair, "repair", defaults.Repair, "Repair the drift found, otherwise it is only reported")
+	cmd.Flags().IntVar(&rate, "rate", defaults.Rate, "Maximum number of calls to relations-api per second, 0 disables the limit")
+	cmd.Flags().IntVar(&batchSize, "batch-size", defaults.BatchSize, "Number of resources, and of workspace tuples, read at a time")
+	cmd.Flags().StringVar(&output, "output", "text", "Format of the report, text or json")
+
+	return cmd
+}
+
+func printReport(cmd *cobra.Command, report *reconciler.Report, output string) error {
+	out := cmd.OutOrStdout()
+	if output == "json" {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	}
+
+	for _, drift := range report.Drifts {
+		status := ""
+		switch {
+		case drift.Repaired:
+			status = "repaired"
+		case drift.Error != "":
+			status = "repair failed: " + drift.Error
+		}
+		_, _ = fmt.Fprintf(out, "%s\t%s/%s:%s\tworkspace=%s\ttuples=%s\t%s\n", drift.Kind, drift.Namespace, drift.ResourceType,
+			drift.ResourceId, drift.Workspace, strings.Join(drift.TupleWorkspaces, ","), status)
+	}
+	_, _ = fmt.Fprintf(out, "%d resource(s) and %d workspace tuple(s) compared: %d missing, %d stale, %d orphaned, %d repaired\n",
+		report.Resources, report.Tuples, report.Count(reconciler.Missing), report.Count(reconciler.Stale),
+		report.Count(reconciler.Orphaned), report.Repaired())
+	return nil
+}
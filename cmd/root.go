@@ -3,8 +3,12 @@ package cmd
 import (
 	"fmt"
 	"github.com/go-kratos/kratos/v2/log"
+	"github.com/project-kessel/inventory-api/cmd/authz"
 	"github.com/project-kessel/inventory-api/cmd/common"
+	"github.com/project-kessel/inventory-api/cmd/consume"
+	"github.com/project-kessel/inventory-api/cmd/events"
 	"github.com/project-kessel/inventory-api/cmd/migrate"
+	"github.com/project-kessel/inventory-api/cmd/reconcile"
 	"github.com/project-kessel/inventory-api/cmd/schema"
 	"github.com/project-kessel/inventory-api/cmd/serve"
 	"github.com/project-kessel/inventory-api/internal/config"
@@ -94,7 +98,7 @@ func init() {
 	if err != nil {
 		panic(err)
 	}
-	serveCmd := serve.NewCommand(options.Server, options.Storage, options.Authn, options.Authz, options.Eventing, loggerOptions)
+	serveCmd := serve.NewCommand(options.Server, options.Storage, options.Authn, options.Authz, options.Eventing, options.Reconciler, loggerOptions)
 	rootCmd.AddCommand(serveCmd)
 	err = viper.BindPFlags(serveCmd.Flags())
 	if err != nil {
@@ -106,6 +110,36 @@ func init() {
 	if err != nil {
 		panic(err)
 	}
+	schemaToolsCmd := schema.NewSchemaCommand(options.Storage, loggerOptions)
+	rootCmd.AddCommand(schemaToolsCmd)
+	err = viper.BindPFlags(schemaToolsCmd.Flags())
+	if err != nil {
+		panic(err)
+	}
+	consumeCmd := consume.NewCommand(options.Consumer, options.Storage, options.Authz, loggerOptions)
+	rootCmd.AddCommand(consumeCmd)
+	err = viper.BindPFlags(consumeCmd.Flags())
+	if err != nil {
+		panic(err)
+	}
+	reconcileCmd := reconcile.NewCommand(options.Reconciler, options.Storage, options.Authz, loggerOptions)
+	rootCmd.AddCommand(reconcileCmd)
+	err = viper.BindPFlags(reconcileCmd.Flags())
+	if err != nil {
+		panic(err)
+	}
+	authzCmd := authz.NewCommand(options.Authz, loggerOptions)
+	rootCmd.AddCommand(authzCmd)
+	err = viper.BindPFlags(authzCmd.Flags())
+	if err != nil {
+		panic(err)
+	}
+	eventsCmd := events.NewCommand(options.Eventing, options.Storage, options.Server, loggerOptions)
+	rootCmd.AddCommand(eventsCmd)
+	err = viper.BindPFlags(eventsCmd.Flags())
+	if err != nil {
+		panic(err)
+	}
 }
 
 // initConfig reads in config file and ENV variables if set.
@@ -0,0 +1,138 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/spf13/cobra"
+	"gorm.io/gorm"
+
+	"github.com/project-kessel/inventory-api/cmd/common"
+	"github.com/project-kessel/inventory-api/internal/biz/model"
+	"github.com/project-kessel/inventory-api/internal/errors"
+	schemas "github.com/project-kessel/inventory-api/internal/schema"
+	"github.com/project-kessel/inventory-api/internal/storage"
+)
+
+const validateDataBatchSize = 500
+
+// NewSchemaCommand creates the parent Cobra command of the schema tooling
+func NewSchemaCommand(storageOptions *storage.Options, loggerOptions common.LoggerOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "schema",
+		Short: "Resource schema tooling",
+	}
+
+	cmd.AddCommand(newDiffCommand(storageOptions, loggerOptions))
+
+	return cmd
+}
+
+func newDiffCommand(storageOptions *storage.Options, loggerOptions common.LoggerOptions) *cobra.Command {
+	var (
+		validateData bool
+		output       string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "diff <old-dir> <new-dir>",
+		Short: "Report compatible and breaking changes between two resource schema trees",
+		Args:  cobra.ExactArgs(2),
+		// breaking changes are reported as errors, which are not usage errors
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			_, logger := common.InitLogger(common.GetLogLevel(), loggerOptions)
+			logHelper := log.NewHelper(log.With(logger, "subsystem", "schema"))
+
+			oldTree, err := schemas.LoadTree(args[0])
+			if err != nil {
+				return fmt.Errorf("failed to load old schemas: %w", err)
+			}
+			newTree, err := schemas.LoadTree(args[1])
+			if err != nil {
+				return fmt.Errorf("failed to load new schemas: %w", err)
+			}
+
+			report, err := schemas.Diff(oldTree, newTree)
+			if err != nil {
+				return err
+			}
+
+			switch output {
+			case "json":
+				encoder := json.NewEncoder(cmd.OutOrStdout())
+				encoder.SetIndent("", "  ")
+				if err := encoder.Encode(report); err != nil {
+					return err
+				}
+			case "text":
+				for _, change := range report.Changes {
+					fmt.Fprintln(cmd.OutOrStdout(), change.String())
+				}
+			default:
+				return fmt.Errorf("invalid output format: %s. Options are 'text' and 'json'", output)
+			}
+
+			invalidRows := 0
+			if validateData {
+				invalidRows, err = validateStoredResources(cmd, storageOptions, newTree, logHelper)
+				if err != nil {
+					return err
+				}
+			}
+
+			breaking := len(report.Breaking())
+			logHelper.Infof("Schema diff found %d change(s), %d breaking", len(report.Changes), breaking)
+
+			if breaking > 0 || invalidRows > 0 {
+				return fmt.Errorf("schema change is not compatible: %d breaking change(s), %d stored resource(s) fail validation", breaking, invalidRows)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&validateData, "validate-data", false, "re-validate the resource_data of every stored resource against the new schemas")
+	cmd.Flags().StringVar(&output, "output", "text", "output format of the report.  Options are 'text' and 'json'.")
+
+	return cmd
+}
+
+// validateStoredResources walks all resources in the database and reports the ones failing the new schemas
+func validateStoredResources(cmd *cobra.Command, options *storage.Options, tree *schemas.Tree, logger *log.Helper) (int, error) {
+	if options.DisablePersistence {
+		logger.Info("Persistence disabled, skipping stored data validation...")
+		return 0, nil
+	}
+
+	if errs := options.Complete(); errs != nil {
+		return 0, errors.NewAggregate(errs)
+	}
+	if errs := options.Validate(); errs != nil {
+		return 0, errors.NewAggregate(errs)
+	}
+
+	db, err := storage.New(storage.NewConfig(options).Complete(), logger)
+	if err != nil {
+		return 0, err
+	}
+
+	checked, invalid := 0, 0
+	var batch []*model.Resource
+	result := db.Model(&model.Resource{}).Order("id").FindInBatches(&batch, validateDataBatchSize, func(tx *gorm.DB, _ int) error {
+		for _, resource := range batch {
+			checked++
+			if err := tree.ValidateResourceData(resource.ResourceType, resource.ReporterType, resource.WorkspaceId, resource.ResourceData); err != nil {
+				invalid++
+				fmt.Fprintf(cmd.OutOrStdout(), "[invalid] %s:%s %s (%s): %v\n", resource.ResourceType, resource.ReporterType, resource.ReporterResourceId, resource.ID, err)
+			}
+		}
+		return nil
+	})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to read stored resources: %w", result.Error)
+	}
+
+	logger.Infof("Validated %d stored resource(s), %d invalid", checked, invalid)
+	return invalid, nil
+}
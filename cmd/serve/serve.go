@@ -22,16 +22,19 @@ import (
 	resourcesvc "github.com/project-kessel/inventory-api/internal/service/resources"
 
 	"github.com/spf13/cobra"
+	"golang.org/x/sync/semaphore"
 	"gorm.io/gorm"
 
 	"github.com/go-kratos/kratos/v2/log"
 
 	"github.com/project-kessel/inventory-api/internal/authn"
 	"github.com/project-kessel/inventory-api/internal/authz"
+	authzapi "github.com/project-kessel/inventory-api/internal/authz/api"
 	"github.com/project-kessel/inventory-api/internal/errors"
 	"github.com/project-kessel/inventory-api/internal/eventing"
 	eventingapi "github.com/project-kessel/inventory-api/internal/eventing/api"
 	"github.com/project-kessel/inventory-api/internal/middleware"
+	"github.com/project-kessel/inventory-api/internal/reconciler"
 	"github.com/project-kessel/inventory-api/internal/server"
 	"github.com/project-kessel/inventory-api/internal/storage"
 
@@ -51,6 +54,7 @@ func NewCommand(
 	authnOptions *authn.Options,
 	authzOptions *authz.Options,
 	eventingOptions *eventing.Options,
+	reconcilerOptions *reconciler.Options,
 	loggerOptions common.LoggerOptions,
 ) *cobra.Command {
 	cmd := &cobra.Command{
@@ -117,6 +121,15 @@ func NewCommand(
 				return errors.NewAggregate(errs)
 			}
 
+			// configure the reconciler
+			if errs := reconcilerOptions.Complete(); errs != nil {
+				return errors.NewAggregate(errs)
+			}
+			if errs := reconcilerOptions.Validate(); errs != nil {
+				return errors.NewAggregate(errs)
+			}
+			reconcilerConfig := reconciler.NewConfig(reconcilerOptions).Complete()
+
 			// construct storage
 			db, err := storage.New(storageConfig, log.NewHelper(log.With(logger, "subsystem", "storage")))
 			if err != nil {
@@ -130,7 +143,7 @@ func NewCommand(
 			}
 
 			// construct authz
-			authorizer, err := authz.New(ctx, authzConfig, log.NewHelper(log.With(logger, "subsystem", "authz")))
+			authorizer, err := authz.New(ctx, authzConfig, db, log.NewHelper(log.With(logger, "subsystem", "authz")))
 			if err != nil {
 				return err
 			}
@@ -143,6 +156,16 @@ func NewCommand(
 				return err
 			}
 
+			// load the resource schemas used to validate reports
+			if err := middleware.PreloadAllSchemas(""); err != nil {
+				return fmt.Errorf("failed to load resource schemas: %w", err)
+			}
+
+			// check relations defines the resource types before writing their tuples
+			if err := authz.VerifySchema(ctx, authzConfig, authorizer, log.NewHelper(log.With(logger, "subsystem", "authz"))); err != nil {
+				return err
+			}
+
 			// construct servers
 			server, err := server.New(serverConfig, middleware.Authentication(authenticator), logger)
 			if err != nil {
@@ -154,54 +177,57 @@ func NewCommand(
 			//v1beta2
 			// wire together resource handling
 			resource_repo := resourcerepo.New(db)
-			resource_controller := resourcesctl.New(resource_repo, inventoryresources_repo, authorizer, eventingManager, "notifications", log.With(logger, "subsystem", "notificationsintegrations_controller"), storageConfig.Options.DisablePersistence)
+			resource_controller := resourcesctl.New(resource_repo, inventoryresources_repo, authorizer, eventingManager, log.With(logger, "subsystem", "notificationsintegrations_controller"), storageConfig.Options.DisablePersistence)
+			resource_controller.IncludeChanges = eventingConfig.IncludeChanges
 			resource_service := resourcesvc.NewKesselResourceServiceV1beta2(resource_controller)
 			pbv1beta2.RegisterKesselResourceServiceServer(server.GrpcServer, resource_service)
 			pbv1beta2.RegisterKesselResourceServiceHTTPServer(server.HttpServer, resource_service)
 
 			// wire together check service
-			check_controller := resourcesctl.New(resource_repo, inventoryresources_repo, authorizer, eventingManager, "authz", log.With(logger, "subsystem", "authz_controller"), storageConfig.Options.DisablePersistence)
+			check_controller := resourcesctl.New(resource_repo, inventoryresources_repo, authorizer, eventingManager, log.With(logger, "subsystem", "authz_controller"), storageConfig.Options.DisablePersistence)
 			check_service := resourcesvc.NewKesselCheckServiceV1beta2(check_controller)
 			pbv1beta2.RegisterKesselCheckServiceServer(server.GrpcServer, check_service)
 			pbv1beta2.RegisterKesselCheckServiceHTTPServer(server.HttpServer, check_service)
 
 			// wire together lookup service
-			streamedlist_controller := resourcesctl.New(resource_repo, inventoryresources_repo, authorizer, eventingManager, "authz", log.With(logger, "subsystem", "authz_controller"), storageConfig.Options.DisablePersistence)
+			streamedlist_controller := resourcesctl.New(resource_repo, inventoryresources_repo, authorizer, eventingManager, log.With(logger, "subsystem", "authz_controller"), storageConfig.Options.DisablePersistence)
 			streamedlist_service := resourcesvc.NewKesselLookupServiceV1beta2(streamedlist_controller)
 			pbv1beta2.RegisterKesselStreamedListServiceServer(server.GrpcServer, streamedlist_service)
 
 			//v1beta1
 			// wire together notificationsintegrations handling
 			notifs_repo := resourcerepo.New(db)
-			notifs_controller := resourcesctl.New(notifs_repo, inventoryresources_repo, authorizer, eventingManager, "notifications", log.With(logger, "subsystem", "notificationsintegrations_controller"), storageConfig.Options.DisablePersistence)
+			notifs_controller := resourcesctl.New(notifs_repo, inventoryresources_repo, authorizer, eventingManager, log.With(logger, "subsystem", "notificationsintegrations_controller"), storageConfig.Options.DisablePersistence)
+			notifs_controller.ListSlots = semaphore.NewWeighted(int64(authzConfig.MaxConcurrentLists))
+			notifs_controller.MaxLookupIds = authzConfig.MaxListLookupIds
 			notifs_service := notifssvc.NewKesselNotificationsIntegrationsServiceV1beta1(notifs_controller)
 			pb.RegisterKesselNotificationsIntegrationServiceServer(server.GrpcServer, notifs_service)
 			pb.RegisterKesselNotificationsIntegrationServiceHTTPServer(server.HttpServer, notifs_service)
 
 			// wire together authz handling
 			authz_repo := resourcerepo.New(db)
-			authz_controller := resourcesctl.New(authz_repo, inventoryresources_repo, authorizer, eventingManager, "authz", log.With(logger, "subsystem", "authz_controller"), storageConfig.Options.DisablePersistence)
+			authz_controller := resourcesctl.New(authz_repo, inventoryresources_repo, authorizer, eventingManager, log.With(logger, "subsystem", "authz_controller"), storageConfig.Options.DisablePersistence)
 			authz_service := resourcesvc.NewKesselCheckServiceV1beta1(authz_controller)
 			authzv1beta1.RegisterKesselCheckServiceServer(server.GrpcServer, authz_service)
 			authzv1beta1.RegisterKesselCheckServiceHTTPServer(server.HttpServer, authz_service)
 
 			// wire together hosts handling
 			hosts_repo := resourcerepo.New(db)
-			hosts_controller := resourcesctl.New(hosts_repo, inventoryresources_repo, authorizer, eventingManager, "hbi", log.With(logger, "subsystem", "hosts_controller"), storageConfig.Options.DisablePersistence)
+			hosts_controller := resourcesctl.New(hosts_repo, inventoryresources_repo, authorizer, eventingManager, log.With(logger, "subsystem", "hosts_controller"), storageConfig.Options.DisablePersistence)
 			hosts_service := hostssvc.NewKesselRhelHostServiceV1beta1(hosts_controller)
 			pb.RegisterKesselRhelHostServiceServer(server.GrpcServer, hosts_service)
 			pb.RegisterKesselRhelHostServiceHTTPServer(server.HttpServer, hosts_service)
 
 			// wire together k8sclusters handling
 			k8sclusters_repo := resourcerepo.New(db)
-			k8sclusters_controller := resourcesctl.New(k8sclusters_repo, inventoryresources_repo, authorizer, eventingManager, "acm", log.With(logger, "subsystem", "k8sclusters_controller"), storageConfig.Options.DisablePersistence)
+			k8sclusters_controller := resourcesctl.New(k8sclusters_repo, inventoryresources_repo, authorizer, eventingManager, log.With(logger, "subsystem", "k8sclusters_controller"), storageConfig.Options.DisablePersistence)
 			k8sclusters_service := k8sclusterssvc.NewKesselK8SClusterServiceV1beta1(k8sclusters_controller)
 			pb.RegisterKesselK8SClusterServiceServer(server.GrpcServer, k8sclusters_service)
 			pb.RegisterKesselK8SClusterServiceHTTPServer(server.HttpServer, k8sclusters_service)
 
 			// wire together k8spolicies handling
 			k8spolicies_repo := resourcerepo.New(db)
-			k8spolicies_controller := resourcesctl.New(k8spolicies_repo, inventoryresources_repo, authorizer, eventingManager, "acm", log.With(logger, "subsystem", "k8spolicies_controller"), storageConfig.Options.DisablePersistence)
+			k8spolicies_controller := resourcesctl.New(k8spolicies_repo, inventoryresources_repo, authorizer, eventingManager, log.With(logger, "subsystem", "k8spolicies_controller"), storageConfig.Options.DisablePersistence)
 			k8spolicies_service := k8spoliciessvc.NewKesselK8SPolicyServiceV1beta1(k8spolicies_controller)
 			pb.RegisterKesselK8SPolicyServiceServer(server.GrpcServer, k8spolicies_service)
 			pb.RegisterKesselK8SPolicyServiceHTTPServer(server.HttpServer, k8spolicies_service)
@@ -219,6 +245,29 @@ func NewCommand(
 			hb.RegisterKesselInventoryHealthServiceServer(server.GrpcServer, health_service)
 			hb.RegisterKesselInventoryHealthServiceHTTPServer(server.HttpServer, health_service)
 
+			// run the reconciler of the workspace tuples in the background
+			reconcilerCtx, stopReconciler := context.WithCancel(ctx)
+			defer stopReconciler()
+			if reconcilerConfig.Interval > 0 {
+				reconcilerLogger := log.NewHelper(log.With(logger, "subsystem", "reconciler"))
+				switch {
+				case storageOptions.DisablePersistence:
+					reconcilerLogger.Warn("Persistence disabled, the reconciler is not run")
+				case authzOptions.Authz == authz.AllowAll:
+					reconcilerLogger.Warnf("The reconciler is not run with the %s authorizer", authz.AllowAll)
+				default:
+					tupleReconciler, err := reconciler.New(reconcilerConfig, db, authorizer, reconcilerLogger)
+					if err != nil {
+						return err
+					}
+					go func() {
+						if err := tupleReconciler.Run(reconcilerCtx); err != nil {
+							reconcilerLogger.Errorf("Reconciler stopped: %v", err)
+						}
+					}()
+				}
+			}
+
 			srvErrs := make(chan error)
 			go func() {
 				srvErrs <- server.Run(ctx)
@@ -227,16 +276,19 @@ func NewCommand(
 			quit := make(chan os.Signal, 1)
 			signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 
-			shutdown := shutdown(db, server, eventingManager, log.NewHelper(logger))
+			shutdown := shutdown(db, server, authorizer, eventingManager, log.NewHelper(logger))
 
+			var reason interface{}
 			select {
 			case err := <-srvErrs:
-				shutdown(err)
+				reason = err
 			case sig := <-quit:
-				shutdown(sig)
+				reason = sig
 			case emErr := <-eventingManager.Errs():
-				shutdown(emErr)
+				reason = emErr
 			}
+			stopReconciler()
+			shutdown(reason)
 			return nil
 		},
 	}
@@ -245,11 +297,12 @@ func NewCommand(
 	authnOptions.AddFlags(cmd.Flags(), "authn")
 	authzOptions.AddFlags(cmd.Flags(), "authz")
 	eventingOptions.AddFlags(cmd.Flags(), "eventing")
+	reconcilerOptions.AddFlags(cmd.Flags(), "reconciler")
 
 	return cmd
 }
 
-func shutdown(db *gorm.DB, srv *server.Server, em eventingapi.Manager, logger *log.Helper) func(reason interface{}) {
+func shutdown(db *gorm.DB, srv *server.Server, authorizer authzapi.Authorizer, em eventingapi.Manager, logger *log.Helper) func(reason interface{}) {
 	return func(reason interface{}) {
 		log.Info(fmt.Sprintf("Server Shutdown: %s", reason))
 
@@ -260,6 +313,12 @@ func shutdown(db *gorm.DB, srv *server.Server, em eventingapi.Manager, logger *l
 			logger.Error(fmt.Sprintf("Error Gracefully Shutting Down API: %v", err))
 		}
 
+		ctx, cancel = context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		if err := authz.Shutdown(ctx, authorizer); err != nil {
+			logger.Error(fmt.Sprintf("Error Gracefully Shutting Down Authz Audit: %v", err))
+		}
+
 		ctx, cancel = context.WithTimeout(context.Background(), timeout)
 		defer cancel()
 		if err := em.Shutdown(ctx); err != nil {
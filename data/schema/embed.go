@@ -0,0 +1,10 @@
+// Package schema embeds the default resource schemas, so the binary can validate reports without a schema
+// directory or cache file next to it.
+package schema
+
+import "embed"
+
+// Resources holds the resources/<resource_type> schema tree.
+//
+//go:embed resources
+var Resources embed.FS
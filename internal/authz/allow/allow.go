@@ -36,41 +36,46 @@ func (a *AllowAllAuthz) CheckForUpdate(context.Context, string, string, *model.R
 	return v1beta1.CheckForUpdateResponse_ALLOWED_TRUE, nil, nil
 }
 
-type mockLookupResourcesClient struct {
+// mockLookupClient is the empty stream of a lookup
+type mockLookupClient[T any] struct {
 	ctx context.Context
 }
 
-func (m *mockLookupResourcesClient) Recv() (*v1beta1.LookupResourcesResponse, error) {
+func (m *mockLookupClient[T]) Recv() (*T, error) {
 	// Return EOF immediately to indicate end of stream
 	return nil, io.EOF
 }
 
-func (m *mockLookupResourcesClient) Header() (metadata.MD, error) {
+func (m *mockLookupClient[T]) Header() (metadata.MD, error) {
 	return nil, nil
 }
 
-func (m *mockLookupResourcesClient) Trailer() metadata.MD {
+func (m *mockLookupClient[T]) Trailer() metadata.MD {
 	return nil
 }
 
-func (m *mockLookupResourcesClient) CloseSend() error {
+func (m *mockLookupClient[T]) CloseSend() error {
 	return nil
 }
 
-func (m *mockLookupResourcesClient) Context() context.Context {
+func (m *mockLookupClient[T]) Context() context.Context {
 	return m.ctx
 }
 
-func (m *mockLookupResourcesClient) SendMsg(msg interface{}) error {
+func (m *mockLookupClient[T]) SendMsg(msg interface{}) error {
 	return nil
 }
 
-func (m *mockLookupResourcesClient) RecvMsg(msg interface{}) error {
+func (m *mockLookupClient[T]) RecvMsg(msg interface{}) error {
 	return nil
 }
 
 func (a *AllowAllAuthz) LookupResources(ctx context.Context, in *v1beta1.LookupResourcesRequest) (grpc.ServerStreamingClient[v1beta1.LookupResourcesResponse], error) {
-	return &mockLookupResourcesClient{ctx: ctx}, nil
+	return &mockLookupClient[v1beta1.LookupResourcesResponse]{ctx: ctx}, nil
+}
+
+func (a *AllowAllAuthz) LookupSubjects(ctx context.Context, in *v1beta1.LookupSubjectsRequest) (grpc.ServerStreamingClient[v1beta1.LookupSubjectsResponse], error) {
+	return &mockLookupClient[v1beta1.LookupSubjectsResponse]{ctx: ctx}, nil
 }
 
 func (a *AllowAllAuthz) CreateTuples(ctx context.Context, r *v1beta1.CreateTuplesRequest) (*v1beta1.CreateTuplesResponse, error) {
@@ -81,6 +86,10 @@ func (a *AllowAllAuthz) DeleteTuples(ctx context.Context, r *v1beta1.DeleteTuple
 	return &v1beta1.DeleteTuplesResponse{}, nil
 }
 
+func (a *AllowAllAuthz) ReadTuples(ctx context.Context, in *v1beta1.ReadTuplesRequest) (grpc.ServerStreamingClient[v1beta1.ReadTuplesResponse], error) {
+	return &mockLookupClient[v1beta1.ReadTuplesResponse]{ctx: ctx}, nil
+}
+
 func (a *AllowAllAuthz) UnsetWorkspace(ctx context.Context, local_resource_id, name, namespace string) (*v1beta1.DeleteTuplesResponse, error) {
 	return &v1beta1.DeleteTuplesResponse{}, nil
 }
@@ -88,3 +97,7 @@ func (a *AllowAllAuthz) UnsetWorkspace(ctx context.Context, local_resource_id, n
 func (a *AllowAllAuthz) SetWorkspace(ctx context.Context, local_resource_id, workspace, name, namespace string, upsert bool) (*v1beta1.CreateTuplesResponse, error) {
 	return &v1beta1.CreateTuplesResponse{}, nil
 }
+
+func (a *AllowAllAuthz) ReplaceWorkspace(ctx context.Context, local_resource_id, previous_workspace, workspace, name, namespace string) (*v1beta1.CreateTuplesResponse, error) {
+	return &v1beta1.CreateTuplesResponse{}, nil
+}
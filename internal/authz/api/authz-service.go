@@ -9,13 +9,22 @@ import (
 	kessel "github.com/project-kessel/relations-api/api/kessel/relations/v1beta1"
 )
 
+// FailOpenToken is the consistency token of the checks allowed only because relations-api is unavailable, see the
+// check-failure-mode of the kessel authorizer.  They aren't decisions of relations-api, they mustn't be cached.
+var FailOpenToken = &kessel.ConsistencyToken{}
+
 type Authorizer interface {
 	Health(ctx context.Context) (*kesselv1.GetReadyzResponse, error)
 	Check(context.Context, string, string, *model.Resource, *kessel.SubjectReference) (kessel.CheckResponse_Allowed, *kessel.ConsistencyToken, error)
 	CheckForUpdate(context.Context, string, string, *model.Resource, *kessel.SubjectReference) (kessel.CheckForUpdateResponse_Allowed, *kessel.ConsistencyToken, error)
 	LookupResources(ctx context.Context, in *kessel.LookupResourcesRequest) (grpc.ServerStreamingClient[kessel.LookupResourcesResponse], error)
+	LookupSubjects(ctx context.Context, in *kessel.LookupSubjectsRequest) (grpc.ServerStreamingClient[kessel.LookupSubjectsResponse], error)
 	CreateTuples(context.Context, *kessel.CreateTuplesRequest) (*kessel.CreateTuplesResponse, error)
 	DeleteTuples(context.Context, *kessel.DeleteTuplesRequest) (*kessel.DeleteTuplesResponse, error)
+	ReadTuples(ctx context.Context, in *kessel.ReadTuplesRequest) (grpc.ServerStreamingClient[kessel.ReadTuplesResponse], error)
 	UnsetWorkspace(context.Context, string, string, string) (*kessel.DeleteTuplesResponse, error)
 	SetWorkspace(context.Context, string, string, string, string, bool) (*kessel.CreateTuplesResponse, error)
+	// ReplaceWorkspace moves a resource from a workspace to another, the resource is left in its previous workspace
+	// when the move fails.
+	ReplaceWorkspace(context.Context, string, string, string, string, string) (*kessel.CreateTuplesResponse, error)
 }
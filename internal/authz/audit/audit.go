@@ -0,0 +1,233 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	kessel "github.com/project-kessel/relations-api/api/kessel/relations/v1beta1"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/metric"
+	"gorm.io/gorm"
+
+	authzapi "github.com/project-kessel/inventory-api/internal/authz/api"
+	"github.com/project-kessel/inventory-api/internal/biz/model"
+	"github.com/project-kessel/inventory-api/internal/middleware"
+)
+
+const (
+	Allowed = "ALLOWED"
+	Denied  = "DENIED"
+	// Failed is the decision of the checks that failed, they are recorded like the denials
+	Failed = "FAILED"
+)
+
+// AuditingAuthz records the decisions of the checks of the authorizer it decorates, with the identity of the
+// caller.  The other calls go through.  The decisions are written to the sink in the background, in order.
+type AuditingAuthz struct {
+	authzapi.Authorizer
+
+	sink              Sink
+	allowedSampleRate float64
+	writeTimeout      time.Duration
+	// sample returns a number in [0, 1), the allowed decisions are recorded when it is below the sample rate
+	sample func() float64
+	Logger *log.Helper
+
+	mu        sync.RWMutex
+	closed    bool
+	decisions chan *model.AuthzDecision
+	done      chan struct{}
+
+	recordedCounter metric.Int64Counter
+	failureCounter  metric.Int64Counter
+	droppedCounter  metric.Int64Counter
+}
+
+var _ authzapi.Authorizer = &AuditingAuthz{}
+
+// New decorates the authorizer with the decision audit log of the configured sink, the db sink writes to db
+func New(authorizer authzapi.Authorizer, config CompletedConfig, db *gorm.DB, logger *log.Helper) (*AuditingAuthz, error) {
+	var sink Sink
+	switch config.Sink {
+	case LogSink:
+		sink = &logSink{logger: logger}
+	case DBSink:
+		if db == nil {
+			return nil, fmt.Errorf("the %s audit sink requires the inventory database", DBSink)
+		}
+		sink = &dbSink{db: db}
+	case KafkaSink:
+		s, err := newKafkaSink(config)
+		if err != nil {
+			return nil, err
+		}
+		sink = s
+	default:
+		return nil, fmt.Errorf("unrecognized audit sink: %s", config.Sink)
+	}
+	return newAuditingAuthz(authorizer, sink, config, logger)
+}
+
+func newAuditingAuthz(authorizer authzapi.Authorizer, sink Sink, config CompletedConfig, logger *log.Helper) (*AuditingAuthz, error) {
+	logger.Infof("Recording the check decisions to the %s sink, %v of the allowed decisions", config.Sink, config.AllowedSampleRate)
+
+	meter := otel.Meter("github.com/project-kessel/inventory-api/blob/main/internal/server/otel")
+
+	recordedCounter, err := meter.Int64Counter("inventory_authz_audit_recorded")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create audit recorded counter: %w", err)
+	}
+
+	failureCounter, err := meter.Int64Counter("inventory_authz_audit_failures")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create audit failures counter: %w", err)
+	}
+
+	droppedCounter, err := meter.Int64Counter("inventory_authz_audit_dropped")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create audit dropped counter: %w", err)
+	}
+
+	a := &AuditingAuthz{
+		Authorizer:        authorizer,
+		sink:              sink,
+		allowedSampleRate: config.AllowedSampleRate,
+		writeTimeout:      config.WriteTimeout,
+		sample:            rand.Float64,
+		Logger:            logger,
+		decisions:         make(chan *model.AuthzDecision, config.QueueSize),
+		done:              make(chan struct{}),
+		recordedCounter:   recordedCounter,
+		failureCounter:    failureCounter,
+		droppedCounter:    droppedCounter,
+	}
+	if s, ok := sink.(deliveringSink); ok {
+		s.deliveries(a.delivered)
+	}
+	go a.write()
+	return a, nil
+}
+
+func (a *AuditingAuthz) Check(ctx context.Context, namespace string, viewPermission string, resource *model.Resource, sub *kessel.SubjectReference) (kessel.CheckResponse_Allowed, *kessel.ConsistencyToken, error) {
+	start := time.Now()
+	allowed, token, err := a.Authorizer.Check(ctx, namespace, viewPermission, resource, sub)
+	a.record(ctx, start, namespace, viewPermission, resource, sub, allowed == kessel.CheckResponse_ALLOWED_TRUE, token, err)
+	return allowed, token, err
+}
+
+func (a *AuditingAuthz) CheckForUpdate(ctx context.Context, namespace string, updatePermission string, resource *model.Resource, sub *kessel.SubjectReference) (kessel.CheckForUpdateResponse_Allowed, *kessel.ConsistencyToken, error) {
+	start := time.Now()
+	allowed, token, err := a.Authorizer.CheckForUpdate(ctx, namespace, updatePermission, resource, sub)
+	a.record(ctx, start, namespace, updatePermission, resource, sub, allowed == kessel.CheckForUpdateResponse_ALLOWED_TRUE, token, err)
+	return allowed, token, err
+}
+
+// record queues the decision, unless it is an allowed decision left out of the sample.  Denials and failures wait for
+// room in the queue and are dropped when the check's context is done first, sampled allowed decisions are dropped
+// when the queue is full rather than delaying the check.
+func (a *AuditingAuthz) record(ctx context.Context, start time.Time, namespace, relation string, resource *model.Resource, sub *kessel.SubjectReference, allowed bool, token *kessel.ConsistencyToken, err error) {
+	d := &model.AuthzDecision{
+		Timestamp:        start.UTC(),
+		Subject:          subjectString(sub),
+		Resource:         namespace + "/" + resource.ResourceType + ":" + resource.ReporterResourceId,
+		Relation:         relation,
+		Decision:         Denied,
+		ConsistencyToken: token.GetToken(),
+		LatencyMicros:    time.Since(start).Microseconds(),
+	}
+	switch {
+	case err != nil:
+		d.Decision = Failed
+		d.Error = err.Error()
+	case allowed:
+		if a.sample() >= a.allowedSampleRate {
+			return
+		}
+		d.Decision = Allowed
+	}
+	if identity, err := middleware.GetIdentity(ctx); err == nil {
+		d.CallerType = identity.Type
+		d.Caller = identity.Principal
+	}
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if a.closed {
+		a.Logger.Warnf("authz decision of %s on %s made after the audit log was shut down: %s", d.Subject, d.Resource, d.Decision)
+		return
+	}
+	if d.Decision == Allowed {
+		select {
+		case a.decisions <- d:
+		default:
+			a.droppedCounter.Add(context.Background(), 1)
+		}
+		return
+	}
+	select {
+	case a.decisions <- d:
+	case <-ctx.Done():
+		a.droppedCounter.Add(context.Background(), 1)
+		a.Logger.Warnf("dropped authz decision of %s on %s, the audit queue is full: %s", d.Subject, d.Resource, d.Decision)
+	}
+}
+
+// write writes the queued decisions to the sink, each within the write timeout.  The decisions of a delivering sink
+// are counted once their delivery is reported.
+func (a *AuditingAuthz) write() {
+	defer close(a.done)
+	_, delivering := a.sink.(deliveringSink)
+	for d := range a.decisions {
+		ctx, cancel := context.WithTimeout(context.Background(), a.writeTimeout)
+		err := a.sink.Write(ctx, d)
+		cancel()
+		if err != nil {
+			a.failureCounter.Add(context.Background(), 1)
+			a.Logger.Errorf("failed to record authz decision of %s on %s: %v", d.Subject, d.Resource, err)
+			continue
+		}
+		if !delivering {
+			a.recordedCounter.Add(context.Background(), 1)
+		}
+	}
+}
+
+// delivered counts the decision on the resource delivered by the sink, or failed when err is set
+func (a *AuditingAuthz) delivered(resource string, err error) {
+	if err != nil {
+		a.failureCounter.Add(context.Background(), 1)
+		a.Logger.Errorf("failed to deliver authz decision on %s: %v", resource, err)
+		return
+	}
+	a.recordedCounter.Add(context.Background(), 1)
+}
+
+// Shutdown records the queued decisions and closes the sink, the decisions made afterwards are only logged
+func (a *AuditingAuthz) Shutdown(ctx context.Context) error {
+	a.mu.Lock()
+	if !a.closed {
+		a.closed = true
+		close(a.decisions)
+	}
+	a.mu.Unlock()
+
+	select {
+	case <-a.done:
+	case <-ctx.Done():
+		return fmt.Errorf("failed to record the queued authz decisions: %w", ctx.Err())
+	}
+	return a.sink.Close(ctx)
+}
+
+func subjectString(sub *kessel.SubjectReference) string {
+	subject := sub.GetSubject()
+	s := subject.GetType().GetNamespace() + "/" + subject.GetType().GetName() + ":" + subject.GetId()
+	if sub.GetRelation() != "" {
+		s += "#" + sub.GetRelation()
+	}
+	return s
+}
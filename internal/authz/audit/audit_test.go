@@ -0,0 +1,349 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	kessel "github.com/project-kessel/relations-api/api/kessel/relations/v1beta1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	authnapi "github.com/project-kessel/inventory-api/internal/authn/api"
+	"github.com/project-kessel/inventory-api/internal/authz/allow"
+	"github.com/project-kessel/inventory-api/internal/biz/model"
+	"github.com/project-kessel/inventory-api/internal/data"
+	"github.com/project-kessel/inventory-api/internal/middleware"
+)
+
+// decidingAuthz allows the resources in allowed, and fails every check when err is set
+type decidingAuthz struct {
+	*allow.AllowAllAuthz
+	allowed map[string]bool
+	err     error
+}
+
+func (a *decidingAuthz) Check(ctx context.Context, namespace string, permission string, resource *model.Resource, sub *kessel.SubjectReference) (kessel.CheckResponse_Allowed, *kessel.ConsistencyToken, error) {
+	if a.err != nil {
+		return kessel.CheckResponse_ALLOWED_UNSPECIFIED, nil, a.err
+	}
+	token := &kessel.ConsistencyToken{Token: "token-" + resource.ReporterResourceId}
+	if a.allowed[resource.ReporterResourceId] {
+		return kessel.CheckResponse_ALLOWED_TRUE, token, nil
+	}
+	return kessel.CheckResponse_ALLOWED_FALSE, token, nil
+}
+
+func (a *decidingAuthz) CheckForUpdate(ctx context.Context, namespace string, permission string, resource *model.Resource, sub *kessel.SubjectReference) (kessel.CheckForUpdateResponse_Allowed, *kessel.ConsistencyToken, error) {
+	if a.allowed[resource.ReporterResourceId] {
+		return kessel.CheckForUpdateResponse_ALLOWED_TRUE, nil, nil
+	}
+	return kessel.CheckForUpdateResponse_ALLOWED_FALSE, nil, nil
+}
+
+// memorySink keeps the decisions written to it
+type memorySink struct {
+	mu        sync.Mutex
+	decisions []model.AuthzDecision
+	closed    bool
+}
+
+func (s *memorySink) Write(ctx context.Context, d *model.AuthzDecision) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.decisions = append(s.decisions, *d)
+	return nil
+}
+
+func (s *memorySink) Close(ctx context.Context) error {
+	s.closed = true
+	return nil
+}
+
+func setupAudit(t *testing.T, sampleRate float64) (*AuditingAuthz, *decidingAuthz, *memorySink) {
+	inner := &decidingAuthz{AllowAllAuthz: allow.New(log.NewHelper(log.DefaultLogger)), allowed: map[string]bool{"host-1": true}}
+	sink := &memorySink{}
+	authz, err := newAuditingAuthz(inner, sink, CompletedConfig{&completedConfig{Enabled: true, Sink: "memory", AllowedSampleRate: sampleRate, QueueSize: 10, WriteTimeout: time.Second}}, log.NewHelper(log.DefaultLogger))
+	require.NoError(t, err)
+	return authz, inner, sink
+}
+
+func principal(id string) *kessel.SubjectReference {
+	return &kessel.SubjectReference{Subject: &kessel.ObjectReference{Type: &kessel.ObjectType{Namespace: "rbac", Name: "principal"}, Id: id}}
+}
+
+func host(id string) *model.Resource {
+	return &model.Resource{ResourceType: "host", ReporterResourceId: id}
+}
+
+func withCaller(principal string) context.Context {
+	return context.WithValue(context.Background(), middleware.IdentityRequestKey, &authnapi.Identity{Principal: principal, Type: "service-account"})
+}
+
+func TestAuditingAuthz_RecordsDecisions(t *testing.T) {
+	authz, _, sink := setupAudit(t, 1)
+	ctx := withCaller("notifications")
+
+	allowed, token, err := authz.Check(ctx, "hbi", "view", host("host-1"), principal("alice"))
+	require.NoError(t, err)
+	assert.Equal(t, kessel.CheckResponse_ALLOWED_TRUE, allowed)
+	assert.Equal(t, "token-host-1", token.GetToken())
+	_, _, _ = authz.Check(ctx, "hbi", "view", host("host-2"), principal("alice"))
+	_, _, _ = authz.CheckForUpdate(ctx, "hbi", "update", host("host-1"), &kessel.SubjectReference{Subject: principal("admins").Subject, Relation: strPtr("member")})
+	require.NoError(t, authz.Shutdown(context.Background()))
+
+	require.Len(t, sink.decisions, 3)
+	for _, d := range sink.decisions {
+		assert.Equal(t, "service-account", d.CallerType)
+		assert.Equal(t, "notifications", d.Caller)
+		assert.False(t, d.Timestamp.IsZero())
+		assert.GreaterOrEqual(t, d.LatencyMicros, int64(0))
+	}
+	assert.Equal(t, "rbac/principal:alice", sink.decisions[0].Subject)
+	assert.Equal(t, "hbi/host:host-1", sink.decisions[0].Resource)
+	assert.Equal(t, "view", sink.decisions[0].Relation)
+	assert.Equal(t, Allowed, sink.decisions[0].Decision)
+	assert.Equal(t, "token-host-1", sink.decisions[0].ConsistencyToken)
+
+	assert.Equal(t, "hbi/host:host-2", sink.decisions[1].Resource)
+	assert.Equal(t, Denied, sink.decisions[1].Decision)
+
+	assert.Equal(t, "rbac/principal:admins#member", sink.decisions[2].Subject)
+	assert.Equal(t, "update", sink.decisions[2].Relation)
+	assert.Equal(t, Allowed, sink.decisions[2].Decision)
+	assert.True(t, sink.closed)
+}
+
+func TestAuditingAuthz_SamplesAllowedDecisions(t *testing.T) {
+	authz, inner, sink := setupAudit(t, 0.5)
+	samples := []float64{0.7, 0.2}
+	authz.sample = func() float64 {
+		s := samples[0]
+		samples = samples[1:]
+		return s
+	}
+	ctx := context.Background()
+
+	// the first allowed decision is left out of the sample, the denials and failures are all recorded
+	_, _, _ = authz.Check(ctx, "hbi", "view", host("host-1"), principal("alice"))
+	_, _, _ = authz.Check(ctx, "hbi", "view", host("host-1"), principal("bob"))
+	_, _, _ = authz.Check(ctx, "hbi", "view", host("host-2"), principal("alice"))
+	inner.err = errors.New("relations-api unavailable")
+	_, _, err := authz.Check(ctx, "hbi", "view", host("host-1"), principal("alice"))
+	assert.EqualError(t, err, "relations-api unavailable")
+	require.NoError(t, authz.Shutdown(ctx))
+
+	require.Len(t, sink.decisions, 3)
+	assert.Equal(t, "rbac/principal:bob", sink.decisions[0].Subject)
+	assert.Equal(t, Allowed, sink.decisions[0].Decision)
+	assert.Equal(t, Denied, sink.decisions[1].Decision)
+	assert.Equal(t, Failed, sink.decisions[2].Decision)
+	assert.Equal(t, "relations-api unavailable", sink.decisions[2].Error)
+	// the caller is unknown outside of a request
+	assert.Empty(t, sink.decisions[2].Caller)
+}
+
+func TestAuditingAuthz_AfterShutdown(t *testing.T) {
+	authz, _, sink := setupAudit(t, 1)
+	ctx := context.Background()
+	require.NoError(t, authz.Shutdown(ctx))
+	require.NoError(t, authz.Shutdown(ctx))
+
+	allowed, _, err := authz.Check(ctx, "hbi", "view", host("host-1"), principal("alice"))
+	assert.NoError(t, err)
+	assert.Equal(t, kessel.CheckResponse_ALLOWED_TRUE, allowed)
+	assert.Empty(t, sink.decisions)
+}
+
+func TestAuditingAuthz_DBSink(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, data.Migrate(db, log.NewHelper(log.DefaultLogger)))
+
+	inner := &decidingAuthz{AllowAllAuthz: allow.New(log.NewHelper(log.DefaultLogger))}
+	config := NewConfig(&Options{Enabled: true, Sink: DBSink, AllowedSampleRate: 1, QueueSize: 10, WriteTimeoutMs: 1000}).Complete()
+	authz, err := New(inner, config, db, log.NewHelper(log.DefaultLogger))
+	require.NoError(t, err)
+
+	ctx := withCaller("notifications")
+	_, _, _ = authz.Check(ctx, "hbi", "view", host("host-1"), principal("alice"))
+	_, _, _ = authz.Check(ctx, "hbi", "view", host("host-2"), principal("alice"))
+	require.NoError(t, authz.Shutdown(context.Background()))
+
+	var decisions []model.AuthzDecision
+	require.NoError(t, db.Order("id").Find(&decisions).Error)
+	require.Len(t, decisions, 2)
+	assert.Equal(t, "hbi/host:host-1", decisions[0].Resource)
+	assert.Equal(t, Denied, decisions[0].Decision)
+	assert.Equal(t, "notifications", decisions[1].Caller)
+	assert.Equal(t, "hbi/host:host-2", decisions[1].Resource)
+}
+
+// blockingSink blocks the writes until it is released or their context is done
+type blockingSink struct {
+	memorySink
+	writing chan struct{}
+	release chan struct{}
+}
+
+func (s *blockingSink) Write(ctx context.Context, d *model.AuthzDecision) error {
+	s.writing <- struct{}{}
+	select {
+	case <-s.release:
+		return s.memorySink.Write(ctx, d)
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// deliveringMemorySink reports the deliveries of its writes, the decisions on failed resources fail
+type deliveringMemorySink struct {
+	memorySink
+	failed    map[string]bool
+	delivered func(resource string, err error)
+}
+
+func (s *deliveringMemorySink) deliveries(delivered func(resource string, err error)) {
+	s.delivered = delivered
+}
+
+func (s *deliveringMemorySink) Write(ctx context.Context, d *model.AuthzDecision) error {
+	if s.failed[d.Resource] {
+		s.delivered(d.Resource, errors.New("message timed out"))
+		return nil
+	}
+	s.delivered(d.Resource, nil)
+	return s.memorySink.Write(ctx, d)
+}
+
+// meterCounters records the counters of the auditing authorizers created while it is installed
+func meterCounters(t *testing.T) func(name string) int64 {
+	previous := otel.GetMeterProvider()
+	reader := sdkmetric.NewManualReader()
+	otel.SetMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+	t.Cleanup(func() { otel.SetMeterProvider(previous) })
+
+	return func(name string) int64 {
+		var rm metricdata.ResourceMetrics
+		require.NoError(t, reader.Collect(context.Background(), &rm))
+		for _, sm := range rm.ScopeMetrics {
+			for _, m := range sm.Metrics {
+				if sum, ok := m.Data.(metricdata.Sum[int64]); ok && m.Name == name {
+					var total int64
+					for _, dp := range sum.DataPoints {
+						total += dp.Value
+					}
+					return total
+				}
+			}
+		}
+		return 0
+	}
+}
+
+func TestAuditingAuthz_DropsWhenTheCheckIsDone(t *testing.T) {
+	counter := meterCounters(t)
+	inner := &decidingAuthz{AllowAllAuthz: allow.New(log.NewHelper(log.DefaultLogger))}
+	sink := &blockingSink{writing: make(chan struct{}, 10), release: make(chan struct{})}
+	authz, err := newAuditingAuthz(inner, sink, CompletedConfig{&completedConfig{Enabled: true, Sink: "blocking", AllowedSampleRate: 1, QueueSize: 1, WriteTimeout: time.Minute}}, log.NewHelper(log.DefaultLogger))
+	require.NoError(t, err)
+
+	// the first decision is being written, the second fills the queue
+	_, _, _ = authz.Check(context.Background(), "hbi", "view", host("host-1"), principal("alice"))
+	<-sink.writing
+	_, _, _ = authz.Check(context.Background(), "hbi", "view", host("host-2"), principal("alice"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	allowed, _, err := authz.Check(ctx, "hbi", "view", host("host-3"), principal("alice"))
+	assert.NoError(t, err)
+	assert.Equal(t, kessel.CheckResponse_ALLOWED_FALSE, allowed)
+	assert.Equal(t, int64(1), counter("inventory_authz_audit_dropped"))
+
+	close(sink.release)
+	require.NoError(t, authz.Shutdown(context.Background()))
+	require.Len(t, sink.decisions, 2)
+	assert.Equal(t, "hbi/host:host-2", sink.decisions[1].Resource)
+	assert.Equal(t, int64(2), counter("inventory_authz_audit_recorded"))
+}
+
+func TestAuditingAuthz_DropsAllowedDecisionsWhenTheQueueIsFull(t *testing.T) {
+	counter := meterCounters(t)
+	inner := &decidingAuthz{AllowAllAuthz: allow.New(log.NewHelper(log.DefaultLogger)), allowed: map[string]bool{"host-3": true}}
+	sink := &blockingSink{writing: make(chan struct{}, 10), release: make(chan struct{})}
+	authz, err := newAuditingAuthz(inner, sink, CompletedConfig{&completedConfig{Enabled: true, Sink: "blocking", AllowedSampleRate: 1, QueueSize: 1, WriteTimeout: time.Minute}}, log.NewHelper(log.DefaultLogger))
+	require.NoError(t, err)
+
+	// the first decision is being written, the second fills the queue
+	_, _, _ = authz.Check(context.Background(), "hbi", "view", host("host-1"), principal("alice"))
+	<-sink.writing
+	_, _, _ = authz.Check(context.Background(), "hbi", "view", host("host-2"), principal("alice"))
+
+	// the allowed decision doesn't wait for the sink, its check has no deadline
+	allowed, _, err := authz.Check(context.Background(), "hbi", "view", host("host-3"), principal("alice"))
+	assert.NoError(t, err)
+	assert.Equal(t, kessel.CheckResponse_ALLOWED_TRUE, allowed)
+	assert.Equal(t, int64(1), counter("inventory_authz_audit_dropped"))
+
+	close(sink.release)
+	require.NoError(t, authz.Shutdown(context.Background()))
+	require.Len(t, sink.decisions, 2)
+	assert.Equal(t, "hbi/host:host-2", sink.decisions[1].Resource)
+}
+
+func TestAuditingAuthz_WriteTimeout(t *testing.T) {
+	counter := meterCounters(t)
+	inner := &decidingAuthz{AllowAllAuthz: allow.New(log.NewHelper(log.DefaultLogger))}
+	sink := &blockingSink{writing: make(chan struct{}, 10), release: make(chan struct{})}
+	authz, err := newAuditingAuthz(inner, sink, CompletedConfig{&completedConfig{Enabled: true, Sink: "blocking", AllowedSampleRate: 1, QueueSize: 10, WriteTimeout: 10 * time.Millisecond}}, log.NewHelper(log.DefaultLogger))
+	require.NoError(t, err)
+
+	_, _, _ = authz.Check(context.Background(), "hbi", "view", host("host-1"), principal("alice"))
+	require.NoError(t, authz.Shutdown(context.Background()))
+
+	assert.Empty(t, sink.decisions)
+	assert.Equal(t, int64(1), counter("inventory_authz_audit_failures"))
+	assert.Equal(t, int64(0), counter("inventory_authz_audit_recorded"))
+}
+
+func TestAuditingAuthz_CountsDeliveries(t *testing.T) {
+	counter := meterCounters(t)
+	inner := &decidingAuthz{AllowAllAuthz: allow.New(log.NewHelper(log.DefaultLogger))}
+	sink := &deliveringMemorySink{failed: map[string]bool{"hbi/host:host-2": true}}
+	authz, err := newAuditingAuthz(inner, sink, CompletedConfig{&completedConfig{Enabled: true, Sink: "delivering", AllowedSampleRate: 1, QueueSize: 10, WriteTimeout: time.Second}}, log.NewHelper(log.DefaultLogger))
+	require.NoError(t, err)
+
+	_, _, _ = authz.Check(context.Background(), "hbi", "view", host("host-1"), principal("alice"))
+	_, _, _ = authz.Check(context.Background(), "hbi", "view", host("host-2"), principal("alice"))
+	require.NoError(t, authz.Shutdown(context.Background()))
+
+	// the decisions the sink accepted but failed to deliver aren't recorded
+	assert.Equal(t, int64(1), counter("inventory_authz_audit_recorded"))
+	assert.Equal(t, int64(1), counter("inventory_authz_audit_failures"))
+}
+
+func TestOptions_Validate(t *testing.T) {
+	options := NewOptions()
+	assert.Empty(t, options.Validate())
+
+	options.Enabled = true
+	options.Sink = KafkaSink
+	options.AllowedSampleRate = 2
+	assert.Len(t, options.Validate(), 2)
+
+	options.AllowedSampleRate = 0.1
+	options.Kafka.BootstrapServers = "localhost:9092"
+	assert.Empty(t, options.Validate())
+}
+
+func strPtr(s string) *string {
+	return &s
+}
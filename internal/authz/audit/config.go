@@ -0,0 +1,49 @@
+package audit
+
+import (
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+)
+
+type Config struct {
+	*Options
+}
+
+func NewConfig(o *Options) *Config {
+	return &Config{Options: o}
+}
+
+type completedConfig struct {
+	Enabled           bool
+	Sink              string
+	AllowedSampleRate float64
+	QueueSize         int
+	WriteTimeout      time.Duration
+	KafkaTopic        string
+	KafkaConfig       *kafka.ConfigMap
+}
+
+type CompletedConfig struct {
+	*completedConfig
+}
+
+func (c *Config) Complete() CompletedConfig {
+	cfg := &completedConfig{
+		Enabled:           c.Enabled,
+		Sink:              c.Sink,
+		AllowedSampleRate: c.AllowedSampleRate,
+		QueueSize:         c.QueueSize,
+		WriteTimeout:      time.Duration(c.WriteTimeoutMs) * time.Millisecond,
+	}
+
+	if c.Sink == KafkaSink {
+		cfg.KafkaTopic = c.Kafka.Topic
+		cfg.KafkaConfig = &kafka.ConfigMap{"bootstrap.servers": c.Kafka.BootstrapServers}
+		for key, value := range c.Kafka.Properties {
+			(*cfg.KafkaConfig)[key] = value
+		}
+	}
+
+	return CompletedConfig{cfg}
+}
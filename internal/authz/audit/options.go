@@ -0,0 +1,93 @@
+package audit
+
+import (
+	"fmt"
+
+	"github.com/spf13/pflag"
+)
+
+const (
+	LogSink   = "log"
+	DBSink    = "db"
+	KafkaSink = "kafka"
+)
+
+type Options struct {
+	Enabled bool   `mapstructure:"enabled"`
+	Sink    string `mapstructure:"sink"`
+	// AllowedSampleRate is the fraction of the allowed decisions recorded, the denials are all recorded
+	AllowedSampleRate float64 `mapstructure:"allowed-sample-rate"`
+	QueueSize         int     `mapstructure:"queue-size"`
+	// WriteTimeoutMs bounds the write of a decision to the sink
+	WriteTimeoutMs int           `mapstructure:"write-timeout-ms"`
+	Kafka          *KafkaOptions `mapstructure:"kafka"`
+}
+
+type KafkaOptions struct {
+	BootstrapServers string `mapstructure:"bootstrap-servers"`
+	Topic            string `mapstructure:"topic"`
+	// Properties are the other librdkafka properties of the producer, e.g. security.protocol
+	Properties map[string]string `mapstructure:"properties"`
+}
+
+func NewOptions() *Options {
+	return &Options{
+		Enabled:           false,
+		Sink:              LogSink,
+		AllowedSampleRate: 1,
+		QueueSize:         1000,
+		WriteTimeoutMs:    5000,
+		Kafka: &KafkaOptions{
+			Topic: "kessel-inventory-authz-decisions",
+		},
+	}
+}
+
+func (o *Options) AddFlags(fs *pflag.FlagSet, prefix string) {
+	if prefix != "" {
+		prefix = prefix + "."
+	}
+	fs.BoolVar(&o.Enabled, prefix+"enabled", o.Enabled, "record the decisions of the checks.")
+	fs.StringVar(&o.Sink, prefix+"sink", o.Sink, "where the decisions are recorded.  Options are 'log', 'db' and 'kafka'.")
+	fs.Float64Var(&o.AllowedSampleRate, prefix+"allowed-sample-rate", o.AllowedSampleRate, "the fraction of the allowed decisions recorded, between 0 and 1.  Denials are all recorded.")
+	fs.IntVar(&o.QueueSize, prefix+"queue-size", o.QueueSize, "the number of decisions waiting to be recorded before denied and failed checks wait for them, and allowed decisions are dropped.")
+	fs.IntVar(&o.WriteTimeoutMs, prefix+"write-timeout-ms", o.WriteTimeoutMs, "the time a decision is written to the sink for before it is counted as failed, in milliseconds.")
+	fs.StringVar(&o.Kafka.BootstrapServers, prefix+"kafka.bootstrap-servers", o.Kafka.BootstrapServers, "the kafka brokers the decisions are sent to.")
+	fs.StringVar(&o.Kafka.Topic, prefix+"kafka.topic", o.Kafka.Topic, "the kafka topic the decisions are sent to.")
+}
+
+func (o *Options) Validate() []error {
+	var errs []error
+
+	if !o.Enabled {
+		return errs
+	}
+	if o.Sink != LogSink && o.Sink != DBSink && o.Sink != KafkaSink {
+		errs = append(errs, fmt.Errorf("invalid audit sink: %s.  Options are 'log', 'db' and 'kafka'", o.Sink))
+	}
+	if o.AllowedSampleRate < 0 || o.AllowedSampleRate > 1 {
+		errs = append(errs, fmt.Errorf("audit allowed-sample-rate must be between 0 and 1"))
+	}
+	if o.QueueSize <= 0 {
+		errs = append(errs, fmt.Errorf("audit queue-size must be positive"))
+	}
+	if o.WriteTimeoutMs <= 0 {
+		errs = append(errs, fmt.Errorf("audit write-timeout-ms must be positive"))
+	}
+	if o.Sink == KafkaSink {
+		if o.Kafka.BootstrapServers == "" {
+			errs = append(errs, fmt.Errorf("audit kafka.bootstrap-servers is required by the kafka sink"))
+		}
+		if o.Kafka.Topic == "" {
+			errs = append(errs, fmt.Errorf("audit kafka.topic is required by the kafka sink"))
+		}
+	}
+
+	return errs
+}
+
+func (o *Options) Complete() []error {
+	var errs []error
+
+	return errs
+}
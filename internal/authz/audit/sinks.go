@@ -0,0 +1,119 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"github.com/go-kratos/kratos/v2/log"
+	"gorm.io/gorm"
+
+	"github.com/project-kessel/inventory-api/internal/biz/model"
+)
+
+// Sink records the decisions, one at a time
+type Sink interface {
+	Write(ctx context.Context, decision *model.AuthzDecision) error
+	// Close flushes the decisions written to the sink
+	Close(ctx context.Context) error
+}
+
+// deliveringSink delivers the written decisions in the background, it reports the delivery of each decision to the
+// function with the resource of the decision
+type deliveringSink interface {
+	Sink
+	deliveries(delivered func(resource string, err error))
+}
+
+// logSink writes the decisions as structured log entries
+type logSink struct {
+	logger *log.Helper
+}
+
+func (s *logSink) Write(ctx context.Context, d *model.AuthzDecision) error {
+	s.logger.Infow(
+		"msg", "authz decision",
+		"timestamp", d.Timestamp,
+		"caller_type", d.CallerType,
+		"caller", d.Caller,
+		"subject", d.Subject,
+		"resource", d.Resource,
+		"relation", d.Relation,
+		"decision", d.Decision,
+		"consistency_token", d.ConsistencyToken,
+		"latency_us", d.LatencyMicros,
+		"error", d.Error,
+	)
+	return nil
+}
+
+func (s *logSink) Close(ctx context.Context) error {
+	return nil
+}
+
+// dbSink inserts the decisions in the authz_decisions table
+type dbSink struct {
+	db *gorm.DB
+}
+
+func (s *dbSink) Write(ctx context.Context, d *model.AuthzDecision) error {
+	return s.db.WithContext(ctx).Create(d).Error
+}
+
+func (s *dbSink) Close(ctx context.Context) error {
+	return nil
+}
+
+// kafkaSink sends the decisions as JSON messages keyed by their resource, their deliveries are reported
+type kafkaSink struct {
+	producer *kafka.Producer
+	topic    string
+	done     chan struct{}
+}
+
+func newKafkaSink(config CompletedConfig) (*kafkaSink, error) {
+	producer, err := kafka.NewProducer(config.KafkaConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the audit kafka producer: %w", err)
+	}
+	return &kafkaSink{producer: producer, topic: config.KafkaTopic, done: make(chan struct{})}, nil
+}
+
+func (s *kafkaSink) deliveries(delivered func(resource string, err error)) {
+	go func() {
+		defer close(s.done)
+		for e := range s.producer.Events() {
+			if m, ok := e.(*kafka.Message); ok {
+				delivered(string(m.Key), m.TopicPartition.Error)
+			}
+		}
+	}()
+}
+
+func (s *kafkaSink) Write(ctx context.Context, d *model.AuthzDecision) error {
+	value, err := json.Marshal(d)
+	if err != nil {
+		return err
+	}
+	return s.producer.Produce(&kafka.Message{
+		TopicPartition: kafka.TopicPartition{Topic: &s.topic, Partition: kafka.PartitionAny},
+		Key:            []byte(d.Resource),
+		Value:          value,
+	}, nil)
+}
+
+func (s *kafkaSink) Close(ctx context.Context) error {
+	timeoutMs := 15000
+	if deadline, ok := ctx.Deadline(); ok {
+		timeoutMs = int(time.Until(deadline).Milliseconds())
+	}
+	pending := s.producer.Flush(timeoutMs)
+	s.producer.Close()
+	<-s.done
+	if pending > 0 {
+		return fmt.Errorf("%d authz decision(s) were not delivered", pending)
+	}
+	return nil
+}
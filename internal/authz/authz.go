@@ -5,18 +5,83 @@ import (
 	"fmt"
 
 	"github.com/go-kratos/kratos/v2/log"
+	"gorm.io/gorm"
 
 	"github.com/project-kessel/inventory-api/internal/authz/allow"
 	"github.com/project-kessel/inventory-api/internal/authz/api"
+	"github.com/project-kessel/inventory-api/internal/authz/audit"
+	"github.com/project-kessel/inventory-api/internal/authz/cache"
 	"github.com/project-kessel/inventory-api/internal/authz/kessel"
+	"github.com/project-kessel/inventory-api/internal/authz/local"
+	"github.com/project-kessel/inventory-api/internal/authz/verify"
 )
 
-func New(ctx context.Context, config CompletedConfig, logger *log.Helper) (api.Authorizer, error) {
+// New returns the configured authorizer, the local authorizer stores its tuples in db.  The decisions of its checks
+// are cached when the cache is enabled, and recorded, cached or not, when the audit log is enabled.
+func New(ctx context.Context, config CompletedConfig, db *gorm.DB, logger *log.Helper) (api.Authorizer, error) {
+	authorizer, err := newAuthorizer(ctx, config, db, logger)
+	if err != nil {
+		return nil, err
+	}
+	if config.Cache.Enabled {
+		if authorizer, err = cache.New(authorizer, config.Cache, logger); err != nil {
+			return nil, err
+		}
+	}
+	if config.Audit.Enabled {
+		return audit.New(authorizer, config.Audit, db, log.NewHelper(log.With(logger.Logger(), "subsystem", "authz-audit")))
+	}
+	return authorizer, nil
+}
+
+// Shutdown records the decisions the audit log has yet to record, if the authorizer has one
+func Shutdown(ctx context.Context, authorizer api.Authorizer) error {
+	if a, ok := authorizer.(*audit.AuditingAuthz); ok {
+		return a.Shutdown(ctx)
+	}
+	return nil
+}
+
+// VerifySchema checks the relations schema, of relations-api or of the local authorizer, defines the types and the
+// relations of the loaded resource schemas.  The problems found are logged, and fail startup with VerifyFail.
+func VerifySchema(ctx context.Context, config CompletedConfig, authorizer api.Authorizer, logger *log.Helper) error {
+	if config.VerifySchema == VerifyOff || config.Authz == AllowAll {
+		return nil
+	}
+
+	definitions := verify.FromRelations(authorizer)
+	if config.Authz == Local {
+		definitions = verify.FromSchema(config.Local.Schema)
+	}
+	report, err := verify.Verify(ctx, definitions)
+	if err != nil {
+		if config.VerifySchema == VerifyFail {
+			return fmt.Errorf("failed to verify the relations schema: %w", err)
+		}
+		logger.Warnf("Failed to verify the relations schema: %v", err)
+		return nil
+	}
+
+	for _, problem := range report.Problems {
+		logger.Warnf("Relations schema: %s", problem)
+	}
+	if len(report.Problems) > 0 && config.VerifySchema == VerifyFail {
+		return fmt.Errorf("the relations schema lacks %d definition(s) or relation(s) of the resource types", len(report.Problems))
+	}
+	if len(report.Problems) == 0 {
+		logger.Infof("Verified the relations schema defines the %d resource type(s)", len(report.Types))
+	}
+	return nil
+}
+
+func newAuthorizer(ctx context.Context, config CompletedConfig, db *gorm.DB, logger *log.Helper) (api.Authorizer, error) {
 	switch config.Authz {
 	case AllowAll:
 		return allow.New(logger), nil
 	case Kessel:
 		return kessel.New(ctx, config.Kessel, logger)
+	case Local:
+		return local.New(config.Local, db, logger)
 	default:
 		return nil, fmt.Errorf("unrecognized authz.impl: %s", config.Authz)
 	}
@@ -29,6 +94,8 @@ func CheckAuthorizer(config CompletedConfig) string {
 		authType = "AllowAll"
 	case Kessel:
 		authType = "Kessel"
+	case Local:
+		authType = "Local"
 	default:
 		authType = "Unknown"
 	}
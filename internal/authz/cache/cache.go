@@ -0,0 +1,234 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/go-kratos/kratos/v2/log"
+	gocache "github.com/patrickmn/go-cache"
+	kessel "github.com/project-kessel/relations-api/api/kessel/relations/v1beta1"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/metric"
+
+	authzapi "github.com/project-kessel/inventory-api/internal/authz/api"
+	"github.com/project-kessel/inventory-api/internal/biz/model"
+)
+
+// CachingAuthz caches the decisions of the checks of the authorizer it decorates.  The other calls go through,
+// the changes of relations made through it invalidate the decisions of their resources.
+type CachingAuthz struct {
+	authzapi.Authorizer
+
+	decisions  *gocache.Cache
+	maxEntries int
+	Logger     *log.Helper
+
+	// mu guards keys and checks.  The decisions are never deleted while holding it, their eviction takes it.
+	mu sync.Mutex
+	// keys indexes the keys of the cached decisions by the key of their resource
+	keys map[string]map[string]struct{}
+	// checks tracks the checks in flight by the key of their resource
+	checks map[string]*inflight
+
+	hitCounter  metric.Int64Counter
+	missCounter metric.Int64Counter
+}
+
+var _ authzapi.Authorizer = &CachingAuthz{}
+
+// decision is a cached check, Requested is the consistency token the check was made with
+type decision struct {
+	Allowed   kessel.CheckResponse_Allowed
+	Token     *kessel.ConsistencyToken
+	Requested string
+}
+
+// inflight counts the checks in flight of a resource, and the invalidations of the resource since the first of them
+type inflight struct {
+	count      int
+	generation uint64
+}
+
+func New(authorizer authzapi.Authorizer, config CompletedConfig, logger *log.Helper) (*CachingAuthz, error) {
+	logger.Infof("Caching the check decisions for %v, up to %d decisions", config.TTL, config.MaxEntries)
+
+	meter := otel.Meter("github.com/project-kessel/inventory-api/blob/main/internal/server/otel")
+
+	hitCounter, err := meter.Int64Counter("inventory_authz_cache_hits")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cache hits counter: %w", err)
+	}
+
+	missCounter, err := meter.Int64Counter("inventory_authz_cache_misses")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cache misses counter: %w", err)
+	}
+
+	a := &CachingAuthz{
+		Authorizer:  authorizer,
+		decisions:   gocache.New(config.TTL, config.TTL),
+		maxEntries:  config.MaxEntries,
+		Logger:      logger,
+		keys:        map[string]map[string]struct{}{},
+		checks:      map[string]*inflight{},
+		hitCounter:  hitCounter,
+		missCounter: missCounter,
+	}
+	a.decisions.OnEvicted(a.evicted)
+	return a, nil
+}
+
+// Check returns the cached decision unless the check asks for a consistency token the decision isn't known to be
+// as fresh as.  The tokens are opaque, a decision is only known to be as fresh as the token it was made with and
+// the token it returned.
+func (a *CachingAuthz) Check(ctx context.Context, namespace string, viewPermission string, resource *model.Resource, sub *kessel.SubjectReference) (kessel.CheckResponse_Allowed, *kessel.ConsistencyToken, error) {
+	prefix := resourceKey(namespace, resource.ResourceType, resource.ReporterResourceId)
+	key := prefix + decisionSuffix(viewPermission, sub)
+
+	if cached, ok := a.decisions.Get(key); ok {
+		d := cached.(decision)
+		if resource.ConsistencyToken == "" || resource.ConsistencyToken == d.Requested || resource.ConsistencyToken == d.Token.GetToken() {
+			a.hitCounter.Add(ctx, 1)
+			return d.Allowed, d.Token, nil
+		}
+	}
+	a.missCounter.Add(ctx, 1)
+
+	generation := a.begin(prefix)
+	allowed, token, err := a.Authorizer.Check(ctx, namespace, viewPermission, resource, sub)
+	if err != nil || token == authzapi.FailOpenToken {
+		a.end(prefix, generation, "", decision{})
+		return allowed, token, err
+	}
+	a.end(prefix, generation, key, decision{Allowed: allowed, Token: token, Requested: resource.ConsistencyToken})
+	return allowed, token, nil
+}
+
+// begin tracks a check of the resource in flight, and returns the generation to hand back to end
+func (a *CachingAuthz) begin(prefix string) uint64 {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	check, ok := a.checks[prefix]
+	if !ok {
+		check = &inflight{}
+		a.checks[prefix] = check
+	}
+	check.count++
+	return check.generation
+}
+
+// end stops tracking a check of the resource, and caches its decision unless the key is empty or the resource
+// was invalidated while the check was in flight: the decision may predate the change.
+func (a *CachingAuthz) end(prefix string, generation uint64, key string, d decision) {
+	if key != "" && a.decisions.ItemCount() >= a.maxEntries {
+		a.decisions.DeleteExpired()
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	check := a.checks[prefix]
+	check.count--
+	if check.count == 0 {
+		delete(a.checks, prefix)
+	}
+	if key == "" || check.generation != generation || a.decisions.ItemCount() >= a.maxEntries {
+		return
+	}
+	if a.keys[prefix] == nil {
+		a.keys[prefix] = map[string]struct{}{}
+	}
+	a.keys[prefix][key] = struct{}{}
+	a.decisions.SetDefault(key, d)
+}
+
+// evicted drops the key of an expired or deleted decision from the index
+func (a *CachingAuthz) evicted(key string, _ interface{}) {
+	prefix := key[:strings.IndexByte(key, 0)+1]
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if keys, ok := a.keys[prefix]; ok {
+		delete(keys, key)
+		if len(keys) == 0 {
+			delete(a.keys, prefix)
+		}
+	}
+}
+
+func (a *CachingAuthz) CreateTuples(ctx context.Context, r *kessel.CreateTuplesRequest) (*kessel.CreateTuplesResponse, error) {
+	resp, err := a.Authorizer.CreateTuples(ctx, r)
+	for _, tuple := range r.GetTuples() {
+		resource := tuple.GetResource()
+		a.invalidate(resource.GetType().GetNamespace(), resource.GetType().GetName(), resource.GetId())
+	}
+	return resp, err
+}
+
+func (a *CachingAuthz) DeleteTuples(ctx context.Context, r *kessel.DeleteTuplesRequest) (*kessel.DeleteTuplesResponse, error) {
+	resp, err := a.Authorizer.DeleteTuples(ctx, r)
+	filter := r.GetFilter()
+	if filter.GetResourceNamespace() != "" && filter.GetResourceType() != "" && filter.GetResourceId() != "" {
+		a.invalidate(filter.GetResourceNamespace(), filter.GetResourceType(), filter.GetResourceId())
+	} else {
+		// the tuples of any resource may be gone
+		a.flush()
+	}
+	return resp, err
+}
+
+func (a *CachingAuthz) SetWorkspace(ctx context.Context, local_resource_id, workspace, namespace, name string, upsert bool) (*kessel.CreateTuplesResponse, error) {
+	resp, err := a.Authorizer.SetWorkspace(ctx, local_resource_id, workspace, namespace, name, upsert)
+	a.invalidate(namespace, name, local_resource_id)
+	return resp, err
+}
+
+func (a *CachingAuthz) ReplaceWorkspace(ctx context.Context, local_resource_id, previous_workspace, workspace, namespace, name string) (*kessel.CreateTuplesResponse, error) {
+	resp, err := a.Authorizer.ReplaceWorkspace(ctx, local_resource_id, previous_workspace, workspace, namespace, name)
+	a.invalidate(namespace, name, local_resource_id)
+	return resp, err
+}
+
+func (a *CachingAuthz) UnsetWorkspace(ctx context.Context, local_resource_id, namespace, name string) (*kessel.DeleteTuplesResponse, error) {
+	resp, err := a.Authorizer.UnsetWorkspace(ctx, local_resource_id, namespace, name)
+	a.invalidate(namespace, name, local_resource_id)
+	return resp, err
+}
+
+// invalidate drops the decisions of a resource, and keeps the checks of the resource in flight from caching theirs.
+// It runs even when the change failed, it may have been applied.
+func (a *CachingAuthz) invalidate(namespace, name, id string) {
+	prefix := resourceKey(namespace, name, id)
+	a.mu.Lock()
+	keys := a.keys[prefix]
+	delete(a.keys, prefix)
+	if check, ok := a.checks[prefix]; ok {
+		check.generation++
+	}
+	a.mu.Unlock()
+
+	for key := range keys {
+		a.decisions.Delete(key)
+	}
+}
+
+// flush drops the decisions of every resource, and keeps the checks in flight from caching theirs
+func (a *CachingAuthz) flush() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.keys = map[string]map[string]struct{}{}
+	for _, check := range a.checks {
+		check.generation++
+	}
+	a.decisions.Flush()
+}
+
+func resourceKey(namespace, name, id string) string {
+	return namespace + "/" + name + ":" + id + "\x00"
+}
+
+func decisionSuffix(relation string, sub *kessel.SubjectReference) string {
+	subject := sub.GetSubject()
+	return relation + "\x00" +
+		subject.GetType().GetNamespace() + "/" + subject.GetType().GetName() + ":" + subject.GetId() + "#" + sub.GetRelation()
+}
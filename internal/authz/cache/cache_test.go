@@ -0,0 +1,233 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	kessel "github.com/project-kessel/relations-api/api/kessel/relations/v1beta1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/project-kessel/inventory-api/internal/authz/allow"
+	"github.com/project-kessel/inventory-api/internal/biz/model"
+)
+
+// countingAuthz allows everything, and counts the checks, answered with the consistency token "token-<n>"
+type countingAuthz struct {
+	*allow.AllowAllAuthz
+	checks int
+	err    error
+	// during runs in the middle of the checks when set
+	during func()
+}
+
+func (a *countingAuthz) Check(ctx context.Context, namespace string, permission string, resource *model.Resource, sub *kessel.SubjectReference) (kessel.CheckResponse_Allowed, *kessel.ConsistencyToken, error) {
+	a.checks++
+	if a.during != nil {
+		a.during()
+	}
+	if a.err != nil {
+		return kessel.CheckResponse_ALLOWED_UNSPECIFIED, nil, a.err
+	}
+	return kessel.CheckResponse_ALLOWED_TRUE, &kessel.ConsistencyToken{Token: fmt.Sprintf("token-%d", a.checks)}, nil
+}
+
+func setupCache(t *testing.T, maxEntries int) (*CachingAuthz, *countingAuthz) {
+	inner := &countingAuthz{AllowAllAuthz: allow.New(log.NewHelper(log.DefaultLogger))}
+	authz, err := New(inner, CompletedConfig{&completedConfig{Enabled: true, TTL: time.Minute, MaxEntries: maxEntries}}, log.NewHelper(log.DefaultLogger))
+	require.NoError(t, err)
+	return authz, inner
+}
+
+func principal(id string) *kessel.SubjectReference {
+	return &kessel.SubjectReference{Subject: &kessel.ObjectReference{Type: &kessel.ObjectType{Namespace: "rbac", Name: "principal"}, Id: id}}
+}
+
+func host(id string, token string) *model.Resource {
+	return &model.Resource{ResourceType: "host", ReporterResourceId: id, ConsistencyToken: token}
+}
+
+func TestCachingAuthz_Check(t *testing.T) {
+	ctx := context.Background()
+	authz, inner := setupCache(t, 100)
+
+	for i := 0; i < 3; i++ {
+		allowed, token, err := authz.Check(ctx, "hbi", "view", host("host-1", ""), principal("alice"))
+		assert.NoError(t, err)
+		assert.Equal(t, kessel.CheckResponse_ALLOWED_TRUE, allowed)
+		assert.Equal(t, "token-1", token.GetToken())
+	}
+	assert.Equal(t, 1, inner.checks)
+
+	// decisions are cached by resource, relation and subject
+	_, _, _ = authz.Check(ctx, "hbi", "update", host("host-1", ""), principal("alice"))
+	_, _, _ = authz.Check(ctx, "hbi", "view", host("host-1", ""), principal("bob"))
+	_, _, _ = authz.Check(ctx, "hbi", "view", host("host-2", ""), principal("alice"))
+	assert.Equal(t, 4, inner.checks)
+}
+
+func TestCachingAuthz_ConsistencyToken(t *testing.T) {
+	ctx := context.Background()
+	authz, inner := setupCache(t, 100)
+
+	_, _, err := authz.Check(ctx, "hbi", "view", host("host-1", ""), principal("alice"))
+	require.NoError(t, err)
+
+	// the returned token is as fresh as the decision
+	_, _, _ = authz.Check(ctx, "hbi", "view", host("host-1", "token-1"), principal("alice"))
+	assert.Equal(t, 1, inner.checks)
+
+	// a token the decision isn't known to be as fresh as bypasses the cache, the new decision is cached with it
+	_, token, _ := authz.Check(ctx, "hbi", "view", host("host-1", "newer"), principal("alice"))
+	assert.Equal(t, "token-2", token.GetToken())
+	_, _, _ = authz.Check(ctx, "hbi", "view", host("host-1", "newer"), principal("alice"))
+	assert.Equal(t, 2, inner.checks)
+}
+
+func TestCachingAuthz_Errors(t *testing.T) {
+	ctx := context.Background()
+	authz, inner := setupCache(t, 100)
+	inner.err = errors.New("relations-api unavailable")
+
+	for i := 0; i < 2; i++ {
+		_, _, err := authz.Check(ctx, "hbi", "view", host("host-1", ""), principal("alice"))
+		assert.EqualError(t, err, "relations-api unavailable")
+	}
+	assert.Equal(t, 2, inner.checks)
+}
+
+func TestCachingAuthz_MaxEntries(t *testing.T) {
+	ctx := context.Background()
+	authz, inner := setupCache(t, 1)
+
+	for i := 0; i < 2; i++ {
+		_, _, _ = authz.Check(ctx, "hbi", "view", host("host-1", ""), principal("alice"))
+		_, _, _ = authz.Check(ctx, "hbi", "view", host("host-2", ""), principal("alice"))
+	}
+	// only the first decision fits in the cache
+	assert.Equal(t, 3, inner.checks)
+}
+
+func TestCachingAuthz_Invalidation(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		change func(authz *CachingAuthz) error
+		// flushed tells whether the decisions of the other resources are dropped too
+		flushed bool
+	}{
+		{
+			name: "set workspace",
+			change: func(authz *CachingAuthz) error {
+				_, err := authz.SetWorkspace(ctx, "host-1", "workspace-2", "hbi", "host", true)
+				return err
+			},
+		},
+		{
+			name: "replace workspace",
+			change: func(authz *CachingAuthz) error {
+				_, err := authz.ReplaceWorkspace(ctx, "host-1", "workspace-1", "workspace-2", "hbi", "host")
+				return err
+			},
+		},
+		{
+			name: "unset workspace",
+			change: func(authz *CachingAuthz) error {
+				_, err := authz.UnsetWorkspace(ctx, "host-1", "hbi", "host")
+				return err
+			},
+		},
+		{
+			name: "create tuples",
+			change: func(authz *CachingAuthz) error {
+				_, err := authz.CreateTuples(ctx, &kessel.CreateTuplesRequest{Tuples: []*kessel.Relationship{{
+					Resource: &kessel.ObjectReference{Type: &kessel.ObjectType{Namespace: "hbi", Name: "host"}, Id: "host-1"},
+					Relation: "workspace",
+					Subject:  &kessel.SubjectReference{Subject: &kessel.ObjectReference{Type: &kessel.ObjectType{Namespace: "rbac", Name: "workspace"}, Id: "workspace-2"}},
+				}}})
+				return err
+			},
+		},
+		{
+			name: "delete tuples of the resource",
+			change: func(authz *CachingAuthz) error {
+				_, err := authz.DeleteTuples(ctx, &kessel.DeleteTuplesRequest{Filter: &kessel.RelationTupleFilter{
+					ResourceNamespace: proto.String("hbi"),
+					ResourceType:      proto.String("host"),
+					ResourceId:        proto.String("host-1"),
+				}})
+				return err
+			},
+		},
+		{
+			name: "delete tuples of any resource",
+			change: func(authz *CachingAuthz) error {
+				_, err := authz.DeleteTuples(ctx, &kessel.DeleteTuplesRequest{Filter: &kessel.RelationTupleFilter{
+					ResourceNamespace: proto.String("hbi"),
+					Relation:          proto.String("workspace"),
+				}})
+				return err
+			},
+			flushed: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authz, inner := setupCache(t, 100)
+			_, _, _ = authz.Check(ctx, "hbi", "view", host("host-1", ""), principal("alice"))
+			_, _, _ = authz.Check(ctx, "hbi", "view", host("host-2", ""), principal("alice"))
+
+			require.NoError(t, tt.change(authz))
+
+			_, _, _ = authz.Check(ctx, "hbi", "view", host("host-1", ""), principal("alice"))
+			assert.Equal(t, 3, inner.checks)
+			_, _, _ = authz.Check(ctx, "hbi", "view", host("host-2", ""), principal("alice"))
+			if tt.flushed {
+				assert.Equal(t, 4, inner.checks)
+			} else {
+				assert.Equal(t, 3, inner.checks)
+			}
+		})
+	}
+}
+
+func TestCachingAuthz_InvalidationDuringCheck(t *testing.T) {
+	ctx := context.Background()
+	authz, inner := setupCache(t, 100)
+
+	inner.during = func() {
+		_, _ = authz.SetWorkspace(ctx, "host-1", "workspace-2", "hbi", "host", true)
+	}
+	_, _, _ = authz.Check(ctx, "hbi", "view", host("host-1", ""), principal("alice"))
+	inner.during = nil
+
+	// the decision may predate the new workspace, it isn't cached
+	_, _, _ = authz.Check(ctx, "hbi", "view", host("host-1", ""), principal("alice"))
+	assert.Equal(t, 2, inner.checks)
+	_, _, _ = authz.Check(ctx, "hbi", "view", host("host-1", ""), principal("alice"))
+	assert.Equal(t, 2, inner.checks)
+	assert.Empty(t, authz.checks)
+}
+
+func TestCachingAuthz_InvalidationDropsTheIndexedKeys(t *testing.T) {
+	ctx := context.Background()
+	authz, _ := setupCache(t, 100)
+
+	_, _, _ = authz.Check(ctx, "hbi", "view", host("host-1", ""), principal("alice"))
+	_, _, _ = authz.Check(ctx, "hbi", "view", host("host-1", ""), principal("bob"))
+	_, _, _ = authz.Check(ctx, "hbi", "view", host("host-2", ""), principal("alice"))
+	assert.Len(t, authz.keys[resourceKey("hbi", "host", "host-1")], 2)
+
+	_, err := authz.UnsetWorkspace(ctx, "host-1", "hbi", "host")
+	require.NoError(t, err)
+	assert.NotContains(t, authz.keys, resourceKey("hbi", "host", "host-1"))
+	assert.Len(t, authz.keys[resourceKey("hbi", "host", "host-2")], 1)
+	assert.Equal(t, 1, authz.decisions.ItemCount())
+}
@@ -0,0 +1,49 @@
+package cache
+
+import (
+	"fmt"
+
+	"github.com/spf13/pflag"
+)
+
+type Options struct {
+	Enabled    bool `mapstructure:"enabled"`
+	TTLMs      int  `mapstructure:"ttl-ms"`
+	MaxEntries int  `mapstructure:"max-entries"`
+}
+
+func NewOptions() *Options {
+	return &Options{
+		Enabled:    false,
+		TTLMs:      10000,
+		MaxEntries: 10000,
+	}
+}
+
+func (o *Options) AddFlags(fs *pflag.FlagSet, prefix string) {
+	if prefix != "" {
+		prefix = prefix + "."
+	}
+	fs.BoolVar(&o.Enabled, prefix+"enabled", o.Enabled, "cache the decisions of the checks.")
+	fs.IntVar(&o.TTLMs, prefix+"ttl-ms", o.TTLMs, "how long a decision is cached, in milliseconds.")
+	fs.IntVar(&o.MaxEntries, prefix+"max-entries", o.MaxEntries, "the maximum number of cached decisions.")
+}
+
+func (o *Options) Validate() []error {
+	var errs []error
+
+	if o.Enabled && o.TTLMs <= 0 {
+		errs = append(errs, fmt.Errorf("cache ttl-ms must be positive"))
+	}
+	if o.Enabled && o.MaxEntries <= 0 {
+		errs = append(errs, fmt.Errorf("cache max-entries must be positive"))
+	}
+
+	return errs
+}
+
+func (o *Options) Complete() []error {
+	var errs []error
+
+	return errs
+}
@@ -3,12 +3,22 @@ package authz
 import (
 	"context"
 
+	"github.com/project-kessel/inventory-api/internal/authz/audit"
+	"github.com/project-kessel/inventory-api/internal/authz/cache"
 	"github.com/project-kessel/inventory-api/internal/authz/kessel"
+	"github.com/project-kessel/inventory-api/internal/authz/local"
 )
 
 type Config struct {
 	Authz  string
 	Kessel *kessel.Config
+	Local  *local.Config
+	Cache  *cache.Config
+	Audit  *audit.Config
+
+	VerifySchema       string
+	MaxConcurrentLists int
+	MaxListLookupIds   int
 }
 
 func NewConfig(o *Options) *Config {
@@ -16,16 +26,34 @@ func NewConfig(o *Options) *Config {
 	if o.Authz == Kessel {
 		kcfg = kessel.NewConfig(o.Kessel)
 	}
+	var lcfg *local.Config
+	if o.Authz == Local {
+		lcfg = local.NewConfig(o.Local)
+	}
 
 	return &Config{
 		Authz:  o.Authz,
 		Kessel: kcfg,
+		Local:  lcfg,
+		Cache:  cache.NewConfig(o.Cache),
+		Audit:  audit.NewConfig(o.Audit),
+
+		VerifySchema:       o.VerifySchema,
+		MaxConcurrentLists: o.MaxConcurrentLists,
+		MaxListLookupIds:   o.MaxListLookupIds,
 	}
 }
 
 type completedConfig struct {
 	Authz  string
 	Kessel kessel.CompletedConfig
+	Local  local.CompletedConfig
+	Cache  cache.CompletedConfig
+	Audit  audit.CompletedConfig
+
+	VerifySchema       string
+	MaxConcurrentLists int
+	MaxListLookupIds   int
 }
 
 type CompletedConfig struct {
@@ -35,6 +63,12 @@ type CompletedConfig struct {
 func (c *Config) Complete(ctx context.Context) (CompletedConfig, []error) {
 	cfg := &completedConfig{
 		Authz: c.Authz,
+		Cache: c.Cache.Complete(),
+		Audit: c.Audit.Complete(),
+
+		VerifySchema:       c.VerifySchema,
+		MaxConcurrentLists: c.MaxConcurrentLists,
+		MaxListLookupIds:   c.MaxListLookupIds,
 	}
 
 	if c.Authz == Kessel {
@@ -45,5 +79,13 @@ func (c *Config) Complete(ctx context.Context) (CompletedConfig, []error) {
 		}
 	}
 
+	if c.Authz == Local {
+		lcl, errs := c.Local.Complete()
+		if errs != nil {
+			return CompletedConfig{}, errs
+		}
+		cfg.Local = lcl
+	}
+
 	return CompletedConfig{cfg}, nil
 }
@@ -0,0 +1,49 @@
+package kessel
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestBreaker(t *testing.T) {
+	now := time.Unix(0, 0)
+	b := newBreaker(2, time.Minute)
+	b.now = func() time.Time { return now }
+	unavailable := status.Error(codes.Unavailable, "connection refused")
+
+	// the errors which don't tell relations-api is down don't count
+	b.Record(unavailable)
+	b.Record(status.Error(codes.InvalidArgument, "invalid"))
+	b.Record(unavailable)
+	assert.True(t, b.Allow())
+
+	b.Record(unavailable)
+	assert.False(t, b.Allow())
+
+	// a single call probes relations-api once the breaker was open long enough
+	now = now.Add(time.Minute)
+	assert.True(t, b.Allow())
+	assert.False(t, b.Allow())
+	b.Record(unavailable)
+	assert.False(t, b.Allow())
+
+	now = now.Add(time.Minute)
+	assert.True(t, b.Allow())
+	b.Record(nil)
+	assert.True(t, b.Allow())
+	assert.True(t, b.Allow())
+}
+
+func TestBreaker_Disabled(t *testing.T) {
+	b := newBreaker(0, time.Minute)
+	for i := 0; i < 10; i++ {
+		b.Record(errors.New("failed"))
+		b.Record(status.Error(codes.Unavailable, "connection refused"))
+	}
+	assert.True(t, b.Allow())
+}
@@ -2,8 +2,13 @@ package kessel
 
 import (
 	"context"
+	"crypto"
+	"crypto/tls"
+	"time"
+
 	"github.com/authzed/grpcutil"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
 )
 
@@ -21,11 +26,30 @@ type tokenClientConfig struct {
 	url            string
 	enableOIDCAuth bool
 	insecure       bool
+	authMethod     string
+	privateKey     crypto.Signer
+	privateKeyId   string
+	tlsConfig      *tls.Config
+	refreshBefore  time.Duration
 }
 
 type completedConfig struct {
 	gRPCConn    *grpc.ClientConn
 	tokenConfig *tokenClientConfig
+	resilience  *resilienceConfig
+}
+
+type resilienceConfig struct {
+	checkTimeout            time.Duration
+	lookupTimeout           time.Duration
+	writeTimeout            time.Duration
+	healthTimeout           time.Duration
+	maxRetries              int
+	retryBackoff            time.Duration
+	retryBackoffMax         time.Duration
+	breakerFailureThreshold int
+	breakerOpen             time.Duration
+	failOpen                bool
 }
 
 type CompletedConfig struct {
@@ -36,13 +60,29 @@ func (c *Config) Complete(ctx context.Context) (CompletedConfig, []error) {
 	var opts []grpc.DialOption
 	opts = append(opts, grpc.EmptyDialOption{})
 
+	clientTLS, err := clientTLSConfig(c.ClientCertFile, c.ClientKeyFile)
+	if err != nil {
+		return CompletedConfig{}, []error{err}
+	}
+
 	if c.Insecure {
 		opts = append(opts, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	} else if clientTLS != nil {
+		// relations-api only accepts the tokens bound to the certificate over connections presenting it
+		opts = append(opts, grpc.WithTransportCredentials(credentials.NewTLS(clientTLS.Clone())))
 	} else {
 		tlsConfig, _ := grpcutil.WithSystemCerts(grpcutil.VerifyCA)
 		opts = append(opts, tlsConfig)
 	}
 
+	var privateKey crypto.Signer
+	if c.ClientAuthMethod == PrivateKeyJWT {
+		privateKey, err = loadPrivateKey(c.PrivateKeyFile)
+		if err != nil {
+			return CompletedConfig{}, []error{err}
+		}
+	}
+
 	conn, err := grpc.NewClient(
 		c.URL,
 		opts...,
@@ -57,7 +97,25 @@ func (c *Config) Complete(ctx context.Context) (CompletedConfig, []error) {
 		url:            c.TokenEndpoint,
 		enableOIDCAuth: c.EnableOidcAuth,
 		insecure:       c.Insecure,
+		authMethod:     c.ClientAuthMethod,
+		privateKey:     privateKey,
+		privateKeyId:   c.PrivateKeyId,
+		tlsConfig:      clientTLS,
+		refreshBefore:  time.Duration(c.TokenRefreshBeforeMs) * time.Millisecond,
+	}
+
+	resilience := &resilienceConfig{
+		checkTimeout:            time.Duration(c.CheckTimeoutMs) * time.Millisecond,
+		lookupTimeout:           time.Duration(c.LookupTimeoutMs) * time.Millisecond,
+		writeTimeout:            time.Duration(c.WriteTimeoutMs) * time.Millisecond,
+		healthTimeout:           time.Duration(c.HealthTimeoutMs) * time.Millisecond,
+		maxRetries:              c.MaxRetries,
+		retryBackoff:            time.Duration(c.RetryBackoffMs) * time.Millisecond,
+		retryBackoffMax:         time.Duration(c.RetryBackoffMaxMs) * time.Millisecond,
+		breakerFailureThreshold: c.BreakerFailureThreshold,
+		breakerOpen:             time.Duration(c.BreakerOpenMs) * time.Millisecond,
+		failOpen:                c.CheckFailureMode == FailOpen,
 	}
 
-	return CompletedConfig{&completedConfig{gRPCConn: conn, tokenConfig: tokenReq}}, nil
+	return CompletedConfig{&completedConfig{gRPCConn: conn, tokenConfig: tokenReq, resilience: resilience}}, nil
 }
@@ -2,6 +2,7 @@ package kessel
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"google.golang.org/protobuf/proto"
@@ -28,13 +29,23 @@ type KesselAuthz struct {
 	Logger         *log.Helper
 	successCounter metric.Int64Counter
 	failureCounter metric.Int64Counter
+
+	resilience      *resilienceConfig
+	breaker         *breaker
+	retryCounter    metric.Int64Counter
+	timeoutCounter  metric.Int64Counter
+	rejectedCounter metric.Int64Counter
+	failOpenCounter metric.Int64Counter
 }
 
 var _ authzapi.Authorizer = &KesselAuthz{}
 
 func New(ctx context.Context, config CompletedConfig, logger *log.Helper) (*KesselAuthz, error) {
 	logger.Info("Using authorizer: kessel")
-	tokenCli := NewTokenClient(config.tokenConfig)
+	tokenCli, err := NewTokenClient(config.tokenConfig)
+	if err != nil {
+		return nil, err
+	}
 
 	meter := otel.Meter("github.com/project-kessel/inventory-api/blob/main/internal/server/otel")
 
@@ -48,6 +59,26 @@ func New(ctx context.Context, config CompletedConfig, logger *log.Helper) (*Kess
 		return nil, fmt.Errorf("failed to create failure counter: %w", err)
 	}
 
+	retryCounter, err := meter.Int64Counter("inventory_relations_api_retries")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create retries counter: %w", err)
+	}
+
+	timeoutCounter, err := meter.Int64Counter("inventory_relations_api_timeouts")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create timeouts counter: %w", err)
+	}
+
+	rejectedCounter, err := meter.Int64Counter("inventory_relations_api_rejected")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create rejected counter: %w", err)
+	}
+
+	failOpenCounter, err := meter.Int64Counter("inventory_relations_api_fail_open")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create fail open counter: %w", err)
+	}
+
 	return &KesselAuthz{
 		HealthService:  kesselv1.NewKesselRelationsHealthServiceClient(config.gRPCConn),
 		CheckService:   kessel.NewKesselCheckServiceClient(config.gRPCConn),
@@ -57,6 +88,13 @@ func New(ctx context.Context, config CompletedConfig, logger *log.Helper) (*Kess
 		tokenClient:    tokenCli,
 		successCounter: successCounter,
 		failureCounter: failureCounter,
+
+		resilience:      config.resilience,
+		breaker:         newBreaker(config.resilience.breakerFailureThreshold, config.resilience.breakerOpen),
+		retryCounter:    retryCounter,
+		timeoutCounter:  timeoutCounter,
+		rejectedCounter: rejectedCounter,
+		failOpenCounter: failOpenCounter,
 	}, nil
 }
 
@@ -69,22 +107,12 @@ func (a *KesselAuthz) incrSuccessCounter(method string) {
 }
 
 func (a *KesselAuthz) Health(ctx context.Context) (*kesselv1.GetReadyzResponse, error) {
-	opts, err := a.getCallOptions()
-	if err != nil {
-		a.incrFailureCounter("Health")
-		return nil, err
-	}
 	if viper.GetBool("log.readyz") {
 		log.Infof("Checking relations-api readyz endpoint")
 	}
-	resp, err := a.HealthService.GetReadyz(ctx, &kesselv1.GetReadyzRequest{}, opts...)
-	if err != nil {
-		a.incrFailureCounter("Health")
-		return nil, err
-	}
-
-	a.incrSuccessCounter("Health")
-	return resp, nil
+	return call(ctx, a, "Health", false, a.resilience.healthTimeout, func(ctx context.Context, opts []grpc.CallOption) (*kesselv1.GetReadyzResponse, error) {
+		return a.HealthService.GetReadyz(ctx, &kesselv1.GetReadyzRequest{}, opts...)
+	})
 }
 
 func (a *KesselAuthz) getCallOptions() ([]grpc.CallOption, error) {
@@ -104,52 +132,66 @@ func (a *KesselAuthz) getCallOptions() ([]grpc.CallOption, error) {
 	return opts, nil
 }
 
+// CreateTuples is only retried when upserting, retrying an insert may fail because the first attempt succeeded.
 func (a *KesselAuthz) CreateTuples(ctx context.Context, r *kessel.CreateTuplesRequest) (*kessel.CreateTuplesResponse, error) {
-	opts, err := a.getCallOptions()
-	if err != nil {
-		a.incrFailureCounter("CreateTuples")
-		return nil, err
-	}
-
-	resp, err := a.TupleService.CreateTuples(ctx, r, opts...)
-	if err != nil {
-		a.incrFailureCounter("CreateTuples")
-		return nil, err
-	}
-
-	a.incrSuccessCounter("CreateTuples")
-	return resp, nil
+	return call(ctx, a, "CreateTuples", r.GetUpsert(), a.resilience.writeTimeout, func(ctx context.Context, opts []grpc.CallOption) (*kessel.CreateTuplesResponse, error) {
+		return a.TupleService.CreateTuples(ctx, r, opts...)
+	})
 }
 
 func (a *KesselAuthz) DeleteTuples(ctx context.Context, r *kessel.DeleteTuplesRequest) (*kessel.DeleteTuplesResponse, error) {
-	opts, err := a.getCallOptions()
-	if err != nil {
-		a.incrFailureCounter("DeleteTuples")
-		return nil, err
-	}
+	return call(ctx, a, "DeleteTuples", true, a.resilience.writeTimeout, func(ctx context.Context, opts []grpc.CallOption) (*kessel.DeleteTuplesResponse, error) {
+		return a.TupleService.DeleteTuples(ctx, r, opts...)
+	})
+}
 
-	resp, err := a.TupleService.DeleteTuples(ctx, r, opts...)
+// ReadTuples retries establishing the stream, the timeout covers reading its results.
+func (a *KesselAuthz) ReadTuples(ctx context.Context, in *kessel.ReadTuplesRequest) (grpc.ServerStreamingClient[kessel.ReadTuplesResponse], error) {
+	ctx, cancel := context.WithTimeout(ctx, a.resilience.lookupTimeout)
+	resp, err := call(ctx, a, "ReadTuples", true, 0, func(ctx context.Context, opts []grpc.CallOption) (grpc.ServerStreamingClient[kessel.ReadTuplesResponse], error) {
+		return a.TupleService.ReadTuples(ctx, in, opts...)
+	})
 	if err != nil {
-		a.incrFailureCounter("DeleteTuples")
+		cancel()
 		return nil, err
 	}
-
-	a.incrSuccessCounter("DeleteTuples")
-	return resp, nil
+	return &timeoutStream[kessel.ReadTuplesResponse]{ServerStreamingClient: resp, cancel: cancel}, nil
 }
 
+// LookupResources retries establishing the stream, the timeout covers reading its results.
 func (a *KesselAuthz) LookupResources(ctx context.Context, in *kessel.LookupResourcesRequest) (grpc.ServerStreamingClient[kessel.LookupResourcesResponse], error) {
-	opts, err := a.getCallOptions()
+	ctx, cancel := context.WithTimeout(ctx, a.resilience.lookupTimeout)
+	resp, err := call(ctx, a, "LookupResources", true, 0, func(ctx context.Context, opts []grpc.CallOption) (grpc.ServerStreamingClient[kessel.LookupResourcesResponse], error) {
+		return a.LookupService.LookupResources(ctx, in, opts...)
+	})
 	if err != nil {
-		a.incrFailureCounter("LookupResources")
+		cancel()
 		return nil, err
 	}
-	resp, err := a.LookupService.LookupResources(ctx, in, opts...)
+	return &timeoutStream[kessel.LookupResourcesResponse]{ServerStreamingClient: resp, cancel: cancel}, nil
+}
+
+// failOpen tells whether a check failing because relations-api is unavailable is allowed, as configured
+func (a *KesselAuthz) failOpen(method string, err error) bool {
+	if !a.resilience.failOpen || !isUnavailable(err) {
+		return false
+	}
+	a.Logger.Warnf("Allowing %s as relations-api is unavailable: %v", method, err)
+	a.incrCounter(a.failOpenCounter, method)
+	return true
+}
+
+// LookupSubjects retries establishing the stream, the timeout covers reading its results.
+func (a *KesselAuthz) LookupSubjects(ctx context.Context, in *kessel.LookupSubjectsRequest) (grpc.ServerStreamingClient[kessel.LookupSubjectsResponse], error) {
+	ctx, cancel := context.WithTimeout(ctx, a.resilience.lookupTimeout)
+	resp, err := call(ctx, a, "LookupSubjects", true, 0, func(ctx context.Context, opts []grpc.CallOption) (grpc.ServerStreamingClient[kessel.LookupSubjectsResponse], error) {
+		return a.LookupService.LookupSubjects(ctx, in, opts...)
+	})
 	if err != nil {
-		a.incrFailureCounter("LookupResources")
+		cancel()
 		return nil, err
 	}
-	return resp, nil
+	return &timeoutStream[kessel.LookupSubjectsResponse]{ServerStreamingClient: resp, cancel: cancel}, nil
 }
 
 func (a *KesselAuthz) UnsetWorkspace(ctx context.Context, local_resource_id, namespace, name string) (*kessel.DeleteTuplesResponse, error) {
@@ -168,12 +210,6 @@ func (a *KesselAuthz) UnsetWorkspace(ctx context.Context, local_resource_id, nam
 func (a *KesselAuthz) Check(ctx context.Context, namespace string, viewPermission string, resource *model.Resource, sub *kessel.SubjectReference) (kessel.CheckResponse_Allowed, *kessel.ConsistencyToken, error) {
 	log.Infof("Check: on %+v", resource)
 
-	opts, err := a.getCallOptions()
-	if err != nil {
-		a.incrFailureCounter("Check")
-		return kessel.CheckResponse_ALLOWED_UNSPECIFIED, nil, err
-	}
-
 	// If resource doesn't exist in inventory DB
 	// default send a minimize_latency check request
 	consistency := &kessel.Consistency{Requirement: &kessel.Consistency_MinimizeLatency{MinimizeLatency: true}}
@@ -186,55 +222,55 @@ func (a *KesselAuthz) Check(ctx context.Context, namespace string, viewPermissio
 		}
 	}
 
-	resp, err := a.CheckService.Check(ctx, &kessel.CheckRequest{
-		Resource: &kessel.ObjectReference{
-			Type: &kessel.ObjectType{
-				Namespace: namespace,
-				Name:      resource.ResourceType,
+	resp, err := call(ctx, a, "Check", true, a.resilience.checkTimeout, func(ctx context.Context, opts []grpc.CallOption) (*kessel.CheckResponse, error) {
+		return a.CheckService.Check(ctx, &kessel.CheckRequest{
+			Resource: &kessel.ObjectReference{
+				Type: &kessel.ObjectType{
+					Namespace: namespace,
+					Name:      resource.ResourceType,
+				},
+				Id: resource.ReporterResourceId,
 			},
-			Id: resource.ReporterResourceId,
-		},
-		Relation:    viewPermission,
-		Subject:     sub,
-		Consistency: consistency,
-	}, opts...)
+			Relation:    viewPermission,
+			Subject:     sub,
+			Consistency: consistency,
+		}, opts...)
+	})
 
 	log.Infof("CheckForView resp: %v err: %v", resp, err)
 
 	if err != nil {
-		a.incrFailureCounter("Check")
+		if a.failOpen("Check", err) {
+			return kessel.CheckResponse_ALLOWED_TRUE, authzapi.FailOpenToken, nil
+		}
 		return kessel.CheckResponse_ALLOWED_UNSPECIFIED, nil, err
 	}
 
-	a.incrSuccessCounter("Check")
 	return resp.GetAllowed(), resp.GetConsistencyToken(), nil
 }
 
 func (a *KesselAuthz) CheckForUpdate(ctx context.Context, namespace string, updatePermission string, resource *model.Resource, sub *kessel.SubjectReference) (kessel.CheckForUpdateResponse_Allowed, *kessel.ConsistencyToken, error) {
-	opts, err := a.getCallOptions()
-	if err != nil {
-		a.incrFailureCounter("CheckForUpdate")
-		return kessel.CheckForUpdateResponse_ALLOWED_UNSPECIFIED, nil, err
-	}
-
-	resp, err := a.CheckService.CheckForUpdate(ctx, &kessel.CheckForUpdateRequest{
-		Resource: &kessel.ObjectReference{
-			Type: &kessel.ObjectType{
-				Namespace: namespace,
-				Name:      resource.ResourceType,
+	resp, err := call(ctx, a, "CheckForUpdate", true, a.resilience.checkTimeout, func(ctx context.Context, opts []grpc.CallOption) (*kessel.CheckForUpdateResponse, error) {
+		return a.CheckService.CheckForUpdate(ctx, &kessel.CheckForUpdateRequest{
+			Resource: &kessel.ObjectReference{
+				Type: &kessel.ObjectType{
+					Namespace: namespace,
+					Name:      resource.ResourceType,
+				},
+				Id: resource.ReporterResourceId,
 			},
-			Id: resource.ReporterResourceId,
-		},
-		Relation: updatePermission,
-		Subject:  sub,
-	}, opts...)
+			Relation: updatePermission,
+			Subject:  sub,
+		}, opts...)
+	})
 
 	if err != nil {
-		a.incrFailureCounter("CheckForUpdate")
+		if a.failOpen("CheckForUpdate", err) {
+			return kessel.CheckForUpdateResponse_ALLOWED_TRUE, authzapi.FailOpenToken, nil
+		}
 		return kessel.CheckForUpdateResponse_ALLOWED_UNSPECIFIED, nil, err
 	}
 
-	a.incrSuccessCounter("CheckForUpdate")
 	return resp.GetAllowed(), resp.GetConsistencyToken(), nil
 }
 
@@ -244,8 +280,67 @@ func (a *KesselAuthz) SetWorkspace(ctx context.Context, local_resource_id, works
 		a.incrFailureCounter("SetWorkspace")
 		return nil, fmt.Errorf("workspace_id is required")
 	}
-	// TODO: remove previous tuple for workspace
-	rels := []*kessel.Relationship{{
+	// a workspace change replaces the previous tuple, see ReplaceWorkspace
+
+	a.incrSuccessCounter("SetWorkspace")
+	return a.CreateTuples(ctx, &kessel.CreateTuplesRequest{
+		Upsert: upsert,
+		Tuples: workspaceTuples(local_resource_id, workspace, namespace, name),
+	})
+}
+
+// ReplaceWorkspace deletes the tuple of the previous workspace then creates the one of the new workspace.  When the
+// creation fails, the tuple of the previous workspace is created again so the resource isn't left in no workspace.
+func (a *KesselAuthz) ReplaceWorkspace(ctx context.Context, local_resource_id, previous_workspace, workspace, namespace, name string) (*kessel.CreateTuplesResponse, error) {
+	if workspace == "" {
+		a.incrFailureCounter("ReplaceWorkspace")
+		return nil, fmt.Errorf("workspace_id is required")
+	}
+
+	_, err := a.DeleteTuples(ctx, &kessel.DeleteTuplesRequest{
+		Filter: &kessel.RelationTupleFilter{
+			ResourceNamespace: proto.String(namespace),
+			ResourceType:      proto.String(name),
+			ResourceId:        proto.String(local_resource_id),
+			Relation:          proto.String("workspace"),
+			SubjectFilter: &kessel.SubjectFilter{
+				SubjectNamespace: proto.String("rbac"),
+				SubjectType:      proto.String("workspace"),
+				SubjectId:        proto.String(previous_workspace),
+			},
+		},
+	})
+	if err != nil {
+		a.incrFailureCounter("ReplaceWorkspace")
+		return nil, fmt.Errorf("failed to delete the tuple of previous workspace %s: %w", previous_workspace, err)
+	}
+
+	resp, err := a.CreateTuples(ctx, &kessel.CreateTuplesRequest{
+		Upsert: true,
+		Tuples: workspaceTuples(local_resource_id, workspace, namespace, name),
+	})
+	if err != nil {
+		a.incrFailureCounter("ReplaceWorkspace")
+		// the creation may have failed because the request is done, the restoration outlives it
+		restoreCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), a.resilience.writeTimeout)
+		defer cancel()
+		_, compensationErr := a.CreateTuples(restoreCtx, &kessel.CreateTuplesRequest{
+			Upsert: true,
+			Tuples: workspaceTuples(local_resource_id, previous_workspace, namespace, name),
+		})
+		if compensationErr != nil {
+			a.Logger.Errorf("Failed to restore previous workspace %s of resource %s/%s:%s, it is in no workspace: %v", previous_workspace, namespace, name, local_resource_id, compensationErr)
+			return nil, errors.Join(fmt.Errorf("failed to create the tuple of workspace %s: %w", workspace, err), compensationErr)
+		}
+		return nil, fmt.Errorf("failed to create the tuple of workspace %s, restored previous workspace %s: %w", workspace, previous_workspace, err)
+	}
+
+	a.incrSuccessCounter("ReplaceWorkspace")
+	return resp, nil
+}
+
+func workspaceTuples(local_resource_id, workspace, namespace, name string) []*kessel.Relationship {
+	return []*kessel.Relationship{{
 		Resource: &kessel.ObjectReference{
 			Type: &kessel.ObjectType{
 				Name:      name,
@@ -264,10 +359,4 @@ func (a *KesselAuthz) SetWorkspace(ctx context.Context, local_resource_id, works
 			},
 		},
 	}}
-
-	a.incrSuccessCounter("SetWorkspace")
-	return a.CreateTuples(ctx, &kessel.CreateTuplesRequest{
-		Upsert: upsert,
-		Tuples: rels,
-	})
 }
@@ -0,0 +1,182 @@
+package kessel
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	kessel "github.com/project-kessel/relations-api/api/kessel/relations/v1beta1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/project-kessel/inventory-api/internal/authz/cache"
+	"github.com/project-kessel/inventory-api/internal/biz/model"
+)
+
+// fakeCheckService fails the checks with the errors it is given, in order, then allows them
+type fakeCheckService struct {
+	kessel.KesselCheckServiceClient
+	errs  []error
+	calls int
+	// block makes the checks wait for their deadline
+	block bool
+	// deny denies the checks that don't fail
+	deny bool
+}
+
+func (s *fakeCheckService) Check(ctx context.Context, in *kessel.CheckRequest, opts ...grpc.CallOption) (*kessel.CheckResponse, error) {
+	s.calls++
+	if s.block {
+		<-ctx.Done()
+		return nil, status.FromContextError(ctx.Err()).Err()
+	}
+	if len(s.errs) > 0 {
+		err := s.errs[0]
+		s.errs = s.errs[1:]
+		return nil, err
+	}
+	if s.deny {
+		return &kessel.CheckResponse{Allowed: kessel.CheckResponse_ALLOWED_FALSE}, nil
+	}
+	return &kessel.CheckResponse{Allowed: kessel.CheckResponse_ALLOWED_TRUE}, nil
+}
+
+func setupAuthz(t *testing.T, checks *fakeCheckService, modify func(options *Options)) *KesselAuthz {
+	options := NewOptions()
+	options.URL = "localhost:9000"
+	options.RetryBackoffMs = 1
+	options.RetryBackoffMaxMs = 2
+	if modify != nil {
+		modify(options)
+	}
+	require.Empty(t, options.Validate())
+
+	config, errs := NewConfig(options).Complete(context.Background())
+	require.Empty(t, errs)
+	authz, err := New(context.Background(), config, log.NewHelper(log.DefaultLogger))
+	require.NoError(t, err)
+
+	authz.CheckService = checks
+	authz.tokenClient = &tokenClient{}
+	return authz
+}
+
+func check(authz *KesselAuthz) (kessel.CheckResponse_Allowed, error) {
+	subject := &kessel.SubjectReference{Subject: &kessel.ObjectReference{Type: &kessel.ObjectType{Namespace: "rbac", Name: "principal"}, Id: "alice"}}
+	allowed, _, err := authz.Check(context.Background(), "hbi", "view", &model.Resource{ResourceType: "host", ReporterResourceId: "host-1"}, subject)
+	return allowed, err
+}
+
+func TestKesselAuthz_Retries(t *testing.T) {
+	unavailable := status.Error(codes.Unavailable, "connection refused")
+
+	tests := []struct {
+		name    string
+		errs    []error
+		calls   int
+		allowed kessel.CheckResponse_Allowed
+		code    codes.Code
+	}{
+		{name: "transient errors", errs: []error{unavailable, unavailable}, calls: 3, allowed: kessel.CheckResponse_ALLOWED_TRUE, code: codes.OK},
+		{name: "retries exhausted", errs: []error{unavailable, unavailable, unavailable}, calls: 3, code: codes.Unavailable},
+		{name: "not retriable", errs: []error{status.Error(codes.InvalidArgument, "invalid")}, calls: 1, code: codes.InvalidArgument},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checks := &fakeCheckService{errs: tt.errs}
+			authz := setupAuthz(t, checks, func(options *Options) { options.BreakerFailureThreshold = 0 })
+
+			allowed, err := check(authz)
+			assert.Equal(t, tt.code, status.Code(err))
+			assert.Equal(t, tt.allowed, allowed)
+			assert.Equal(t, tt.calls, checks.calls)
+		})
+	}
+}
+
+func TestKesselAuthz_Timeout(t *testing.T) {
+	checks := &fakeCheckService{block: true}
+	authz := setupAuthz(t, checks, func(options *Options) {
+		options.CheckTimeoutMs = 10
+		options.MaxRetries = 0
+	})
+
+	start := time.Now()
+	_, err := check(authz)
+	assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestKesselAuthz_FailOpenNotCached(t *testing.T) {
+	checks := &fakeCheckService{errs: []error{status.Error(codes.Unavailable, "connection refused")}, deny: true}
+	authz := setupAuthz(t, checks, func(options *Options) {
+		options.MaxRetries = 0
+		options.CheckFailureMode = FailOpen
+	})
+	cached, err := cache.New(authz, cache.NewConfig(&cache.Options{Enabled: true, TTLMs: 60000, MaxEntries: 100}).Complete(), log.NewHelper(log.DefaultLogger))
+	require.NoError(t, err)
+
+	subject := &kessel.SubjectReference{Subject: &kessel.ObjectReference{Type: &kessel.ObjectType{Namespace: "rbac", Name: "principal"}, Id: "alice"}}
+	resource := &model.Resource{ResourceType: "host", ReporterResourceId: "host-1"}
+
+	// allowed while relations-api is unavailable
+	allowed, _, err := cached.Check(context.Background(), "hbi", "view", resource, subject)
+	require.NoError(t, err)
+	assert.Equal(t, kessel.CheckResponse_ALLOWED_TRUE, allowed)
+
+	// once it recovers, its decision is used rather than the allowed one
+	allowed, _, err = cached.Check(context.Background(), "hbi", "view", resource, subject)
+	require.NoError(t, err)
+	assert.Equal(t, kessel.CheckResponse_ALLOWED_FALSE, allowed)
+	assert.Equal(t, 2, checks.calls)
+}
+
+func TestKesselAuthz_Breaker(t *testing.T) {
+	unavailable := status.Error(codes.Unavailable, "connection refused")
+	checks := &fakeCheckService{errs: []error{unavailable, unavailable, unavailable}}
+	authz := setupAuthz(t, checks, func(options *Options) {
+		options.MaxRetries = 0
+		options.BreakerFailureThreshold = 3
+	})
+
+	for i := 0; i < 3; i++ {
+		_, err := check(authz)
+		assert.Equal(t, codes.Unavailable, status.Code(err))
+	}
+
+	// the breaker fails fast without calling relations-api
+	_, err := check(authz)
+	assert.Equal(t, errBreakerOpen, err)
+	assert.Equal(t, 3, checks.calls)
+}
+
+func TestKesselAuthz_CheckFailureMode(t *testing.T) {
+	unavailable := status.Error(codes.Unavailable, "connection refused")
+
+	authz := setupAuthz(t, &fakeCheckService{errs: []error{unavailable}}, func(options *Options) {
+		options.MaxRetries = 0
+		options.CheckFailureMode = FailOpen
+	})
+	allowed, err := check(authz)
+	assert.NoError(t, err)
+	assert.Equal(t, kessel.CheckResponse_ALLOWED_TRUE, allowed)
+
+	// only the unavailability of relations-api is allowed
+	authz = setupAuthz(t, &fakeCheckService{errs: []error{status.Error(codes.InvalidArgument, "invalid")}}, func(options *Options) {
+		options.CheckFailureMode = FailOpen
+	})
+	_, err = check(authz)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	authz = setupAuthz(t, &fakeCheckService{errs: []error{unavailable}}, func(options *Options) {
+		options.MaxRetries = 0
+	})
+	allowed, err = check(authz)
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+	assert.Equal(t, kessel.CheckResponse_ALLOWED_UNSPECIFIED, allowed)
+}
@@ -14,12 +14,55 @@ type Options struct {
 	ClientId       string `mapstructure:"sa-client-id"`
 	ClientSecret   string `mapstructure:"sa-client-secret"`
 	TokenEndpoint  string `mapstructure:"sso-token-endpoint"`
+	// ClientAuthMethod is how the service account authenticates to the token endpoint: client_secret_post,
+	// private_key_jwt or tls_client_auth
+	ClientAuthMethod string `mapstructure:"sa-client-auth-method"`
+	PrivateKeyFile   string `mapstructure:"sa-private-key-file"`
+	PrivateKeyId     string `mapstructure:"sa-private-key-id"`
+	// ClientCertFile and ClientKeyFile are the certificate presented to the token endpoint and relations-api, the
+	// tokens bound to it are only accepted over connections presenting it
+	ClientCertFile       string `mapstructure:"sa-client-cert-file"`
+	ClientKeyFile        string `mapstructure:"sa-client-key-file"`
+	TokenRefreshBeforeMs int    `mapstructure:"token-refresh-before-ms"`
+
+	CheckTimeoutMs  int `mapstructure:"check-timeout-ms"`
+	LookupTimeoutMs int `mapstructure:"lookup-timeout-ms"`
+	WriteTimeoutMs  int `mapstructure:"write-timeout-ms"`
+	HealthTimeoutMs int `mapstructure:"health-timeout-ms"`
+	// MaxRetries bounds the retries of the idempotent calls failing with transient errors
+	MaxRetries        int `mapstructure:"max-retries"`
+	RetryBackoffMs    int `mapstructure:"retry-backoff-ms"`
+	RetryBackoffMaxMs int `mapstructure:"retry-backoff-max-ms"`
+	// BreakerFailureThreshold is the number of consecutive failures opening the circuit breaker, 0 disables it
+	BreakerFailureThreshold int `mapstructure:"breaker-failure-threshold"`
+	BreakerOpenMs           int `mapstructure:"breaker-open-ms"`
+	// CheckFailureMode is "closed" to fail the checks relations-api can't answer, "open" to allow them
+	CheckFailureMode string `mapstructure:"check-failure-mode"`
 }
 
+const (
+	FailClosed = "closed"
+	FailOpen   = "open"
+)
+
 func NewOptions() *Options {
 	return &Options{
 		Insecure:       false,
 		EnableOidcAuth: true,
+
+		ClientAuthMethod:     ClientSecretPost,
+		TokenRefreshBeforeMs: 30000,
+
+		CheckTimeoutMs:          2000,
+		LookupTimeoutMs:         30000,
+		WriteTimeoutMs:          5000,
+		HealthTimeoutMs:         2000,
+		MaxRetries:              2,
+		RetryBackoffMs:          100,
+		RetryBackoffMaxMs:       2000,
+		BreakerFailureThreshold: 5,
+		BreakerOpenMs:           10000,
+		CheckFailureMode:        FailClosed,
 	}
 }
 
@@ -31,8 +74,24 @@ func (o *Options) AddFlags(fs *pflag.FlagSet, prefix string) {
 	fs.StringVar(&o.ClientId, prefix+"sa-client-id", o.ClientId, "service account client id")
 	fs.StringVar(&o.ClientSecret, prefix+"sa-client-secret", o.ClientSecret, "service account secret")
 	fs.StringVar(&o.TokenEndpoint, prefix+"sso-token-endpoint", o.TokenEndpoint, "sso token endpoint.")
+	fs.StringVar(&o.ClientAuthMethod, prefix+"sa-client-auth-method", o.ClientAuthMethod, "how the service account authenticates to the sso token endpoint: client_secret_post, private_key_jwt or tls_client_auth.")
+	fs.StringVar(&o.PrivateKeyFile, prefix+"sa-private-key-file", o.PrivateKeyFile, "PEM private key signing the client assertions of private_key_jwt.")
+	fs.StringVar(&o.PrivateKeyId, prefix+"sa-private-key-id", o.PrivateKeyId, "key id of the client assertions of private_key_jwt.")
+	fs.StringVar(&o.ClientCertFile, prefix+"sa-client-cert-file", o.ClientCertFile, "PEM client certificate presented to the sso token endpoint and kessel, for mTLS bound tokens.")
+	fs.StringVar(&o.ClientKeyFile, prefix+"sa-client-key-file", o.ClientKeyFile, "PEM private key of the client certificate.")
+	fs.IntVar(&o.TokenRefreshBeforeMs, prefix+"token-refresh-before-ms", o.TokenRefreshBeforeMs, "how long before their expiry the tokens are refreshed, in milliseconds.")
 	fs.BoolVar(&o.EnableOidcAuth, prefix+"enable-oidc-auth", o.EnableOidcAuth, "enable oidc token auth to connect with kessel service")
 	fs.BoolVar(&o.Insecure, prefix+"insecure-client", o.Insecure, "the http client that connects to kessel should not verify certificates.")
+	fs.IntVar(&o.CheckTimeoutMs, prefix+"check-timeout-ms", o.CheckTimeoutMs, "timeout of the checks, in milliseconds.")
+	fs.IntVar(&o.LookupTimeoutMs, prefix+"lookup-timeout-ms", o.LookupTimeoutMs, "timeout of the lookups, including reading their results, in milliseconds.")
+	fs.IntVar(&o.WriteTimeoutMs, prefix+"write-timeout-ms", o.WriteTimeoutMs, "timeout of the writes of tuples, in milliseconds.")
+	fs.IntVar(&o.HealthTimeoutMs, prefix+"health-timeout-ms", o.HealthTimeoutMs, "timeout of the readiness calls, in milliseconds.")
+	fs.IntVar(&o.MaxRetries, prefix+"max-retries", o.MaxRetries, "retries of the idempotent calls failing with transient errors.")
+	fs.IntVar(&o.RetryBackoffMs, prefix+"retry-backoff-ms", o.RetryBackoffMs, "base of the jittered exponential backoff between retries, in milliseconds.")
+	fs.IntVar(&o.RetryBackoffMaxMs, prefix+"retry-backoff-max-ms", o.RetryBackoffMaxMs, "maximum backoff between retries, in milliseconds.")
+	fs.IntVar(&o.BreakerFailureThreshold, prefix+"breaker-failure-threshold", o.BreakerFailureThreshold, "consecutive failures opening the circuit breaker, 0 disables it.")
+	fs.IntVar(&o.BreakerOpenMs, prefix+"breaker-open-ms", o.BreakerOpenMs, "how long the circuit breaker stays open before probing relations-api, in milliseconds.")
+	fs.StringVar(&o.CheckFailureMode, prefix+"check-failure-mode", o.CheckFailureMode, "'closed' fails the checks relations-api can't answer, 'open' allows them.")
 }
 
 func (o *Options) Validate() []error {
@@ -42,6 +101,44 @@ func (o *Options) Validate() []error {
 		errs = append(errs, fmt.Errorf("kessel url may not be empty"))
 	}
 
+	switch o.ClientAuthMethod {
+	case ClientSecretPost:
+	case PrivateKeyJWT:
+		if o.PrivateKeyFile == "" {
+			errs = append(errs, fmt.Errorf("kessel sa-private-key-file is required by private_key_jwt"))
+		}
+	case TLSClientAuth:
+		if o.ClientCertFile == "" {
+			errs = append(errs, fmt.Errorf("kessel sa-client-cert-file is required by tls_client_auth"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("invalid kessel sa-client-auth-method: %s.  Options are 'client_secret_post', 'private_key_jwt' and 'tls_client_auth'", o.ClientAuthMethod))
+	}
+
+	if (o.ClientCertFile == "") != (o.ClientKeyFile == "") {
+		errs = append(errs, fmt.Errorf("kessel sa-client-cert-file and sa-client-key-file must be set together"))
+	}
+
+	if o.TokenRefreshBeforeMs < 0 {
+		errs = append(errs, fmt.Errorf("kessel token-refresh-before-ms must not be negative"))
+	}
+
+	if o.CheckTimeoutMs <= 0 || o.LookupTimeoutMs <= 0 || o.WriteTimeoutMs <= 0 || o.HealthTimeoutMs <= 0 {
+		errs = append(errs, fmt.Errorf("kessel timeouts must be positive"))
+	}
+
+	if o.MaxRetries < 0 || o.RetryBackoffMs < 0 || o.RetryBackoffMaxMs < o.RetryBackoffMs {
+		errs = append(errs, fmt.Errorf("kessel max-retries and retry-backoff-ms must not be negative, retry-backoff-max-ms must not be lower than retry-backoff-ms"))
+	}
+
+	if o.BreakerFailureThreshold < 0 || o.BreakerOpenMs < 0 {
+		errs = append(errs, fmt.Errorf("kessel breaker-failure-threshold and breaker-open-ms must not be negative"))
+	}
+
+	if o.CheckFailureMode != FailClosed && o.CheckFailureMode != FailOpen {
+		errs = append(errs, fmt.Errorf("invalid kessel check-failure-mode: %s.  Options are 'closed' and 'open'", o.CheckFailureMode))
+	}
+
 	return errs
 }
 
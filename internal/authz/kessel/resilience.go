@@ -0,0 +1,117 @@
+package kessel
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// errBreakerOpen is returned without calling relations-api while the circuit breaker is open
+var errBreakerOpen = status.Error(codes.Unavailable, "relations-api circuit breaker is open")
+
+// call makes a call to relations-api through the circuit breaker, with a timeout per attempt unless timeout is 0.
+// The idempotent calls are retried when they fail with transient errors.
+func call[T any](ctx context.Context, a *KesselAuthz, method string, idempotent bool, timeout time.Duration, fn func(ctx context.Context, opts []grpc.CallOption) (T, error)) (T, error) {
+	var zero T
+	retries := 0
+	if idempotent {
+		retries = a.resilience.maxRetries
+	}
+
+	for try := 0; ; try++ {
+		if !a.breaker.Allow() {
+			a.incrCounter(a.rejectedCounter, method)
+			a.incrFailureCounter(method)
+			return zero, errBreakerOpen
+		}
+
+		resp, err := attempt(ctx, a, method, timeout, fn)
+		a.breaker.Record(err)
+		if err == nil {
+			a.incrSuccessCounter(method)
+			return resp, nil
+		}
+
+		if try >= retries || !isRetriable(err) || ctx.Err() != nil {
+			a.incrFailureCounter(method)
+			return zero, err
+		}
+
+		backoff := a.backoff(try)
+		a.Logger.Warnf("Call %s to relations-api failed, retrying in %v: %v", method, backoff, err)
+		a.incrCounter(a.retryCounter, method)
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			a.incrFailureCounter(method)
+			return zero, err
+		}
+	}
+}
+
+// attempt makes a single call, counting the ones timing out
+func attempt[T any](ctx context.Context, a *KesselAuthz, method string, timeout time.Duration, fn func(ctx context.Context, opts []grpc.CallOption) (T, error)) (T, error) {
+	var zero T
+	opts, err := a.getCallOptions()
+	if err != nil {
+		return zero, err
+	}
+
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	resp, err := fn(ctx, opts)
+	if err != nil && (status.Code(err) == codes.DeadlineExceeded || errors.Is(err, context.DeadlineExceeded)) {
+		a.incrCounter(a.timeoutCounter, method)
+	}
+	return resp, err
+}
+
+// backoff returns the full jitter exponential backoff before the retry following the try
+func (a *KesselAuthz) backoff(try int) time.Duration {
+	ceiling := a.resilience.retryBackoff << try
+	if ceiling <= 0 || ceiling > a.resilience.retryBackoffMax {
+		ceiling = a.resilience.retryBackoffMax
+	}
+	if ceiling <= 0 {
+		return 0
+	}
+	return rand.N(ceiling)
+}
+
+// isRetriable tells whether a call failing with the error may succeed when retried
+func isRetriable(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted, codes.Aborted:
+		return true
+	}
+	return false
+}
+
+func (a *KesselAuthz) incrCounter(counter metric.Int64Counter, method string) {
+	counter.Add(context.Background(), 1, metric.WithAttributes(attribute.String("method", method)))
+}
+
+// timeoutStream cancels the timeout of a lookup once its results are read
+type timeoutStream[T any] struct {
+	grpc.ServerStreamingClient[T]
+	cancel context.CancelFunc
+}
+
+func (s *timeoutStream[T]) Recv() (*T, error) {
+	resp, err := s.ServerStreamingClient.Recv()
+	if err != nil {
+		s.cancel()
+	}
+	return resp, err
+}
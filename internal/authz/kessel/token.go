@@ -3,22 +3,44 @@ package kessel
 import (
 	"bytes"
 	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
+	"encoding/pem"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"os"
+	"sync"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
-	"github.com/patrickmn/go-cache"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"golang.org/x/sync/singleflight"
 	"google.golang.org/grpc"
 )
 
 const (
 	tokenLifeDuration            = 5 * time.Minute
-	cacheCleanupInterval         = 5 * time.Minute
+	tokenRequestTimeout          = 10 * time.Second
 	client_credentials_granttype = "client_credentials"
+	jwt_bearer_assertion_type    = "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+)
+
+// The client authentication methods of the token requests
+const (
+	ClientSecretPost = "client_secret_post"
+	PrivateKeyJWT    = "private_key_jwt"
+	TLSClientAuth    = "tls_client_auth"
 )
 
 type secureMetadataCreds map[string]string
@@ -50,15 +72,39 @@ func WithInsecureBearerToken(token string) grpc.CallOption {
 }
 
 // NewTokenClient creates and returns a new tokenClient client.
-func NewTokenClient(config *tokenClientConfig) *tokenClient {
+func NewTokenClient(config *tokenClientConfig) (*tokenClient, error) {
+	meter := otel.Meter("github.com/project-kessel/inventory-api/blob/main/internal/server/otel")
+
+	refreshCounter, err := meter.Int64Counter("inventory_relations_api_token_refreshes")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create token refreshes counter: %w", err)
+	}
+
+	failureCounter, err := meter.Int64Counter("inventory_relations_api_token_failures")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create token failures counter: %w", err)
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if config.tlsConfig != nil {
+		transport.TLSClientConfig = config.tlsConfig
+	}
+
 	return &tokenClient{
 		ClientID:       config.clientId,
 		ClientSecret:   config.clientSecret,
 		URL:            config.url,
 		EnableOIDCAuth: config.enableOIDCAuth,
 		Insecure:       config.insecure,
-		cache:          cache.New(tokenLifeDuration, cacheCleanupInterval),
-	}
+		AuthMethod:     config.authMethod,
+		privateKey:     config.privateKey,
+		privateKeyId:   config.privateKeyId,
+		refreshBefore:  config.refreshBefore,
+		httpClient:     &http.Client{Transport: transport, Timeout: tokenRequestTimeout},
+		now:            time.Now,
+		refreshCounter: refreshCounter,
+		failureCounter: failureCounter,
+	}, nil
 }
 
 type TokenResponse struct {
@@ -67,22 +113,29 @@ type TokenResponse struct {
 	RefreshToken string `json:"refresh_token"`
 }
 
+// tokenClient requests the tokens of the service account with the client credentials grant.  A token is cached until
+// refreshBefore its expiry, the concurrent calls needing a new token share a single request.
 type tokenClient struct {
 	ClientID       string
 	ClientSecret   string
 	URL            string
 	EnableOIDCAuth bool
 	Insecure       bool
-	cache          *cache.Cache
-}
+	AuthMethod     string
 
-func (a *tokenClient) GetCachedToken(tokenKey string) (string, error) {
-	cachedToken, isCached := a.cache.Get(tokenKey)
-	ct, _ := cachedToken.(string)
-	if isCached {
-		return ct, nil
-	}
-	return "", fmt.Errorf("failed to retrieve cached token")
+	privateKey    crypto.Signer
+	privateKeyId  string
+	refreshBefore time.Duration
+	httpClient    *http.Client
+	now           func() time.Time
+
+	mu     sync.Mutex
+	token  *TokenResponse
+	expiry time.Time
+	group  singleflight.Group
+
+	refreshCounter metric.Int64Counter
+	failureCounter metric.Int64Counter
 }
 
 func IsJWTTokenExpired(accessToken string) (bool, time.Time) {
@@ -96,20 +149,78 @@ func IsJWTTokenExpired(accessToken string) (bool, time.Time) {
 	return true, time.Time{}
 }
 
+// cached returns the cached token, and whether it is due for a refresh
+func (a *tokenClient) cached() (*TokenResponse, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.token == nil || !a.now().Before(a.expiry) {
+		return nil, true
+	}
+	return a.token, !a.now().Before(a.expiry.Add(-a.refreshBefore))
+}
+
 func (a *tokenClient) getToken() (*TokenResponse, error) {
+	token, refresh := a.cached()
+	if !refresh {
+		return token, nil
+	}
+
+	refreshed, err, _ := a.group.Do(a.URL+a.ClientID, func() (interface{}, error) {
+		return a.refresh()
+	})
+	if err != nil {
+		if token != nil {
+			// the cached token is still valid, the refresh is tried again by the next call
+			return token, nil
+		}
+		return nil, err
+	}
+	return refreshed.(*TokenResponse), nil
+}
+
+// refresh requests a new token and caches it
+func (a *tokenClient) refresh() (*TokenResponse, error) {
+	a.refreshCounter.Add(context.Background(), 1)
+	token, err := a.requestToken()
+	if err != nil {
+		a.failureCounter.Add(context.Background(), 1, metric.WithAttributes(attribute.String("auth_method", a.AuthMethod)))
+		return nil, err
+	}
 
-	cachedTokenKey := fmt.Sprintf("%s%s", a.URL, a.ClientID)
-	cachedToken, _ := a.GetCachedToken(cachedTokenKey)
-	IsExpired, _ := IsJWTTokenExpired(cachedToken)
-	if cachedToken != "" && !IsExpired {
-		return &TokenResponse{AccessToken: cachedToken}, nil
+	now := a.now()
+	expiry := now.Add(tokenLifeDuration)
+	if token.ExpiresIn > 0 {
+		expiry = now.Add(time.Duration(token.ExpiresIn) * time.Second)
+	} else if _, exp := IsJWTTokenExpired(token.AccessToken); !exp.IsZero() {
+		expiry = exp
 	}
 
-	client := &http.Client{}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.token = token
+	a.expiry = expiry
+	return token, nil
+}
+
+func (a *tokenClient) requestToken() (*TokenResponse, error) {
 	data := url.Values{}
 	data.Set("client_id", a.ClientID)
-	data.Set("client_secret", a.ClientSecret)
 	data.Set("grant_type", client_credentials_granttype)
+	switch a.AuthMethod {
+	case PrivateKeyJWT:
+		assertion, err := a.clientAssertion()
+		if err != nil {
+			return nil, err
+		}
+		data.Set("client_assertion_type", jwt_bearer_assertion_type)
+		data.Set("client_assertion", assertion)
+	case TLSClientAuth:
+		// the client certificate of the connection authenticates the client
+	default:
+		data.Set("client_secret", a.ClientSecret)
+	}
+
 	req, err := http.NewRequest("POST", a.URL, bytes.NewBufferString(data.Encode()))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create token request: %w", err)
@@ -118,7 +229,7 @@ func (a *tokenClient) getToken() (*TokenResponse, error) {
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 	req.Header.Set("Accept", "application/json")
 
-	resp, err := client.Do(req)
+	resp, err := a.httpClient.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("token request failed: %w", err)
 	}
@@ -142,6 +253,93 @@ func (a *tokenClient) getToken() (*TokenResponse, error) {
 	if err := json.Unmarshal(body, &tokenResponse); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal token response: %w", err)
 	}
-	a.cache.Set(cachedTokenKey, tokenResponse.AccessToken, cacheCleanupInterval)
+	if tokenResponse.AccessToken == "" {
+		return nil, fmt.Errorf("token response has no access token")
+	}
 	return &tokenResponse, nil
 }
+
+// clientAssertion returns the JWT authenticating the client with private_key_jwt, signed by its private key
+func (a *tokenClient) clientAssertion() (string, error) {
+	method, err := signingMethod(a.privateKey)
+	if err != nil {
+		return "", err
+	}
+
+	now := a.now()
+	token := jwt.NewWithClaims(method, jwt.RegisteredClaims{
+		Issuer:    a.ClientID,
+		Subject:   a.ClientID,
+		Audience:  jwt.ClaimStrings{a.URL},
+		ID:        uuid.NewString(),
+		IssuedAt:  jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(now.Add(tokenLifeDuration)),
+	})
+	if a.privateKeyId != "" {
+		token.Header["kid"] = a.privateKeyId
+	}
+
+	assertion, err := token.SignedString(a.privateKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign client assertion: %w", err)
+	}
+	return assertion, nil
+}
+
+func signingMethod(key crypto.Signer) (jwt.SigningMethod, error) {
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		return jwt.SigningMethodRS256, nil
+	case *ecdsa.PrivateKey:
+		switch k.Curve {
+		case elliptic.P256():
+			return jwt.SigningMethodES256, nil
+		case elliptic.P384():
+			return jwt.SigningMethodES384, nil
+		case elliptic.P521():
+			return jwt.SigningMethodES512, nil
+		}
+	case ed25519.PrivateKey:
+		return jwt.SigningMethodEdDSA, nil
+	}
+	return nil, fmt.Errorf("unsupported private key type %T", key)
+}
+
+// clientTLSConfig returns the TLS configuration presenting the client certificate, nil when there is none
+func clientTLSConfig(certFile, keyFile string) (*tls.Config, error) {
+	if certFile == "" {
+		return nil, nil
+	}
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load client certificate: %w", err)
+	}
+	return &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}, nil
+}
+
+// loadPrivateKey reads the PEM encoded PKCS #8, PKCS #1 or EC private key signing the client assertions
+func loadPrivateKey(file string) (crypto.Signer, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read private key: %w", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("failed to decode private key %s: no PEM block", file)
+	}
+
+	if key, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		signer, ok := key.(crypto.Signer)
+		if !ok {
+			return nil, fmt.Errorf("unsupported private key type %T", key)
+		}
+		return signer, nil
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParseECPrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	return nil, fmt.Errorf("failed to parse private key %s", file)
+}
@@ -0,0 +1,207 @@
+package kessel
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// tokenEndpoint stands in for the OIDC token endpoint, it issues tokens valid for expiresIn after checking the
+// client authentication with authenticate
+type tokenEndpoint struct {
+	*httptest.Server
+	requests     atomic.Int32
+	expiresIn    int
+	fail         atomic.Bool
+	delay        time.Duration
+	authenticate func(r *http.Request) bool
+}
+
+func newTokenEndpoint(t *testing.T, authenticate func(r *http.Request) bool) *tokenEndpoint {
+	e := &tokenEndpoint{expiresIn: 300, authenticate: authenticate}
+	e.Server = httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := e.requests.Add(1)
+		time.Sleep(e.delay)
+		if err := r.ParseForm(); err != nil || r.PostForm.Get("grant_type") != "client_credentials" || r.PostForm.Get("client_id") != "svc-test" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if e.fail.Load() || !e.authenticate(r) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(TokenResponse{AccessToken: fmt.Sprintf("token-%d", n), ExpiresIn: e.expiresIn})
+	}))
+	t.Cleanup(e.Close)
+	return e
+}
+
+func newTestTokenClient(t *testing.T, config *tokenClientConfig) *tokenClient {
+	config.clientId = "svc-test"
+	config.enableOIDCAuth = true
+	client, err := NewTokenClient(config)
+	require.NoError(t, err)
+	return client
+}
+
+func secretAuthenticated(r *http.Request) bool {
+	return r.PostForm.Get("client_secret") == "secret"
+}
+
+func TestTokenClient_Caching(t *testing.T) {
+	endpoint := newTokenEndpoint(t, secretAuthenticated)
+	endpoint.Start()
+
+	client := newTestTokenClient(t, &tokenClientConfig{url: endpoint.URL, clientSecret: "secret", authMethod: ClientSecretPost, refreshBefore: 30 * time.Second})
+	now := time.Now()
+	client.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		token, err := client.getToken()
+		require.NoError(t, err)
+		assert.Equal(t, "token-1", token.AccessToken)
+	}
+	assert.EqualValues(t, 1, endpoint.requests.Load())
+
+	// the token is refreshed shortly before it expires
+	now = now.Add(271 * time.Second)
+	token, err := client.getToken()
+	require.NoError(t, err)
+	assert.Equal(t, "token-2", token.AccessToken)
+	assert.EqualValues(t, 2, endpoint.requests.Load())
+}
+
+func TestTokenClient_RefreshFailure(t *testing.T) {
+	endpoint := newTokenEndpoint(t, secretAuthenticated)
+	endpoint.Start()
+
+	client := newTestTokenClient(t, &tokenClientConfig{url: endpoint.URL, clientSecret: "secret", authMethod: ClientSecretPost, refreshBefore: 30 * time.Second})
+	now := time.Now()
+	client.now = func() time.Time { return now }
+
+	_, err := client.getToken()
+	require.NoError(t, err)
+
+	// the token still valid is used while it can't be refreshed
+	endpoint.fail.Store(true)
+	now = now.Add(280 * time.Second)
+	token, err := client.getToken()
+	assert.NoError(t, err)
+	assert.Equal(t, "token-1", token.AccessToken)
+
+	now = now.Add(20 * time.Second)
+	_, err = client.getToken()
+	assert.EqualError(t, err, "unexpected status code: 401")
+	assert.EqualValues(t, 3, endpoint.requests.Load())
+}
+
+func TestTokenClient_SingleFlight(t *testing.T) {
+	endpoint := newTokenEndpoint(t, secretAuthenticated)
+	endpoint.delay = 50 * time.Millisecond
+	endpoint.Start()
+
+	client := newTestTokenClient(t, &tokenClientConfig{url: endpoint.URL, clientSecret: "secret", authMethod: ClientSecretPost})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			token, err := client.getToken()
+			if assert.NoError(t, err) {
+				assert.Equal(t, "token-1", token.AccessToken)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.EqualValues(t, 1, endpoint.requests.Load())
+}
+
+func TestTokenClient_PrivateKeyJWT(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	keyFile := filepath.Join(t.TempDir(), "key.pem")
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0600))
+
+	var endpoint *tokenEndpoint
+	endpoint = newTokenEndpoint(t, func(r *http.Request) bool {
+		if r.PostForm.Get("client_secret") != "" || r.PostForm.Get("client_assertion_type") != jwt_bearer_assertion_type {
+			return false
+		}
+		claims := jwt.RegisteredClaims{}
+		token, err := jwt.ParseWithClaims(r.PostForm.Get("client_assertion"), &claims, func(token *jwt.Token) (interface{}, error) {
+			return &key.PublicKey, nil
+		}, jwt.WithValidMethods([]string{"ES256"}), jwt.WithAudience(endpoint.URL), jwt.WithIssuer("svc-test"))
+		return err == nil && token.Header["kid"] == "key-1" && claims.Subject == "svc-test" && claims.ID != ""
+	})
+	endpoint.Start()
+
+	privateKey, err := loadPrivateKey(keyFile)
+	require.NoError(t, err)
+	client := newTestTokenClient(t, &tokenClientConfig{url: endpoint.URL, authMethod: PrivateKeyJWT, privateKey: privateKey, privateKeyId: "key-1"})
+
+	token, err := client.getToken()
+	require.NoError(t, err)
+	assert.Equal(t, "token-1", token.AccessToken)
+}
+
+func TestTokenClient_TLSClientAuth(t *testing.T) {
+	dir := t.TempDir()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "svc-test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600))
+
+	endpoint := newTokenEndpoint(t, func(r *http.Request) bool {
+		return r.PostForm.Get("client_secret") == "" && len(r.TLS.PeerCertificates) == 1 && r.TLS.PeerCertificates[0].Subject.CommonName == "svc-test"
+	})
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(cert)
+	endpoint.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs}
+	endpoint.StartTLS()
+
+	tlsConfig, err := clientTLSConfig(certFile, keyFile)
+	require.NoError(t, err)
+	tlsConfig.RootCAs = x509.NewCertPool()
+	tlsConfig.RootCAs.AddCert(endpoint.Certificate())
+	client := newTestTokenClient(t, &tokenClientConfig{url: endpoint.URL, authMethod: TLSClientAuth, tlsConfig: tlsConfig})
+
+	token, err := client.getToken()
+	require.NoError(t, err)
+	assert.Equal(t, "token-1", token.AccessToken)
+}
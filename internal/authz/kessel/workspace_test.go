@@ -0,0 +1,137 @@
+package kessel
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+
+	kessel "github.com/project-kessel/relations-api/api/kessel/relations/v1beta1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// fakeTupleServer is a relations server keeping the workspace tuples of the resources by their id.  It fails the
+// creations of tuples of the workspaces in failCreate, and cancels the requests creating the tuples of the workspaces
+// in cancelCreate.
+type fakeTupleServer struct {
+	kessel.UnimplementedKesselTupleServiceServer
+
+	mu           sync.Mutex
+	workspaces   map[string]map[string]bool
+	failCreate   map[string]bool
+	cancelCreate map[string]context.CancelFunc
+}
+
+func (s *fakeTupleServer) CreateTuples(ctx context.Context, r *kessel.CreateTuplesRequest) (*kessel.CreateTuplesResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, tuple := range r.GetTuples() {
+		workspace := tuple.GetSubject().GetSubject().GetId()
+		if s.failCreate[workspace] {
+			return nil, status.Error(codes.Internal, "failed to write tuples")
+		}
+		if cancel, ok := s.cancelCreate[workspace]; ok {
+			cancel()
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}
+		if s.workspaces[tuple.GetResource().GetId()] == nil {
+			s.workspaces[tuple.GetResource().GetId()] = map[string]bool{}
+		}
+		s.workspaces[tuple.GetResource().GetId()][workspace] = true
+	}
+	return &kessel.CreateTuplesResponse{ConsistencyToken: &kessel.ConsistencyToken{Token: "token"}}, nil
+}
+
+func (s *fakeTupleServer) DeleteTuples(ctx context.Context, r *kessel.DeleteTuplesRequest) (*kessel.DeleteTuplesResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	filter := r.GetFilter()
+	if filter.SubjectFilter == nil || filter.GetSubjectFilter().SubjectId == nil {
+		delete(s.workspaces, filter.GetResourceId())
+	} else {
+		delete(s.workspaces[filter.GetResourceId()], filter.GetSubjectFilter().GetSubjectId())
+	}
+	return &kessel.DeleteTuplesResponse{}, nil
+}
+
+func (s *fakeTupleServer) workspacesOf(id string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var workspaces []string
+	for workspace := range s.workspaces[id] {
+		workspaces = append(workspaces, workspace)
+	}
+	return workspaces
+}
+
+func setupRelationsServer(t *testing.T) (*KesselAuthz, *fakeTupleServer) {
+	listener := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer()
+	tuples := &fakeTupleServer{workspaces: map[string]map[string]bool{}, failCreate: map[string]bool{}, cancelCreate: map[string]context.CancelFunc{}}
+	kessel.RegisterKesselTupleServiceServer(server, tuples)
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	authz := setupAuthz(t, &fakeCheckService{}, nil)
+	authz.TupleService = kessel.NewKesselTupleServiceClient(conn)
+	return authz, tuples
+}
+
+func TestKesselAuthz_ReplaceWorkspace(t *testing.T) {
+	ctx := context.Background()
+	authz, tuples := setupRelationsServer(t)
+
+	_, err := authz.SetWorkspace(ctx, "host-1", "workspace-1", "hbi", "host", true)
+	require.NoError(t, err)
+
+	resp, err := authz.ReplaceWorkspace(ctx, "host-1", "workspace-1", "workspace-2", "hbi", "host")
+	assert.NoError(t, err)
+	assert.Equal(t, "token", resp.GetConsistencyToken().GetToken())
+	assert.Equal(t, []string{"workspace-2"}, tuples.workspacesOf("host-1"))
+}
+
+func TestKesselAuthz_ReplaceWorkspaceRestoresPrevious(t *testing.T) {
+	ctx := context.Background()
+	authz, tuples := setupRelationsServer(t)
+
+	_, err := authz.SetWorkspace(ctx, "host-1", "workspace-1", "hbi", "host", true)
+	require.NoError(t, err)
+
+	tuples.failCreate["workspace-2"] = true
+	_, err = authz.ReplaceWorkspace(ctx, "host-1", "workspace-1", "workspace-2", "hbi", "host")
+	assert.Equal(t, codes.Internal, status.Code(err))
+	assert.Equal(t, []string{"workspace-1"}, tuples.workspacesOf("host-1"))
+
+	// the resource is in no workspace when the previous one can't be restored either
+	tuples.failCreate["workspace-1"] = true
+	_, err = authz.ReplaceWorkspace(ctx, "host-1", "workspace-1", "workspace-2", "hbi", "host")
+	assert.Error(t, err)
+	assert.Empty(t, tuples.workspacesOf("host-1"))
+}
+
+func TestKesselAuthz_ReplaceWorkspaceRestoresPreviousWhenCanceled(t *testing.T) {
+	authz, tuples := setupRelationsServer(t)
+
+	_, err := authz.SetWorkspace(context.Background(), "host-1", "workspace-1", "hbi", "host", true)
+	require.NoError(t, err)
+
+	// the request is canceled while the tuple of the new workspace is created
+	ctx, cancel := context.WithCancel(context.Background())
+	tuples.cancelCreate["workspace-2"] = cancel
+	_, err = authz.ReplaceWorkspace(ctx, "host-1", "workspace-1", "workspace-2", "hbi", "host")
+	assert.Equal(t, codes.Canceled, status.Code(err))
+	assert.Equal(t, []string{"workspace-1"}, tuples.workspacesOf("host-1"))
+}
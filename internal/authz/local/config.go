@@ -0,0 +1,26 @@
+package local
+
+type Config struct {
+	*Options
+}
+
+func NewConfig(o *Options) *Config {
+	return &Config{Options: o}
+}
+
+type completedConfig struct {
+	Schema *Schema
+}
+
+type CompletedConfig struct {
+	*completedConfig
+}
+
+func (c *Config) Complete() (CompletedConfig, []error) {
+	schema, err := LoadSchema(c.SchemaFile)
+	if err != nil {
+		return CompletedConfig{}, []error{err}
+	}
+
+	return CompletedConfig{&completedConfig{Schema: schema}}, nil
+}
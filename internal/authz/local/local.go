@@ -0,0 +1,651 @@
+package local
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-kratos/kratos/v2/log"
+	kesselv1 "github.com/project-kessel/relations-api/api/kessel/relations/v1"
+	kessel "github.com/project-kessel/relations-api/api/kessel/relations/v1beta1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	authzapi "github.com/project-kessel/inventory-api/internal/authz/api"
+	"github.com/project-kessel/inventory-api/internal/biz/model"
+	"github.com/project-kessel/inventory-api/internal/storage"
+)
+
+// maxDepth bounds the nesting of the relations walked by a check, like the dispatch depth of SpiceDB
+const maxDepth = 50
+
+// wildcard is the subject id of the tuples relating all the subjects of a type, e.g. rbac/principal:*
+const wildcard = "*"
+
+// LocalAuthz evaluates the checks against the schema, with the tuples stored in the inventory database.
+type LocalAuthz struct {
+	DB     *gorm.DB
+	Schema *Schema
+	Logger *log.Helper
+}
+
+var _ authzapi.Authorizer = &LocalAuthz{}
+
+func New(config CompletedConfig, db *gorm.DB, logger *log.Helper) (*LocalAuthz, error) {
+	logger.Info("Using authorizer: local")
+	if db == nil {
+		return nil, fmt.Errorf("the local authorizer requires storage")
+	}
+	return &LocalAuthz{
+		DB:     db,
+		Schema: config.Schema,
+		Logger: logger,
+	}, nil
+}
+
+func (a *LocalAuthz) Health(ctx context.Context) (*kesselv1.GetReadyzResponse, error) {
+	return &kesselv1.GetReadyzResponse{Status: "OK", Code: 200}, nil
+}
+
+func (a *LocalAuthz) Check(ctx context.Context, namespace string, viewPermission string, resource *model.Resource, sub *kessel.SubjectReference) (kessel.CheckResponse_Allowed, *kessel.ConsistencyToken, error) {
+	allowed, err := a.checkResource(ctx, namespace, viewPermission, resource, sub)
+	if err != nil {
+		return kessel.CheckResponse_ALLOWED_UNSPECIFIED, nil, err
+	}
+	if allowed {
+		return kessel.CheckResponse_ALLOWED_TRUE, nil, nil
+	}
+	return kessel.CheckResponse_ALLOWED_FALSE, nil, nil
+}
+
+func (a *LocalAuthz) CheckForUpdate(ctx context.Context, namespace string, updatePermission string, resource *model.Resource, sub *kessel.SubjectReference) (kessel.CheckForUpdateResponse_Allowed, *kessel.ConsistencyToken, error) {
+	allowed, err := a.checkResource(ctx, namespace, updatePermission, resource, sub)
+	if err != nil {
+		return kessel.CheckForUpdateResponse_ALLOWED_UNSPECIFIED, nil, err
+	}
+	if allowed {
+		return kessel.CheckForUpdateResponse_ALLOWED_TRUE, nil, nil
+	}
+	return kessel.CheckForUpdateResponse_ALLOWED_FALSE, nil, nil
+}
+
+func (a *LocalAuthz) checkResource(ctx context.Context, namespace string, permission string, resource *model.Resource, sub *kessel.SubjectReference) (bool, error) {
+	resourceType := namespace + "/" + resource.ResourceType
+	definition, name, err := a.permission(resourceType, permission)
+	if err != nil {
+		return false, err
+	}
+	subject, err := subjectOf(sub)
+	if err != nil {
+		return false, err
+	}
+	return a.evaluator(ctx).check(definition, resource.ReporterResourceId, name, subject, 0)
+}
+
+// permission resolves a permission or relation of a type, relations can be referred to without their t_ prefix.
+func (a *LocalAuthz) permission(resourceType string, name string) (*Definition, string, error) {
+	definition, ok := a.Schema.Definitions[resourceType]
+	if !ok {
+		return nil, "", status.Errorf(codes.InvalidArgument, "object definition `%s` not found", resourceType)
+	}
+	if definition.has(name) {
+		return definition, name, nil
+	}
+	if _, ok := definition.Relations["t_"+name]; ok {
+		return definition, "t_" + name, nil
+	}
+	return nil, "", status.Errorf(codes.InvalidArgument, "relation/permission `%s` not found under definition `%s`", name, resourceType)
+}
+
+// relation resolves the relation of a tuple, relations are stored with their t_ prefix.
+func (a *LocalAuthz) relation(resourceType string, name string) (string, []SubjectType, error) {
+	definition, ok := a.Schema.Definitions[resourceType]
+	if !ok {
+		return "", nil, status.Errorf(codes.InvalidArgument, "object definition `%s` not found", resourceType)
+	}
+	for _, relation := range []string{"t_" + name, name} {
+		if subjects, ok := definition.Relations[relation]; ok {
+			return relation, subjects, nil
+		}
+	}
+	return "", nil, status.Errorf(codes.InvalidArgument, "relation `%s` not found under definition `%s`", name, resourceType)
+}
+
+func (a *LocalAuthz) LookupResources(ctx context.Context, in *kessel.LookupResourcesRequest) (grpc.ServerStreamingClient[kessel.LookupResourcesResponse], error) {
+	if in.GetResourceType() == nil {
+		return nil, status.Errorf(codes.InvalidArgument, "resource_type is required")
+	}
+	resourceType := typeName(in.GetResourceType())
+	definition, name, err := a.permission(resourceType, in.GetRelation())
+	if err != nil {
+		return nil, err
+	}
+	subject, err := subjectOf(in.GetSubject())
+	if err != nil {
+		return nil, err
+	}
+
+	ids, err := a.objects(ctx, resourceType, in.GetPagination().GetContinuationToken())
+	if err != nil {
+		return nil, err
+	}
+
+	limit := int(in.GetPagination().GetLimit())
+	var responses []*kessel.LookupResourcesResponse
+	evaluator := a.evaluator(ctx)
+	for _, id := range ids {
+		allowed, err := evaluator.check(definition, id, name, subject, 0)
+		if err != nil {
+			return nil, err
+		}
+		if !allowed {
+			continue
+		}
+		responses = append(responses, &kessel.LookupResourcesResponse{
+			Resource:   &kessel.ObjectReference{Type: in.GetResourceType(), Id: id},
+			Pagination: &kessel.ResponsePagination{ContinuationToken: id},
+		})
+		if limit > 0 && len(responses) == limit {
+			break
+		}
+	}
+	return &lookupClient[kessel.LookupResourcesResponse]{ctx: ctx, responses: responses}, nil
+}
+
+// LookupSubjects checks the relation of the resource for each subject of the type found in the tuples, in order of
+// their ids.  The continuation token is the id of the last subject returned.
+func (a *LocalAuthz) LookupSubjects(ctx context.Context, in *kessel.LookupSubjectsRequest) (grpc.ServerStreamingClient[kessel.LookupSubjectsResponse], error) {
+	if in.GetResource().GetType() == nil || in.GetResource().GetId() == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "resource is required")
+	}
+	if in.GetSubjectType() == nil {
+		return nil, status.Errorf(codes.InvalidArgument, "subject_type is required")
+	}
+	definition, name, err := a.permission(typeName(in.GetResource().GetType()), in.GetRelation())
+	if err != nil {
+		return nil, err
+	}
+
+	subjectType := typeName(in.GetSubjectType())
+	ids, err := a.objects(ctx, subjectType, in.GetPagination().GetContinuationToken())
+	if err != nil {
+		return nil, err
+	}
+
+	limit := int(in.GetPagination().GetLimit())
+	var responses []*kessel.LookupSubjectsResponse
+	evaluator := a.evaluator(ctx)
+	for _, id := range ids {
+		allowed, err := evaluator.check(definition, in.GetResource().GetId(), name, subject{Type: subjectType, Id: id, Relation: in.GetSubjectRelation()}, 0)
+		if err != nil {
+			return nil, err
+		}
+		if !allowed {
+			continue
+		}
+		responses = append(responses, &kessel.LookupSubjectsResponse{
+			Subject: &kessel.SubjectReference{
+				Subject:  &kessel.ObjectReference{Type: in.GetSubjectType(), Id: id},
+				Relation: in.SubjectRelation,
+			},
+			Pagination: &kessel.ResponsePagination{ContinuationToken: id},
+		})
+		if limit > 0 && len(responses) == limit {
+			break
+		}
+	}
+	return &lookupClient[kessel.LookupSubjectsResponse]{ctx: ctx, responses: responses}, nil
+}
+
+// objects returns the ids of the objects of a type found in the tuples, in order, after the given id.  The ids are
+// compared bytewise like sort.Strings orders them, whatever the collation of the database.
+func (a *LocalAuthz) objects(ctx context.Context, objectType string, after string) ([]string, error) {
+	var resources, subjects []string
+	db := a.DB.WithContext(ctx).Model(&model.RelationTuple{})
+	if err := db.Distinct("resource_id").Where("resource_type = ? AND "+storage.BinaryOrder(a.DB, "resource_id")+" > ?", objectType, after).Pluck("resource_id", &resources).Error; err != nil {
+		return nil, err
+	}
+	db = a.DB.WithContext(ctx).Model(&model.RelationTuple{})
+	if err := db.Distinct("subject_id").Where("subject_type = ? AND "+storage.BinaryOrder(a.DB, "subject_id")+" > ? AND subject_id <> ?", objectType, after, wildcard).Pluck("subject_id", &subjects).Error; err != nil {
+		return nil, err
+	}
+
+	seen := map[string]bool{}
+	var ids []string
+	for _, id := range append(resources, subjects...) {
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+func (a *LocalAuthz) CreateTuples(ctx context.Context, r *kessel.CreateTuplesRequest) (*kessel.CreateTuplesResponse, error) {
+	tuples := make([]model.RelationTuple, 0, len(r.GetTuples()))
+	for _, relationship := range r.GetTuples() {
+		tuple, err := a.tupleOf(relationship)
+		if err != nil {
+			return nil, err
+		}
+		tuples = append(tuples, tuple)
+	}
+
+	err := a.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, tuple := range tuples {
+			if r.GetUpsert() {
+				if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&tuple).Error; err != nil {
+					return err
+				}
+				continue
+			}
+
+			var count int64
+			if err := tx.Model(&model.RelationTuple{}).Where(map[string]interface{}{
+				"resource_type":    tuple.ResourceType,
+				"resource_id":      tuple.ResourceId,
+				"relation":         tuple.Relation,
+				"subject_type":     tuple.SubjectType,
+				"subject_id":       tuple.SubjectId,
+				"subject_relation": tuple.SubjectRelation,
+			}).Count(&count).Error; err != nil {
+				return err
+			}
+			if count > 0 {
+				return status.Errorf(codes.AlreadyExists, "relationship %s already exists", tupleString(tuple))
+			}
+			if err := tx.Create(&tuple).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &kessel.CreateTuplesResponse{}, nil
+}
+
+// tupleOf validates a relationship against the schema.
+func (a *LocalAuthz) tupleOf(relationship *kessel.Relationship) (model.RelationTuple, error) {
+	if relationship.GetResource().GetType() == nil || relationship.GetSubject().GetSubject().GetType() == nil {
+		return model.RelationTuple{}, status.Errorf(codes.InvalidArgument, "relationship resource and subject are required")
+	}
+
+	resourceType := typeName(relationship.GetResource().GetType())
+	relation, allowed, err := a.relation(resourceType, relationship.GetRelation())
+	if err != nil {
+		return model.RelationTuple{}, err
+	}
+
+	tuple := model.RelationTuple{
+		ResourceType:    resourceType,
+		ResourceId:      relationship.GetResource().GetId(),
+		Relation:        relation,
+		SubjectType:     typeName(relationship.GetSubject().GetSubject().GetType()),
+		SubjectId:       relationship.GetSubject().GetSubject().GetId(),
+		SubjectRelation: relationship.GetSubject().GetRelation(),
+	}
+	if tuple.ResourceId == "" || tuple.SubjectId == "" {
+		return model.RelationTuple{}, status.Errorf(codes.InvalidArgument, "relationship resource and subject ids are required")
+	}
+	for _, subject := range allowed {
+		if subject.Type == tuple.SubjectType && subject.Relation == tuple.SubjectRelation && subject.Wildcard == (tuple.SubjectId == wildcard) {
+			return tuple, nil
+		}
+	}
+	return model.RelationTuple{}, status.Errorf(codes.InvalidArgument, "subject of relationship %s is not allowed by relation `%s` of `%s`", tupleString(tuple), relation, resourceType)
+}
+
+func (a *LocalAuthz) DeleteTuples(ctx context.Context, r *kessel.DeleteTuplesRequest) (*kessel.DeleteTuplesResponse, error) {
+	filter := r.GetFilter()
+	if filter == nil || filter.ResourceNamespace == nil && filter.ResourceType == nil && filter.ResourceId == nil && filter.Relation == nil && filter.SubjectFilter == nil {
+		return nil, status.Errorf(codes.InvalidArgument, "filter is required")
+	}
+
+	if err := whereFilter(a.DB.WithContext(ctx), filter).Delete(&model.RelationTuple{}).Error; err != nil {
+		return nil, err
+	}
+	return &kessel.DeleteTuplesResponse{}, nil
+}
+
+// ReadTuples streams the tuples matching the filter in the order of their key, the continuation token is the number
+// of tuples read before.
+func (a *LocalAuthz) ReadTuples(ctx context.Context, in *kessel.ReadTuplesRequest) (grpc.ServerStreamingClient[kessel.ReadTuplesResponse], error) {
+	offset := 0
+	if token := in.GetPagination().GetContinuationToken(); token != "" {
+		var err error
+		if offset, err = strconv.Atoi(token); err != nil || offset < 0 {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid continuation token %q", token)
+		}
+	}
+
+	db := whereFilter(a.DB.WithContext(ctx).Model(&model.RelationTuple{}), in.GetFilter()).
+		Order("resource_type, " + storage.BinaryOrder(a.DB, "resource_id") + ", relation, subject_type, subject_id, subject_relation").
+		Offset(offset)
+	if limit := in.GetPagination().GetLimit(); limit > 0 {
+		db = db.Limit(int(limit))
+	}
+	var tuples []model.RelationTuple
+	if err := db.Find(&tuples).Error; err != nil {
+		return nil, err
+	}
+
+	responses := make([]*kessel.ReadTuplesResponse, 0, len(tuples))
+	for i, tuple := range tuples {
+		responses = append(responses, &kessel.ReadTuplesResponse{
+			Tuple:      relationshipOf(tuple),
+			Pagination: &kessel.ResponsePagination{ContinuationToken: strconv.Itoa(offset + i + 1)},
+		})
+	}
+	return &lookupClient[kessel.ReadTuplesResponse]{ctx: ctx, responses: responses}, nil
+}
+
+// whereFilter filters the tuples, a nil filter matches every tuple.
+func whereFilter(db *gorm.DB, filter *kessel.RelationTupleFilter) *gorm.DB {
+	if filter == nil {
+		return db
+	}
+	db = whereType(db, "resource_type", filter.ResourceNamespace, filter.ResourceType)
+	if filter.ResourceId != nil {
+		db = db.Where("resource_id = ?", filter.GetResourceId())
+	}
+	if filter.Relation != nil {
+		// relations are stored with their t_ prefix
+		db = db.Where("relation IN ?", []string{filter.GetRelation(), "t_" + filter.GetRelation()})
+	}
+	if subjectFilter := filter.GetSubjectFilter(); subjectFilter != nil {
+		db = whereType(db, "subject_type", subjectFilter.SubjectNamespace, subjectFilter.SubjectType)
+		if subjectFilter.SubjectId != nil {
+			db = db.Where("subject_id = ?", subjectFilter.GetSubjectId())
+		}
+		if subjectFilter.Relation != nil {
+			db = db.Where("subject_relation = ?", subjectFilter.GetRelation())
+		}
+	}
+	return db
+}
+
+// whereType filters the namespaced type column by namespace, by name, or by both.
+func whereType(db *gorm.DB, column string, namespace *string, name *string) *gorm.DB {
+	switch {
+	case namespace != nil && name != nil:
+		return db.Where(column+" = ?", *namespace+"/"+*name)
+	case namespace != nil:
+		return db.Where(column+" LIKE ?", *namespace+"/%")
+	case name != nil:
+		return db.Where(column+" LIKE ?", "%/"+*name)
+	}
+	return db
+}
+
+func (a *LocalAuthz) UnsetWorkspace(ctx context.Context, local_resource_id, namespace, name string) (*kessel.DeleteTuplesResponse, error) {
+	return a.DeleteTuples(ctx, &kessel.DeleteTuplesRequest{
+		Filter: &kessel.RelationTupleFilter{
+			ResourceNamespace: proto.String(namespace),
+			ResourceType:      proto.String(name),
+			ResourceId:        proto.String(local_resource_id),
+			Relation:          proto.String("workspace"),
+		},
+	})
+}
+
+func (a *LocalAuthz) SetWorkspace(ctx context.Context, local_resource_id, workspace, namespace, name string, upsert bool) (*kessel.CreateTuplesResponse, error) {
+	if workspace == "" {
+		return nil, fmt.Errorf("workspace_id is required")
+	}
+	return a.CreateTuples(ctx, &kessel.CreateTuplesRequest{
+		Upsert: upsert,
+		Tuples: []*kessel.Relationship{{
+			Resource: &kessel.ObjectReference{
+				Type: &kessel.ObjectType{Namespace: namespace, Name: name},
+				Id:   local_resource_id,
+			},
+			Relation: "workspace",
+			Subject: &kessel.SubjectReference{
+				Subject: &kessel.ObjectReference{
+					Type: &kessel.ObjectType{Namespace: "rbac", Name: "workspace"},
+					Id:   workspace,
+				},
+			},
+		}},
+	})
+}
+
+// ReplaceWorkspace deletes the tuple of the previous workspace and creates the one of the new workspace in a transaction
+func (a *LocalAuthz) ReplaceWorkspace(ctx context.Context, local_resource_id, previous_workspace, workspace, namespace, name string) (*kessel.CreateTuplesResponse, error) {
+	var resp *kessel.CreateTuplesResponse
+	err := a.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		authz := &LocalAuthz{DB: tx, Schema: a.Schema, Logger: a.Logger}
+		_, err := authz.DeleteTuples(ctx, &kessel.DeleteTuplesRequest{Filter: workspaceFilter(local_resource_id, previous_workspace, namespace, name)})
+		if err != nil {
+			return err
+		}
+		resp, err = authz.SetWorkspace(ctx, local_resource_id, workspace, namespace, name, true)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// workspaceFilter matches the tuple of a resource in a workspace
+func workspaceFilter(local_resource_id, workspace, namespace, name string) *kessel.RelationTupleFilter {
+	return &kessel.RelationTupleFilter{
+		ResourceNamespace: proto.String(namespace),
+		ResourceType:      proto.String(name),
+		ResourceId:        proto.String(local_resource_id),
+		Relation:          proto.String("workspace"),
+		SubjectFilter: &kessel.SubjectFilter{
+			SubjectNamespace: proto.String("rbac"),
+			SubjectType:      proto.String("workspace"),
+			SubjectId:        proto.String(workspace),
+		},
+	}
+}
+
+// subject is a subject of a check, or a set of subjects when it has a relation.
+type subject struct {
+	Type     string
+	Id       string
+	Relation string
+}
+
+func subjectOf(sub *kessel.SubjectReference) (subject, error) {
+	if sub.GetSubject().GetType() == nil || sub.GetSubject().GetId() == "" {
+		return subject{}, status.Errorf(codes.InvalidArgument, "subject is required")
+	}
+	return subject{Type: typeName(sub.GetSubject().GetType()), Id: sub.GetSubject().GetId(), Relation: sub.GetRelation()}, nil
+}
+
+// relationshipOf returns the relationship of a stored tuple, the relation without its t_ prefix
+func relationshipOf(tuple model.RelationTuple) *kessel.Relationship {
+	relationship := &kessel.Relationship{
+		Resource: &kessel.ObjectReference{Type: objectTypeOf(tuple.ResourceType), Id: tuple.ResourceId},
+		Relation: strings.TrimPrefix(tuple.Relation, "t_"),
+		Subject: &kessel.SubjectReference{
+			Subject: &kessel.ObjectReference{Type: objectTypeOf(tuple.SubjectType), Id: tuple.SubjectId},
+		},
+	}
+	if tuple.SubjectRelation != "" {
+		relationship.Subject.Relation = proto.String(tuple.SubjectRelation)
+	}
+	return relationship
+}
+
+func objectTypeOf(name string) *kessel.ObjectType {
+	namespace, name, _ := strings.Cut(name, "/")
+	return &kessel.ObjectType{Namespace: namespace, Name: name}
+}
+
+func typeName(objectType *kessel.ObjectType) string {
+	return objectType.GetNamespace() + "/" + objectType.GetName()
+}
+
+func tupleString(tuple model.RelationTuple) string {
+	subject := tuple.SubjectType + ":" + tuple.SubjectId
+	if tuple.SubjectRelation != "" {
+		subject += "#" + tuple.SubjectRelation
+	}
+	return fmt.Sprintf("%s:%s#%s@%s", tuple.ResourceType, tuple.ResourceId, tuple.Relation, subject)
+}
+
+// evaluator walks the relations of the schema for one request.
+type evaluator struct {
+	ctx    context.Context
+	db     *gorm.DB
+	schema *Schema
+	// visiting holds the checks in progress, a check depending on itself is false
+	visiting map[string]bool
+}
+
+func (a *LocalAuthz) evaluator(ctx context.Context) *evaluator {
+	return &evaluator{ctx: ctx, db: a.DB.WithContext(ctx), schema: a.Schema, visiting: map[string]bool{}}
+}
+
+// check tells whether the subject has the permission or relation name on the object.
+func (e *evaluator) check(definition *Definition, id string, name string, sub subject, depth int) (bool, error) {
+	if depth > maxDepth {
+		return false, status.Errorf(codes.FailedPrecondition, "max depth exceeded checking %s:%s#%s", definition.Name, id, name)
+	}
+	// a set of subjects is a member of itself
+	if definition.Name == sub.Type && id == sub.Id && name == sub.Relation {
+		return true, nil
+	}
+
+	key := strings.Join([]string{definition.Name, id, name}, "\x00")
+	if e.visiting[key] {
+		return false, nil
+	}
+	e.visiting[key] = true
+	defer delete(e.visiting, key)
+
+	if expr, ok := definition.Permissions[name]; ok {
+		return e.eval(definition, id, expr, sub, depth)
+	}
+	if _, ok := definition.Relations[name]; ok {
+		return e.relation(definition, id, name, sub, depth)
+	}
+	return false, nil
+}
+
+func (e *evaluator) eval(definition *Definition, id string, expr Expr, sub subject, depth int) (bool, error) {
+	switch x := expr.(type) {
+	case RelationExpr:
+		return e.check(definition, id, x.Name, sub, depth+1)
+	case ArrowExpr:
+		tuples, err := e.tuples(definition.Name, id, x.Tupleset)
+		if err != nil {
+			return false, err
+		}
+		for _, tuple := range tuples {
+			target, ok := e.schema.Definitions[tuple.SubjectType]
+			if !ok || tuple.SubjectId == wildcard {
+				continue
+			}
+			if allowed, err := e.check(target, tuple.SubjectId, x.Computed, sub, depth+1); err != nil || allowed {
+				return allowed, err
+			}
+		}
+		return false, nil
+	case SetExpr:
+		for i, child := range x.Children {
+			allowed, err := e.eval(definition, id, child, sub, depth)
+			if err != nil {
+				return false, err
+			}
+			switch {
+			case x.Op == '+' && allowed:
+				return true, nil
+			case x.Op == '&' && !allowed:
+				return false, nil
+			case x.Op == '-' && i == 0 && !allowed:
+				return false, nil
+			case x.Op == '-' && i > 0 && allowed:
+				return false, nil
+			}
+		}
+		return x.Op != '+', nil
+	}
+	return false, nil
+}
+
+func (e *evaluator) relation(definition *Definition, id string, name string, sub subject, depth int) (bool, error) {
+	tuples, err := e.tuples(definition.Name, id, name)
+	if err != nil {
+		return false, err
+	}
+	for _, tuple := range tuples {
+		if tuple.SubjectType == sub.Type && tuple.SubjectRelation == sub.Relation &&
+			(tuple.SubjectId == sub.Id || tuple.SubjectId == wildcard && sub.Relation == "") {
+			return true, nil
+		}
+		if tuple.SubjectRelation == "" {
+			continue
+		}
+		// the subjects of a relation of another object, e.g. rbac/group#member
+		target, ok := e.schema.Definitions[tuple.SubjectType]
+		if !ok {
+			continue
+		}
+		if allowed, err := e.check(target, tuple.SubjectId, tuple.SubjectRelation, sub, depth+1); err != nil || allowed {
+			return allowed, err
+		}
+	}
+	return false, nil
+}
+
+func (e *evaluator) tuples(resourceType string, id string, relation string) ([]model.RelationTuple, error) {
+	var tuples []model.RelationTuple
+	err := e.db.Where("resource_type = ? AND resource_id = ? AND relation = ?", resourceType, id, relation).Find(&tuples).Error
+	return tuples, err
+}
+
+// lookupClient streams the resources, subjects or tuples found by a read.
+type lookupClient[T any] struct {
+	ctx       context.Context
+	responses []*T
+}
+
+func (c *lookupClient[T]) Recv() (*T, error) {
+	if len(c.responses) == 0 {
+		return nil, io.EOF
+	}
+	response := c.responses[0]
+	c.responses = c.responses[1:]
+	return response, nil
+}
+
+func (c *lookupClient[T]) Header() (metadata.MD, error) {
+	return nil, nil
+}
+
+func (c *lookupClient[T]) Trailer() metadata.MD {
+	return nil
+}
+
+func (c *lookupClient[T]) CloseSend() error {
+	return nil
+}
+
+func (c *lookupClient[T]) Context() context.Context {
+	return c.ctx
+}
+
+func (c *lookupClient[T]) SendMsg(msg interface{}) error {
+	return nil
+}
+
+func (c *lookupClient[T]) RecvMsg(msg interface{}) error {
+	return nil
+}
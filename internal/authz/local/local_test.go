@@ -0,0 +1,317 @@
+package local
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/go-kratos/kratos/v2/log"
+	kessel "github.com/project-kessel/relations-api/api/kessel/relations/v1beta1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"github.com/project-kessel/inventory-api/internal/biz/model"
+	"github.com/project-kessel/inventory-api/internal/data"
+)
+
+func setupAuthz(t *testing.T) *LocalAuthz {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, data.Migrate(db, log.NewHelper(log.DefaultLogger)))
+
+	schema, err := LoadSchema("../../../deploy/schema.zed")
+	require.NoError(t, err)
+
+	authz, err := New(CompletedConfig{&completedConfig{Schema: schema}}, db, log.NewHelper(log.DefaultLogger))
+	require.NoError(t, err)
+	return authz
+}
+
+func relationship(resourceType, resourceId, relation, subjectType, subjectId string, subjectRelation ...string) *kessel.Relationship {
+	r := &kessel.Relationship{
+		Resource: &kessel.ObjectReference{Type: objectType(resourceType), Id: resourceId},
+		Relation: relation,
+		Subject:  &kessel.SubjectReference{Subject: &kessel.ObjectReference{Type: objectType(subjectType), Id: subjectId}},
+	}
+	if len(subjectRelation) > 0 {
+		r.Subject.Relation = proto.String(subjectRelation[0])
+	}
+	return r
+}
+
+func objectType(name string) *kessel.ObjectType {
+	for i := range name {
+		if name[i] == '/' {
+			return &kessel.ObjectType{Namespace: name[:i], Name: name[i+1:]}
+		}
+	}
+	return &kessel.ObjectType{Name: name}
+}
+
+func principal(id string) *kessel.SubjectReference {
+	return &kessel.SubjectReference{Subject: &kessel.ObjectReference{Type: objectType("rbac/principal"), Id: id}}
+}
+
+func host(id string) *model.Resource {
+	return &model.Resource{ResourceType: "host", ReporterResourceId: id}
+}
+
+// setupBindings grants the inventory viewer role on workspace ws-parent to the members of group admins, alice being
+// one of them, and the role of all permissions on workspace ws-child to bob.  ws-child is a child of ws-parent.
+func setupBindings(t *testing.T, authz *LocalAuthz) {
+	_, err := authz.CreateTuples(context.Background(), &kessel.CreateTuplesRequest{Tuples: []*kessel.Relationship{
+		relationship("rbac/workspace", "ws-child", "parent", "rbac/workspace", "ws-parent"),
+		relationship("rbac/workspace", "ws-parent", "binding", "rbac/role_binding", "viewers"),
+		relationship("rbac/role_binding", "viewers", "role", "rbac/role", "inventory-viewer"),
+		relationship("rbac/role_binding", "viewers", "subject", "rbac/group", "admins", "member"),
+		relationship("rbac/group", "admins", "member", "rbac/principal", "alice"),
+		relationship("rbac/role", "inventory-viewer", "inventory_hosts_read", "rbac/principal", "*"),
+		relationship("rbac/workspace", "ws-child", "binding", "rbac/role_binding", "admins"),
+		relationship("rbac/role_binding", "admins", "role", "rbac/role", "admin"),
+		relationship("rbac/role_binding", "admins", "subject", "rbac/principal", "bob"),
+		relationship("rbac/role", "admin", "all_all_all", "rbac/principal", "*"),
+	}})
+	require.NoError(t, err)
+}
+
+func TestLocalAuthz_Check(t *testing.T) {
+	ctx := context.Background()
+	authz := setupAuthz(t)
+	setupBindings(t, authz)
+
+	_, err := authz.SetWorkspace(ctx, "host-1", "ws-child", "hbi", "host", true)
+	require.NoError(t, err)
+	_, err = authz.SetWorkspace(ctx, "host-2", "ws-parent", "hbi", "host", true)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		host       string
+		permission string
+		subject    string
+		allowed    kessel.CheckResponse_Allowed
+	}{
+		{name: "bound on the workspace", host: "host-1", permission: "view", subject: "bob", allowed: kessel.CheckResponse_ALLOWED_TRUE},
+		{name: "bound through a group", host: "host-2", permission: "view", subject: "alice", allowed: kessel.CheckResponse_ALLOWED_TRUE},
+		{name: "role without the permission", host: "host-2", permission: "update", subject: "alice", allowed: kessel.CheckResponse_ALLOWED_FALSE},
+		{name: "not bound", host: "host-2", permission: "view", subject: "bob", allowed: kessel.CheckResponse_ALLOWED_FALSE},
+		{name: "unknown host", host: "host-3", permission: "view", subject: "bob", allowed: kessel.CheckResponse_ALLOWED_FALSE},
+		{name: "workspace of a principal", host: "host-1", permission: "workspace", subject: "bob", allowed: kessel.CheckResponse_ALLOWED_FALSE},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			allowed, _, err := authz.Check(ctx, "hbi", tt.permission, host(tt.host), principal(tt.subject))
+			assert.NoError(t, err)
+			assert.Equal(t, tt.allowed, allowed)
+		})
+	}
+
+	allowed, _, err := authz.CheckForUpdate(ctx, "hbi", "update", host("host-1"), principal("bob"))
+	assert.NoError(t, err)
+	assert.Equal(t, kessel.CheckForUpdateResponse_ALLOWED_TRUE, allowed)
+
+	_, _, err = authz.Check(ctx, "hbi", "delete", host("host-1"), principal("bob"))
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestLocalAuthz_LookupResources(t *testing.T) {
+	ctx := context.Background()
+	authz := setupAuthz(t)
+	setupBindings(t, authz)
+
+	for _, id := range []string{"host-3", "host-1", "host-2"} {
+		_, err := authz.SetWorkspace(ctx, id, "ws-child", "hbi", "host", true)
+		require.NoError(t, err)
+	}
+	_, err := authz.SetWorkspace(ctx, "host-4", "ws-other", "hbi", "host", true)
+	require.NoError(t, err)
+
+	lookup := func(pagination *kessel.RequestPagination) []string {
+		stream, err := authz.LookupResources(ctx, &kessel.LookupResourcesRequest{
+			ResourceType: objectType("hbi/host"),
+			Relation:     "view",
+			Subject:      principal("bob"),
+			Pagination:   pagination,
+		})
+		require.NoError(t, err)
+
+		var ids []string
+		for {
+			response, err := stream.Recv()
+			if err == io.EOF {
+				return ids
+			}
+			require.NoError(t, err)
+			ids = append(ids, response.GetResource().GetId())
+		}
+	}
+
+	assert.Equal(t, []string{"host-1", "host-2", "host-3"}, lookup(nil))
+	assert.Equal(t, []string{"host-1", "host-2"}, lookup(&kessel.RequestPagination{Limit: 2}))
+	assert.Equal(t, []string{"host-3"}, lookup(&kessel.RequestPagination{Limit: 2, ContinuationToken: proto.String("host-2")}))
+}
+
+func TestLocalAuthz_LookupSubjects(t *testing.T) {
+	ctx := context.Background()
+	authz := setupAuthz(t)
+	setupBindings(t, authz)
+	relationships := []*kessel.Relationship{
+		relationship("rbac/group", "admins", "member", "rbac/principal", "carol"),
+		relationship("rbac/group", "others", "member", "rbac/principal", "dave"),
+	}
+	_, err := authz.CreateTuples(ctx, &kessel.CreateTuplesRequest{Tuples: relationships})
+	require.NoError(t, err)
+	_, err = authz.SetWorkspace(ctx, "host-1", "ws-child", "hbi", "host", true)
+	require.NoError(t, err)
+
+	lookup := func(pagination *kessel.RequestPagination) []string {
+		stream, err := authz.LookupSubjects(ctx, &kessel.LookupSubjectsRequest{
+			Resource:    &kessel.ObjectReference{Type: objectType("hbi/host"), Id: "host-1"},
+			Relation:    "view",
+			SubjectType: objectType("rbac/principal"),
+			Pagination:  pagination,
+		})
+		require.NoError(t, err)
+
+		var ids []string
+		for {
+			response, err := stream.Recv()
+			if err == io.EOF {
+				return ids
+			}
+			require.NoError(t, err)
+			ids = append(ids, response.GetSubject().GetSubject().GetId())
+		}
+	}
+
+	// bob is bound on the workspace of the host, the members of admins on its parent
+	assert.Equal(t, []string{"alice", "bob", "carol"}, lookup(nil))
+	assert.Equal(t, []string{"alice", "bob"}, lookup(&kessel.RequestPagination{Limit: 2}))
+	assert.Equal(t, []string{"carol"}, lookup(&kessel.RequestPagination{Limit: 2, ContinuationToken: proto.String("bob")}))
+
+	_, err = authz.LookupSubjects(ctx, &kessel.LookupSubjectsRequest{Resource: &kessel.ObjectReference{Type: objectType("hbi/host"), Id: "host-1"}, Relation: "view"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestLocalAuthz_ReadTuples(t *testing.T) {
+	ctx := context.Background()
+	authz := setupAuthz(t)
+	setupBindings(t, authz)
+	for _, id := range []string{"host-1", "host-2", "host-3"} {
+		_, err := authz.SetWorkspace(ctx, id, "ws-child", "hbi", "host", true)
+		require.NoError(t, err)
+	}
+
+	read := func(pagination *kessel.RequestPagination) ([]string, string) {
+		stream, err := authz.ReadTuples(ctx, &kessel.ReadTuplesRequest{
+			Filter: &kessel.RelationTupleFilter{
+				ResourceNamespace: proto.String("hbi"),
+				ResourceType:      proto.String("host"),
+				Relation:          proto.String("workspace"),
+			},
+			Pagination: pagination,
+		})
+		require.NoError(t, err)
+
+		var ids []string
+		var token string
+		for {
+			response, err := stream.Recv()
+			if err == io.EOF {
+				return ids, token
+			}
+			require.NoError(t, err)
+			// the relation is read without its t_ prefix
+			assert.Equal(t, "workspace", response.GetTuple().GetRelation())
+			assert.Equal(t, "ws-child", response.GetTuple().GetSubject().GetSubject().GetId())
+			ids = append(ids, response.GetTuple().GetResource().GetId())
+			token = response.GetPagination().GetContinuationToken()
+		}
+	}
+
+	ids, _ := read(nil)
+	assert.Equal(t, []string{"host-1", "host-2", "host-3"}, ids)
+	ids, token := read(&kessel.RequestPagination{Limit: 2})
+	assert.Equal(t, []string{"host-1", "host-2"}, ids)
+	ids, _ = read(&kessel.RequestPagination{Limit: 2, ContinuationToken: proto.String(token)})
+	assert.Equal(t, []string{"host-3"}, ids)
+
+	_, err := authz.ReadTuples(ctx, &kessel.ReadTuplesRequest{Pagination: &kessel.RequestPagination{ContinuationToken: proto.String("x")}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestLocalAuthz_CreateTuples(t *testing.T) {
+	ctx := context.Background()
+	authz := setupAuthz(t)
+
+	tuple := relationship("rbac/group", "admins", "member", "rbac/principal", "alice")
+	_, err := authz.CreateTuples(ctx, &kessel.CreateTuplesRequest{Tuples: []*kessel.Relationship{tuple}})
+	assert.NoError(t, err)
+
+	var stored []model.RelationTuple
+	assert.NoError(t, authz.DB.Find(&stored).Error)
+	assert.Equal(t, "t_member", stored[0].Relation)
+
+	_, err = authz.CreateTuples(ctx, &kessel.CreateTuplesRequest{Tuples: []*kessel.Relationship{tuple}})
+	assert.Equal(t, codes.AlreadyExists, status.Code(err))
+	_, err = authz.CreateTuples(ctx, &kessel.CreateTuplesRequest{Upsert: true, Tuples: []*kessel.Relationship{tuple}})
+	assert.NoError(t, err)
+
+	for name, invalid := range map[string]*kessel.Relationship{
+		"undefined type":        relationship("rbac/team", "admins", "member", "rbac/principal", "alice"),
+		"undefined relation":    relationship("rbac/group", "admins", "viewer", "rbac/principal", "alice"),
+		"subject not allowed":   relationship("rbac/group", "admins", "member", "rbac/role", "admin"),
+		"wildcard not allowed":  relationship("rbac/group", "admins", "member", "rbac/principal", "*"),
+		"subject relation":      relationship("rbac/group", "admins", "member", "rbac/group", "others", "owner"),
+		"missing subject types": {Resource: tuple.Resource, Relation: "member", Subject: &kessel.SubjectReference{}},
+	} {
+		_, err := authz.CreateTuples(ctx, &kessel.CreateTuplesRequest{Tuples: []*kessel.Relationship{invalid}})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err), name)
+	}
+}
+
+func TestLocalAuthz_DeleteTuples(t *testing.T) {
+	ctx := context.Background()
+	authz := setupAuthz(t)
+	setupBindings(t, authz)
+
+	_, err := authz.SetWorkspace(ctx, "host-1", "ws-child", "hbi", "host", true)
+	require.NoError(t, err)
+	allowed, _, err := authz.Check(ctx, "hbi", "view", host("host-1"), principal("bob"))
+	require.NoError(t, err)
+	require.Equal(t, kessel.CheckResponse_ALLOWED_TRUE, allowed)
+
+	_, err = authz.UnsetWorkspace(ctx, "host-1", "hbi", "host")
+	assert.NoError(t, err)
+	allowed, _, err = authz.Check(ctx, "hbi", "view", host("host-1"), principal("bob"))
+	assert.NoError(t, err)
+	assert.Equal(t, kessel.CheckResponse_ALLOWED_FALSE, allowed)
+
+	// moving the host to another workspace removes it from the previous one
+	_, err = authz.SetWorkspace(ctx, "host-1", "ws-parent", "hbi", "host", true)
+	require.NoError(t, err)
+	_, err = authz.ReplaceWorkspace(ctx, "host-1", "ws-parent", "ws-child", "hbi", "host")
+	assert.NoError(t, err)
+	var workspaces []string
+	assert.NoError(t, authz.DB.Model(&model.RelationTuple{}).Where("resource_id = ? AND relation = ?", "host-1", "t_workspace").Pluck("subject_id", &workspaces).Error)
+	assert.Equal(t, []string{"ws-child"}, workspaces)
+
+	// the members of a group are removed by subject
+	_, err = authz.DeleteTuples(ctx, &kessel.DeleteTuplesRequest{Filter: &kessel.RelationTupleFilter{
+		ResourceNamespace: proto.String("rbac"),
+		SubjectFilter:     &kessel.SubjectFilter{SubjectNamespace: proto.String("rbac"), SubjectType: proto.String("principal"), SubjectId: proto.String("alice")},
+	}})
+	assert.NoError(t, err)
+	var count int64
+	assert.NoError(t, authz.DB.Model(&model.RelationTuple{}).Where("subject_id = ?", "alice").Count(&count).Error)
+	assert.Zero(t, count)
+
+	_, err = authz.DeleteTuples(ctx, &kessel.DeleteTuplesRequest{Filter: &kessel.RelationTupleFilter{}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
@@ -0,0 +1,40 @@
+package local
+
+import (
+	"fmt"
+
+	"github.com/spf13/pflag"
+)
+
+type Options struct {
+	SchemaFile string `mapstructure:"schema-file"`
+}
+
+func NewOptions() *Options {
+	return &Options{
+		SchemaFile: "deploy/schema.zed",
+	}
+}
+
+func (o *Options) AddFlags(fs *pflag.FlagSet, prefix string) {
+	if prefix != "" {
+		prefix = prefix + "."
+	}
+	fs.StringVar(&o.SchemaFile, prefix+"schema-file", o.SchemaFile, "the SpiceDB schema evaluated by the local authorizer.")
+}
+
+func (o *Options) Validate() []error {
+	var errs []error
+
+	if len(o.SchemaFile) == 0 {
+		errs = append(errs, fmt.Errorf("local schema-file may not be empty"))
+	}
+
+	return errs
+}
+
+func (o *Options) Complete() []error {
+	var errs []error
+
+	return errs
+}
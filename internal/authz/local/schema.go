@@ -0,0 +1,427 @@
+package local
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Schema is a parsed SpiceDB schema, its definitions are keyed by their namespaced type, e.g. rbac/workspace.
+type Schema struct {
+	Definitions map[string]*Definition
+}
+
+// Definition is an object type with its relations and permissions.
+type Definition struct {
+	Name        string
+	Relations   map[string][]SubjectType
+	Permissions map[string]Expr
+}
+
+// SubjectType is an allowed subject of a relation: a type, a wildcard of a type (rbac/principal:*), or a relation of
+// a type (rbac/group#member).
+type SubjectType struct {
+	Type     string
+	Relation string
+	Wildcard bool
+}
+
+// Expr is the expression of a permission.
+type Expr interface {
+	isExpr()
+}
+
+// RelationExpr refers to a relation or a permission of the object.
+type RelationExpr struct {
+	Name string
+}
+
+// ArrowExpr walks the objects related through Tupleset and evaluates Computed on them, e.g. t_workspace->view.
+type ArrowExpr struct {
+	Tupleset string
+	Computed string
+}
+
+// SetExpr combines expressions, Op is one of '+' (union), '&' (intersection) or '-' (exclusion).
+type SetExpr struct {
+	Op       byte
+	Children []Expr
+}
+
+// NilExpr is the empty set.
+type NilExpr struct{}
+
+func (RelationExpr) isExpr() {}
+func (ArrowExpr) isExpr()    {}
+func (SetExpr) isExpr()      {}
+func (NilExpr) isExpr()      {}
+
+// LoadSchema reads and parses a schema file.
+func LoadSchema(path string) (*Schema, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema: %w", err)
+	}
+	schema, err := ParseSchema(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse schema %s: %w", path, err)
+	}
+	return schema, nil
+}
+
+// ParseSchema parses the definitions of a SpiceDB schema.  Caveats aren't supported.
+func ParseSchema(source string) (*Schema, error) {
+	tokens, err := tokenize(source)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	schema := &Schema{Definitions: map[string]*Definition{}}
+
+	for !p.done() {
+		definition, err := p.definition()
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := schema.Definitions[definition.Name]; ok {
+			return nil, fmt.Errorf("duplicate definition %s", definition.Name)
+		}
+		schema.Definitions[definition.Name] = definition
+	}
+
+	if err := schema.validate(); err != nil {
+		return nil, err
+	}
+	return schema, nil
+}
+
+// validate checks the references of the relations and permissions.
+func (s *Schema) validate() error {
+	for _, name := range s.names() {
+		definition := s.Definitions[name]
+		for relation, subjects := range definition.Relations {
+			for _, subject := range subjects {
+				target, ok := s.Definitions[subject.Type]
+				if !ok {
+					return fmt.Errorf("relation %s#%s: undefined subject type %s", name, relation, subject.Type)
+				}
+				if subject.Relation != "" && !target.has(subject.Relation) {
+					return fmt.Errorf("relation %s#%s: undefined subject relation %s#%s", name, relation, subject.Type, subject.Relation)
+				}
+			}
+		}
+		for permission, expr := range definition.Permissions {
+			if err := definition.validateExpr(expr); err != nil {
+				return fmt.Errorf("permission %s#%s: %w", name, permission, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (d *Definition) validateExpr(expr Expr) error {
+	switch e := expr.(type) {
+	case RelationExpr:
+		if !d.has(e.Name) {
+			return fmt.Errorf("undefined relation or permission %s", e.Name)
+		}
+	case ArrowExpr:
+		if _, ok := d.Relations[e.Tupleset]; !ok {
+			return fmt.Errorf("undefined relation %s", e.Tupleset)
+		}
+	case SetExpr:
+		for _, child := range e.Children {
+			if err := d.validateExpr(child); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (d *Definition) has(name string) bool {
+	_, relation := d.Relations[name]
+	_, permission := d.Permissions[name]
+	return relation || permission
+}
+
+func (s *Schema) names() []string {
+	names := make([]string, 0, len(s.Definitions))
+	for name := range s.Definitions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+type token struct {
+	text string
+	line int
+}
+
+// tokenize splits the schema into identifiers, type names and symbols, dropping the comments.
+func tokenize(source string) ([]token, error) {
+	var tokens []token
+	line := 1
+	for i := 0; i < len(source); {
+		c := source[i]
+		switch {
+		case c == '\n':
+			line++
+			i++
+		case unicode.IsSpace(rune(c)):
+			i++
+		case strings.HasPrefix(source[i:], "//"):
+			for i < len(source) && source[i] != '\n' {
+				i++
+			}
+		case strings.HasPrefix(source[i:], "/*"):
+			end := strings.Index(source[i+2:], "*/")
+			if end < 0 {
+				return nil, fmt.Errorf("line %d: unterminated comment", line)
+			}
+			line += strings.Count(source[i:i+2+end], "\n")
+			i += end + 4
+		case strings.HasPrefix(source[i:], "->"):
+			tokens = append(tokens, token{"->", line})
+			i += 2
+		case strings.ContainsRune("{}=:|+&-()#*", rune(c)):
+			tokens = append(tokens, token{string(c), line})
+			i++
+		case isIdentifier(c):
+			start := i
+			for i < len(source) && isIdentifier(source[i]) {
+				i++
+			}
+			tokens = append(tokens, token{source[start:i], line})
+		default:
+			return nil, fmt.Errorf("line %d: unexpected character %q", line, c)
+		}
+	}
+	return tokens, nil
+}
+
+func isIdentifier(c byte) bool {
+	return c == '_' || c == '/' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) done() bool {
+	return p.pos >= len(p.tokens)
+}
+
+func (p *parser) peek() string {
+	if p.done() {
+		return ""
+	}
+	return p.tokens[p.pos].text
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	line := 0
+	if p.done() {
+		if len(p.tokens) > 0 {
+			line = p.tokens[len(p.tokens)-1].line
+		}
+	} else {
+		line = p.tokens[p.pos].line
+	}
+	return fmt.Errorf("line %d: %s", line, fmt.Sprintf(format, args...))
+}
+
+func (p *parser) next() string {
+	text := p.peek()
+	p.pos++
+	return text
+}
+
+func (p *parser) expect(text string) error {
+	if p.peek() != text {
+		return p.errorf("expected %q, found %q", text, p.peek())
+	}
+	p.pos++
+	return nil
+}
+
+func (p *parser) identifier() (string, error) {
+	text := p.peek()
+	if text == "" || !isIdentifier(text[0]) {
+		return "", p.errorf("expected an identifier, found %q", text)
+	}
+	p.pos++
+	return text, nil
+}
+
+func (p *parser) definition() (*Definition, error) {
+	if p.peek() == "caveat" {
+		return nil, p.errorf("caveats are not supported")
+	}
+	if err := p.expect("definition"); err != nil {
+		return nil, err
+	}
+	name, err := p.identifier()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+
+	definition := &Definition{Name: name, Relations: map[string][]SubjectType{}, Permissions: map[string]Expr{}}
+	for p.peek() != "}" {
+		if p.done() {
+			return nil, p.errorf("unterminated definition %s", name)
+		}
+		switch keyword := p.next(); keyword {
+		case "relation":
+			relation, subjects, err := p.relation()
+			if err != nil {
+				return nil, err
+			}
+			if definition.has(relation) {
+				return nil, p.errorf("duplicate relation %s#%s", name, relation)
+			}
+			definition.Relations[relation] = subjects
+		case "permission":
+			permission, expr, err := p.permission()
+			if err != nil {
+				return nil, err
+			}
+			if definition.has(permission) {
+				return nil, p.errorf("duplicate permission %s#%s", name, permission)
+			}
+			definition.Permissions[permission] = expr
+		default:
+			p.pos--
+			return nil, p.errorf("expected relation or permission, found %q", keyword)
+		}
+	}
+	p.pos++
+	return definition, nil
+}
+
+func (p *parser) relation() (string, []SubjectType, error) {
+	name, err := p.identifier()
+	if err != nil {
+		return "", nil, err
+	}
+	if err := p.expect(":"); err != nil {
+		return "", nil, err
+	}
+
+	var subjects []SubjectType
+	for {
+		subjectType, err := p.identifier()
+		if err != nil {
+			return "", nil, err
+		}
+		subject := SubjectType{Type: subjectType}
+		switch p.peek() {
+		case ":":
+			p.pos++
+			if err := p.expect("*"); err != nil {
+				return "", nil, err
+			}
+			subject.Wildcard = true
+		case "#":
+			p.pos++
+			if subject.Relation, err = p.identifier(); err != nil {
+				return "", nil, err
+			}
+		}
+		if p.peek() == "with" {
+			return "", nil, p.errorf("caveats are not supported")
+		}
+		subjects = append(subjects, subject)
+
+		if p.peek() != "|" {
+			return name, subjects, nil
+		}
+		p.pos++
+	}
+}
+
+func (p *parser) permission() (string, Expr, error) {
+	name, err := p.identifier()
+	if err != nil {
+		return "", nil, err
+	}
+	if err := p.expect("="); err != nil {
+		return "", nil, err
+	}
+	expr, err := p.union()
+	if err != nil {
+		return "", nil, err
+	}
+	return name, expr, nil
+}
+
+// union, intersection and exclusion parse the operators from the lowest to the highest precedence.
+func (p *parser) union() (Expr, error) {
+	return p.set('+', p.intersection)
+}
+
+func (p *parser) intersection() (Expr, error) {
+	return p.set('&', p.exclusion)
+}
+
+func (p *parser) exclusion() (Expr, error) {
+	return p.set('-', p.term)
+}
+
+func (p *parser) set(op byte, operand func() (Expr, error)) (Expr, error) {
+	first, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	children := []Expr{first}
+	for p.peek() == string(op) {
+		p.pos++
+		child, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, child)
+	}
+	if len(children) == 1 {
+		return first, nil
+	}
+	return SetExpr{Op: op, Children: children}, nil
+}
+
+func (p *parser) term() (Expr, error) {
+	if p.peek() == "(" {
+		p.pos++
+		expr, err := p.union()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		return expr, nil
+	}
+
+	name, err := p.identifier()
+	if err != nil {
+		return nil, err
+	}
+	if name == "nil" {
+		return NilExpr{}, nil
+	}
+	if p.peek() != "->" {
+		return RelationExpr{Name: name}, nil
+	}
+	p.pos++
+	computed, err := p.identifier()
+	if err != nil {
+		return nil, err
+	}
+	return ArrowExpr{Tupleset: name, Computed: computed}, nil
+}
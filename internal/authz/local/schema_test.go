@@ -0,0 +1,95 @@
+package local
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadSchema_Deployed(t *testing.T) {
+	schema, err := LoadSchema("../../../deploy/schema.zed")
+	require.NoError(t, err)
+
+	host := schema.Definitions["hbi/host"]
+	require.NotNil(t, host)
+	assert.Equal(t, ArrowExpr{Tupleset: "t_workspace", Computed: "inventory_host_view"}, host.Permissions["view"])
+	assert.Equal(t, []SubjectType{{Type: "rbac/workspace"}}, host.Relations["t_workspace"])
+
+	group := schema.Definitions["rbac/group"]
+	assert.Equal(t, []SubjectType{{Type: "rbac/principal"}, {Type: "rbac/group", Relation: "member"}}, group.Relations["t_member"])
+	assert.Equal(t, []SubjectType{{Type: "rbac/principal", Wildcard: true}}, schema.Definitions["rbac/role"].Relations["t_all_all_all"])
+}
+
+func TestParseSchema_Expressions(t *testing.T) {
+	schema, err := ParseSchema(`
+		/* a document */
+		definition test/user {}
+
+		definition test/document {
+			relation owner: test/user
+			relation reader: test/user | test/user:*
+			relation banned: test/user
+			// readers which aren't banned, and owners
+			permission view = reader - banned + owner
+			permission edit = (owner & reader) + nil
+		}
+	`)
+	require.NoError(t, err)
+
+	document := schema.Definitions["test/document"]
+	assert.Equal(t, SetExpr{Op: '+', Children: []Expr{
+		SetExpr{Op: '-', Children: []Expr{RelationExpr{Name: "reader"}, RelationExpr{Name: "banned"}}},
+		RelationExpr{Name: "owner"},
+	}}, document.Permissions["view"])
+	assert.Equal(t, SetExpr{Op: '+', Children: []Expr{
+		SetExpr{Op: '&', Children: []Expr{RelationExpr{Name: "owner"}, RelationExpr{Name: "reader"}}},
+		NilExpr{},
+	}}, document.Permissions["edit"])
+}
+
+func TestParseSchema_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+		err    string
+	}{
+		{
+			name:   "undefined subject type",
+			schema: "definition test/document { relation owner: test/user }",
+			err:    "relation test/document#owner: undefined subject type test/user",
+		},
+		{
+			name:   "undefined relation",
+			schema: "definition test/document { permission view = owner }",
+			err:    "permission test/document#view: undefined relation or permission owner",
+		},
+		{
+			name:   "undefined tupleset",
+			schema: "definition test/document { permission view = parent->view }",
+			err:    "permission test/document#view: undefined relation parent",
+		},
+		{
+			name:   "duplicate definition",
+			schema: "definition test/user {}\ndefinition test/user {}",
+			err:    "duplicate definition test/user",
+		},
+		{
+			name:   "syntax",
+			schema: "definition test/user {\n relation owner test/user\n}",
+			err:    `line 2: expected ":", found "test/user"`,
+		},
+		{
+			name:   "caveat",
+			schema: "caveat only_admins(admin bool) { admin }",
+			err:    "line 1: caveats are not supported",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseSchema(tt.schema)
+			assert.EqualError(t, err, tt.err)
+		})
+	}
+}
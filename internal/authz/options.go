@@ -5,25 +5,51 @@ import (
 
 	"github.com/spf13/pflag"
 
+	"github.com/project-kessel/inventory-api/internal/authz/audit"
+	"github.com/project-kessel/inventory-api/internal/authz/cache"
 	"github.com/project-kessel/inventory-api/internal/authz/kessel"
+	"github.com/project-kessel/inventory-api/internal/authz/local"
 )
 
 // TODO: presumably more will go here to authenticate Common Inventory as a service to call Kessel.
 type Options struct {
 	Authz  string          `mapstructure:"impl"`
 	Kessel *kessel.Options `mapstructure:"kessel"`
+	Local  *local.Options  `mapstructure:"local"`
+	Cache  *cache.Options  `mapstructure:"cache"`
+	Audit  *audit.Options  `mapstructure:"audit"`
+	// VerifySchema is what startup does when the relations schema lacks a resource type: VerifyWarn, VerifyFail or
+	// VerifyOff
+	VerifySchema string `mapstructure:"verify-schema"`
+	// MaxConcurrentLists bounds the workspace listings reading lookups from the authorizer at a time
+	MaxConcurrentLists int `mapstructure:"max-concurrent-lists"`
+	// MaxListLookupIds bounds the ids of a lookup a workspace listing keeps, the resources of larger lookups are checked
+	// one at a time instead
+	MaxListLookupIds int `mapstructure:"max-list-lookup-ids"`
 }
 
 const (
 	AllowAll     = "allow-all"
 	Kessel       = "kessel"
+	Local        = "local"
 	RelationsAPI = "kessel-relations"
+
+	VerifyWarn = "warn"
+	VerifyFail = "fail"
+	VerifyOff  = "off"
 )
 
 func NewOptions() *Options {
 	return &Options{
 		Authz:  AllowAll,
 		Kessel: kessel.NewOptions(),
+		Local:  local.NewOptions(),
+		Cache:  cache.NewOptions(),
+		Audit:  audit.NewOptions(),
+
+		VerifySchema:       VerifyWarn,
+		MaxConcurrentLists: 20,
+		MaxListLookupIds:   10000,
 	}
 }
 
@@ -32,21 +58,46 @@ func (o *Options) AddFlags(fs *pflag.FlagSet, prefix string) {
 		prefix = prefix + "."
 	}
 
-	fs.StringVar(&o.Authz, prefix+"impl", o.Authz, "Authz impl to use.  Options are 'allow-all' and 'kessel'.")
+	fs.StringVar(&o.Authz, prefix+"impl", o.Authz, "Authz impl to use.  Options are 'allow-all', 'kessel' and 'local'.")
 	o.Kessel.AddFlags(fs, prefix+"kessel")
+	o.Local.AddFlags(fs, prefix+"local")
+	o.Cache.AddFlags(fs, prefix+"cache")
+	o.Audit.AddFlags(fs, prefix+"audit")
+	fs.StringVar(&o.VerifySchema, prefix+"verify-schema", o.VerifySchema, "What startup does when the relations schema lacks a definition or relation of the resource types.  Options are 'warn', 'fail' and 'off'.")
+	fs.IntVar(&o.MaxConcurrentLists, prefix+"max-concurrent-lists", o.MaxConcurrentLists, "The number of workspace listings reading the lookups of their resources at a time, across requests.")
+	fs.IntVar(&o.MaxListLookupIds, prefix+"max-list-lookup-ids", o.MaxListLookupIds, "The number of ids of a lookup a workspace listing keeps, the resources of a type with a larger lookup are checked one at a time.")
 }
 
 func (o *Options) Validate() []error {
 	var errs []error
 
-	if o.Authz != AllowAll && o.Authz != Kessel {
-		errs = append(errs, fmt.Errorf("invalid authz.impl: %s.  Options are 'allow-all' and 'kessel'", o.Authz))
+	if o.Authz != AllowAll && o.Authz != Kessel && o.Authz != Local {
+		errs = append(errs, fmt.Errorf("invalid authz.impl: %s.  Options are 'allow-all', 'kessel' and 'local'", o.Authz))
 	}
 
 	if o.Authz == Kessel {
 		errs = append(errs, o.Kessel.Validate()...)
 	}
 
+	if o.Authz == Local {
+		errs = append(errs, o.Local.Validate()...)
+	}
+
+	errs = append(errs, o.Cache.Validate()...)
+	errs = append(errs, o.Audit.Validate()...)
+
+	if o.VerifySchema != VerifyWarn && o.VerifySchema != VerifyFail && o.VerifySchema != VerifyOff {
+		errs = append(errs, fmt.Errorf("invalid authz.verify-schema: %s.  Options are 'warn', 'fail' and 'off'", o.VerifySchema))
+	}
+
+	if o.MaxConcurrentLists <= 0 {
+		errs = append(errs, fmt.Errorf("authz.max-concurrent-lists must be positive: %d", o.MaxConcurrentLists))
+	}
+
+	if o.MaxListLookupIds <= 0 {
+		errs = append(errs, fmt.Errorf("authz.max-list-lookup-ids must be positive: %d", o.MaxListLookupIds))
+	}
+
 	return errs
 }
 
@@ -0,0 +1,205 @@
+package verify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+
+	kessel "github.com/project-kessel/relations-api/api/kessel/relations/v1beta1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	authzapi "github.com/project-kessel/inventory-api/internal/authz/api"
+	"github.com/project-kessel/inventory-api/internal/authz/local"
+	"github.com/project-kessel/inventory-api/internal/middleware"
+)
+
+// WorkspaceRelation is the relation inventory writes for every resource
+const WorkspaceRelation = "workspace"
+
+// Type is a relations type inventory writes tuples of, with the relations it writes and the reporters of its
+// resources.
+type Type struct {
+	Namespace string   `json:"namespace"`
+	Name      string   `json:"name"`
+	Relations []string `json:"relations"`
+	Reporters []string `json:"reporters"`
+}
+
+func (t Type) String() string {
+	return t.Namespace + "/" + t.Name
+}
+
+// Problem is a type, or a relation of a type, the relations schema doesn't define.
+type Problem struct {
+	Type     string `json:"type"`
+	Relation string `json:"relation,omitempty"`
+	// Reporters are the reporters whose resources are of the type
+	Reporters []string `json:"reporters"`
+	Message   string   `json:"message"`
+}
+
+func (p Problem) String() string {
+	return fmt.Sprintf("%s (reported by %s): %s", p.Type, strings.Join(p.Reporters, ", "), p.Message)
+}
+
+// Report lists the types verified and the problems found by Verify.
+type Report struct {
+	Types    []Type    `json:"types"`
+	Problems []Problem `json:"problems"`
+}
+
+// Definitions tells which types and relations the relations schema defines.
+type Definitions interface {
+	// Defines returns whether the type is defined and, when it is, whether it defines the relation
+	Defines(ctx context.Context, namespace, name, relation string) (typeDefined bool, relationDefined bool, err error)
+}
+
+// Types returns the relations types of the loaded resource schemas: the namespace of every reporter of every
+// resource type, with the workspace relation and the relations declared by the reporter.
+func Types() ([]Type, error) {
+	types := map[string]*Type{}
+	for _, resourceType := range middleware.LoadResourceTypes() {
+		reporters, err := middleware.LoadValidReporters(resourceType)
+		if err != nil {
+			return nil, err
+		}
+		for _, reporter := range reporters {
+			namespace, err := middleware.ResolveNamespace(resourceType, reporter)
+			if err != nil {
+				return nil, err
+			}
+			key := namespace + "/" + resourceType
+			t, ok := types[key]
+			if !ok {
+				t = &Type{Namespace: namespace, Name: resourceType, Relations: []string{WorkspaceRelation}}
+				types[key] = t
+			}
+			t.Reporters = append(t.Reporters, reporter)
+
+			config, err := middleware.LoadReporterConfig(resourceType, reporter)
+			if errors.Is(err, middleware.ErrConfigNotFound) {
+				continue
+			} else if err != nil {
+				return nil, err
+			}
+			for _, relation := range config.Relations {
+				if !slices.Contains(t.Relations, relation.Relation) {
+					t.Relations = append(t.Relations, relation.Relation)
+				}
+			}
+		}
+	}
+
+	result := make([]Type, 0, len(types))
+	for _, t := range types {
+		result = append(result, *t)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].String() < result[j].String() })
+	return result, nil
+}
+
+// Verify compares the relations types of the loaded resource schemas with the definitions of the relations schema.
+func Verify(ctx context.Context, definitions Definitions) (*Report, error) {
+	types, err := Types()
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{Types: types, Problems: []Problem{}}
+	for _, t := range types {
+		for _, relation := range t.Relations {
+			typeDefined, relationDefined, err := definitions.Defines(ctx, t.Namespace, t.Name, relation)
+			if err != nil {
+				return report, fmt.Errorf("failed to verify %s: %w", t, err)
+			}
+			if !typeDefined {
+				report.Problems = append(report.Problems, Problem{Type: t.String(), Reporters: t.Reporters,
+					Message: fmt.Sprintf("no definition of %s", t)})
+				break
+			}
+			if !relationDefined {
+				report.Problems = append(report.Problems, Problem{Type: t.String(), Relation: relation, Reporters: t.Reporters,
+					Message: fmt.Sprintf("definition of %s has no %s relation", t, relation)})
+			}
+		}
+	}
+	return report, nil
+}
+
+// schemaDefinitions are the definitions of a parsed schema, e.g. deploy/schema.zed
+type schemaDefinitions struct {
+	schema *local.Schema
+}
+
+// FromSchema returns the definitions of a parsed schema.
+func FromSchema(schema *local.Schema) Definitions {
+	return &schemaDefinitions{schema: schema}
+}
+
+func (d *schemaDefinitions) Defines(ctx context.Context, namespace, name, relation string) (bool, bool, error) {
+	definition, ok := d.schema.Definitions[namespace+"/"+name]
+	if !ok {
+		return false, false, nil
+	}
+	// relations-api prefixes the relations of the tuples with t_
+	for _, r := range []string{"t_" + relation, relation} {
+		if _, ok := definition.Relations[r]; ok {
+			return true, true, nil
+		}
+	}
+	return true, false, nil
+}
+
+// relationsDefinitions probes relations-api, which doesn't serve its schema, by reading a tuple of the type and
+// relation.  Reading the tuples of an undefined type or relation fails.
+type relationsDefinitions struct {
+	authorizer authzapi.Authorizer
+}
+
+// FromRelations returns the definitions of the schema of relations-api, probed through the authorizer.
+func FromRelations(authorizer authzapi.Authorizer) Definitions {
+	return &relationsDefinitions{authorizer: authorizer}
+}
+
+func (d *relationsDefinitions) Defines(ctx context.Context, namespace, name, relation string) (bool, bool, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stream, err := d.authorizer.ReadTuples(ctx, &kessel.ReadTuplesRequest{
+		Filter: &kessel.RelationTupleFilter{
+			ResourceNamespace: proto.String(namespace),
+			ResourceType:      proto.String(name),
+			Relation:          proto.String(relation),
+		},
+		Pagination: &kessel.RequestPagination{Limit: 1},
+	})
+	if err == nil {
+		_, err = stream.Recv()
+	}
+	if err == nil || err == io.EOF {
+		return true, true, nil
+	}
+	return undefined(err)
+}
+
+// undefined classifies the error of reading the tuples of a type and relation, the messages are SpiceDB's
+func undefined(err error) (bool, bool, error) {
+	s, ok := status.FromError(err)
+	if !ok || (s.Code() != codes.InvalidArgument && s.Code() != codes.FailedPrecondition && s.Code() != codes.NotFound) {
+		return false, false, err
+	}
+	switch message := s.Message(); {
+	case strings.Contains(message, "not found under definition"):
+		return true, false, nil
+	case strings.Contains(message, "object definition"):
+		return false, false, nil
+	default:
+		return false, false, err
+	}
+}
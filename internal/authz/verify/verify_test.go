@@ -0,0 +1,119 @@
+package verify
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/go-kratos/kratos/v2/log"
+	kessel "github.com/project-kessel/relations-api/api/kessel/relations/v1beta1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/project-kessel/inventory-api/internal/authz/allow"
+	"github.com/project-kessel/inventory-api/internal/authz/local"
+	"github.com/project-kessel/inventory-api/internal/middleware"
+)
+
+// the definitions of hbi/host and acm/k8s_policy, acm/k8s_cluster lacks its workspace relation
+const relationsSchema = `
+definition rbac/workspace {}
+
+definition hbi/host {
+	relation t_workspace: rbac/workspace
+}
+
+definition acm/k8s_cluster {}
+
+definition acm/k8s_policy {
+	relation t_workspace: rbac/workspace
+}
+`
+
+func setupSchema(t *testing.T) *local.Schema {
+	require.NoError(t, middleware.PreloadAllSchemas(""))
+	schema, err := local.ParseSchema(relationsSchema)
+	require.NoError(t, err)
+	return schema
+}
+
+func expectedProblems() []Problem {
+	return []Problem{
+		{Type: "acm/k8s_cluster", Relation: "workspace", Reporters: []string{"ACM"}, Message: "definition of acm/k8s_cluster has no workspace relation"},
+		{Type: "acs/k8s_cluster", Reporters: []string{"ACS"}, Message: "no definition of acs/k8s_cluster"},
+		{Type: "notifications/notifications_integration", Reporters: []string{"NOTIFICATIONS"}, Message: "no definition of notifications/notifications_integration"},
+		{Type: "ocm/k8s_cluster", Reporters: []string{"OCM"}, Message: "no definition of ocm/k8s_cluster"},
+	}
+}
+
+func TestTypes(t *testing.T) {
+	setupSchema(t)
+
+	types, err := Types()
+	require.NoError(t, err)
+
+	names := make([]string, 0, len(types))
+	for _, typ := range types {
+		names = append(names, typ.String())
+		assert.Equal(t, []string{WorkspaceRelation}, typ.Relations)
+	}
+	assert.Equal(t, []string{"acm/k8s_cluster", "acm/k8s_policy", "acs/k8s_cluster", "hbi/host", "notifications/notifications_integration", "ocm/k8s_cluster"}, names)
+	assert.Equal(t, []string{"HBI"}, types[3].Reporters)
+}
+
+func TestVerify_Schema(t *testing.T) {
+	schema := setupSchema(t)
+
+	report, err := Verify(context.Background(), FromSchema(schema))
+	require.NoError(t, err)
+	assert.Len(t, report.Types, 6)
+	assert.Equal(t, expectedProblems(), report.Problems)
+}
+
+// relationsAuthz reads the tuples the way relations-api does: reading the tuples of a type or a relation the schema
+// doesn't define fails
+type relationsAuthz struct {
+	*allow.AllowAllAuthz
+	schema *local.Schema
+	err    error
+}
+
+type emptyStream struct {
+	grpc.ClientStream
+}
+
+func (emptyStream) Recv() (*kessel.ReadTuplesResponse, error) {
+	return nil, io.EOF
+}
+
+func (a *relationsAuthz) ReadTuples(ctx context.Context, in *kessel.ReadTuplesRequest) (grpc.ServerStreamingClient[kessel.ReadTuplesResponse], error) {
+	if a.err != nil {
+		return nil, a.err
+	}
+	resourceType := in.GetFilter().GetResourceNamespace() + "/" + in.GetFilter().GetResourceType()
+	definition, ok := a.schema.Definitions[resourceType]
+	if !ok {
+		return nil, status.Errorf(codes.FailedPrecondition, "error reading relationships: object definition `%s` not found", resourceType)
+	}
+	if _, ok := definition.Relations["t_"+in.GetFilter().GetRelation()]; !ok {
+		return nil, status.Errorf(codes.FailedPrecondition, "error reading relationships: relation/permission `t_%s` not found under definition `%s`", in.GetFilter().GetRelation(), resourceType)
+	}
+	return emptyStream{}, nil
+}
+
+func TestVerify_Relations(t *testing.T) {
+	schema := setupSchema(t)
+	authz := &relationsAuthz{AllowAllAuthz: allow.New(log.NewHelper(log.DefaultLogger)), schema: schema}
+
+	report, err := Verify(context.Background(), FromRelations(authz))
+	require.NoError(t, err)
+	assert.Equal(t, expectedProblems(), report.Problems)
+
+	// relations-api being unavailable isn't a missing definition
+	authz.err = status.Error(codes.Unavailable, "connection refused")
+	_, err = Verify(context.Background(), FromRelations(authz))
+	assert.ErrorContains(t, err, "failed to verify acm/k8s_cluster")
+}
@@ -2,26 +2,44 @@ package biz
 
 import (
 	"context"
-	"strings"
 	"time"
 
 	authzapi "github.com/project-kessel/inventory-api/internal/authz/api"
 	"github.com/project-kessel/inventory-api/internal/biz/model"
 	eventingapi "github.com/project-kessel/inventory-api/internal/eventing/api"
 	"github.com/project-kessel/inventory-api/internal/middleware"
+	kessel "github.com/project-kessel/relations-api/api/kessel/relations/v1beta1"
 )
 
 func DefaultResourceSendEvent(ctx context.Context, model *model.Resource, eventer eventingapi.Manager, reportedTime time.Time, operationType eventingapi.OperationType) error {
+	return DefaultResourceSendEventWithChanges(ctx, model, nil, eventer, reportedTime, operationType)
+}
+
+// DefaultResourceSendEventWithChanges sends the event of the resource, including the changes when they aren't nil.
+func DefaultResourceSendEventWithChanges(ctx context.Context, model *model.Resource, changes *eventingapi.ResourceChanges, eventer eventingapi.Manager, reportedTime time.Time, operationType eventingapi.OperationType) error {
 	identity, err := middleware.GetIdentity(ctx)
 	if err != nil {
 		return err
 	}
 
-	producer, _ := eventer.Lookup(identity, model.ResourceType, model.ID)
+	reporterType := model.ReporterType
+	if reporterType == "" {
+		reporterType = model.Reporter.ReporterType //nolint:staticcheck
+	}
+
+	producer, _ := eventer.Lookup(identity, eventingapi.Route{
+		EventType:     eventingapi.ResourceEventType,
+		ResourceType:  model.ResourceType,
+		ReporterType:  reporterType,
+		OperationType: operationType,
+	}, eventingapi.ResourceKey(model))
 	evt, err := eventingapi.NewResourceEvent(operationType, model, reportedTime)
 	if err != nil {
 		return err
 	}
+	if changes != nil {
+		evt.SetChanges(changes)
+	}
 
 	err = producer.Produce(ctx, evt)
 	if err != nil {
@@ -37,7 +55,12 @@ func DefaultRelationshipSendEvent(ctx context.Context, m *model.Relationship, ev
 		return err
 	}
 
-	producer, _ := eventer.Lookup(identity, m.RelationshipType, m.ID)
+	producer, _ := eventer.Lookup(identity, eventingapi.Route{
+		EventType:     eventingapi.RelationshipEventType,
+		ResourceType:  m.RelationshipType,
+		ReporterType:  m.Reporter.ReporterType,
+		OperationType: operationType,
+	}, m.SubjectId)
 	evt, err := eventingapi.NewRelationshipEvent(operationType, m, reportedTime)
 	if err != nil {
 		return err
@@ -51,11 +74,19 @@ func DefaultRelationshipSendEvent(ctx context.Context, m *model.Relationship, ev
 	return nil
 }
 
-func DefaultSetWorkspace(ctx context.Context, namespace string, model *model.Resource, authz authzapi.Authorizer, upsert bool) (string, error) {
-	if model.ReporterType != "" {
-		namespace = strings.ToLower(model.ReporterType)
+// DefaultSetWorkspace sets the workspace of the resource, replacing its previous workspace when the resource was moved.
+func DefaultSetWorkspace(ctx context.Context, model *model.Resource, authz authzapi.Authorizer, upsert bool) (string, error) {
+	namespace, err := middleware.ResolveNamespace(model.ResourceType, model.ReporterType)
+	if err != nil {
+		return "", err
+	}
+
+	var r *kessel.CreateTuplesResponse
+	if model.PreviousWorkspaceId != "" && model.PreviousWorkspaceId != model.WorkspaceId {
+		r, err = authz.ReplaceWorkspace(ctx, model.ReporterResourceId, model.PreviousWorkspaceId, model.WorkspaceId, namespace, model.ResourceType)
+	} else {
+		r, err = authz.SetWorkspace(ctx, model.ReporterResourceId, model.WorkspaceId, namespace, model.ResourceType, upsert) //nolint:staticcheck
 	}
-	r, err := authz.SetWorkspace(ctx, model.ReporterResourceId, model.WorkspaceId, namespace, model.ResourceType, upsert) //nolint:staticcheck
 	if err != nil {
 		return "", err
 	}
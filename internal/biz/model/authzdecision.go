@@ -0,0 +1,19 @@
+package model
+
+import "time"
+
+// AuthzDecision is a decision of the authorizer recorded by the decision audit log.  The subject and the resource
+// are namespaced, e.g. rbac/principal:alice and hbi/host:host-1, the subject carries its relation, if any, after a #.
+type AuthzDecision struct {
+	ID               uint64    `gorm:"primarykey" json:"-"`
+	Timestamp        time.Time `gorm:"index" json:"timestamp"`
+	CallerType       string    `json:"caller_type,omitempty"`
+	Caller           string    `gorm:"index" json:"caller,omitempty"`
+	Subject          string    `gorm:"index" json:"subject"`
+	Resource         string    `gorm:"index" json:"resource"`
+	Relation         string    `json:"relation"`
+	Decision         string    `json:"decision"`
+	ConsistencyToken string    `json:"consistency_token,omitempty"`
+	LatencyMicros    int64     `json:"latency_us"`
+	Error            string    `json:"error,omitempty"`
+}
@@ -21,6 +21,9 @@ type Relationship struct {
 	CreatedAt        *time.Time
 	UpdatedAt        *time.Time
 
+	// HistoryId is the id of the history row written by the last change of the relationship, it is not stored
+	HistoryId uuid.UUID `gorm:"-" json:"-"`
+
 	// Used to create FKs
 	Subject Resource `gorm:"foreignKey:SubjectId"`
 	Object  Resource `gorm:"foreignKey:ObjectId"`
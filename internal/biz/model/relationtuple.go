@@ -0,0 +1,15 @@
+package model
+
+import "time"
+
+// RelationTuple is a relationship of the local authorizer, it relates a resource to a subject, or to a relation of
+// a subject.  The types are namespaced, e.g. rbac/workspace.
+type RelationTuple struct {
+	ResourceType    string `gorm:"primarykey"`
+	ResourceId      string `gorm:"primarykey"`
+	Relation        string `gorm:"primarykey"`
+	SubjectType     string `gorm:"primarykey;index:idx_relation_tuple_subject"`
+	SubjectId       string `gorm:"primarykey;index:idx_relation_tuple_subject"`
+	SubjectRelation string `gorm:"primarykey"`
+	CreatedAt       *time.Time
+}
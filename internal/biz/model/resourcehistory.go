@@ -22,6 +22,8 @@ type ResourceHistory struct {
 
 	ResourceId    uuid.UUID     `gorm:"type:uuid;index"`
 	OperationType OperationType `gorm:"index"`
+	// InventoryId is the inventory id of the resource when it changed, nil in the rows recorded before it was kept
+	InventoryId *uuid.UUID `gorm:"type:uuid"`
 }
 
 func (r *ResourceHistory) ResourceHistory(db *gorm.DB, s *schema.Schema) error {
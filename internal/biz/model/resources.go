@@ -35,6 +35,10 @@ type Resource struct {
 	ReporterId string `json:"reporter_id"`
 	// Deprecated: Use Reporter Fields instead(ReporterId, ReporterResourceId)
 	Reporter ResourceReporter
+	// HistoryId is the id of the history row written by the last change of the resource, it is not stored
+	HistoryId uuid.UUID `gorm:"-" json:"-"`
+	// PreviousWorkspaceId is the workspace the last change moved the resource from, it is not stored
+	PreviousWorkspaceId string `gorm:"-" json:"-"`
 }
 
 type ReporterResourceUniqueIndex struct {
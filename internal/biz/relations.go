@@ -0,0 +1,314 @@
+package biz
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+
+	authzapi "github.com/project-kessel/inventory-api/internal/authz/api"
+	"github.com/project-kessel/inventory-api/internal/biz/model"
+	"github.com/project-kessel/inventory-api/internal/middleware"
+	kessel "github.com/project-kessel/relations-api/api/kessel/relations/v1beta1"
+)
+
+// SchemaRelations returns the relations declared in the reporter config.yaml of the resource.
+// Resources whose reporter doesn't declare a config have no additional relations.
+func SchemaRelations(m *model.Resource) ([]middleware.RelationConfig, error) {
+	if m.ResourceType == "" || m.ReporterType == "" {
+		return nil, nil
+	}
+
+	config, err := middleware.LoadReporterConfig(m.ResourceType, m.ReporterType)
+	if err != nil {
+		if errors.Is(err, middleware.ErrConfigNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return config.Relations, nil
+}
+
+// SchemaRelationships builds the relations tuples declared in the reporter config.yaml from the reported data.
+// Relations whose subject field is absent from the reported data produce no tuples.
+func SchemaRelationships(namespace string, m *model.Resource) ([]*kessel.Relationship, error) {
+	relations, err := SchemaRelations(m)
+	if err != nil {
+		return nil, err
+	}
+
+	var rels []*kessel.Relationship
+	for _, relation := range relations {
+		subjectIds, err := resolveRelationField(m, relation.SubjectIdField)
+		if err != nil {
+			return nil, fmt.Errorf("relation '%s': %w", relation.Relation, err)
+		}
+
+		subjectNamespace := relation.SubjectNamespace
+		if subjectNamespace == "" {
+			subjectNamespace = namespace
+		}
+
+		for _, subjectId := range subjectIds {
+			subject := &kessel.SubjectReference{
+				Subject: &kessel.ObjectReference{
+					Type: &kessel.ObjectType{
+						Namespace: subjectNamespace,
+						Name:      relation.SubjectType,
+					},
+					Id: subjectId,
+				},
+			}
+			if relation.SubjectRelation != "" {
+				subject.Relation = proto.String(relation.SubjectRelation)
+			}
+
+			rels = append(rels, &kessel.Relationship{
+				Resource: &kessel.ObjectReference{
+					Type: &kessel.ObjectType{
+						Namespace: namespace,
+						Name:      m.ResourceType,
+					},
+					Id: m.ReporterResourceId,
+				},
+				Relation: relation.Relation,
+				Subject:  subject,
+			})
+		}
+	}
+
+	return rels, nil
+}
+
+// DefaultSetRelations replaces the schema-declared relations of the resource with the ones derived from its current data.
+// The current tuples are upserted before the stale ones are deleted, so a failure never leaves the resource without
+// the relations it still has. Only the tuples of the declared relations are replaced, the tuples of other relations
+// belong to other writers.
+func DefaultSetRelations(ctx context.Context, m *model.Resource, authz authzapi.Authorizer) (string, error) {
+	relations, err := SchemaRelations(m)
+	if err != nil || len(relations) == 0 {
+		return "", err
+	}
+
+	namespace, err := middleware.ResolveNamespace(m.ResourceType, m.ReporterType)
+	if err != nil {
+		return "", err
+	}
+
+	rels, err := SchemaRelationships(namespace, m)
+	if err != nil {
+		return "", err
+	}
+
+	var token *kessel.ConsistencyToken
+	if len(rels) > 0 {
+		r, err := authz.CreateTuples(ctx, &kessel.CreateTuplesRequest{
+			Upsert: true,
+			Tuples: rels,
+		})
+		if err != nil {
+			return "", err
+		}
+		token = r.ConsistencyToken
+	}
+
+	var stale []*kessel.Relationship
+	for _, relation := range relationNames(relations) {
+		s, err := staleRelationships(ctx, namespace, m, relation, rels, token, authz)
+		if err != nil {
+			return "", err
+		}
+		stale = append(stale, s...)
+	}
+
+	for _, rel := range stale {
+		r, err := authz.DeleteTuples(ctx, &kessel.DeleteTuplesRequest{Filter: relationshipFilter(rel)})
+		if err != nil {
+			return "", err
+		}
+		if r.ConsistencyToken != nil {
+			token = r.ConsistencyToken
+		}
+	}
+
+	if token != nil {
+		return token.Token, nil
+	}
+	return "", nil
+}
+
+// DefaultUnsetRelations removes every tuple of the schema-declared relations of the resource.
+func DefaultUnsetRelations(ctx context.Context, m *model.Resource, authz authzapi.Authorizer) error {
+	relations, err := SchemaRelations(m)
+	if err != nil || len(relations) == 0 {
+		return err
+	}
+
+	namespace, err := middleware.ResolveNamespace(m.ResourceType, m.ReporterType)
+	if err != nil {
+		return err
+	}
+
+	for _, relation := range relations {
+		_, err := authz.DeleteTuples(ctx, &kessel.DeleteTuplesRequest{
+			Filter: &kessel.RelationTupleFilter{
+				ResourceNamespace: proto.String(namespace),
+				ResourceType:      proto.String(m.ResourceType),
+				ResourceId:        proto.String(m.ReporterResourceId),
+				Relation:          proto.String(relation.Relation),
+			},
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// relationNames returns the distinct relation names of the relations, in order.
+func relationNames(relations []middleware.RelationConfig) []string {
+	var names []string
+	seen := map[string]bool{}
+	for _, relation := range relations {
+		if !seen[relation.Relation] {
+			seen[relation.Relation] = true
+			names = append(names, relation.Relation)
+		}
+	}
+	return names
+}
+
+// staleRelationships reads the tuples of the relation of the resource, at least as fresh as the token when given, and
+// returns the ones that aren't one of the current relationships.
+func staleRelationships(ctx context.Context, namespace string, m *model.Resource, relation string, current []*kessel.Relationship, token *kessel.ConsistencyToken, authz authzapi.Authorizer) ([]*kessel.Relationship, error) {
+	keep := make(map[string]struct{}, len(current))
+	for _, rel := range current {
+		keep[relationshipKey(rel)] = struct{}{}
+	}
+
+	request := &kessel.ReadTuplesRequest{
+		Filter: &kessel.RelationTupleFilter{
+			ResourceNamespace: proto.String(namespace),
+			ResourceType:      proto.String(m.ResourceType),
+			ResourceId:        proto.String(m.ReporterResourceId),
+			Relation:          proto.String(relation),
+		},
+	}
+	if token != nil {
+		request.Consistency = &kessel.Consistency{Requirement: &kessel.Consistency_AtLeastAsFresh{AtLeastAsFresh: token}}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stream, err := authz.ReadTuples(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+
+	var stale []*kessel.Relationship
+	for {
+		resp, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		rel := resp.GetTuple()
+		if _, ok := keep[relationshipKey(rel)]; !ok {
+			stale = append(stale, rel)
+		}
+	}
+
+	return stale, nil
+}
+
+// relationshipFilter matches exactly the given relationship.
+func relationshipFilter(rel *kessel.Relationship) *kessel.RelationTupleFilter {
+	filter := &kessel.RelationTupleFilter{
+		ResourceNamespace: proto.String(rel.GetResource().GetType().GetNamespace()),
+		ResourceType:      proto.String(rel.GetResource().GetType().GetName()),
+		ResourceId:        proto.String(rel.GetResource().GetId()),
+		Relation:          proto.String(rel.GetRelation()),
+		SubjectFilter: &kessel.SubjectFilter{
+			SubjectNamespace: proto.String(rel.GetSubject().GetSubject().GetType().GetNamespace()),
+			SubjectType:      proto.String(rel.GetSubject().GetSubject().GetType().GetName()),
+			SubjectId:        proto.String(rel.GetSubject().GetSubject().GetId()),
+		},
+	}
+	if rel.GetSubject().Relation != nil {
+		filter.SubjectFilter.Relation = proto.String(rel.GetSubject().GetRelation())
+	}
+	return filter
+}
+
+// relationshipKey identifies the relation and subject of a tuple of the resource.
+func relationshipKey(rel *kessel.Relationship) string {
+	subject := rel.GetSubject()
+	return fmt.Sprintf("%s %s/%s:%s#%s", rel.GetRelation(),
+		subject.GetSubject().GetType().GetNamespace(), subject.GetSubject().GetType().GetName(), subject.GetSubject().GetId(), subject.GetRelation())
+}
+
+// resolveRelationField returns the subject ids found at the given field of the resource.
+// The field can hold a single string or a list of strings.
+func resolveRelationField(m *model.Resource, field string) ([]string, error) {
+	var value interface{}
+
+	switch field {
+	case "org_id":
+		value = m.OrgId
+	case "workspace_id":
+		value = m.WorkspaceId
+	case "reporter_id":
+		value = m.ReporterId
+	case "reporter_instance_id":
+		value = m.ReporterInstanceId
+	case "local_resource_id":
+		value = m.ReporterResourceId
+	default:
+		path, ok := strings.CutPrefix(field, "resource_data.")
+		if !ok {
+			return nil, fmt.Errorf("unsupported subject_id_field '%s'", field)
+		}
+
+		value = map[string]interface{}(m.ResourceData)
+		for _, key := range strings.Split(path, ".") {
+			obj, ok := value.(map[string]interface{})
+			if !ok {
+				return nil, nil
+			}
+			value = obj[key]
+		}
+	}
+
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		if v == "" {
+			return nil, nil
+		}
+		return []string{v}, nil
+	case []interface{}:
+		var ids []string
+		for _, item := range v {
+			id, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("subject_id_field '%s' must contain strings (got %T)", field, item)
+			}
+			if id != "" {
+				ids = append(ids, id)
+			}
+		}
+		return ids, nil
+	default:
+		return nil, fmt.Errorf("subject_id_field '%s' must be a string or a list of strings (got %T)", field, value)
+	}
+}
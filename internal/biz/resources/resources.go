@@ -4,8 +4,7 @@ import (
 	"context"
 	"errors"
 	"google.golang.org/grpc"
-	"strings"
-	"sync"
+	"io"
 	"time"
 
 	"github.com/google/uuid"
@@ -15,17 +14,21 @@ import (
 	"github.com/project-kessel/inventory-api/internal/biz"
 	"github.com/project-kessel/inventory-api/internal/biz/model"
 	eventingapi "github.com/project-kessel/inventory-api/internal/eventing/api"
+	"github.com/project-kessel/inventory-api/internal/middleware"
 	"github.com/project-kessel/inventory-api/internal/server"
 	kessel "github.com/project-kessel/relations-api/api/kessel/relations/v1beta1"
+	"golang.org/x/sync/semaphore"
 	"gorm.io/gorm"
 )
 
 type ReporterResourceRepository interface {
 	Create(context.Context, *model.Resource) (*model.Resource, []*model.Resource, error)
 	Update(context.Context, *model.Resource, uuid.UUID) (*model.Resource, []*model.Resource, error)
+	UpdateConsistencyToken(ctx context.Context, id uuid.UUID, token string) error
 	Delete(context.Context, uuid.UUID) (*model.Resource, error)
 	FindByID(context.Context, uuid.UUID) (*model.Resource, error)
 	FindByWorkspaceId(context.Context, string) ([]*model.Resource, error)
+	FindByWorkspaceIdAfter(ctx context.Context, workspaceId string, after uuid.UUID, limit int) ([]*model.Resource, error)
 	FindByReporterResourceId(context.Context, model.ReporterResourceId) (*model.Resource, error)
 	FindByReporterResourceIdv1beta2(context.Context, model.ReporterResourceUniqueIndex) (*model.Resource, error)
 	FindByReporterData(context.Context, string, string) (*model.Resource, error)
@@ -50,20 +53,25 @@ type Usecase struct {
 	inventoryResourceRepository InventoryResourceRepository
 	Authz                       authzapi.Authorizer
 	Eventer                     eventingapi.Manager
-	Namespace                   string
 	log                         *log.Helper
 	Server                      server.Server
 	DisablePersistence          bool
+	// IncludeChanges adds the previous values and a JSON Patch of the changed fields to the updated events of reports
+	IncludeChanges bool
+	// ListSlots bounds the workspace listings reading lookups from relations-api at a time, across requests
+	ListSlots *semaphore.Weighted
+	// MaxLookupIds bounds the ids of a lookup a workspace listing keeps, the resources of a type whose lookup returns
+	// more are checked one at a time instead.  Zero keeps every id.
+	MaxLookupIds int
 }
 
 func New(reporterResourceRepository ReporterResourceRepository, inventoryResourceRepository InventoryResourceRepository,
-	authz authzapi.Authorizer, eventer eventingapi.Manager, namespace string, logger log.Logger, disablePersistence bool) *Usecase {
+	authz authzapi.Authorizer, eventer eventingapi.Manager, logger log.Logger, disablePersistence bool) *Usecase {
 	return &Usecase{
 		reporterResourceRepository:  reporterResourceRepository,
 		inventoryResourceRepository: inventoryResourceRepository,
 		Authz:                       authz,
 		Eventer:                     eventer,
-		Namespace:                   namespace,
 		log:                         log.NewHelper(logger),
 		DisablePersistence:          disablePersistence,
 	}
@@ -118,30 +126,47 @@ func createNewReporterResource(ctx context.Context, m *model.Resource, uc *Useca
 
 	//TODO: adding eventing and relations calls for v1beta2 schema demo purposes. Needs to be updated to be done via outbox with the consistency Epic
 	if uc.Eventer != nil {
-		// Send event for the created resource
-		err := biz.DefaultResourceSendEvent(ctx, m, uc.Eventer, *m.CreatedAt, eventingapi.OperationTypeCreated)
-		if err != nil {
-			return nil, err
-		}
-
-		// Send events for any updated resources
-		for _, updatedResource := range updatedResources {
-			err := biz.DefaultResourceSendEvent(ctx, updatedResource, uc.Eventer, *updatedResource.UpdatedAt, eventingapi.OperationTypeUpdated)
+		// the events of the created resource and of the resources sharing its workspace are sent together
+		err := eventingapi.Batch(ctx, uc.Eventer, func(ctx context.Context) error {
+			// Send event for the created resource
+			err := biz.DefaultResourceSendEvent(ctx, m, uc.Eventer, *m.CreatedAt, eventingapi.OperationTypeCreated)
 			if err != nil {
-				return nil, err
+				return err
 			}
+
+			// Send events for any updated resources
+			for _, updatedResource := range updatedResources {
+				err := biz.DefaultResourceSendEvent(ctx, updatedResource, uc.Eventer, *updatedResource.UpdatedAt, eventingapi.OperationTypeUpdated)
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
 		}
 	}
 
 	if uc.Authz != nil {
 		// Send workspace for the created resource
-		ct, err := biz.DefaultSetWorkspace(ctx, uc.Namespace, ret, uc.Authz, false)
+		ct, err := biz.DefaultSetWorkspace(ctx, ret, uc.Authz, false)
 		if err != nil {
 			return nil, err
 		}
 
 		ret.ConsistencyToken = ct
 
+		// Send schema-declared relations for the created resource
+		ct, err = biz.DefaultSetRelations(ctx, ret, uc.Authz)
+		if err != nil {
+			return nil, err
+		}
+
+		if ct != "" {
+			ret.ConsistencyToken = ct
+		}
+
 		if !uc.DisablePersistence {
 			_, _, err = uc.reporterResourceRepository.Update(ctx, ret, ret.ID)
 			if err != nil {
@@ -150,7 +175,7 @@ func createNewReporterResource(ctx context.Context, m *model.Resource, uc *Useca
 		}
 		// Send workspace for any updated resources
 		for _, updatedResource := range updatedResources {
-			ct, err := biz.DefaultSetWorkspace(ctx, uc.Namespace, updatedResource, uc.Authz, false)
+			ct, err := biz.DefaultSetWorkspace(ctx, updatedResource, uc.Authz, false)
 			if err != nil {
 				return nil, err
 			}
@@ -185,6 +210,12 @@ func updateExistingReporterResource(ctx context.Context, m *model.Resource, exis
 	if m.InventoryId != nil && existingResource.InventoryId.String() != m.InventoryId.String() {
 		return nil, ErrInventoryIdMismatch
 	}
+	// compares the report with the stored resource before the save overwrites it
+	changes, err := eventingapi.NewResourceChanges(existingResource, m)
+	if err != nil {
+		return nil, err
+	}
+
 	log.Info("Updating resource: ", m)
 	ret, updatedResources, err := uc.reporterResourceRepository.Update(ctx, m, existingResource.ID)
 	if err != nil {
@@ -193,21 +224,62 @@ func updateExistingReporterResource(ctx context.Context, m *model.Resource, exis
 
 	//TODO: adding eventing and relations calls for v1beta2 schema demo purposes. Needs to be updated to be done via outbox with the consistency Epic
 	if uc.Eventer != nil {
-		for _, updatedResource := range updatedResources {
-			err := biz.DefaultResourceSendEvent(ctx, updatedResource, uc.Eventer, *updatedResource.UpdatedAt, eventingapi.OperationTypeUpdated)
-			if err != nil {
-				return nil, err
+		err := eventingapi.Batch(ctx, uc.Eventer, func(ctx context.Context) error {
+			for _, updatedResource := range updatedResources {
+				if updatedResource.ID != ret.ID {
+					// resources sharing the inventory id of the reported resource, only their workspace changed
+					err := biz.DefaultResourceSendEvent(ctx, updatedResource, uc.Eventer, *updatedResource.UpdatedAt, eventingapi.OperationTypeUpdated)
+					if err != nil {
+						return err
+					}
+					continue
+				}
+
+				if changes == nil {
+					uc.log.WithContext(ctx).Debugf("Report of resource %v(%v) changed nothing, skipping its event", ret.ID, ret.ResourceType)
+					continue
+				}
+				var eventChanges *eventingapi.ResourceChanges
+				if uc.IncludeChanges {
+					eventChanges = changes
+				}
+				err := biz.DefaultResourceSendEventWithChanges(ctx, updatedResource, eventChanges, uc.Eventer, *updatedResource.UpdatedAt, eventingapi.OperationTypeUpdated)
+				if err != nil {
+					return err
+				}
 			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
 		}
 	}
 
 	if uc.Authz != nil {
 		for _, updatedResource := range updatedResources {
-			_, err := biz.DefaultSetWorkspace(ctx, uc.Namespace, updatedResource, uc.Authz, true)
+			_, err := biz.DefaultSetWorkspace(ctx, updatedResource, uc.Authz, true)
 			if err != nil {
 				return nil, err
 			}
 		}
+
+		// Replace schema-declared relations of the updated resource
+		ct, err := biz.DefaultSetRelations(ctx, ret, uc.Authz)
+		if err != nil {
+			return nil, err
+		}
+
+		if ct != "" {
+			ret.ConsistencyToken = ct
+
+			// the token alone changed, it isn't a new version of the resource
+			if !uc.DisablePersistence {
+				err = uc.reporterResourceRepository.UpdateConsistencyToken(ctx, ret.ID, ct)
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
 	}
 
 	uc.log.WithContext(ctx).Infof("Updated Resource: %v(%v)", m.ID, m.ResourceType)
@@ -218,6 +290,11 @@ func (uc *Usecase) LookupResources(ctx context.Context, request *kessel.LookupRe
 	return uc.Authz.LookupResources(ctx, request)
 }
 
+// LookupSubjects streams the subjects having the relation to the resource of the request.
+func (uc *Usecase) LookupSubjects(ctx context.Context, request *kessel.LookupSubjectsRequest) (grpc.ServerStreamingClient[kessel.LookupSubjectsResponse], error) {
+	return uc.Authz.LookupSubjects(ctx, request)
+}
+
 func (uc *Usecase) Check(ctx context.Context, permission, namespace string, sub *kessel.SubjectReference, id model.ReporterResourceId) (bool, error) {
 	res, err := uc.reporterResourceRepository.FindByReporterResourceId(ctx, id)
 	if err != nil {
@@ -281,56 +358,133 @@ func (uc *Usecase) CheckForUpdate(ctx context.Context, permission, namespace str
 	return false, nil
 }
 
+// listPageSize is the number of resources of the workspace read from the database at a time
+const listPageSize = 100
+
+// ListResourcesInWorkspace streams the resources of the workspace the subject has the permission on.  The resources
+// are read from the database a page at a time and matched against the LookupResources of their types, each read in full
+// the first time a page holds its type, up to MaxLookupIds.  The resources of the types with larger lookups are checked
+// one at a time.  The listing stops when the context is done, a failure is sent to the error channel.
 func (uc *Usecase) ListResourcesInWorkspace(ctx context.Context, permission, namespace string, sub *kessel.SubjectReference, id string) (chan *model.Resource, chan error, error) {
-	resources, err := uc.reporterResourceRepository.FindByWorkspaceId(ctx, id)
+	page, err := uc.reporterResourceRepository.FindByWorkspaceIdAfter(ctx, id, uuid.Nil, listPageSize)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	log.Infof("ListResourcesInWorkspace: resources %+v", resources)
+	resourceChan := make(chan *model.Resource)
+	errorChan := make(chan error, 1)
+	go func() {
+		defer close(resourceChan)
+		defer close(errorChan)
 
-	const NUM_WORKERS = 100
+		if err := uc.listAllowedResources(ctx, permission, namespace, sub, id, page, resourceChan); err != nil {
+			errorChan <- err
+		}
+	}()
 
-	resourceChan := make(chan *model.Resource, len(resources))
-	allowedChan := make(chan *model.Resource, len(resources))
-	errorChan := make(chan error, 1)
-	var wg sync.WaitGroup
+	return resourceChan, errorChan, nil
+}
 
-	// start workers
-	for i := 0; i < NUM_WORKERS; i++ {
-		go uc.checkWorker(ctx, permission, namespace, sub, resourceChan, allowedChan, errorChan, &wg)
-	}
+func (uc *Usecase) listAllowedResources(ctx context.Context, permission, namespace string, sub *kessel.SubjectReference, workspaceId string, page []*model.Resource, out chan<- *model.Resource) error {
+	allowed := map[string]map[string]bool{}
+	for len(page) > 0 {
+		if err := uc.lookupAllowed(ctx, permission, namespace, sub, page, allowed); err != nil {
+			return err
+		}
 
-	wg.Add(len(resources))
-	go func() {
-		defer close(allowedChan)
-		defer close(errorChan)
+		for _, resource := range page {
+			ids := allowed[resource.ResourceType]
+			if ids == nil {
+				// the lookup of the type was too large to keep
+				decision, _, err := uc.Authz.Check(ctx, namespace, permission, resource, sub)
+				if err != nil {
+					return err
+				}
+				if decision != kessel.CheckResponse_ALLOWED_TRUE {
+					continue
+				}
+			} else if !ids[resource.ReporterResourceId] {
+				continue
+			}
+			select {
+			case out <- resource:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
 
-		// feed workers with resources to check
-		for _, res := range resources {
-			resourceChan <- res
+		if len(page) < listPageSize {
+			return nil
 		}
-		close(resourceChan)
+		var err error
+		page, err = uc.reporterResourceRepository.FindByWorkspaceIdAfter(ctx, workspaceId, page[len(page)-1].ID, listPageSize)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
-		// wait for workers to finish processing resources before close output channels
-		wg.Wait()
-	}()
+// lookupAllowed reads the allowed ids of the resource types of the page that weren't looked up yet, the ids of a type
+// whose lookup has more than MaxLookupIds are left nil.  The lookups hold a list slot while they are read, the resources
+// are sent without it.
+func (uc *Usecase) lookupAllowed(ctx context.Context, permission, namespace string, sub *kessel.SubjectReference, page []*model.Resource, allowed map[string]map[string]bool) error {
+	var resourceTypes []string
+	for _, resource := range page {
+		if _, ok := allowed[resource.ResourceType]; !ok {
+			allowed[resource.ResourceType] = nil
+			resourceTypes = append(resourceTypes, resource.ResourceType)
+		}
+	}
+	if len(resourceTypes) == 0 {
+		return nil
+	}
+
+	if uc.ListSlots != nil {
+		if err := uc.ListSlots.Acquire(ctx, 1); err != nil {
+			return err
+		}
+		defer uc.ListSlots.Release(1)
+	}
 
-	return allowedChan, errorChan, nil
+	for _, resourceType := range resourceTypes {
+		ids, err := uc.allowedIds(ctx, &kessel.LookupResourcesRequest{
+			ResourceType: &kessel.ObjectType{Namespace: namespace, Name: resourceType},
+			Relation:     permission,
+			Subject:      sub,
+		})
+		if err != nil {
+			return err
+		}
+		allowed[resourceType] = ids
+	}
+	return nil
 }
 
-func (uc *Usecase) checkWorker(ctx context.Context, permission, namespace string, sub *kessel.SubjectReference, resourceChan <-chan *model.Resource, allowedChan chan<- *model.Resource, errorChan chan<- error, wg *sync.WaitGroup) {
-	for resource := range resourceChan {
-		log.Debugf("ListResourcesInWorkspace: checkforview on %+v", resource)
+// allowedIds reads the ids of every resource returned by the lookup, it returns nil once the lookup has more than
+// MaxLookupIds.
+func (uc *Usecase) allowedIds(ctx context.Context, request *kessel.LookupResourcesRequest) (map[string]bool, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
 
-		if allowed, _, err := uc.Authz.Check(ctx, namespace, permission, resource, sub); err == nil && allowed == kessel.CheckResponse_ALLOWED_TRUE {
-			allowedChan <- resource
-		} else if err != nil {
-			errorChan <- err
-		} else if allowed != kessel.CheckResponse_ALLOWED_TRUE {
-			log.Debugf("ListResourcesInWorkspace: response was not allowed: %v", allowed)
+	stream, err := uc.Authz.LookupResources(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := map[string]bool{}
+	for {
+		resp, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return ids, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		ids[resp.GetResource().GetId()] = true
+		if uc.MaxLookupIds > 0 && len(ids) > uc.MaxLookupIds {
+			return nil, nil
 		}
-		wg.Done()
 	}
 }
 
@@ -375,13 +529,19 @@ func (uc *Usecase) Delete(ctx context.Context, id model.ReporterResourceId) erro
 	if uc.Authz != nil {
 		var resourceType string
 
-		namespace := uc.Namespace
-		if id.ReporterType != "" {
-			namespace = strings.ToLower(id.ReporterType)
-		}
 		if m.ResourceType != "" {
 			resourceType = m.ResourceType
-			err := biz.DefaultUnsetWorkspace(ctx, namespace, id.LocalResourceId, resourceType, uc.Authz)
+			namespace, err := middleware.ResolveNamespace(resourceType, id.ReporterType)
+			if err != nil {
+				return err
+			}
+
+			err = biz.DefaultUnsetWorkspace(ctx, namespace, id.LocalResourceId, resourceType, uc.Authz)
+			if err != nil {
+				return err
+			}
+
+			err = biz.DefaultUnsetRelations(ctx, m, uc.Authz)
 			if err != nil {
 				return err
 			}
@@ -428,30 +588,47 @@ func (uc *Usecase) Create(ctx context.Context, m *model.Resource) (*model.Resour
 	}
 
 	if uc.Eventer != nil {
-		// Send event for the created resource
-		err := biz.DefaultResourceSendEvent(ctx, m, uc.Eventer, *m.CreatedAt, eventingapi.OperationTypeCreated)
-		if err != nil {
-			return nil, err
-		}
-
-		// Send events for any updated resources
-		for _, updatedResource := range updatedResources {
-			err := biz.DefaultResourceSendEvent(ctx, updatedResource, uc.Eventer, *updatedResource.UpdatedAt, eventingapi.OperationTypeUpdated)
+		// the events of the created resource and of the resources sharing its workspace are sent together
+		err := eventingapi.Batch(ctx, uc.Eventer, func(ctx context.Context) error {
+			// Send event for the created resource
+			err := biz.DefaultResourceSendEvent(ctx, m, uc.Eventer, *m.CreatedAt, eventingapi.OperationTypeCreated)
 			if err != nil {
-				return nil, err
+				return err
 			}
+
+			// Send events for any updated resources
+			for _, updatedResource := range updatedResources {
+				err := biz.DefaultResourceSendEvent(ctx, updatedResource, uc.Eventer, *updatedResource.UpdatedAt, eventingapi.OperationTypeUpdated)
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
 		}
 	}
 
 	if uc.Authz != nil {
 		// Send workspace for the created resource
-		ct, err := biz.DefaultSetWorkspace(ctx, uc.Namespace, ret, uc.Authz, true)
+		ct, err := biz.DefaultSetWorkspace(ctx, ret, uc.Authz, true)
 		if err != nil {
 			return nil, err
 		}
 
 		ret.ConsistencyToken = ct
 
+		// Send schema-declared relations for the created resource
+		ct, err = biz.DefaultSetRelations(ctx, ret, uc.Authz)
+		if err != nil {
+			return nil, err
+		}
+
+		if ct != "" {
+			ret.ConsistencyToken = ct
+		}
+
 		if !uc.DisablePersistence {
 			_, _, err = uc.reporterResourceRepository.Update(ctx, ret, ret.ID)
 			if err != nil {
@@ -461,7 +638,7 @@ func (uc *Usecase) Create(ctx context.Context, m *model.Resource) (*model.Resour
 
 		// Send workspace for any updated resources
 		for _, updatedResource := range updatedResources {
-			ct, err := biz.DefaultSetWorkspace(ctx, uc.Namespace, updatedResource, uc.Authz, true)
+			ct, err := biz.DefaultSetWorkspace(ctx, updatedResource, uc.Authz, true)
 			if err != nil {
 				return nil, err
 			}
@@ -510,21 +687,45 @@ func (uc *Usecase) Update(ctx context.Context, m *model.Resource, id model.Repor
 	}
 
 	if uc.Eventer != nil {
-		for _, updatedResource := range updatedResources {
-			err := biz.DefaultResourceSendEvent(ctx, updatedResource, uc.Eventer, *updatedResource.UpdatedAt, eventingapi.OperationTypeUpdated)
-			if err != nil {
-				return nil, err
+		err := eventingapi.Batch(ctx, uc.Eventer, func(ctx context.Context) error {
+			for _, updatedResource := range updatedResources {
+				err := biz.DefaultResourceSendEvent(ctx, updatedResource, uc.Eventer, *updatedResource.UpdatedAt, eventingapi.OperationTypeUpdated)
+				if err != nil {
+					return err
+				}
 			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
 		}
 	}
 
 	if uc.Authz != nil {
 		for _, updatedResource := range updatedResources {
-			_, err := biz.DefaultSetWorkspace(ctx, uc.Namespace, updatedResource, uc.Authz, true)
+			_, err := biz.DefaultSetWorkspace(ctx, updatedResource, uc.Authz, true)
 			if err != nil {
 				return nil, err
 			}
 		}
+
+		// Replace schema-declared relations of the updated resource
+		ct, err := biz.DefaultSetRelations(ctx, ret, uc.Authz)
+		if err != nil {
+			return nil, err
+		}
+
+		if ct != "" {
+			ret.ConsistencyToken = ct
+
+			// the token alone changed, it isn't a new version of the resource
+			if !uc.DisablePersistence {
+				err = uc.reporterResourceRepository.UpdateConsistencyToken(ctx, ret.ID, ct)
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
 	}
 
 	uc.log.WithContext(ctx).Infof("Updated Resource: %v(%v)", m.ID, m.ResourceType)
@@ -3,19 +3,30 @@ package resources
 import (
 	"context"
 	"errors"
+	"fmt"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/metadata"
 	"io"
+	"os"
+	"path/filepath"
 	"sort"
 	"testing"
+	"time"
 
 	"github.com/go-kratos/kratos/v2/log"
 	"github.com/google/uuid"
+	authnapi "github.com/project-kessel/inventory-api/internal/authn/api"
+	"github.com/project-kessel/inventory-api/internal/biz"
 	"github.com/project-kessel/inventory-api/internal/biz/model"
+	eventingapi "github.com/project-kessel/inventory-api/internal/eventing/api"
+	"github.com/project-kessel/inventory-api/internal/middleware"
 	kesselv1 "github.com/project-kessel/relations-api/api/kessel/relations/v1"
 	"github.com/project-kessel/relations-api/api/kessel/relations/v1beta1"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sync/semaphore"
+	"google.golang.org/protobuf/proto"
 	"gorm.io/gorm"
 )
 
@@ -34,10 +45,15 @@ type MockLookupResourcesStream struct {
 	mock.Mock
 	responses []*v1beta1.LookupResourcesResponse
 	current   int
+	// err ends the stream instead of io.EOF when it is set
+	err error
 }
 
 func (m *MockLookupResourcesStream) Recv() (*v1beta1.LookupResourcesResponse, error) {
 	if m.current >= len(m.responses) {
+		if m.err != nil {
+			return nil, m.err
+		}
 		return nil, io.EOF
 	}
 	res := m.responses[m.current]
@@ -137,7 +153,7 @@ func TestLookupResources_Success(t *testing.T) {
 	// Set up authz mock
 	authz.On("LookupResources", ctx, req).Return(mockStream, nil)
 
-	useCase := New(repo, inventoryRepo, authz, nil, "", log.DefaultLogger, false)
+	useCase := New(repo, inventoryRepo, authz, nil, log.DefaultLogger, false)
 	stream, err := useCase.LookupResources(ctx, req)
 
 	assert.Nil(t, err)
@@ -157,6 +173,69 @@ func TestLookupResources_Success(t *testing.T) {
 	assert.Equal(t, io.EOF, err)
 }
 
+// lookupSubjectsStream streams the subjects it is given
+type lookupSubjectsStream struct {
+	grpc.ServerStreamingClient[v1beta1.LookupSubjectsResponse]
+	responses []*v1beta1.LookupSubjectsResponse
+}
+
+func (s *lookupSubjectsStream) Recv() (*v1beta1.LookupSubjectsResponse, error) {
+	if len(s.responses) == 0 {
+		return nil, io.EOF
+	}
+	res := s.responses[0]
+	s.responses = s.responses[1:]
+	return res, nil
+}
+
+// readTuplesStream streams the tuples it is given
+type readTuplesStream struct {
+	grpc.ServerStreamingClient[v1beta1.ReadTuplesResponse]
+	tuples []*v1beta1.Relationship
+}
+
+func (s *readTuplesStream) Recv() (*v1beta1.ReadTuplesResponse, error) {
+	if len(s.tuples) == 0 {
+		return nil, io.EOF
+	}
+	tuple := s.tuples[0]
+	s.tuples = s.tuples[1:]
+	return &v1beta1.ReadTuplesResponse{Tuple: tuple}, nil
+}
+
+func TestLookupSubjects_Success(t *testing.T) {
+	ctx := context.TODO()
+	repo := &MockedReporterResourceRepository{}
+	inventoryRepo := &MockedInventoryResourceRepository{}
+	authz := &MockAuthz{}
+
+	req := &v1beta1.LookupSubjectsRequest{
+		Resource:    &v1beta1.ObjectReference{Type: &v1beta1.ObjectType{Namespace: "acm", Name: "k8s_cluster"}, Id: "cluster-1"},
+		Relation:    "view",
+		SubjectType: &v1beta1.ObjectType{Namespace: "rbac", Name: "principal"},
+	}
+	principal := func(id string) *v1beta1.LookupSubjectsResponse {
+		return &v1beta1.LookupSubjectsResponse{Subject: &v1beta1.SubjectReference{Subject: &v1beta1.ObjectReference{Type: req.SubjectType, Id: id}}}
+	}
+	authz.On("LookupSubjects", ctx, req).Return(&lookupSubjectsStream{responses: []*v1beta1.LookupSubjectsResponse{principal("alice"), principal("bob")}}, nil)
+
+	useCase := New(repo, inventoryRepo, authz, nil, log.DefaultLogger, false)
+	stream, err := useCase.LookupSubjects(ctx, req)
+	assert.Nil(t, err)
+
+	var ids []string
+	for {
+		res, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		assert.Nil(t, err)
+		ids = append(ids, res.Subject.Subject.Id)
+	}
+	assert.Equal(t, []string{"alice", "bob"}, ids)
+	authz.AssertExpectations(t)
+}
+
 func (r *MockedReporterResourceRepository) Create(ctx context.Context, resource *model.Resource) (*model.Resource, []*model.Resource, error) {
 	args := r.Called(ctx, resource)
 	return args.Get(0).(*model.Resource), args.Get(1).([]*model.Resource), args.Error(2)
@@ -167,6 +246,11 @@ func (r *MockedReporterResourceRepository) Update(ctx context.Context, resource
 	return args.Get(0).(*model.Resource), args.Get(1).([]*model.Resource), args.Error(2)
 }
 
+func (r *MockedReporterResourceRepository) UpdateConsistencyToken(ctx context.Context, id uuid.UUID, token string) error {
+	args := r.Called(ctx, id, token)
+	return args.Error(0)
+}
+
 func (r *MockedReporterResourceRepository) Delete(ctx context.Context, id uuid.UUID) (*model.Resource, error) {
 	args := r.Called(ctx, id)
 	return args.Get(0).(*model.Resource), args.Error(1)
@@ -217,6 +301,11 @@ func (r *MockedReporterResourceRepository) FindByWorkspaceId(ctx context.Context
 	return args.Get(0).([]*model.Resource), args.Error(1)
 }
 
+func (r *MockedReporterResourceRepository) FindByWorkspaceIdAfter(ctx context.Context, workspaceId string, after uuid.UUID, limit int) ([]*model.Resource, error) {
+	args := r.Called(ctx, workspaceId, after, limit)
+	return args.Get(0).([]*model.Resource), args.Error(1)
+}
+
 func (m *MockAuthz) Health(ctx context.Context) (*kesselv1.GetReadyzResponse, error) {
 	args := m.Called(ctx)
 	return args.Get(0).(*kesselv1.GetReadyzResponse), args.Error(1)
@@ -242,6 +331,11 @@ func (m *MockAuthz) DeleteTuples(ctx context.Context, request *v1beta1.DeleteTup
 	return args.Get(0).(*v1beta1.DeleteTuplesResponse), args.Error(1)
 }
 
+func (m *MockAuthz) ReadTuples(ctx context.Context, in *v1beta1.ReadTuplesRequest) (grpc.ServerStreamingClient[v1beta1.ReadTuplesResponse], error) {
+	args := m.Called(ctx, in)
+	return args.Get(0).(grpc.ServerStreamingClient[v1beta1.ReadTuplesResponse]), args.Error(1)
+}
+
 func (m *MockAuthz) UnsetWorkspace(ctx context.Context, namespace, localResourceId, resourceType string) (*v1beta1.DeleteTuplesResponse, error) {
 	args := m.Called(ctx, namespace, localResourceId, resourceType)
 	return args.Get(0).(*v1beta1.DeleteTuplesResponse), args.Error(1)
@@ -252,6 +346,16 @@ func (m *MockAuthz) SetWorkspace(ctx context.Context, local_resource_id, workspa
 	return args.Get(0).(*v1beta1.CreateTuplesResponse), args.Error(1)
 }
 
+func (m *MockAuthz) LookupSubjects(ctx context.Context, in *v1beta1.LookupSubjectsRequest) (grpc.ServerStreamingClient[v1beta1.LookupSubjectsResponse], error) {
+	args := m.Called(ctx, in)
+	return args.Get(0).(grpc.ServerStreamingClient[v1beta1.LookupSubjectsResponse]), args.Error(1)
+}
+
+func (m *MockAuthz) ReplaceWorkspace(ctx context.Context, local_resource_id, previous_workspace, workspace, namespace, name string) (*v1beta1.CreateTuplesResponse, error) {
+	args := m.Called(ctx, local_resource_id, previous_workspace, workspace, namespace, name)
+	return args.Get(0).(*v1beta1.CreateTuplesResponse), args.Error(1)
+}
+
 func resource1() *model.Resource {
 	return &model.Resource{
 		ID:    uuid.UUID{},
@@ -368,7 +472,7 @@ func TestCreateReturnsDbError(t *testing.T) {
 	// DB Error
 	repo.On("FindByReporterData", mock.Anything, mock.Anything, mock.Anything).Return((*model.Resource)(nil), gorm.ErrDuplicatedKey)
 
-	useCase := New(repo, inventoryRepo, nil, nil, "", log.DefaultLogger, false)
+	useCase := New(repo, inventoryRepo, nil, nil, log.DefaultLogger, false)
 	ctx := context.TODO()
 
 	_, err := useCase.Create(ctx, resource)
@@ -386,7 +490,7 @@ func TestCreateReturnsDbErrorBackwardsCompatible(t *testing.T) {
 	// DB Error
 	repo.On("FindByReporterResourceId", mock.Anything, mock.Anything).Return((*model.Resource)(nil), gorm.ErrDuplicatedKey)
 
-	useCase := New(repo, inventoryRepo, nil, nil, "", log.DefaultLogger, false)
+	useCase := New(repo, inventoryRepo, nil, nil, log.DefaultLogger, false)
 	ctx := context.TODO()
 
 	_, err := useCase.Create(ctx, resource)
@@ -402,7 +506,7 @@ func TestCreateResourceAlreadyExists(t *testing.T) {
 	// Resource already exists
 	repo.On("FindByReporterData", mock.Anything, mock.Anything, mock.Anything).Return(&model.Resource{}, nil)
 
-	useCase := New(repo, inventoryRepo, nil, nil, "", log.DefaultLogger, false)
+	useCase := New(repo, inventoryRepo, nil, nil, log.DefaultLogger, false)
 	ctx := context.TODO()
 
 	_, err := useCase.Create(ctx, resource)
@@ -420,7 +524,7 @@ func TestCreateResourceAlreadyExistsBackwardsCompatible(t *testing.T) {
 	// Resource already exists
 	repo.On("FindByReporterResourceId", mock.Anything, mock.Anything).Return(&model.Resource{}, nil)
 
-	useCase := New(repo, inventoryRepo, nil, nil, "", log.DefaultLogger, false)
+	useCase := New(repo, inventoryRepo, nil, nil, log.DefaultLogger, false)
 	ctx := context.TODO()
 
 	_, err := useCase.Create(ctx, resource)
@@ -443,7 +547,7 @@ func TestCreateNewResource(t *testing.T) {
 	repo.On("FindByReporterResourceId", mock.Anything, mock.Anything).Return((*model.Resource)(nil), gorm.ErrRecordNotFound)
 	repo.On("Create", mock.Anything, mock.Anything).Return(&returnedResource, []*model.Resource{}, nil)
 
-	useCase := New(repo, inventoryRepo, nil, nil, "", log.DefaultLogger, false)
+	useCase := New(repo, inventoryRepo, nil, nil, log.DefaultLogger, false)
 	ctx := context.TODO()
 
 	r, err := useCase.Create(ctx, resource)
@@ -471,7 +575,7 @@ func TestCreateNewResource_ConsistencyToken(t *testing.T) {
 	m.On("SetWorkspace", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(&v1beta1.CreateTuplesResponse{ConsistencyToken: &v1beta1.ConsistencyToken{Token: "foo-bar-consistency-token"}}, nil)
 	repo.On("Update", mock.Anything, mock.Anything, mock.Anything).Return(&model.Resource{}, []*model.Resource{}, nil)
 
-	useCase := New(repo, inventoryRepo, m, nil, "", log.DefaultLogger, false)
+	useCase := New(repo, inventoryRepo, m, nil, log.DefaultLogger, false)
 	ctx := context.TODO()
 
 	r, err := useCase.Create(ctx, resource)
@@ -489,7 +593,7 @@ func TestUpdateReturnsDbError(t *testing.T) {
 	// DB Error
 	repo.On("FindByReporterData", mock.Anything, mock.Anything, mock.Anything).Return((*model.Resource)(nil), gorm.ErrDuplicatedKey)
 
-	useCase := New(repo, inventoryRepo, nil, nil, "", log.DefaultLogger, false)
+	useCase := New(repo, inventoryRepo, nil, nil, log.DefaultLogger, false)
 	ctx := context.TODO()
 
 	_, err := useCase.Update(ctx, resource, model.ReporterResourceId{})
@@ -506,7 +610,7 @@ func TestUpdateReturnsDbErrorBackwardsCompatible(t *testing.T) {
 	// DB Error
 	repo.On("FindByReporterResourceId", mock.Anything, mock.Anything).Return((*model.Resource)(nil), gorm.ErrDuplicatedKey)
 
-	useCase := New(repo, inventoryRepo, nil, nil, "", log.DefaultLogger, false)
+	useCase := New(repo, inventoryRepo, nil, nil, log.DefaultLogger, false)
 	ctx := context.TODO()
 
 	_, err := useCase.Update(ctx, resource, model.ReporterResourceId{})
@@ -530,7 +634,7 @@ func TestUpdateNewResourceCreatesIt(t *testing.T) {
 	repo.On("FindByReporterResourceId", mock.Anything, mock.Anything).Return((*model.Resource)(nil), gorm.ErrRecordNotFound)
 	repo.On("Create", mock.Anything, mock.Anything).Return(&returnedResource, []*model.Resource{}, nil)
 
-	useCase := New(repo, inventoryRepo, nil, nil, "", log.DefaultLogger, false)
+	useCase := New(repo, inventoryRepo, nil, nil, log.DefaultLogger, false)
 	ctx := context.TODO()
 
 	r, err := useCase.Update(ctx, resource, model.ReporterResourceId{})
@@ -556,7 +660,7 @@ func TestUpdateExistingResource(t *testing.T) {
 	repo.On("FindByReporterData", mock.Anything, mock.Anything, mock.Anything).Return(resource, nil)
 	repo.On("Update", mock.Anything, mock.Anything, mock.Anything).Return(&returnedResource, []*model.Resource{}, nil)
 
-	useCase := New(repo, inventoryRepo, nil, nil, "", log.DefaultLogger, false)
+	useCase := New(repo, inventoryRepo, nil, nil, log.DefaultLogger, false)
 	ctx := context.TODO()
 
 	r, err := useCase.Update(ctx, resource, model.ReporterResourceId{})
@@ -584,7 +688,7 @@ func TestUpdateExistingResourceBackwardsCompatible(t *testing.T) {
 	repo.On("FindByReporterResourceId", mock.Anything, mock.Anything).Return(resource, nil)
 	repo.On("Update", mock.Anything, mock.Anything, mock.Anything).Return(&returnedResource, []*model.Resource{}, nil)
 
-	useCase := New(repo, inventoryRepo, nil, nil, "", log.DefaultLogger, false)
+	useCase := New(repo, inventoryRepo, nil, nil, log.DefaultLogger, false)
 	ctx := context.TODO()
 
 	r, err := useCase.Update(ctx, resource, model.ReporterResourceId{})
@@ -601,7 +705,7 @@ func TestDeleteReturnsDbError(t *testing.T) {
 	// Validates backwards compatibility
 	repo.On("FindByReporterData", mock.Anything, mock.Anything, mock.Anything).Return((*model.Resource)(nil), gorm.ErrDuplicatedKey)
 
-	useCase := New(repo, inventoryRepo, nil, nil, "", log.DefaultLogger, false)
+	useCase := New(repo, inventoryRepo, nil, nil, log.DefaultLogger, false)
 	ctx := context.TODO()
 
 	err := useCase.Delete(ctx, model.ReporterResourceId{})
@@ -617,7 +721,7 @@ func TestDeleteReturnsDbErrorBackwardsCompatible(t *testing.T) {
 	// DB Error
 	repo.On("FindByReporterResourceId", mock.Anything, mock.Anything).Return((*model.Resource)(nil), gorm.ErrDuplicatedKey)
 
-	useCase := New(repo, inventoryRepo, nil, nil, "", log.DefaultLogger, false)
+	useCase := New(repo, inventoryRepo, nil, nil, log.DefaultLogger, false)
 	ctx := context.TODO()
 
 	err := useCase.Delete(ctx, model.ReporterResourceId{})
@@ -633,7 +737,7 @@ func TestDeleteNonexistentResource(t *testing.T) {
 	repo.On("FindByReporterData", mock.Anything, mock.Anything, mock.Anything).Return((*model.Resource)(nil), gorm.ErrRecordNotFound)
 	repo.On("FindByReporterResourceId", mock.Anything, mock.Anything).Return((*model.Resource)(nil), gorm.ErrRecordNotFound)
 
-	useCase := New(repo, inventoryRepo, nil, nil, "", log.DefaultLogger, false)
+	useCase := New(repo, inventoryRepo, nil, nil, log.DefaultLogger, false)
 	ctx := context.TODO()
 
 	err := useCase.Delete(ctx, model.ReporterResourceId{})
@@ -654,7 +758,7 @@ func TestDeleteResource(t *testing.T) {
 	}, nil)
 	repo.On("Delete", mock.Anything, (uuid.UUID)(id)).Return(&model.Resource{}, nil)
 
-	useCase := New(repo, inventoryRepo, nil, nil, "", log.DefaultLogger, false)
+	useCase := New(repo, inventoryRepo, nil, nil, log.DefaultLogger, false)
 
 	err = useCase.Delete(ctx, model.ReporterResourceId{})
 	assert.Nil(t, err)
@@ -677,7 +781,7 @@ func TestDeleteResourceBackwardsCompatible(t *testing.T) {
 	}, nil)
 	repo.On("Delete", mock.Anything, (uuid.UUID)(id)).Return(&model.Resource{}, nil)
 
-	useCase := New(repo, inventoryRepo, nil, nil, "", log.DefaultLogger, false)
+	useCase := New(repo, inventoryRepo, nil, nil, log.DefaultLogger, false)
 
 	err = useCase.Delete(ctx, model.ReporterResourceId{})
 	assert.Nil(t, err)
@@ -697,7 +801,7 @@ func TestCreateResource_PersistenceDisabled(t *testing.T) {
 	repo.On("Create", mock.Anything, mock.Anything).Return(nil, nil)
 
 	disablePersistence := true
-	useCase := New(repo, inventoryRepo, nil, nil, "", log.DefaultLogger, disablePersistence)
+	useCase := New(repo, inventoryRepo, nil, nil, log.DefaultLogger, disablePersistence)
 
 	// Create the resource
 	r, err := useCase.Create(ctx, resource)
@@ -728,7 +832,7 @@ func TestUpdateResource_PersistenceDisabled(t *testing.T) {
 	repo.On("Create", mock.Anything, mock.Anything, mock.Anything).Return(nil, nil)
 
 	disablePersistence := true
-	useCase := New(repo, inventoryRepo, nil, nil, "", log.DefaultLogger, disablePersistence)
+	useCase := New(repo, inventoryRepo, nil, nil, log.DefaultLogger, disablePersistence)
 
 	r, err := useCase.Update(ctx, resource, model.ReporterResourceId{})
 	assert.Nil(t, err)
@@ -757,7 +861,7 @@ func TestDeleteResource_PersistenceDisabled(t *testing.T) {
 	repo.On("Delete", mock.Anything, (uint64)(33)).Return(&model.Resource{}, nil)
 
 	disablePersistence := true
-	useCase := New(repo, inventoryRepo, nil, nil, "", log.DefaultLogger, disablePersistence)
+	useCase := New(repo, inventoryRepo, nil, nil, log.DefaultLogger, disablePersistence)
 
 	err = useCase.Delete(ctx, model.ReporterResourceId{})
 	assert.Nil(t, err)
@@ -777,7 +881,7 @@ func TestCheck_MissingResource(t *testing.T) {
 	repo.On("FindByReporterResourceId", mock.Anything, mock.Anything).Return(&model.Resource{}, gorm.ErrRecordNotFound)
 	m.On("Check", mock.Anything, mock.Anything, "notifications_integration_view", mock.Anything, mock.Anything).Return(v1beta1.CheckResponse_ALLOWED_TRUE, &v1beta1.ConsistencyToken{}, nil)
 
-	useCase := New(repo, inventoryRepo, m, nil, "", log.DefaultLogger, false)
+	useCase := New(repo, inventoryRepo, m, nil, log.DefaultLogger, false)
 	allowed, err := useCase.Check(ctx, "notifications_integration_view", "rbac", &v1beta1.SubjectReference{}, model.ReporterResourceId{})
 
 	assert.Nil(t, err)
@@ -802,7 +906,7 @@ func TestCheck_ResourceExistsError(t *testing.T) {
 
 	repo.On("FindByReporterResourceId", mock.Anything, mock.Anything).Return(&model.Resource{}, gorm.ErrUnsupportedDriver) // some random error
 
-	useCase := New(repo, inventoryRepo, m, nil, "", log.DefaultLogger, false)
+	useCase := New(repo, inventoryRepo, m, nil, log.DefaultLogger, false)
 	allowed, err := useCase.Check(ctx, "notifications_integration_view", "rbac", &v1beta1.SubjectReference{}, model.ReporterResourceId{})
 
 	assert.NotNil(t, err)
@@ -821,7 +925,7 @@ func TestCheck_ErrorWithKessel(t *testing.T) {
 	repo.On("FindByReporterResourceId", mock.Anything, mock.Anything).Return(&model.Resource{}, nil)
 	m.On("Check", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(v1beta1.CheckResponse_ALLOWED_FALSE, &v1beta1.ConsistencyToken{}, errors.New("failed during call to relations"))
 
-	useCase := New(repo, inventoryRepo, m, nil, "", log.DefaultLogger, false)
+	useCase := New(repo, inventoryRepo, m, nil, log.DefaultLogger, false)
 	allowed, err := useCase.Check(ctx, "notifications_integration_view", "rbac", &v1beta1.SubjectReference{}, model.ReporterResourceId{})
 
 	assert.NotNil(t, err)
@@ -841,7 +945,7 @@ func TestCheck_Allowed(t *testing.T) {
 	repo.On("FindByReporterResourceId", mock.Anything, mock.Anything).Return(resource, nil)
 	m.On("Check", mock.Anything, mock.Anything, "notifications_integration_write", mock.Anything, mock.Anything).Return(v1beta1.CheckResponse_ALLOWED_TRUE, &v1beta1.ConsistencyToken{}, nil)
 
-	useCase := New(repo, inventoryRepo, m, nil, "", log.DefaultLogger, false)
+	useCase := New(repo, inventoryRepo, m, nil, log.DefaultLogger, false)
 	allowed, err := useCase.Check(ctx, "notifications_integration_write", "rbac", &v1beta1.SubjectReference{}, model.ReporterResourceId{})
 
 	assert.Nil(t, err)
@@ -866,7 +970,7 @@ func TestCheckForUpdate_ResourceExistsError(t *testing.T) {
 
 	repo.On("FindByReporterResourceId", mock.Anything, mock.Anything).Return(&model.Resource{}, gorm.ErrUnsupportedDriver) // some random error
 
-	useCase := New(repo, inventoryRepo, m, nil, "", log.DefaultLogger, false)
+	useCase := New(repo, inventoryRepo, m, nil, log.DefaultLogger, false)
 	allowed, err := useCase.CheckForUpdate(ctx, "notifications_integration_view", "rbac", &v1beta1.SubjectReference{}, model.ReporterResourceId{})
 
 	assert.NotNil(t, err)
@@ -885,7 +989,7 @@ func TestCheckForUpdate_ErrorWithKessel(t *testing.T) {
 	repo.On("FindByReporterResourceId", mock.Anything, mock.Anything).Return(&model.Resource{}, nil)
 	m.On("CheckForUpdate", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(v1beta1.CheckForUpdateResponse_ALLOWED_FALSE, &v1beta1.ConsistencyToken{}, errors.New("failed during call to relations"))
 
-	useCase := New(repo, inventoryRepo, m, nil, "", log.DefaultLogger, false)
+	useCase := New(repo, inventoryRepo, m, nil, log.DefaultLogger, false)
 	allowed, err := useCase.CheckForUpdate(ctx, "notifications_integration_view", "rbac", &v1beta1.SubjectReference{}, model.ReporterResourceId{})
 
 	assert.NotNil(t, err)
@@ -905,7 +1009,7 @@ func TestCheckForUpdate_WorkspaceAllowed(t *testing.T) {
 	repo.On("FindByReporterResourceId", mock.Anything, mock.Anything).Return(resource, nil)
 	m.On("CheckForUpdate", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(v1beta1.CheckForUpdateResponse_ALLOWED_TRUE, &v1beta1.ConsistencyToken{}, nil)
 
-	useCase := New(repo, inventoryRepo, m, nil, "", log.DefaultLogger, false)
+	useCase := New(repo, inventoryRepo, m, nil, log.DefaultLogger, false)
 	allowed, err := useCase.CheckForUpdate(ctx, "notifications_integration_view", "rbac", &v1beta1.SubjectReference{}, model.ReporterResourceId{ResourceType: "workspace"})
 
 	assert.Nil(t, err)
@@ -924,7 +1028,7 @@ func TestCheckForUpdate_MissingResource_Allowed(t *testing.T) {
 	repo.On("FindByReporterResourceId", mock.Anything, mock.Anything).Return(&model.Resource{}, gorm.ErrRecordNotFound)
 	m.On("CheckForUpdate", mock.Anything, mock.Anything, "notifications_integration_view", mock.Anything, mock.Anything).Return(v1beta1.CheckForUpdateResponse_ALLOWED_TRUE, &v1beta1.ConsistencyToken{}, nil)
 
-	useCase := New(repo, inventoryRepo, m, nil, "", log.DefaultLogger, false)
+	useCase := New(repo, inventoryRepo, m, nil, log.DefaultLogger, false)
 	allowed, err := useCase.CheckForUpdate(ctx, "notifications_integration_view", "rbac", &v1beta1.SubjectReference{}, model.ReporterResourceId{})
 
 	// no consistency token being written.
@@ -949,7 +1053,7 @@ func TestCheckForUpdate_Allowed(t *testing.T) {
 
 	repo.On("Update", mock.Anything, mock.Anything, mock.Anything).Return(&model.Resource{}, []*model.Resource{}, nil)
 
-	useCase := New(repo, inventoryRepo, m, nil, "", log.DefaultLogger, false)
+	useCase := New(repo, inventoryRepo, m, nil, log.DefaultLogger, false)
 	allowed, err := useCase.CheckForUpdate(ctx, "notifications_integration_view", "rbac", &v1beta1.SubjectReference{}, model.ReporterResourceId{})
 
 	assert.Nil(t, err)
@@ -966,6 +1070,29 @@ func TestCheckForUpdate_Allowed(t *testing.T) {
 	repo.AssertExpectations(t)
 }
 
+// lookupStream streams the lookup of the resources with the given ids
+func lookupStream(ids ...string) *MockLookupResourcesStream {
+	stream := &MockLookupResourcesStream{}
+	for _, id := range ids {
+		stream.responses = append(stream.responses, &v1beta1.LookupResourcesResponse{Resource: &v1beta1.ObjectReference{Id: id}})
+	}
+	return stream
+}
+
+// lookupOf matches the lookups of the resource type
+func lookupOf(resourceType string) interface{} {
+	return mock.MatchedBy(func(r *v1beta1.LookupResourcesRequest) bool {
+		return r.GetResourceType().GetName() == resourceType
+	})
+}
+
+// inWorkspace gives the resource an id, to page through the resources, and a local resource id
+func inWorkspace(resource *model.Resource, localResourceId string) *model.Resource {
+	resource.ID = uuid.New()
+	resource.ReporterResourceId = localResourceId
+	return resource
+}
+
 func TestListResourcesInWorkspace_Error(t *testing.T) {
 	ctx := context.TODO()
 
@@ -973,9 +1100,9 @@ func TestListResourcesInWorkspace_Error(t *testing.T) {
 	repo := &MockedReporterResourceRepository{}
 	m := &MockAuthz{}
 
-	repo.On("FindByWorkspaceId", mock.Anything, mock.Anything).Return([]*model.Resource{}, errors.New("failed querying"))
+	repo.On("FindByWorkspaceIdAfter", mock.Anything, "foo-id", uuid.Nil, listPageSize).Return([]*model.Resource{}, errors.New("failed querying"))
 
-	useCase := New(repo, inventoryRepo, m, nil, "", log.DefaultLogger, false)
+	useCase := New(repo, inventoryRepo, m, nil, log.DefaultLogger, false)
 	resource_chan, err_chan, err := useCase.ListResourcesInWorkspace(ctx, "notifications_integration_view", "rbac", &v1beta1.SubjectReference{}, "foo-id")
 
 	assert.NotNil(t, err)
@@ -992,9 +1119,9 @@ func TestListResourcesInWorkspace_NoResources(t *testing.T) {
 	repo := &MockedReporterResourceRepository{}
 	m := &MockAuthz{}
 
-	repo.On("FindByWorkspaceId", mock.Anything, mock.Anything).Return([]*model.Resource{}, nil)
+	repo.On("FindByWorkspaceIdAfter", mock.Anything, "foo-id", uuid.Nil, listPageSize).Return([]*model.Resource{}, nil)
 
-	useCase := New(repo, inventoryRepo, m, nil, "", log.DefaultLogger, false)
+	useCase := New(repo, inventoryRepo, m, nil, log.DefaultLogger, false)
 	resource_chan, err_chan, err := useCase.ListResourcesInWorkspace(ctx, "notifications_integration_view", "rbac", &v1beta1.SubjectReference{}, "foo-id")
 
 	assert.Nil(t, err)
@@ -1005,6 +1132,7 @@ func TestListResourcesInWorkspace_NoResources(t *testing.T) {
 	assert.Empty(t, err_chan) // dont want any errors.
 
 	repo.AssertExpectations(t)
+	m.AssertNotCalled(t, "LookupResources", mock.Anything, mock.Anything)
 }
 
 func TestListResourcesInWorkspace_ResourcesAllowedTrue(t *testing.T) {
@@ -1014,12 +1142,14 @@ func TestListResourcesInWorkspace_ResourcesAllowedTrue(t *testing.T) {
 	repo := &MockedReporterResourceRepository{}
 	m := &MockAuthz{}
 
-	resource := resource1()
+	resource := inWorkspace(resource1(), "foo-resource")
 
-	repo.On("FindByWorkspaceId", mock.Anything, mock.Anything).Return([]*model.Resource{resource}, nil)
-	m.On("Check", mock.Anything, mock.Anything, "notifications_integration_write", mock.Anything, mock.Anything).Return(v1beta1.CheckResponse_ALLOWED_TRUE, &v1beta1.ConsistencyToken{}, nil)
+	repo.On("FindByWorkspaceIdAfter", mock.Anything, "foo-id", uuid.Nil, listPageSize).Return([]*model.Resource{resource}, nil)
+	m.On("LookupResources", mock.Anything, mock.MatchedBy(func(r *v1beta1.LookupResourcesRequest) bool {
+		return r.Relation == "notifications_integration_write"
+	})).Return(lookupStream("other-resource", "foo-resource"), nil)
 
-	useCase := New(repo, inventoryRepo, m, nil, "", log.DefaultLogger, false)
+	useCase := New(repo, inventoryRepo, m, nil, log.DefaultLogger, false)
 	resource_chan, err_chan, err := useCase.ListResourcesInWorkspace(ctx, "notifications_integration_write", "rbac", &v1beta1.SubjectReference{}, "foo-id")
 
 	assert.Nil(t, err)
@@ -1035,7 +1165,9 @@ func TestListResourcesInWorkspace_ResourcesAllowedTrue(t *testing.T) {
 	assert.Empty(t, err_chan) // dont want any errors.
 
 	// check negative case (not allowed)
-	m.On("Check", mock.Anything, mock.Anything, "notifications_integration_view", mock.Anything, mock.Anything).Return(v1beta1.CheckResponse_ALLOWED_FALSE, &v1beta1.ConsistencyToken{}, nil)
+	m.On("LookupResources", mock.Anything, mock.MatchedBy(func(r *v1beta1.LookupResourcesRequest) bool {
+		return r.Relation == "notifications_integration_view"
+	})).Return(lookupStream("other-resource"), nil)
 	resource_chan, err_chan, err = useCase.ListResourcesInWorkspace(ctx, "notifications_integration_view", "rbac", &v1beta1.SubjectReference{}, "foo-id")
 
 	assert.Nil(t, err)
@@ -1055,14 +1187,17 @@ func TestListResourcesInWorkspace_MultipleResourcesAllowedTrue(t *testing.T) {
 	repo := &MockedReporterResourceRepository{}
 	m := &MockAuthz{}
 
-	resource := resource1()
-	resource2 := resource2()
-	resource3 := resource3()
+	resource := inWorkspace(resource1(), "foo-resource")
+	resource2 := inWorkspace(resource2(), "foo-resource2")
+	resource3 := inWorkspace(resource3(), "foo-resource3")
 
-	repo.On("FindByWorkspaceId", mock.Anything, mock.Anything).Return([]*model.Resource{resource, resource2, resource3}, nil)
-	m.On("Check", mock.Anything, mock.Anything, "notifications_integration_write", mock.Anything, mock.Anything).Return(v1beta1.CheckResponse_ALLOWED_TRUE, &v1beta1.ConsistencyToken{}, nil)
+	repo.On("FindByWorkspaceIdAfter", mock.Anything, "foo-id", uuid.Nil, listPageSize).Return([]*model.Resource{resource, resource2, resource3}, nil)
+	// a single lookup per resource type
+	for _, r := range []*model.Resource{resource, resource2, resource3} {
+		m.On("LookupResources", mock.Anything, lookupOf(r.ResourceType)).Return(lookupStream(r.ReporterResourceId), nil).Once()
+	}
 
-	useCase := New(repo, inventoryRepo, m, nil, "", log.DefaultLogger, false)
+	useCase := New(repo, inventoryRepo, m, nil, log.DefaultLogger, false)
 	resource_chan, err_chan, err := useCase.ListResourcesInWorkspace(ctx, "notifications_integration_write", "rbac", &v1beta1.SubjectReference{}, "foo-id")
 
 	assert.Nil(t, err)
@@ -1083,6 +1218,7 @@ func TestListResourcesInWorkspace_MultipleResourcesAllowedTrue(t *testing.T) {
 	}
 
 	assert.Empty(t, err_chan) // dont want any errors.
+	m.AssertExpectations(t)
 }
 
 // not authorized for the middle one and error on the third should just pass first
@@ -1093,36 +1229,29 @@ func TestListResourcesInWorkspace_MultipleResourcesOneFalseTwoTrueLastError(t *t
 	repo := &MockedReporterResourceRepository{}
 	m := &MockAuthz{}
 
-	resource := resource1()
-	resource2 := resource2()
-	resource3 := resource3()
+	resource := inWorkspace(resource1(), "foo-resource")
+	resource2 := inWorkspace(resource2(), "foo-resource2")
+	resource3 := inWorkspace(resource3(), "foo-resource3")
 	theError := errors.New("failed calling relations")
 
-	repo.On("FindByWorkspaceId", mock.Anything, mock.Anything).Return([]*model.Resource{resource, resource2, resource3}, nil)
-	m.On("Check", mock.Anything, mock.Anything, "notifications_integration_write", resource, mock.Anything).Return(v1beta1.CheckResponse_ALLOWED_FALSE, &v1beta1.ConsistencyToken{}, nil)
-	m.On("Check", mock.Anything, mock.Anything, "notifications_integration_write", resource2, mock.Anything).Return(v1beta1.CheckResponse_ALLOWED_TRUE, &v1beta1.ConsistencyToken{}, nil)
-	m.On("Check", mock.Anything, mock.Anything, "notifications_integration_write", resource3, mock.Anything).Return(v1beta1.CheckResponse_ALLOWED_UNSPECIFIED, &v1beta1.ConsistencyToken{}, theError)
+	repo.On("FindByWorkspaceIdAfter", mock.Anything, "foo-id", uuid.Nil, listPageSize).Return([]*model.Resource{resource, resource2, resource3}, nil)
+	m.On("LookupResources", mock.Anything, lookupOf(resource.ResourceType)).Return(lookupStream(), nil)
+	m.On("LookupResources", mock.Anything, lookupOf(resource2.ResourceType)).Return(lookupStream(resource2.ReporterResourceId), nil)
+	m.On("LookupResources", mock.Anything, lookupOf(resource3.ResourceType)).Return((*MockLookupResourcesStream)(nil), theError)
 
-	useCase := New(repo, inventoryRepo, m, nil, "", log.DefaultLogger, false)
+	useCase := New(repo, inventoryRepo, m, nil, log.DefaultLogger, false)
 	resource_chan, err_chan, err := useCase.ListResourcesInWorkspace(ctx, "notifications_integration_write", "rbac", &v1beta1.SubjectReference{}, "foo-id")
 
 	assert.Nil(t, err)
 
-	out_allowed := make([]*model.Resource, 1)
-	out_allowed[0] = <-resource_chan
-
-	in_allowed := []*model.Resource{resource2}
-	sort.Slice(in_allowed, func(i, j int) bool { return len(in_allowed[i].ResourceType) < len(in_allowed[j].ResourceType) })
-	sort.Slice(out_allowed, func(i, j int) bool { return len(out_allowed[i].ResourceType) < len(out_allowed[j].ResourceType) })
-	assert.Equal(t, in_allowed, out_allowed) // all 3 are there in any order
-
+	// the lookups of the page are read before its resources are sent
 	_, ok := <-resource_chan
 	if ok {
-		t.Error("resource_chan should have been closed") // and there was no other resource
+		t.Error("resource_chan should have been closed") // and there was no resource
 	}
 
 	backError := <-err_chan
-	assert.Equal(t, theError, backError) // dont want any errors.
+	assert.Equal(t, theError, backError)
 }
 
 func TestListResourcesInWorkspace_ResourcesAllowedError(t *testing.T) {
@@ -1132,12 +1261,14 @@ func TestListResourcesInWorkspace_ResourcesAllowedError(t *testing.T) {
 	repo := &MockedReporterResourceRepository{}
 	m := &MockAuthz{}
 
-	resource := resource1()
+	resource := inWorkspace(resource1(), "foo-resource")
+	stream := lookupStream("other-resource")
+	stream.err = errors.New("failed calling relations")
 
-	repo.On("FindByWorkspaceId", mock.Anything, mock.Anything).Return([]*model.Resource{resource}, nil)
-	m.On("Check", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(v1beta1.CheckResponse_ALLOWED_TRUE, &v1beta1.ConsistencyToken{}, errors.New("failed calling relations"))
+	repo.On("FindByWorkspaceIdAfter", mock.Anything, "foo-id", uuid.Nil, listPageSize).Return([]*model.Resource{resource}, nil)
+	m.On("LookupResources", mock.Anything, mock.Anything).Return(stream, nil)
 
-	useCase := New(repo, inventoryRepo, m, nil, "", log.DefaultLogger, false)
+	useCase := New(repo, inventoryRepo, m, nil, log.DefaultLogger, false)
 	resource_chan, err_chan, err := useCase.ListResourcesInWorkspace(ctx, "notifications_integration_view", "rbac", &v1beta1.SubjectReference{}, "foo-id")
 
 	assert.Nil(t, err)
@@ -1147,3 +1278,539 @@ func TestListResourcesInWorkspace_ResourcesAllowedError(t *testing.T) {
 
 	assert.NotEmpty(t, err_chan) // we want an errors.
 }
+
+func TestListResourcesInWorkspace_Pages(t *testing.T) {
+	ctx := context.TODO()
+
+	inventoryRepo := &MockedInventoryResourceRepository{}
+	repo := &MockedReporterResourceRepository{}
+	m := &MockAuthz{}
+
+	var resources []*model.Resource
+	var allowed []string
+	for i := 0; i <= listPageSize; i++ {
+		resource := inWorkspace(resource1(), fmt.Sprintf("resource-%d", i))
+		resources = append(resources, resource)
+		if i%2 == 0 {
+			allowed = append(allowed, resource.ReporterResourceId)
+		}
+	}
+
+	firstPage, lastPage := resources[:listPageSize], resources[listPageSize:]
+	repo.On("FindByWorkspaceIdAfter", mock.Anything, "foo-id", uuid.Nil, listPageSize).Return(firstPage, nil)
+	repo.On("FindByWorkspaceIdAfter", mock.Anything, "foo-id", firstPage[listPageSize-1].ID, listPageSize).Return(lastPage, nil)
+	m.On("LookupResources", mock.Anything, mock.Anything).Return(lookupStream(allowed...), nil).Once()
+
+	useCase := New(repo, inventoryRepo, m, nil, log.DefaultLogger, false)
+	resource_chan, err_chan, err := useCase.ListResourcesInWorkspace(ctx, "notifications_integration_view", "rbac", &v1beta1.SubjectReference{}, "foo-id")
+	assert.Nil(t, err)
+
+	var out []string
+	for resource := range resource_chan {
+		out = append(out, resource.ReporterResourceId)
+	}
+	assert.Equal(t, allowed, out)
+	assert.Empty(t, err_chan)
+
+	repo.AssertExpectations(t)
+	m.AssertExpectations(t)
+}
+
+func TestListResourcesInWorkspace_StopsWhenCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	inventoryRepo := &MockedInventoryResourceRepository{}
+	repo := &MockedReporterResourceRepository{}
+	m := &MockAuthz{}
+
+	resource := inWorkspace(resource1(), "foo-resource")
+	other := inWorkspace(resource1(), "other-resource")
+
+	repo.On("FindByWorkspaceIdAfter", mock.Anything, "foo-id", uuid.Nil, listPageSize).Return([]*model.Resource{resource, other}, nil)
+	m.On("LookupResources", mock.Anything, mock.Anything).Return(lookupStream("foo-resource", "other-resource"), nil)
+
+	useCase := New(repo, inventoryRepo, m, nil, log.DefaultLogger, false)
+	resource_chan, err_chan, err := useCase.ListResourcesInWorkspace(ctx, "notifications_integration_view", "rbac", &v1beta1.SubjectReference{}, "foo-id")
+	assert.Nil(t, err)
+
+	assert.Equal(t, resource, <-resource_chan)
+
+	// the client goes away before reading the second resource
+	cancel()
+	assert.Equal(t, context.Canceled, <-err_chan)
+	_, ok := <-resource_chan
+	assert.False(t, ok)
+}
+
+func TestListResourcesInWorkspace_WaitsForASlot(t *testing.T) {
+	ctx := context.TODO()
+
+	inventoryRepo := &MockedInventoryResourceRepository{}
+	repo := &MockedReporterResourceRepository{}
+	m := &MockAuthz{}
+
+	resource := inWorkspace(resource1(), "foo-resource")
+	repo.On("FindByWorkspaceIdAfter", mock.Anything, "foo-id", uuid.Nil, listPageSize).Return([]*model.Resource{resource}, nil)
+	m.On("LookupResources", mock.Anything, mock.Anything).Return(lookupStream("foo-resource"), nil)
+
+	useCase := New(repo, inventoryRepo, m, nil, log.DefaultLogger, false)
+	useCase.ListSlots = semaphore.NewWeighted(2)
+	// the listings of other requests hold every slot
+	require.NoError(t, useCase.ListSlots.Acquire(ctx, 2))
+
+	resource_chan, _, err := useCase.ListResourcesInWorkspace(ctx, "notifications_integration_view", "rbac", &v1beta1.SubjectReference{}, "foo-id")
+	assert.Nil(t, err)
+
+	select {
+	case <-resource_chan:
+		t.Fatal("the listing should wait for a slot")
+	case <-time.After(50 * time.Millisecond):
+	}
+	m.AssertNotCalled(t, "LookupResources", mock.Anything, mock.Anything)
+
+	useCase.ListSlots.Release(2)
+	assert.Equal(t, resource, <-resource_chan)
+}
+
+func TestListResourcesInWorkspace_ReleasesTheSlotWhileSending(t *testing.T) {
+	ctx := context.TODO()
+
+	inventoryRepo := &MockedInventoryResourceRepository{}
+	repo := &MockedReporterResourceRepository{}
+	m := &MockAuthz{}
+
+	resource := inWorkspace(resource1(), "foo-resource")
+	other := inWorkspace(resource1(), "other-resource")
+	stream := lookupStream("foo-resource", "other-resource", "elsewhere-resource")
+	repo.On("FindByWorkspaceIdAfter", mock.Anything, "foo-id", uuid.Nil, listPageSize).Return([]*model.Resource{resource, other}, nil)
+	m.On("LookupResources", mock.Anything, mock.Anything).Return(stream, nil).Once()
+
+	useCase := New(repo, inventoryRepo, m, nil, log.DefaultLogger, false)
+	useCase.ListSlots = semaphore.NewWeighted(1)
+	resource_chan, err_chan, err := useCase.ListResourcesInWorkspace(ctx, "notifications_integration_view", "rbac", &v1beta1.SubjectReference{}, "foo-id")
+	assert.Nil(t, err)
+
+	// the client doesn't read yet, the lookup is read in full and the slot released
+	assert.Eventually(t, func() bool {
+		if !useCase.ListSlots.TryAcquire(1) {
+			return false
+		}
+		useCase.ListSlots.Release(1)
+		return true
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, len(stream.responses), stream.current)
+
+	var out []*model.Resource
+	for r := range resource_chan {
+		out = append(out, r)
+	}
+	assert.Equal(t, []*model.Resource{resource, other}, out)
+	assert.Empty(t, err_chan)
+	m.AssertExpectations(t)
+}
+
+func TestListResourcesInWorkspace_ChecksTheResourcesOfLargeLookups(t *testing.T) {
+	ctx := context.TODO()
+
+	inventoryRepo := &MockedInventoryResourceRepository{}
+	repo := &MockedReporterResourceRepository{}
+	m := &MockAuthz{}
+
+	resource := inWorkspace(resource1(), "foo-resource")
+	other := inWorkspace(resource1(), "other-resource")
+	stream := lookupStream("elsewhere-1", "elsewhere-2", "foo-resource", "elsewhere-3")
+	repo.On("FindByWorkspaceIdAfter", mock.Anything, "foo-id", uuid.Nil, listPageSize).Return([]*model.Resource{resource, other}, nil)
+	m.On("LookupResources", mock.Anything, mock.Anything).Return(stream, nil).Once()
+	m.On("Check", mock.Anything, "rbac", "notifications_integration_view", resource, mock.Anything).Return(v1beta1.CheckResponse_ALLOWED_TRUE, (*v1beta1.ConsistencyToken)(nil), nil)
+	m.On("Check", mock.Anything, "rbac", "notifications_integration_view", other, mock.Anything).Return(v1beta1.CheckResponse_ALLOWED_FALSE, (*v1beta1.ConsistencyToken)(nil), nil)
+
+	useCase := New(repo, inventoryRepo, m, nil, log.DefaultLogger, false)
+	useCase.MaxLookupIds = 2
+	resource_chan, err_chan, err := useCase.ListResourcesInWorkspace(ctx, "notifications_integration_view", "rbac", &v1beta1.SubjectReference{}, "foo-id")
+	assert.Nil(t, err)
+
+	var out []*model.Resource
+	for r := range resource_chan {
+		out = append(out, r)
+	}
+	assert.Equal(t, []*model.Resource{resource}, out)
+	assert.Empty(t, err_chan)
+	// the lookup is abandoned once it has more ids than kept
+	assert.Equal(t, 3, stream.current)
+	m.AssertExpectations(t)
+}
+
+func preloadRelationsSchema(t *testing.T) {
+	schemaDir := t.TempDir()
+	files := map[string]string{
+		"relations_policy/config.yaml": "resource_type: relations_policy\nresource_reporters:\n  - ACM\n",
+		"relations_policy/reporters/acm/config.yaml": `resource_type: relations_policy
+reporter_name: acm
+namespace: acm
+relations:
+  - relation: t_cluster
+    subject_type: k8s_cluster
+    subject_id_field: resource_data.cluster_ids
+`,
+	}
+	for name, content := range files {
+		path := filepath.Join(schemaDir, name)
+		assert.Nil(t, os.MkdirAll(filepath.Dir(path), 0755))
+		assert.Nil(t, os.WriteFile(path, []byte(content), 0644))
+	}
+	assert.Nil(t, middleware.PreloadAllSchemasFromFilesystem(schemaDir))
+}
+
+func relationsPolicy() *model.Resource {
+	return &model.Resource{
+		OrgId:              "my-org",
+		ResourceType:       "relations_policy",
+		WorkspaceId:        "my-workspace",
+		ReporterType:       "ACM",
+		ReporterInstanceId: "acm-instance",
+		ReporterResourceId: "policy-1",
+		ResourceData: map[string]any{
+			"cluster_ids": []any{"cluster-1", "cluster-2"},
+		},
+	}
+}
+
+func relationsPolicyFilter() *v1beta1.DeleteTuplesRequest {
+	return &v1beta1.DeleteTuplesRequest{
+		Filter: &v1beta1.RelationTupleFilter{
+			ResourceNamespace: proto.String("acm"),
+			ResourceType:      proto.String("relations_policy"),
+			ResourceId:        proto.String("policy-1"),
+			Relation:          proto.String("t_cluster"),
+		},
+	}
+}
+
+func relationsPolicyTuple(relation, subjectType, subjectId string) *v1beta1.Relationship {
+	return &v1beta1.Relationship{
+		Resource: &v1beta1.ObjectReference{
+			Type: &v1beta1.ObjectType{Namespace: "acm", Name: "relations_policy"},
+			Id:   "policy-1",
+		},
+		Relation: relation,
+		Subject: &v1beta1.SubjectReference{
+			Subject: &v1beta1.ObjectReference{
+				Type: &v1beta1.ObjectType{Namespace: "acm", Name: subjectType},
+				Id:   subjectId,
+			},
+		},
+	}
+}
+
+func relationsPolicyTupleFilter(relation, subjectType, subjectId string) *v1beta1.DeleteTuplesRequest {
+	return &v1beta1.DeleteTuplesRequest{
+		Filter: &v1beta1.RelationTupleFilter{
+			ResourceNamespace: proto.String("acm"),
+			ResourceType:      proto.String("relations_policy"),
+			ResourceId:        proto.String("policy-1"),
+			Relation:          proto.String(relation),
+			SubjectFilter: &v1beta1.SubjectFilter{
+				SubjectNamespace: proto.String("acm"),
+				SubjectType:      proto.String(subjectType),
+				SubjectId:        proto.String(subjectId),
+			},
+		},
+	}
+}
+
+func TestUpsertNewResource_SchemaRelations(t *testing.T) {
+	preloadRelationsSchema(t)
+	resource := relationsPolicy()
+
+	repo := &MockedReporterResourceRepository{}
+	inventoryRepo := &MockedInventoryResourceRepository{}
+	m := &MockAuthz{}
+
+	repo.On("FindByReporterResourceIdv1beta2", mock.Anything, mock.Anything).Return((*model.Resource)(nil), gorm.ErrRecordNotFound)
+	repo.On("Create", mock.Anything, resource).Return(resource, []*model.Resource{}, nil)
+	repo.On("Update", mock.Anything, resource, mock.Anything).Return(resource, []*model.Resource{}, nil)
+	m.On("SetWorkspace", mock.Anything, "policy-1", "my-workspace", "acm", "relations_policy", false).Return(&v1beta1.CreateTuplesResponse{ConsistencyToken: &v1beta1.ConsistencyToken{Token: "workspace-token"}}, nil)
+	m.On("CreateTuples", mock.Anything, &v1beta1.CreateTuplesRequest{
+		Upsert: true,
+		Tuples: []*v1beta1.Relationship{
+			relationsPolicyTuple("t_cluster", "k8s_cluster", "cluster-1"),
+			relationsPolicyTuple("t_cluster", "k8s_cluster", "cluster-2"),
+		},
+	}).Return(&v1beta1.CreateTuplesResponse{ConsistencyToken: &v1beta1.ConsistencyToken{Token: "relations-token"}}, nil)
+	m.On("ReadTuples", mock.Anything, mock.MatchedBy(func(req *v1beta1.ReadTuplesRequest) bool {
+		return req.GetFilter().GetRelation() == "t_cluster" && req.GetConsistency().GetAtLeastAsFresh().GetToken() == "relations-token"
+	})).Return(&readTuplesStream{tuples: []*v1beta1.Relationship{
+		relationsPolicyTuple("t_cluster", "k8s_cluster", "cluster-1"),
+		relationsPolicyTuple("t_cluster", "k8s_cluster", "cluster-2"),
+	}}, nil)
+
+	useCase := New(repo, inventoryRepo, m, nil, log.DefaultLogger, false)
+	ctx := context.TODO()
+
+	r, err := useCase.Upsert(ctx, resource)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "relations-token", r.ConsistencyToken)
+	repo.AssertExpectations(t)
+	m.AssertExpectations(t)
+	m.AssertNotCalled(t, "DeleteTuples", mock.Anything, mock.Anything)
+}
+
+func TestUpsertExistingResource_SchemaRelationsDeletesStaleTuples(t *testing.T) {
+	preloadRelationsSchema(t)
+	stored := relationsPolicy()
+	stored.ID = uuid.New()
+	reported := relationsPolicy()
+	reported.ResourceData = map[string]any{"cluster_ids": []any{"cluster-1"}}
+
+	repo := &MockedReporterResourceRepository{}
+	inventoryRepo := &MockedInventoryResourceRepository{}
+	m := &MockAuthz{}
+
+	now := time.Now()
+	saved := *reported
+	saved.ID = stored.ID
+	saved.UpdatedAt = &now
+
+	repo.On("FindByReporterResourceIdv1beta2", mock.Anything, mock.Anything).Return(stored, nil)
+	repo.On("Update", mock.Anything, reported, stored.ID).Return(&saved, []*model.Resource{&saved}, nil)
+	repo.On("UpdateConsistencyToken", mock.Anything, stored.ID, "delete-token").Return(nil)
+	m.On("SetWorkspace", mock.Anything, "policy-1", "my-workspace", "acm", "relations_policy", true).Return(&v1beta1.CreateTuplesResponse{}, nil)
+	m.On("CreateTuples", mock.Anything, &v1beta1.CreateTuplesRequest{
+		Upsert: true,
+		Tuples: []*v1beta1.Relationship{relationsPolicyTuple("t_cluster", "k8s_cluster", "cluster-1")},
+	}).Return(&v1beta1.CreateTuplesResponse{ConsistencyToken: &v1beta1.ConsistencyToken{Token: "create-token"}}, nil)
+	// cluster-2 was dropped from the data, only the tuples of the declared relations are read
+	m.On("ReadTuples", mock.Anything, mock.MatchedBy(func(req *v1beta1.ReadTuplesRequest) bool {
+		return req.GetFilter().GetRelation() == "t_cluster"
+	})).Return(&readTuplesStream{tuples: []*v1beta1.Relationship{
+		relationsPolicyTuple("t_cluster", "k8s_cluster", "cluster-1"),
+		relationsPolicyTuple("t_cluster", "k8s_cluster", "cluster-2"),
+	}}, nil)
+	m.On("DeleteTuples", mock.Anything, relationsPolicyTupleFilter("t_cluster", "k8s_cluster", "cluster-2")).Return(&v1beta1.DeleteTuplesResponse{ConsistencyToken: &v1beta1.ConsistencyToken{Token: "delete-token"}}, nil)
+
+	useCase := New(repo, inventoryRepo, m, nil, log.DefaultLogger, false)
+	r, err := useCase.Upsert(context.TODO(), reported)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "delete-token", r.ConsistencyToken, "the token of the update is kept")
+	repo.AssertExpectations(t)
+	// the token is saved without a new version of the resource
+	repo.AssertNumberOfCalls(t, "Update", 1)
+	m.AssertExpectations(t)
+
+	// the current tuples are written before the stale ones are deleted
+	var methods []string
+	for _, call := range m.Calls {
+		methods = append(methods, call.Method)
+	}
+	assert.Equal(t, []string{"SetWorkspace", "CreateTuples", "ReadTuples", "DeleteTuples"}, methods)
+}
+
+func TestDeleteResource_SchemaRelations(t *testing.T) {
+	preloadRelationsSchema(t)
+	resource := relationsPolicy()
+	id, err := uuid.NewV7()
+	assert.Nil(t, err)
+	resource.ID = id
+
+	repo := &MockedReporterResourceRepository{}
+	inventoryRepo := &MockedInventoryResourceRepository{}
+	m := &MockAuthz{}
+
+	repo.On("FindByReporterData", mock.Anything, mock.Anything, mock.Anything).Return(resource, nil)
+	repo.On("Delete", mock.Anything, id).Return(resource, nil)
+	m.On("UnsetWorkspace", mock.Anything, "policy-1", "acm", "relations_policy").Return(&v1beta1.DeleteTuplesResponse{}, nil)
+	m.On("DeleteTuples", mock.Anything, relationsPolicyFilter()).Return(&v1beta1.DeleteTuplesResponse{}, nil)
+
+	useCase := New(repo, inventoryRepo, m, nil, log.DefaultLogger, false)
+	ctx := context.TODO()
+
+	err = useCase.Delete(ctx, model.ReporterResourceId{
+		LocalResourceId: "policy-1",
+		ResourceType:    "relations_policy",
+		ReporterId:      "acm-instance",
+		ReporterType:    "ACM",
+	})
+
+	assert.Nil(t, err)
+	repo.AssertExpectations(t)
+	m.AssertExpectations(t)
+}
+
+func TestUpsertNewResource_ReporterConfigNamespace(t *testing.T) {
+	schemaDir := t.TempDir()
+	files := map[string]string{
+		"namespaced_cluster/config.yaml":               "resource_type: namespaced_cluster\nresource_reporters:\n  - ACM\n",
+		"namespaced_cluster/reporters/acm/config.yaml": "resource_type: namespaced_cluster\nreporter_name: acm\nnamespace: kubernetes\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(schemaDir, name)
+		assert.Nil(t, os.MkdirAll(filepath.Dir(path), 0755))
+		assert.Nil(t, os.WriteFile(path, []byte(content), 0644))
+	}
+	assert.Nil(t, middleware.PreloadAllSchemasFromFilesystem(schemaDir))
+
+	resource := &model.Resource{
+		OrgId:              "my-org",
+		ResourceType:       "namespaced_cluster",
+		WorkspaceId:        "my-workspace",
+		ReporterType:       "ACM",
+		ReporterInstanceId: "acm-instance",
+		ReporterResourceId: "cluster-1",
+	}
+
+	repo := &MockedReporterResourceRepository{}
+	inventoryRepo := &MockedInventoryResourceRepository{}
+	m := &MockAuthz{}
+
+	repo.On("FindByReporterResourceIdv1beta2", mock.Anything, mock.Anything).Return((*model.Resource)(nil), gorm.ErrRecordNotFound)
+	repo.On("Create", mock.Anything, resource).Return(resource, []*model.Resource{}, nil)
+	repo.On("Update", mock.Anything, resource, mock.Anything).Return(resource, []*model.Resource{}, nil)
+	m.On("SetWorkspace", mock.Anything, "cluster-1", "my-workspace", "kubernetes", "namespaced_cluster", false).Return(&v1beta1.CreateTuplesResponse{}, nil)
+
+	useCase := New(repo, inventoryRepo, m, nil, log.DefaultLogger, false)
+	_, err := useCase.Upsert(context.TODO(), resource)
+
+	assert.Nil(t, err)
+	m.AssertExpectations(t)
+	// the config declares no relations, there are no tuples to replace
+	m.AssertNotCalled(t, "ReadTuples", mock.Anything, mock.Anything)
+}
+
+// recordingEventer records the events produced through it, and the ids they were looked up with.
+type recordingEventer struct {
+	events []*eventingapi.Event
+	keys   []uuid.UUID
+}
+
+func (e *recordingEventer) Lookup(identity *authnapi.Identity, route eventingapi.Route, resource_id uuid.UUID) (eventingapi.Producer, error) {
+	e.keys = append(e.keys, resource_id)
+	return e, nil
+}
+
+func (e *recordingEventer) Produce(ctx context.Context, event *eventingapi.Event) error {
+	e.events = append(e.events, event)
+	return nil
+}
+
+func (e *recordingEventer) Errs() <-chan error {
+	return nil
+}
+
+func (e *recordingEventer) Shutdown(ctx context.Context) error {
+	return nil
+}
+
+func upsertExistingResource(t *testing.T, stored *model.Resource, reported *model.Resource, includeChanges bool) *recordingEventer {
+	repo := &MockedReporterResourceRepository{}
+	inventoryRepo := &MockedInventoryResourceRepository{}
+	eventer := &recordingEventer{}
+
+	now := time.Now()
+	saved := *reported
+	saved.ID = stored.ID
+	saved.UpdatedAt = &now
+
+	repo.On("FindByReporterResourceIdv1beta2", mock.Anything, mock.Anything).Return(stored, nil)
+	repo.On("Update", mock.Anything, reported, stored.ID).Return(&saved, []*model.Resource{&saved}, nil)
+
+	useCase := New(repo, inventoryRepo, nil, eventer, log.DefaultLogger, false)
+	useCase.IncludeChanges = includeChanges
+	ctx := context.WithValue(context.TODO(), middleware.IdentityRequestKey, &authnapi.Identity{Principal: "acm-instance"})
+
+	_, err := useCase.Upsert(ctx, reported)
+	assert.Nil(t, err)
+	repo.AssertExpectations(t)
+	return eventer
+}
+
+func TestResourceEvents_KeyedByInventoryId(t *testing.T) {
+	ctx := context.WithValue(context.TODO(), middleware.IdentityRequestKey, &authnapi.Identity{Principal: "acm-instance"})
+	eventer := &recordingEventer{}
+	inventoryId := uuid.New()
+
+	// the same cluster reported by two reporters
+	acm := resource1()
+	acm.ID = uuid.New()
+	acm.InventoryId = &inventoryId
+	ocm := resource1()
+	ocm.ID = uuid.New()
+	ocm.ReporterType = "OCM"
+	ocm.InventoryId = &inventoryId
+	// a resource reported before it had an inventory id
+	legacy := resource1()
+	legacy.ID = uuid.New()
+
+	for _, resource := range []*model.Resource{acm, ocm, legacy} {
+		require.NoError(t, biz.DefaultResourceSendEvent(ctx, resource, eventer, time.Now(), eventingapi.OperationTypeUpdated))
+	}
+	assert.Equal(t, []uuid.UUID{inventoryId, inventoryId, legacy.ID}, eventer.keys)
+}
+
+func TestUpsertExistingResource_NoOpSkipsEvent(t *testing.T) {
+	stored := relationsPolicy()
+	stored.ID = uuid.New()
+
+	eventer := upsertExistingResource(t, stored, relationsPolicy(), true)
+	assert.Empty(t, eventer.events)
+}
+
+func TestUpsertExistingResource_Changes(t *testing.T) {
+	stored := relationsPolicy()
+	stored.ID = uuid.New()
+	reported := relationsPolicy()
+	reported.ResourceData = map[string]any{"cluster_ids": []any{"cluster-1"}}
+
+	eventer := upsertExistingResource(t, stored, reported, false)
+	assert.Len(t, eventer.events, 1)
+	assert.Nil(t, eventer.events[0].Data.(eventingapi.ResourceData).Changes, "changes are only included when enabled")
+
+	eventer = upsertExistingResource(t, stored, reported, true)
+	assert.Len(t, eventer.events, 1)
+	assert.Equal(t, &eventingapi.ResourceChanges{
+		Previous: map[string]interface{}{"/resource_data/cluster_ids": []interface{}{"cluster-1", "cluster-2"}},
+		Patch: []eventingapi.PatchOperation{
+			{Op: eventingapi.PatchOperationReplace, Path: "/resource_data/cluster_ids", Value: []interface{}{"cluster-1"}},
+		},
+	}, eventer.events[0].Data.(eventingapi.ResourceData).Changes)
+}
+
+func TestUpsertExistingResource_MovedReplacesWorkspace(t *testing.T) {
+	stored := relationsPolicy()
+	stored.ID = uuid.New()
+	reported := relationsPolicy()
+	reported.WorkspaceId = "new-workspace"
+
+	repo := &MockedReporterResourceRepository{}
+	inventoryRepo := &MockedInventoryResourceRepository{}
+	m := &MockAuthz{}
+
+	now := time.Now()
+	saved := *reported
+	saved.ID = stored.ID
+	saved.UpdatedAt = &now
+	saved.PreviousWorkspaceId = "my-workspace"
+	// a resource sharing the inventory id, moved along
+	shared := relationsPolicy()
+	shared.ReporterResourceId = "policy-2"
+	shared.WorkspaceId = "new-workspace"
+	shared.PreviousWorkspaceId = "my-workspace"
+
+	repo.On("FindByReporterResourceIdv1beta2", mock.Anything, mock.Anything).Return(stored, nil)
+	repo.On("Update", mock.Anything, reported, stored.ID).Return(&saved, []*model.Resource{&saved, shared}, nil)
+	m.On("ReplaceWorkspace", mock.Anything, "policy-1", "my-workspace", "new-workspace", "acm", "relations_policy").Return(&v1beta1.CreateTuplesResponse{}, nil)
+	m.On("ReplaceWorkspace", mock.Anything, "policy-2", "my-workspace", "new-workspace", "acm", "relations_policy").Return(&v1beta1.CreateTuplesResponse{}, nil)
+	m.On("DeleteTuples", mock.Anything, mock.Anything).Return(&v1beta1.DeleteTuplesResponse{}, nil).Maybe()
+	m.On("CreateTuples", mock.Anything, mock.Anything).Return(&v1beta1.CreateTuplesResponse{}, nil).Maybe()
+	m.On("ReadTuples", mock.Anything, mock.Anything).Return(&readTuplesStream{}, nil).Maybe()
+
+	useCase := New(repo, inventoryRepo, m, nil, log.DefaultLogger, false)
+	_, err := useCase.Upsert(context.TODO(), reported)
+
+	assert.Nil(t, err)
+	repo.AssertExpectations(t)
+	m.AssertExpectations(t)
+	m.AssertNotCalled(t, "SetWorkspace", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
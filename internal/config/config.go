@@ -7,7 +7,9 @@ import (
 	"github.com/go-kratos/kratos/v2/log"
 	"github.com/project-kessel/inventory-api/internal/authn"
 	"github.com/project-kessel/inventory-api/internal/authz"
+	"github.com/project-kessel/inventory-api/internal/consumer"
 	"github.com/project-kessel/inventory-api/internal/eventing"
+	"github.com/project-kessel/inventory-api/internal/reconciler"
 	"github.com/project-kessel/inventory-api/internal/server"
 	"github.com/project-kessel/inventory-api/internal/storage"
 	clowder "github.com/redhatinsights/app-common-go/pkg/api/v1"
@@ -15,11 +17,13 @@ import (
 
 // OptionsConfig contains the settings for each configuration option
 type OptionsConfig struct {
-	Authn    *authn.Options
-	Authz    *authz.Options
-	Storage  *storage.Options
-	Eventing *eventing.Options
-	Server   *server.Options
+	Authn      *authn.Options
+	Authz      *authz.Options
+	Storage    *storage.Options
+	Eventing   *eventing.Options
+	Server     *server.Options
+	Consumer   *consumer.Options
+	Reconciler *reconciler.Options
 }
 
 // NewOptionsConfig returns a new OptionsConfig with default options set
@@ -30,6 +34,8 @@ func NewOptionsConfig() *OptionsConfig {
 		storage.NewOptions(),
 		eventing.NewOptions(),
 		server.NewOptions(),
+		consumer.NewOptions(),
+		reconciler.NewOptions(),
 	}
 }
 
@@ -64,6 +70,10 @@ func LogConfigurationInfo(options *OptionsConfig) {
 			options.Authz.Kessel.EnableOidcAuth,
 		)
 	}
+
+	if options.Authz.Authz == authz.Local {
+		log.Debugf("Authz Configuration: Schema file: %s", options.Authz.Local.SchemaFile)
+	}
 }
 
 // InjectClowdAppConfig updates service options based on values in the ClowdApp AppConfig
@@ -0,0 +1,69 @@
+package consumer
+
+import (
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+)
+
+type Config struct {
+	*Options
+
+	// this can be set manually for testing
+	KafkaConfig *kafka.ConfigMap
+}
+
+type completedConfig struct {
+	Topic           string
+	RetryBackoff    time.Duration
+	RetryBackoffMax time.Duration
+	KafkaConfig     *kafka.ConfigMap
+}
+
+type CompletedConfig struct {
+	*completedConfig
+}
+
+func NewConfig(o *Options) *Config {
+	return &Config{
+		Options: o,
+	}
+}
+
+func (c *Config) Complete() (CompletedConfig, error) {
+	config := c.KafkaConfig
+	if config == nil {
+		config = &kafka.ConfigMap{
+			"bootstrap.servers":  c.BootstrapServers,
+			"group.id":           c.ConsumerGroupId,
+			"client.id":          c.ClientId,
+			"auto.offset.reset":  c.AutoOffsetReset,
+			"session.timeout.ms": c.SessionTimeoutMs,
+			"security.protocol":  c.SecurityProtocol,
+			// offsets are committed once the tuples of the message are written
+			"enable.auto.commit": false,
+		}
+
+		// librdkafka rejects empty values of these keys
+		for key, value := range map[string]string{
+			"sasl.mechanism":  c.SaslMechanism,
+			"sasl.username":   c.SaslUsername,
+			"sasl.password":   c.SaslPassword,
+			"ssl.ca.location": c.SslCaLocation,
+		} {
+			if value == "" {
+				continue
+			}
+			if err := config.SetKey(key, value); err != nil {
+				return CompletedConfig{}, err
+			}
+		}
+	}
+
+	return CompletedConfig{&completedConfig{
+		Topic:           c.Topic,
+		RetryBackoff:    time.Duration(c.RetryBackoffMs) * time.Millisecond,
+		RetryBackoffMax: time.Duration(c.RetryBackoffMaxMs) * time.Millisecond,
+		KafkaConfig:     config,
+	}}, nil
+}
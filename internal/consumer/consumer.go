@@ -0,0 +1,208 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/google/uuid"
+	kessel "github.com/project-kessel/relations-api/api/kessel/relations/v1beta1"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+
+	authzapi "github.com/project-kessel/inventory-api/internal/authz/api"
+)
+
+// pollTimeoutMs bounds how long a poll blocks, and so how long the consumer takes to notice a shutdown
+const pollTimeoutMs = 100
+
+// KafkaConsumer is the part of the confluent consumer used by the TupleConsumer.
+type KafkaConsumer interface {
+	SubscribeTopics(topics []string, rebalanceCb kafka.RebalanceCb) error
+	Poll(timeoutMs int) kafka.Event
+	CommitMessage(m *kafka.Message) ([]kafka.TopicPartition, error)
+	Close() error
+}
+
+// TokenStore records the consistency token returned by relations-api on the resource the tuples belong to.
+type TokenStore interface {
+	UpdateConsistencyToken(ctx context.Context, id uuid.UUID, token string) error
+}
+
+// TupleConsumer replicates the tuple events of the outbox into relations-api.  Offsets are committed only once the
+// tuples of a message are written, so a message is applied at least once.
+type TupleConsumer struct {
+	Config   CompletedConfig
+	Consumer KafkaConsumer
+	Authz    authzapi.Authorizer
+	// Tokens is nil when persistence is disabled, the consistency tokens are then dropped
+	Tokens TokenStore
+
+	Logger *log.Helper
+
+	eventsCounter metric.Int64Counter
+}
+
+func New(config CompletedConfig, authorizer authzapi.Authorizer, tokens TokenStore, logger *log.Helper) (*TupleConsumer, error) {
+	consumer, err := kafka.NewConsumer(config.KafkaConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create kafka consumer: %w", err)
+	}
+
+	meter := otel.Meter("github.com/project-kessel/inventory-api/blob/main/internal/server/otel")
+	eventsCounter, err := meter.Int64Counter("consumer_tuple_event", metric.WithUnit("{event}"))
+	if err != nil {
+		return nil, err
+	}
+
+	return &TupleConsumer{
+		Config:   config,
+		Consumer: consumer,
+		Authz:    authorizer,
+		Tokens:   tokens,
+
+		Logger: logger,
+
+		eventsCounter: eventsCounter,
+	}, nil
+}
+
+// Run consumes the tuples topic until the context is done or the consumer fails.
+func (c *TupleConsumer) Run(ctx context.Context) error {
+	if err := c.Consumer.SubscribeTopics([]string{c.Config.Topic}, nil); err != nil {
+		return fmt.Errorf("failed to subscribe to %s: %w", c.Config.Topic, err)
+	}
+	c.Logger.Infof("Consuming tuple events from %s", c.Config.Topic)
+
+	for {
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		switch ev := c.Consumer.Poll(pollTimeoutMs).(type) {
+		case *kafka.Message:
+			if err := c.handleMessage(ctx, ev); err != nil {
+				if ctx.Err() != nil {
+					return nil
+				}
+				return err
+			}
+		case kafka.Error:
+			if ev.IsFatal() {
+				return ev
+			}
+			c.Logger.Warnf("Consumer error: %v", ev)
+		case nil:
+		default:
+			c.Logger.Debugf("Ignored event: %v", ev)
+		}
+	}
+}
+
+// Close leaves the consumer group.
+func (c *TupleConsumer) Close() error {
+	return c.Consumer.Close()
+}
+
+// handleMessage applies the message, retrying failures with an exponential backoff, and commits its offset.
+// Messages that can never be applied are logged and skipped.
+func (c *TupleConsumer) handleMessage(ctx context.Context, msg *kafka.Message) error {
+	event, err := ParseTupleEvent(msg)
+	if err != nil {
+		c.Logger.Errorf("Skipping invalid tuple event at %v: %v", msg.TopicPartition, err)
+		c.count("", "skipped")
+		return c.commit(msg)
+	}
+
+	backoff := c.Config.RetryBackoff
+	for {
+		err := c.Process(ctx, event)
+		if err == nil {
+			c.count(event.Operation, "applied")
+			break
+		}
+		if isPermanent(err) {
+			c.Logger.Errorf("Skipping tuple event at %v rejected by relations-api: %v", msg.TopicPartition, err)
+			c.count(event.Operation, "skipped")
+			break
+		}
+
+		c.Logger.Warnf("Failed to apply tuple event at %v, retrying in %v: %v", msg.TopicPartition, backoff, err)
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		backoff = min(backoff*2, c.Config.RetryBackoffMax)
+	}
+
+	return c.commit(msg)
+}
+
+// Process writes the tuples of the event and records the returned consistency token on its resource.
+func (c *TupleConsumer) Process(ctx context.Context, event *TupleEvent) error {
+	var token *kessel.ConsistencyToken
+	if event.Create != nil {
+		resp, err := c.Authz.CreateTuples(ctx, event.Create)
+		if err != nil {
+			return err
+		}
+		token = resp.GetConsistencyToken()
+	}
+	if event.Delete != nil {
+		resp, err := c.Authz.DeleteTuples(ctx, event.Delete)
+		if err != nil {
+			return err
+		}
+		token = resp.GetConsistencyToken()
+	}
+
+	if token.GetToken() == "" || event.ResourceId == uuid.Nil || c.Tokens == nil {
+		return nil
+	}
+	err := c.Tokens.UpdateConsistencyToken(ctx, event.ResourceId, token.GetToken())
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// the resource was deleted since, there is no row to record the token on
+		c.Logger.Debugf("Resource %s not found, dropping its consistency token", event.ResourceId)
+		return nil
+	}
+	return err
+}
+
+func (c *TupleConsumer) commit(msg *kafka.Message) error {
+	if _, err := c.Consumer.CommitMessage(msg); err != nil {
+		// the message is applied again after a rebalance or a restart, which the upserts make harmless
+		c.Logger.Warnf("Failed to commit offset %v: %v", msg.TopicPartition, err)
+	}
+	return nil
+}
+
+func (c *TupleConsumer) count(operation, outcome string) {
+	if c.eventsCounter == nil {
+		return
+	}
+	c.eventsCounter.Add(
+		context.Background(),
+		1,
+		metric.WithAttributes(
+			attribute.String("operation", operation),
+			attribute.String("outcome", outcome),
+		),
+	)
+}
+
+// isPermanent reports whether relations-api rejected the request itself, retrying it would fail the same way.
+func isPermanent(err error) bool {
+	switch status.Code(err) {
+	case codes.InvalidArgument, codes.FailedPrecondition, codes.OutOfRange, codes.Unimplemented:
+		return true
+	}
+	return false
+}
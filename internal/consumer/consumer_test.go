@@ -0,0 +1,271 @@
+package consumer
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/google/uuid"
+	kessel "github.com/project-kessel/relations-api/api/kessel/relations/v1beta1"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"gorm.io/gorm"
+
+	"github.com/project-kessel/inventory-api/internal/authz/allow"
+)
+
+const sampleTuple = `{"subject":{"subject":{"id":"my_workspace","type":{"name":"workspace","namespace":"rbac"}}},"relation":"t_workspace","resource":{"id":"my_integration","type":{"name":"integration","namespace":"notifications"}}}`
+
+func tupleMessage(offset int, key string, operation string, value string) *kafka.Message {
+	topic := "outbox.event.kessel.tuples"
+	return &kafka.Message{
+		TopicPartition: kafka.TopicPartition{Topic: &topic, Offset: kafka.Offset(offset)},
+		Key:            []byte(key),
+		Value:          []byte(value),
+		Headers:        []kafka.Header{{Key: OperationHeader, Value: []byte(operation)}},
+	}
+}
+
+// fakeConsumer returns the messages in order, then cancels the context of the test.
+type fakeConsumer struct {
+	messages []*kafka.Message
+	commits  []kafka.Offset
+	cancel   context.CancelFunc
+}
+
+func (c *fakeConsumer) SubscribeTopics(topics []string, rebalanceCb kafka.RebalanceCb) error {
+	return nil
+}
+
+func (c *fakeConsumer) Poll(timeoutMs int) kafka.Event {
+	if len(c.messages) == 0 {
+		c.cancel()
+		return nil
+	}
+	msg := c.messages[0]
+	c.messages = c.messages[1:]
+	return msg
+}
+
+func (c *fakeConsumer) CommitMessage(m *kafka.Message) ([]kafka.TopicPartition, error) {
+	c.commits = append(c.commits, m.TopicPartition.Offset)
+	return nil, nil
+}
+
+func (c *fakeConsumer) Close() error {
+	return nil
+}
+
+// fakeAuthz records the tuple requests and fails with the queued errors first.
+type fakeAuthz struct {
+	*allow.AllowAllAuthz
+	creates []*kessel.CreateTuplesRequest
+	deletes []*kessel.DeleteTuplesRequest
+	errs    []error
+}
+
+func (a *fakeAuthz) nextErr() error {
+	if len(a.errs) == 0 {
+		return nil
+	}
+	err := a.errs[0]
+	a.errs = a.errs[1:]
+	return err
+}
+
+func (a *fakeAuthz) CreateTuples(ctx context.Context, r *kessel.CreateTuplesRequest) (*kessel.CreateTuplesResponse, error) {
+	if err := a.nextErr(); err != nil {
+		return nil, err
+	}
+	a.creates = append(a.creates, r)
+	return &kessel.CreateTuplesResponse{ConsistencyToken: &kessel.ConsistencyToken{Token: fmt.Sprintf("create-%d", len(a.creates))}}, nil
+}
+
+func (a *fakeAuthz) DeleteTuples(ctx context.Context, r *kessel.DeleteTuplesRequest) (*kessel.DeleteTuplesResponse, error) {
+	if err := a.nextErr(); err != nil {
+		return nil, err
+	}
+	a.deletes = append(a.deletes, r)
+	return &kessel.DeleteTuplesResponse{ConsistencyToken: &kessel.ConsistencyToken{Token: fmt.Sprintf("delete-%d", len(a.deletes))}}, nil
+}
+
+type fakeTokens map[uuid.UUID]string
+
+func (t fakeTokens) UpdateConsistencyToken(ctx context.Context, id uuid.UUID, token string) error {
+	if _, ok := t[id]; !ok {
+		return gorm.ErrRecordNotFound
+	}
+	t[id] = token
+	return nil
+}
+
+func TestParseTupleEvent(t *testing.T) {
+	resourceId := uuid.New()
+	relationship := &kessel.Relationship{
+		Resource: &kessel.ObjectReference{Type: &kessel.ObjectType{Namespace: "notifications", Name: "integration"}, Id: "my_integration"},
+		Relation: "t_workspace",
+		Subject:  &kessel.SubjectReference{Subject: &kessel.ObjectReference{Type: &kessel.ObjectType{Namespace: "rbac", Name: "workspace"}, Id: "my_workspace"}},
+	}
+
+	tests := []struct {
+		name     string
+		msg      *kafka.Message
+		expected *TupleEvent
+		err      string
+	}{
+		{
+			name: "create tuple",
+			msg:  tupleMessage(0, resourceId.String(), OperationCreateTuple, sampleTuple),
+			expected: &TupleEvent{
+				ResourceId: resourceId,
+				Operation:  OperationCreateTuple,
+				Create:     &kessel.CreateTuplesRequest{Upsert: true, Tuples: []*kessel.Relationship{relationship}},
+			},
+		},
+		{
+			name: "json converter envelopes",
+			msg: tupleMessage(0,
+				fmt.Sprintf(`{"schema":{"type":"string"},"payload":"%s"}`, resourceId),
+				`"`+OperationCreateTuple+`"`,
+				fmt.Sprintf(`{"schema":{"type":"string"},"payload":%q}`, sampleTuple)),
+			expected: &TupleEvent{
+				ResourceId: resourceId,
+				Operation:  OperationCreateTuple,
+				Create:     &kessel.CreateTuplesRequest{Upsert: true, Tuples: []*kessel.Relationship{relationship}},
+			},
+		},
+		{
+			name: "delete tuple",
+			msg:  tupleMessage(0, "1", OperationDeleteTuple, sampleTuple),
+			expected: &TupleEvent{
+				Operation: OperationDeleteTuple,
+				Delete: &kessel.DeleteTuplesRequest{Filter: &kessel.RelationTupleFilter{
+					ResourceNamespace: proto.String("notifications"),
+					ResourceType:      proto.String("integration"),
+					ResourceId:        proto.String("my_integration"),
+					Relation:          proto.String("t_workspace"),
+					SubjectFilter: &kessel.SubjectFilter{
+						SubjectNamespace: proto.String("rbac"),
+						SubjectType:      proto.String("workspace"),
+						SubjectId:        proto.String("my_workspace"),
+					},
+				}},
+			},
+		},
+		{
+			name: "create tuples",
+			msg:  tupleMessage(0, "1", OperationCreateTuples, `{"tuples":[`+sampleTuple+`]}`),
+			expected: &TupleEvent{
+				Operation: OperationCreateTuples,
+				Create:    &kessel.CreateTuplesRequest{Upsert: true, Tuples: []*kessel.Relationship{relationship}},
+			},
+		},
+		{
+			name: "missing operation",
+			msg:  &kafka.Message{Value: []byte(sampleTuple)},
+			err:  "missing operation header",
+		},
+		{
+			name: "unknown operation",
+			msg:  tupleMessage(0, "1", "TouchTuple", sampleTuple),
+			err:  "unknown operation TouchTuple",
+		},
+		{
+			name: "invalid payload",
+			msg:  tupleMessage(0, "1", OperationCreateTuple, `{"relation": 1}`),
+			err:  "invalid payload",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event, err := ParseTupleEvent(tt.msg)
+			if tt.err != "" {
+				assert.ErrorContains(t, err, tt.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected.ResourceId, event.ResourceId)
+			assert.Equal(t, tt.expected.Operation, event.Operation)
+			assert.True(t, proto.Equal(tt.expected.Create, event.Create), "create: %v", event.Create)
+			assert.True(t, proto.Equal(tt.expected.Delete, event.Delete), "delete: %v", event.Delete)
+		})
+	}
+}
+
+func TestTupleConsumer_Run(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	resourceId, deletedId := uuid.New(), uuid.New()
+	consumer := &fakeConsumer{
+		cancel: cancel,
+		messages: []*kafka.Message{
+			tupleMessage(1, resourceId.String(), OperationCreateTuple, sampleTuple),
+			tupleMessage(2, resourceId.String(), OperationDeleteTuple, sampleTuple),
+			tupleMessage(3, resourceId.String(), "TouchTuple", sampleTuple),
+			tupleMessage(4, deletedId.String(), OperationCreateTuple, sampleTuple),
+			tupleMessage(5, resourceId.String(), OperationCreateTuple, sampleTuple),
+		},
+	}
+	authz := &fakeAuthz{
+		AllowAllAuthz: allow.New(log.NewHelper(log.DefaultLogger)),
+		errs: []error{
+			nil,
+			// the delete is retried until relations-api is back
+			status.Error(codes.Unavailable, "relations-api unavailable"),
+			status.Error(codes.Unavailable, "relations-api unavailable"),
+			nil,
+			nil,
+			// the last create is rejected and skipped
+			status.Error(codes.InvalidArgument, "invalid tuple"),
+		},
+	}
+	tokens := fakeTokens{resourceId: ""}
+
+	c := &TupleConsumer{
+		Config:   CompletedConfig{&completedConfig{Topic: "outbox.event.kessel.tuples", RetryBackoff: time.Millisecond, RetryBackoffMax: 2 * time.Millisecond}},
+		Consumer: consumer,
+		Authz:    authz,
+		Tokens:   tokens,
+		Logger:   log.NewHelper(log.DefaultLogger),
+	}
+
+	assert.NoError(t, c.Run(ctx))
+
+	assert.Equal(t, []kafka.Offset{1, 2, 3, 4, 5}, consumer.commits)
+	assert.Len(t, authz.creates, 2)
+	assert.Len(t, authz.deletes, 1)
+	// the token of the delete is recorded, the resource of the second create no longer exists
+	assert.Equal(t, fakeTokens{resourceId: "delete-1"}, tokens)
+}
+
+func TestTupleConsumer_StopsWhileRetrying(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	consumer := &fakeConsumer{
+		cancel:   cancel,
+		messages: []*kafka.Message{tupleMessage(1, "1", OperationCreateTuple, sampleTuple)},
+	}
+	authz := &fakeAuthz{AllowAllAuthz: allow.New(log.NewHelper(log.DefaultLogger))}
+	for i := 0; i < 100; i++ {
+		authz.errs = append(authz.errs, status.Error(codes.Unavailable, "relations-api unavailable"))
+	}
+
+	c := &TupleConsumer{
+		Config:   CompletedConfig{&completedConfig{Topic: "outbox.event.kessel.tuples", RetryBackoff: time.Hour, RetryBackoffMax: time.Hour}},
+		Consumer: consumer,
+		Authz:    authz,
+		Logger:   log.NewHelper(log.DefaultLogger),
+	}
+
+	time.AfterFunc(20*time.Millisecond, cancel)
+	assert.NoError(t, c.Run(ctx))
+	// the message is not committed, it is consumed again after a restart
+	assert.Empty(t, consumer.commits)
+}
@@ -0,0 +1,91 @@
+package consumer
+
+import (
+	"fmt"
+
+	"github.com/spf13/pflag"
+)
+
+type Options struct {
+	// Topic is the topic the outbox router writes the tuple events to
+	Topic            string `mapstructure:"topic"`
+	ConsumerGroupId  string `mapstructure:"consumer-group-id"`
+	BootstrapServers string `mapstructure:"bootstrap-servers"`
+	ClientId         string `mapstructure:"client-id"`
+	// AutoOffsetReset is where a new consumer group starts reading, either earliest or latest
+	AutoOffsetReset  string `mapstructure:"auto-offset-reset"`
+	SessionTimeoutMs int    `mapstructure:"session-timeout-ms"`
+	SecurityProtocol string `mapstructure:"security-protocol"`
+	SaslMechanism    string `mapstructure:"sasl-mechanism"`
+	SaslUsername     string `mapstructure:"sasl-username"`
+	SaslPassword     string `mapstructure:"sasl-password"`
+	SslCaLocation    string `mapstructure:"ssl-ca-location"`
+	// RetryBackoffMs is the first delay before a tuple event failing against relations-api is applied again
+	RetryBackoffMs    int `mapstructure:"retry-backoff-ms"`
+	RetryBackoffMaxMs int `mapstructure:"retry-backoff-max-ms"`
+}
+
+func NewOptions() *Options {
+	return &Options{
+		Topic:             "outbox.event.kessel.tuples",
+		ConsumerGroupId:   "inventory-consumer",
+		BootstrapServers:  "",
+		ClientId:          "inventory-consumer",
+		AutoOffsetReset:   "earliest",
+		SessionTimeoutMs:  45000,
+		SecurityProtocol:  "plaintext",
+		RetryBackoffMs:    500,
+		RetryBackoffMaxMs: 30000,
+	}
+}
+
+func (o *Options) AddFlags(fs *pflag.FlagSet, prefix string) {
+	if prefix != "" {
+		prefix = prefix + "."
+	}
+
+	fs.StringVar(&o.Topic, prefix+"topic", o.Topic, "The topic the tuple events are read from.")
+	fs.StringVar(&o.ConsumerGroupId, prefix+"consumer-group-id", o.ConsumerGroupId, "The consumer group the offsets are committed for.")
+	fs.StringVar(&o.BootstrapServers, prefix+"bootstrap-servers", o.BootstrapServers, "Initial list of brokers as a CSV list of broker host or host:port.")
+	fs.StringVar(&o.ClientId, prefix+"client-id", o.ClientId, "Client identifier.")
+	fs.StringVar(&o.AutoOffsetReset, prefix+"auto-offset-reset", o.AutoOffsetReset, "Where a consumer group without committed offsets starts reading.  Either earliest or latest.")
+	fs.IntVar(&o.SessionTimeoutMs, prefix+"session-timeout-ms", o.SessionTimeoutMs, "Client group session and failure detection timeout in milliseconds.")
+	fs.StringVar(&o.SecurityProtocol, prefix+"security-protocol", o.SecurityProtocol, "Protocol used to communicate with brokers.")
+	fs.StringVar(&o.SaslMechanism, prefix+"sasl-mechanism", o.SaslMechanism, "SASL mechanism to use for authentication.")
+	fs.StringVar(&o.SaslUsername, prefix+"sasl-username", o.SaslUsername, "SASL username for use with the PLAIN and SASL-SCRAM-.. mechanisms.")
+	fs.StringVar(&o.SaslPassword, prefix+"sasl-password", o.SaslPassword, "SASL password for use with the PLAIN and SASL-SCRAM-.. mechanisms.")
+	fs.StringVar(&o.SslCaLocation, prefix+"ssl-ca-location", o.SslCaLocation, "File or directory path to CA certificate(s) for verifying the broker's key.")
+	fs.IntVar(&o.RetryBackoffMs, prefix+"retry-backoff-ms", o.RetryBackoffMs, "Delay in milliseconds before a tuple event failing against relations-api is applied again, doubled after every failure.")
+	fs.IntVar(&o.RetryBackoffMaxMs, prefix+"retry-backoff-max-ms", o.RetryBackoffMaxMs, "Maximum delay in milliseconds between two attempts to apply a tuple event.")
+}
+
+func (o *Options) Validate() []error {
+	var errs []error
+
+	if o.Topic == "" {
+		errs = append(errs, fmt.Errorf("topic must be set"))
+	}
+	if o.ConsumerGroupId == "" {
+		errs = append(errs, fmt.Errorf("consumer-group-id must be set"))
+	}
+	if o.BootstrapServers == "" {
+		errs = append(errs, fmt.Errorf("bootstrap-servers must be set"))
+	}
+	if o.AutoOffsetReset != "earliest" && o.AutoOffsetReset != "latest" {
+		errs = append(errs, fmt.Errorf("invalid auto-offset-reset %s. Options are 'earliest' and 'latest'", o.AutoOffsetReset))
+	}
+	if o.RetryBackoffMs <= 0 {
+		errs = append(errs, fmt.Errorf("retry-backoff-ms must be positive"))
+	}
+	if o.RetryBackoffMaxMs < o.RetryBackoffMs {
+		errs = append(errs, fmt.Errorf("retry-backoff-max-ms must not be less than retry-backoff-ms"))
+	}
+
+	return errs
+}
+
+func (o *Options) Complete() []error {
+	var errs []error
+
+	return errs
+}
@@ -0,0 +1,143 @@
+package consumer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"github.com/google/uuid"
+	kessel "github.com/project-kessel/relations-api/api/kessel/relations/v1beta1"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// OperationHeader is the message header holding the type column of the outbox row, placed there by the
+// table.fields.additional.placement setting of the outbox event router.
+const OperationHeader = "operation"
+
+const (
+	// OperationCreateTuple upserts the kessel.Relationship of the payload.
+	OperationCreateTuple = "CreateTuple"
+	// OperationDeleteTuple deletes the kessel.Relationship of the payload.
+	OperationDeleteTuple = "DeleteTuple"
+	// OperationCreateTuples sends the kessel.CreateTuplesRequest of the payload.
+	OperationCreateTuples = "CreateTuples"
+	// OperationDeleteTuples sends the kessel.DeleteTuplesRequest of the payload.
+	OperationDeleteTuples = "DeleteTuples"
+)
+
+// TupleEvent is a tuple change read from the tuples topic.
+type TupleEvent struct {
+	// ResourceId is the aggregate id of the outbox row, the inventory resource the tuples belong to.  It is
+	// uuid.Nil when the aggregate id isn't a resource id.
+	ResourceId uuid.UUID
+	Operation  string
+	Create     *kessel.CreateTuplesRequest
+	Delete     *kessel.DeleteTuplesRequest
+}
+
+// ParseTupleEvent decodes the message written by the outbox event router.  Payloads wrapped in the schema envelope
+// of the Kafka Connect JSON converter, or sent as a JSON string, are unwrapped first.
+func ParseTupleEvent(msg *kafka.Message) (*TupleEvent, error) {
+	operation := ""
+	for _, header := range msg.Headers {
+		if header.Key == OperationHeader {
+			operation = strings.Trim(string(header.Value), `"`)
+		}
+	}
+
+	payload, err := unwrapPayload(msg.Value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid payload: %w", err)
+	}
+
+	event := &TupleEvent{Operation: operation}
+	if key, err := unwrapPayload(msg.Key); err == nil {
+		if id, err := uuid.Parse(string(key)); err == nil {
+			event.ResourceId = id
+		}
+	}
+
+	switch operation {
+	case OperationCreateTuple:
+		relationship := &kessel.Relationship{}
+		if err := unmarshal(payload, relationship); err != nil {
+			return nil, err
+		}
+		event.Create = &kessel.CreateTuplesRequest{Upsert: true, Tuples: []*kessel.Relationship{relationship}}
+	case OperationDeleteTuple:
+		relationship := &kessel.Relationship{}
+		if err := unmarshal(payload, relationship); err != nil {
+			return nil, err
+		}
+		event.Delete = &kessel.DeleteTuplesRequest{Filter: relationshipFilter(relationship)}
+	case OperationCreateTuples:
+		event.Create = &kessel.CreateTuplesRequest{}
+		if err := unmarshal(payload, event.Create); err != nil {
+			return nil, err
+		}
+		// messages are delivered at least once, applying a message again must not fail
+		event.Create.Upsert = true
+	case OperationDeleteTuples:
+		event.Delete = &kessel.DeleteTuplesRequest{}
+		if err := unmarshal(payload, event.Delete); err != nil {
+			return nil, err
+		}
+	case "":
+		return nil, fmt.Errorf("missing %s header", OperationHeader)
+	default:
+		return nil, fmt.Errorf("unknown operation %s", operation)
+	}
+
+	return event, nil
+}
+
+// unwrapPayload returns the JSON document of a message key or value.
+func unwrapPayload(value []byte) ([]byte, error) {
+	value = bytes.TrimSpace(value)
+
+	var envelope struct {
+		Schema  json.RawMessage `json:"schema"`
+		Payload json.RawMessage `json:"payload"`
+	}
+	if err := json.Unmarshal(value, &envelope); err == nil && envelope.Schema != nil && envelope.Payload != nil {
+		value = bytes.TrimSpace(envelope.Payload)
+	}
+
+	if len(value) > 0 && value[0] == '"' {
+		var s string
+		if err := json.Unmarshal(value, &s); err != nil {
+			return nil, err
+		}
+		value = []byte(s)
+	}
+	return value, nil
+}
+
+func unmarshal(payload []byte, m proto.Message) error {
+	if err := protojson.Unmarshal(payload, m); err != nil {
+		return fmt.Errorf("invalid payload: %w", err)
+	}
+	return nil
+}
+
+// relationshipFilter returns the filter matching exactly the relationship.
+func relationshipFilter(r *kessel.Relationship) *kessel.RelationTupleFilter {
+	filter := &kessel.RelationTupleFilter{
+		ResourceNamespace: proto.String(r.GetResource().GetType().GetNamespace()),
+		ResourceType:      proto.String(r.GetResource().GetType().GetName()),
+		ResourceId:        proto.String(r.GetResource().GetId()),
+		Relation:          proto.String(r.GetRelation()),
+		SubjectFilter: &kessel.SubjectFilter{
+			SubjectNamespace: proto.String(r.GetSubject().GetSubject().GetType().GetNamespace()),
+			SubjectType:      proto.String(r.GetSubject().GetSubject().GetType().GetName()),
+			SubjectId:        proto.String(r.GetSubject().GetSubject().GetId()),
+		},
+	}
+	if r.GetSubject().Relation != nil {
+		filter.SubjectFilter.Relation = proto.String(r.GetSubject().GetRelation())
+	}
+	return filter
+}
@@ -19,6 +19,8 @@ func Migrate(db *gorm.DB, logger *log.Helper) error {
 		&model.RelationshipHistory{},
 		&model.LocalInventoryToResource{}, // Deprecated
 		&model.InventoryResource{},
+		&model.RelationTuple{},
+		&model.AuthzDecision{},
 	}
 
 	if err := db.AutoMigrate(models...); err != nil {
@@ -41,9 +41,11 @@ func (r *Repo) Save(ctx context.Context, m *model.Relationship) (*model.Relation
 		return nil, err
 	}
 
-	if err := session.Create(copyHistory(m, m.ID, model.OperationTypeCreate)).Error; err != nil {
+	history := copyHistory(m, m.ID, model.OperationTypeCreate)
+	if err := session.Create(history).Error; err != nil {
 		return nil, err
 	}
+	m.HistoryId = history.ID
 
 	return m, nil
 }
@@ -58,11 +60,13 @@ func (r *Repo) Update(ctx context.Context, m *model.Relationship, id uuid.UUID)
 		return nil, err
 	}
 
-	if err := session.Create(copyHistory(m, id, model.OperationTypeUpdate)).Error; err != nil {
+	history := copyHistory(m, id, model.OperationTypeUpdate)
+	if err := session.Create(history).Error; err != nil {
 		return nil, err
 	}
 
 	m.ID = id
+	m.HistoryId = history.ID
 	if err := session.Save(m).Error; err != nil {
 		return nil, err
 	}
@@ -80,9 +84,11 @@ func (r *Repo) Delete(ctx context.Context, id uuid.UUID) (*model.Relationship, e
 		return nil, err
 	}
 
-	if err := session.Create(copyHistory(relationship, relationship.ID, model.OperationTypeDelete)).Error; err != nil {
+	history := copyHistory(relationship, relationship.ID, model.OperationTypeDelete)
+	if err := session.Create(history).Error; err != nil {
 		return nil, err
 	}
+	relationship.HistoryId = history.ID
 
 	if err := session.Delete(relationship).Error; err != nil {
 		return nil, err
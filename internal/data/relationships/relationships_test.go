@@ -130,6 +130,9 @@ func assertEqualRelationship(t *testing.T, r1 *model.Relationship, r2 *model.Rel
 	r2b.CreatedAt = nil
 	r1b.UpdatedAt = nil
 	r2b.UpdatedAt = nil
+	// the history id is only known by the model returned by the change
+	r1b.HistoryId = uuid.Nil
+	r2b.HistoryId = uuid.Nil
 
 	assert.Equal(t, r1b, r2b)
 }
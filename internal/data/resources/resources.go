@@ -33,6 +33,7 @@ func copyHistory(m *model.Resource, id uuid.UUID, operationType model.OperationT
 		Labels:        m.Labels,
 		ResourceId:    id,
 		OperationType: operationType,
+		InventoryId:   m.InventoryId,
 	}
 }
 
@@ -60,10 +61,12 @@ func (r *Repo) Create(ctx context.Context, m *model.Resource) (*model.Resource,
 		return nil, nil, err
 	}
 
-	if err := tx.Create(copyHistory(m, m.ID, model.OperationTypeCreate)).Error; err != nil {
+	history := copyHistory(m, m.ID, model.OperationTypeCreate)
+	if err := tx.Create(history).Error; err != nil {
 		tx.Rollback()
 		return nil, nil, err
 	}
+	m.HistoryId = history.ID
 
 	// Handle workspace updates for other resources with the same inventory ID
 	updatedResources, err := r.handleWorkspaceUpdates(tx, m, updatedResources)
@@ -95,15 +98,21 @@ func (r *Repo) Update(ctx context.Context, m *model.Resource, id uuid.UUID) (*mo
 		return nil, nil, err
 	}
 
+	m.InventoryId = resource.InventoryId
+
 	tx := db.Begin()
-	if err := tx.Create(copyHistory(m, id, model.OperationTypeUpdate)).Error; err != nil {
+	history := copyHistory(m, id, model.OperationTypeUpdate)
+	if err := tx.Create(history).Error; err != nil {
 		tx.Rollback()
 		return nil, nil, err
 	}
 
 	m.ID = id
+	m.HistoryId = history.ID
+	if resource.WorkspaceId != m.WorkspaceId {
+		m.PreviousWorkspaceId = resource.WorkspaceId
+	}
 	m.CreatedAt = resource.CreatedAt
-	m.InventoryId = resource.InventoryId
 	if err := tx.Save(m).Error; err != nil {
 		tx.Rollback()
 		return nil, nil, err
@@ -131,10 +140,12 @@ func (r *Repo) Delete(ctx context.Context, id uuid.UUID) (*model.Resource, error
 	}
 
 	tx := db.Begin()
-	if err := tx.Create(copyHistory(resource, resource.ID, model.OperationTypeDelete)).Error; err != nil {
+	history := copyHistory(resource, resource.ID, model.OperationTypeDelete)
+	if err := tx.Create(history).Error; err != nil {
 		tx.Rollback()
 		return nil, err
 	}
+	resource.HistoryId = history.ID
 
 	// Delete relationships - We don't yet care about keeping history of deleted relationships of a deleted resource.
 	if err := tx.Where("subject_id = ? or object_id = ?", id, id).Delete(&model.Relationship{}).Error; err != nil {
@@ -166,6 +177,19 @@ func (r *Repo) Delete(ctx context.Context, id uuid.UUID) (*model.Resource, error
 	return resource, nil
 }
 
+// UpdateConsistencyToken records the consistency token of the last tuples written for the resource, without
+// writing a history row.
+func (r *Repo) UpdateConsistencyToken(ctx context.Context, id uuid.UUID, token string) error {
+	result := r.DB.Session(&gorm.Session{}).Model(&model.Resource{}).Where("id = ?", id).Update("consistency_token", token)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
 func (r *Repo) FindByID(ctx context.Context, id uuid.UUID) (*model.Resource, error) {
 	resource := model.Resource{}
 	if err := r.DB.Session(&gorm.Session{}).First(&resource, id).Error; err != nil {
@@ -188,6 +212,21 @@ func (r *Repo) FindByWorkspaceId(ctx context.Context, workspace_id string) ([]*m
 	return data, nil
 }
 
+// FindByWorkspaceIdAfter returns a page of the resources of the workspace, in the order of their ids, starting after
+// the given id.
+func (r *Repo) FindByWorkspaceIdAfter(ctx context.Context, workspace_id string, after uuid.UUID, limit int) ([]*model.Resource, error) {
+	data := []*model.Resource{}
+	err := r.DB.Session(&gorm.Session{}).WithContext(ctx).
+		Where("workspace_id = ? AND id > ?", workspace_id, after).
+		Order("id").
+		Limit(limit).
+		Find(&data).Error
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
 // Deprecated: Prefer FindByReporterData instead
 func (r *Repo) FindByReporterResourceId(ctx context.Context, id model.ReporterResourceId) (*model.Resource, error) {
 	session := r.DB.Session(&gorm.Session{})
@@ -283,6 +322,9 @@ func (r *Repo) handleWorkspaceUpdates(tx *gorm.DB, m *model.Resource, updatedRes
 					// skip the primary resource being updated
 					continue
 				}
+				if resource.WorkspaceId != m.WorkspaceId {
+					resource.PreviousWorkspaceId = resource.WorkspaceId
+				}
 				resource.WorkspaceId = m.WorkspaceId
 				if err := tx.Save(&resource).Error; err != nil {
 					return nil, fmt.Errorf("updating resource workspace ID: %w", err)
@@ -2,6 +2,7 @@ package resources
 
 import (
 	"context"
+	"fmt"
 	"testing"
 
 	"github.com/go-kratos/kratos/v2/log"
@@ -73,6 +74,11 @@ func assertEqualResource(t *testing.T, r1 *model.Resource, r2 *model.Resource) {
 	r2b.CreatedAt = nil
 	r1b.UpdatedAt = nil
 	r2b.UpdatedAt = nil
+	// the history id and previous workspace are only known by the model returned by the change
+	r1b.HistoryId = uuid.Nil
+	r2b.HistoryId = uuid.Nil
+	r1b.PreviousWorkspaceId = ""
+	r2b.PreviousWorkspaceId = ""
 
 	assert.Equal(t, r1b, r2b)
 }
@@ -91,6 +97,7 @@ func assertEqualResourceHistory(t *testing.T, r *model.Resource, rh *model.Resou
 		ResourceId:    r.ID,
 		Timestamp:     rh.Timestamp,
 		OperationType: operationType,
+		InventoryId:   r.InventoryId,
 	}
 
 	assert.Equal(t, r.CreatedAt.Unix(), rh.Timestamp.Unix())
@@ -128,6 +135,7 @@ func TestCreateResource(t *testing.T) {
 	assert.Nil(t, db.Find(&resourceHistory).Error)
 	assert.Len(t, resourceHistory, 1)
 	assertEqualResourceHistory(t, &resource, &resourceHistory[0], model.OperationTypeCreate)
+	assert.Equal(t, resourceHistory[0].ID, r.HistoryId)
 
 	// One LocalInventoryToResource mapping is also created
 	localInventoryToResource := []model.LocalInventoryToResource{}
@@ -169,6 +177,8 @@ func TestCreateResourceWithInventoryId(t *testing.T) {
 	assert.NotNil(t, r2)
 	assert.Nil(t, err)
 	assert.Len(t, updatedResources, 1) // r1 was updated
+	// r1 was moved from its workspace
+	assert.Equal(t, "my-workspace", updatedResources[0].PreviousWorkspaceId)
 
 	resource2 := model.Resource{}
 	assert.Nil(t, db.First(&resource2, r2.ID).Error)
@@ -222,6 +232,7 @@ func TestUpdateResource(t *testing.T) {
 	assert.Equal(t, r.ID, r2.ID)
 	assert.Len(t, updatedResources, 1) // r1 was updated
 	assert.Equal(t, createdAt.Unix(), r2.CreatedAt.Unix())
+	assert.Equal(t, r.WorkspaceId, r2.PreviousWorkspaceId)
 
 	// The resource is now in the database and is equal to the return value from Update
 	resource := model.Resource{}
@@ -281,6 +292,7 @@ func TestDeleteAfterCreate(t *testing.T) {
 	assert.Nil(t, db.Find(&resourceHistory).Error)
 	assert.Len(t, resourceHistory, 2)
 	assertEqualResourceHistory(t, r, &resourceHistory[1], model.OperationTypeDelete)
+	assert.Equal(t, resourceHistory[1].ID, r1del.HistoryId)
 
 	// Ensure InventoryResource is cleaned up
 	assert.Nil(t, db.Find(&inventoryResource).Count(&count).Error)
@@ -385,6 +397,35 @@ func TestFindByWorkspaceId(t *testing.T) {
 	assert.Equal(t, []*model.Resource{}, resources)
 }
 
+func TestFindByWorkspaceIdAfter(t *testing.T) {
+	db := setupGorm(t)
+	repo := New(db)
+	ctx := context.TODO()
+	for i, workspace := range []string{"1234", "1234", "other", "1234"} {
+		res := resource1()
+		res.ReporterResourceId = fmt.Sprintf("res-%d", i)
+		res.WorkspaceId = workspace
+		_, _, err := repo.Create(ctx, res)
+		require.Nil(t, err)
+	}
+
+	// pages through the resources of the workspace in the order of their ids
+	page, err := repo.FindByWorkspaceIdAfter(ctx, "1234", uuid.Nil, 2)
+	assert.Nil(t, err)
+	assert.Len(t, page, 2)
+	assert.True(t, page[0].ID.String() < page[1].ID.String())
+
+	rest, err := repo.FindByWorkspaceIdAfter(ctx, "1234", page[1].ID, 2)
+	assert.Nil(t, err)
+	assert.Len(t, rest, 1)
+	assert.True(t, page[1].ID.String() < rest[0].ID.String())
+	assert.Equal(t, "1234", rest[0].WorkspaceId)
+
+	rest, err = repo.FindByWorkspaceIdAfter(ctx, "1234", rest[0].ID, 2)
+	assert.Nil(t, err)
+	assert.Empty(t, rest)
+}
+
 func TestListAll(t *testing.T) {
 	db := setupGorm(t)
 	repo := New(db)
@@ -406,3 +447,25 @@ func TestListAll(t *testing.T) {
 	assert.Len(t, resources, 1)
 	assertEqualResource(t, resources[0], r)
 }
+
+func TestUpdateConsistencyToken(t *testing.T) {
+	db := setupGorm(t)
+	repo := New(db)
+	ctx := context.TODO()
+
+	r, _, err := repo.Create(ctx, resource1())
+	assert.Nil(t, err)
+
+	assert.Nil(t, repo.UpdateConsistencyToken(ctx, r.ID, "token-1"))
+
+	found, err := repo.FindByID(ctx, r.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, "token-1", found.ConsistencyToken)
+
+	// the token is not a change of the resource, no history is written
+	var histories []model.ResourceHistory
+	assert.Nil(t, db.Where("resource_id = ?", r.ID).Find(&histories).Error)
+	assert.Len(t, histories, 1)
+
+	assert.ErrorIs(t, repo.UpdateConsistencyToken(ctx, uuid.New(), "token-2"), gorm.ErrRecordNotFound)
+}
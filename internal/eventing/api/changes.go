@@ -0,0 +1,156 @@
+package api
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/project-kessel/inventory-api/internal/biz/model"
+)
+
+// ResourceChanges describes the change of an updated resource, over the fields carried by the resource events.
+type ResourceChanges struct {
+	// Previous holds the values of the replaced and removed fields before the change, by JSON Pointer
+	Previous map[string]interface{} `json:"previous"`
+	// Patch is the JSON Patch (RFC 6902) turning the previous data of the resource into the data of the event
+	Patch []PatchOperation `json:"patch"`
+}
+
+// PatchOperation is an add, remove or replace operation of a JSON Patch.
+type PatchOperation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value"`
+}
+
+const (
+	PatchOperationAdd     = "add"
+	PatchOperationRemove  = "remove"
+	PatchOperationReplace = "replace"
+)
+
+// MarshalJSON keeps the value of add and replace operations even when it is null.
+func (o PatchOperation) MarshalJSON() ([]byte, error) {
+	if o.Op == PatchOperationRemove {
+		return json.Marshal(struct {
+			Op   string `json:"op"`
+			Path string `json:"path"`
+		}{o.Op, o.Path})
+	}
+	return json.Marshal(struct {
+		Op    string      `json:"op"`
+		Path  string      `json:"path"`
+		Value interface{} `json:"value"`
+	}{o.Op, o.Path, o.Value})
+}
+
+// SetChanges adds the changes to the data of a resource event.
+func (e *Event) SetChanges(changes *ResourceChanges) {
+	if data, ok := e.Data.(ResourceData); ok {
+		data.Changes = changes
+		e.Data = data
+	}
+}
+
+// resourceState is the part of the event data that changes with the reports of a resource.  Its paths are the
+// paths of the event data.
+type resourceState struct {
+	Metadata struct {
+		WorkspaceId string          `json:"workspace_id"`
+		Labels      []ResourceLabel `json:"labels,omitempty"`
+	} `json:"metadata"`
+	ReporterData struct {
+		ConsoleHref     string `json:"console_href"`
+		ApiHref         string `json:"api_href"`
+		ReporterVersion string `json:"reporter_version"`
+	} `json:"reporter_data"`
+	ResourceData model.JsonObject `json:"resource_data,omitempty"`
+}
+
+// NewResourceChanges compares the resource before and after a report.  It returns nil when the report changes
+// nothing the resource events carry.
+func NewResourceChanges(previous, current *model.Resource) (*ResourceChanges, error) {
+	before, err := stateOf(previous)
+	if err != nil {
+		return nil, err
+	}
+	after, err := stateOf(current)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := &ResourceChanges{Previous: map[string]interface{}{}}
+	diff(before, after, "", changes)
+	if len(changes.Patch) == 0 {
+		return nil, nil
+	}
+	return changes, nil
+}
+
+// stateOf returns the generic JSON document of the state of the resource.
+func stateOf(resource *model.Resource) (interface{}, error) {
+	state := resourceState{ResourceData: resource.ResourceData}
+	state.Metadata.WorkspaceId = resource.WorkspaceId
+	for _, label := range resource.Labels {
+		state.Metadata.Labels = append(state.Metadata.Labels, ResourceLabel{Key: label.Key, Value: label.Value})
+	}
+	state.ReporterData.ConsoleHref = resource.ConsoleHref
+	state.ReporterData.ApiHref = resource.ApiHref
+	state.ReporterData.ReporterVersion = resource.Reporter.ReporterVersion //nolint:staticcheck
+
+	encoded, err := json.Marshal(state)
+	if err != nil {
+		return nil, err
+	}
+	var document interface{}
+	if err := json.Unmarshal(encoded, &document); err != nil {
+		return nil, err
+	}
+	return document, nil
+}
+
+// diff appends the operations turning before into after.  Objects are compared member by member, other values,
+// arrays included, are replaced as a whole.
+func diff(before, after interface{}, path string, changes *ResourceChanges) {
+	beforeObject, beforeIsObject := before.(map[string]interface{})
+	afterObject, afterIsObject := after.(map[string]interface{})
+	if !beforeIsObject || !afterIsObject {
+		if !reflect.DeepEqual(before, after) {
+			changes.Previous[path] = before
+			changes.Patch = append(changes.Patch, PatchOperation{Op: PatchOperationReplace, Path: path, Value: after})
+		}
+		return
+	}
+
+	// the members are walked in order so the patch is deterministic
+	for _, key := range sortedKeys(beforeObject) {
+		memberPath := path + "/" + escapePointer(key)
+		afterValue, ok := afterObject[key]
+		if !ok {
+			changes.Previous[memberPath] = beforeObject[key]
+			changes.Patch = append(changes.Patch, PatchOperation{Op: PatchOperationRemove, Path: memberPath})
+			continue
+		}
+		diff(beforeObject[key], afterValue, memberPath, changes)
+	}
+	for _, key := range sortedKeys(afterObject) {
+		if _, ok := beforeObject[key]; !ok {
+			changes.Patch = append(changes.Patch, PatchOperation{Op: PatchOperationAdd, Path: path + "/" + escapePointer(key), Value: afterObject[key]})
+		}
+	}
+}
+
+func sortedKeys(object map[string]interface{}) []string {
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// escapePointer escapes a member name as a JSON Pointer (RFC 6901) reference token.
+func escapePointer(key string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(key, "~", "~0"), "/", "~1")
+}
@@ -0,0 +1,79 @@
+package api
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/project-kessel/inventory-api/internal/biz/model"
+)
+
+func TestNewResourceChanges_NoOp(t *testing.T) {
+	previous := testResource()
+	previous.ResourceData = model.JsonObject{"cpu": 4, "nodes": []interface{}{"node-1"}}
+	previous.Labels = model.Labels{{Key: "env", Value: "prod"}}
+
+	current := testResource()
+	// values are compared by their JSON encoding, the stored data is decoded from JSON
+	current.ResourceData = model.JsonObject{"cpu": float64(4), "nodes": []interface{}{"node-1"}}
+	current.Labels = model.Labels{{Key: "env", Value: "prod"}}
+	// fields that aren't carried by the events aren't compared
+	current.ConsistencyToken = "token"
+	now := time.Now()
+	current.UpdatedAt = &now
+
+	changes, err := NewResourceChanges(previous, current)
+	assert.NoError(t, err)
+	assert.Nil(t, changes)
+}
+
+func TestNewResourceChanges(t *testing.T) {
+	previous := testResource()
+	previous.ResourceData = model.JsonObject{"a/b": "1", "m~n": nil, "nodes": []interface{}{"node-1"}}
+	previous.ConsoleHref = "https://console.example.com/old"
+
+	current := testResource()
+	current.ResourceData = model.JsonObject{"a/b": "2", "nodes": []interface{}{"node-1", "node-2"}, "status": nil}
+	current.ConsoleHref = "https://console.example.com/new"
+	current.Labels = model.Labels{{Key: "env", Value: "prod"}}
+
+	changes, err := NewResourceChanges(previous, current)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"/reporter_data/console_href": "https://console.example.com/old",
+		"/resource_data/a~1b":         "1",
+		"/resource_data/m~0n":         nil,
+		"/resource_data/nodes":        []interface{}{"node-1"},
+	}, changes.Previous)
+
+	patch, err := json.Marshal(changes.Patch)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `[
+		{"op": "add", "path": "/metadata/labels", "value": [{"key": "env", "value": "prod"}]},
+		{"op": "replace", "path": "/reporter_data/console_href", "value": "https://console.example.com/new"},
+		{"op": "replace", "path": "/resource_data/a~1b", "value": "2"},
+		{"op": "remove", "path": "/resource_data/m~0n"},
+		{"op": "replace", "path": "/resource_data/nodes", "value": ["node-1", "node-2"]},
+		{"op": "add", "path": "/resource_data/status", "value": null}
+	]`, string(patch))
+}
+
+func TestEvent_SetChanges(t *testing.T) {
+	changes := &ResourceChanges{
+		Previous: map[string]interface{}{"/metadata/workspace_id": "workspace-0"},
+		Patch:    []PatchOperation{{Op: PatchOperationReplace, Path: "/metadata/workspace_id", Value: "workspace-1"}},
+	}
+
+	event, err := NewResourceEvent(OperationTypeUpdated, testResource(), time.Now())
+	assert.NoError(t, err)
+	event.SetChanges(changes)
+	assert.Equal(t, changes, event.Data.(ResourceData).Changes)
+
+	// relationship events don't carry changes
+	relationshipEvent, err := NewRelationshipEvent(OperationTypeUpdated, &model.Relationship{}, time.Now())
+	assert.NoError(t, err)
+	relationshipEvent.SetChanges(changes)
+	assert.IsType(t, RelationshipData{}, relationshipEvent.Data)
+}
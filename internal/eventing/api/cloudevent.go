@@ -0,0 +1,32 @@
+package api
+
+import (
+	"context"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+)
+
+// CloudEvent returns the cloudevent attributes and extensions of the event, sent by the given source.  The data is
+// left to the producers, which choose its encoding.  The traceparent of the context is used when the event has none.
+func (e *Event) CloudEvent(ctx context.Context, source string) cloudevents.Event {
+	ce := cloudevents.NewEvent()
+
+	ce.SetSpecVersion(cloudevents.VersionV1)
+	ce.SetType(e.Type)
+	ce.SetSource(source)
+	ce.SetID(e.Id)
+	ce.SetTime(e.Time)
+	ce.SetSubject(e.Subject)
+
+	extensions := e.Extensions()
+	if _, ok := extensions["traceparent"]; !ok {
+		if traceParent := TraceParent(ctx); traceParent != "" {
+			extensions["traceparent"] = traceParent
+		}
+	}
+	for name, value := range extensions {
+		ce.SetExtension(name, value)
+	}
+
+	return ce
+}
@@ -0,0 +1,173 @@
+package api
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/xeipuuv/gojsonschema"
+	"google.golang.org/protobuf/proto"
+
+	pb "github.com/project-kessel/inventory-api/api/kessel/inventory/events/v1"
+	"github.com/project-kessel/inventory-api/internal/biz/model"
+)
+
+var update = flag.Bool("update", false, "update the golden files of the event contract")
+
+const contractDir = "../../../api/kessel/inventory/events/v1"
+
+func contractResource() *model.Resource {
+	resource := testResource()
+	resource.Reporter = model.ResourceReporter{
+		Reporter: model.Reporter{
+			ReporterId:      "reporter-instance-1",
+			ReporterType:    "ACM",
+			ReporterVersion: "2.12",
+		},
+		LocalResourceId: "cluster-1",
+	}
+	resource.ConsoleHref = "https://console.example.com/clusters/cluster-1"
+	resource.ApiHref = "https://api.example.com/clusters/cluster-1"
+	resource.Labels = model.Labels{{Key: "env", Value: "prod"}}
+	resource.ResourceData = model.JsonObject{
+		"external_cluster_id": "cluster-1",
+		"cluster_status":      "READY",
+		"nodes":               []interface{}{map[string]interface{}{"name": "node-1", "cpu": "4"}},
+	}
+	return resource
+}
+
+func contractRelationship() *model.Relationship {
+	return &model.Relationship{
+		ID:               uuid.MustParse("0194d9b4-3bc5-7c4b-93a5-0b1e3cf1e7b1"),
+		OrgId:            "org-1",
+		RelationshipType: "k8s_policy_ispropagatedto_k8s_cluster",
+		RelationshipData: model.JsonObject{"status": "VIOLATIONS"},
+		HistoryId:        uuid.MustParse("0194d9b4-3bc5-7c4b-93a5-0b1e3cf1e7b2"),
+		Reporter: model.RelationshipReporter{
+			Reporter: model.Reporter{
+				ReporterId:      "reporter-instance-1",
+				ReporterType:    "ACM",
+				ReporterVersion: "2.12",
+			},
+			SubjectLocalResourceId: "policy-1",
+			SubjectResourceType:    "k8s_policy",
+			ObjectLocalResourceId:  "cluster-1",
+			ObjectResourceType:     "k8s_cluster",
+		},
+	}
+}
+
+// TestEventContract checks every event shape against its golden files, the JSON encoding of the event and the
+// protobuf encoding of its data, and validates the JSON data against the published JSON schema.
+func TestEventContract(t *testing.T) {
+	reportedTime := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	operations := []OperationType{OperationTypeCreated, OperationTypeUpdated, OperationTypeDeleted}
+
+	type eventShape struct {
+		name   string
+		event  *Event
+		schema string
+		proto  proto.Message
+	}
+
+	var shapes []eventShape
+	for _, operation := range operations {
+		event, err := NewResourceEvent(operation, contractResource(), reportedTime)
+		assert.NoError(t, err)
+		shapes = append(shapes, eventShape{
+			name:   "resource_" + string(operation.OperationType()),
+			event:  event,
+			schema: "resource_data.schema.json",
+			proto:  &pb.ResourceData{},
+		})
+
+		event, err = NewRelationshipEvent(operation, contractRelationship(), reportedTime)
+		assert.NoError(t, err)
+		shapes = append(shapes, eventShape{
+			name:   "relationship_" + string(operation.OperationType()),
+			event:  event,
+			schema: "relationship_data.schema.json",
+			proto:  &pb.RelationshipData{},
+		})
+	}
+
+	// updated events including the changes of the resource
+	previous := contractResource()
+	previous.WorkspaceId = "workspace-0"
+	previous.ResourceData = model.JsonObject{"external_cluster_id": "cluster-1", "cluster_status": "INSTALLING", "version": "4.15"}
+	changes, err := NewResourceChanges(previous, contractResource())
+	assert.NoError(t, err)
+	event, err := NewResourceEvent(OperationTypeUpdated, contractResource(), reportedTime)
+	assert.NoError(t, err)
+	event.SetChanges(changes)
+	shapes = append(shapes, eventShape{
+		name:   "resource_updated_changes",
+		event:  event,
+		schema: "resource_data.schema.json",
+		proto:  &pb.ResourceData{},
+	})
+
+	for _, shape := range shapes {
+		t.Run(shape.name, func(t *testing.T) {
+			event, err := json.MarshalIndent(shape.event, "", "  ")
+			assert.NoError(t, err)
+			assertGolden(t, shape.name+".json", event)
+
+			msg, err := shape.event.ProtoData()
+			assert.NoError(t, err)
+			assert.IsType(t, shape.proto, msg)
+			// the text formats of protobuf aren't stable, the golden file holds the deterministic binary encoding
+			encoded, err := proto.MarshalOptions{Deterministic: true}.Marshal(msg)
+			assert.NoError(t, err)
+			assertGolden(t, shape.name+".binpb", encoded)
+
+			assert.NoError(t, proto.Unmarshal(encoded, shape.proto))
+			assert.True(t, proto.Equal(msg, shape.proto))
+
+			schema, err := filepath.Abs(filepath.Join(contractDir, shape.schema))
+			assert.NoError(t, err)
+			dataJson, err := json.Marshal(shape.event.Data)
+			assert.NoError(t, err)
+			result, err := gojsonschema.Validate(gojsonschema.NewReferenceLoader("file://"+schema), gojsonschema.NewBytesLoader(dataJson))
+			assert.NoError(t, err)
+			assert.True(t, result.Valid(), "%v", result.Errors())
+		})
+	}
+}
+
+func TestEventContract_TypeAndSchema(t *testing.T) {
+	event, err := NewResourceEvent(OperationTypeCreated, contractResource(), time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, "redhat.inventory.resources.k8s_cluster.created.v1", event.Type)
+	assert.Equal(t, ResourceDataSchema, event.DataSchema)
+
+	msg, err := event.ProtoData()
+	assert.NoError(t, err)
+	assert.Equal(t, "type.googleapis.com/kessel.inventory.events.v1.ResourceData", ProtoDataSchema(msg))
+
+	relationshipEvent, err := NewRelationshipEvent(OperationTypeDeleted, contractRelationship(), time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, "redhat.inventory.resources-relationship.k8s_policy_ispropagatedto_k8s_cluster.deleted.v1", relationshipEvent.Type)
+	assert.Equal(t, RelationshipDataSchema, relationshipEvent.DataSchema)
+}
+
+func assertGolden(t *testing.T, name string, actual []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+
+	if *update {
+		assert.NoError(t, os.MkdirAll("testdata", 0o755))
+		assert.NoError(t, os.WriteFile(path, actual, 0o644))
+		return
+	}
+
+	expected, err := os.ReadFile(path)
+	assert.NoError(t, err, "run the tests with -update to create the golden file")
+	assert.Equal(t, string(expected), string(actual))
+}
@@ -1,11 +1,15 @@
 package api
 
 import (
+	"context"
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/propagation"
+
 	"github.com/project-kessel/inventory-api/internal/biz/model"
 )
 
@@ -18,13 +22,62 @@ type Event struct {
 	Subject         string      `json:"subject"`
 	Time            time.Time   `json:"time"`
 	DataContentType string      `json:"datacontenttype"`
+	DataSchema      string      `json:"dataschema,omitempty"`
 	Data            interface{} `json:"data"`
+
+	// CloudEvents extension attributes, omitted when empty
+	OrgId        string `json:"orgid,omitempty"`
+	InventoryId  string `json:"inventoryid,omitempty"`
+	ReporterType string `json:"reportertype,omitempty"`
+	ResourceType string `json:"resourcetype,omitempty"`
+	// TraceParent is the W3C trace context of the request that caused the event, set by the producers
+	TraceParent string `json:"traceparent,omitempty"`
+}
+
+// Extensions returns the non-empty CloudEvents extension attributes of the event.
+func (e *Event) Extensions() map[string]string {
+	extensions := map[string]string{}
+	for name, value := range map[string]string{
+		"orgid":        e.OrgId,
+		"inventoryid":  e.InventoryId,
+		"reportertype": e.ReporterType,
+		"resourcetype": e.ResourceType,
+		"traceparent":  e.TraceParent,
+	} {
+		if value != "" {
+			extensions[name] = value
+		}
+	}
+	return extensions
+}
+
+// TraceParent returns the W3C traceparent of the span in the context, or an empty string without a valid span.
+func TraceParent(ctx context.Context) string {
+	carrier := propagation.MapCarrier{}
+	propagation.TraceContext{}.Inject(ctx, carrier)
+	return carrier.Get("traceparent")
+}
+
+// eventNamespace is the namespace of the name based event ids
+var eventNamespace = uuid.NewSHA1(uuid.NameSpaceURL, []byte("https://github.com/project-kessel/inventory-api/events"))
+
+// makeEventId derives the event id from the changed resource, the change and the operation, so the events sent again
+// for the same change keep their id and consumers can dedupe them.  The change is identified by the id of its history
+// row, or by the time of the change when no history row was written (e.g. persistence is disabled).
+func makeEventId(id uuid.UUID, historyId uuid.UUID, changedAt time.Time, operationType OperationType) string {
+	generation := historyId.String()
+	if historyId == uuid.Nil {
+		generation = strconv.FormatInt(changedAt.UnixNano(), 10)
+	}
+	return uuid.NewSHA1(eventNamespace, []byte(strings.Join([]string{id.String(), generation, string(operationType.OperationType())}, "/"))).String()
 }
 
 type ResourceData struct {
 	Metadata     ResourceMetadata `json:"metadata"`
 	ReporterData ResourceReporter `json:"reporter_data"`
 	ResourceData model.JsonObject `json:"resource_data,omitempty"`
+	// Changes is only set by updated events, when the changes are included
+	Changes *ResourceChanges `json:"changes,omitempty"`
 }
 
 type RelationshipData struct {
@@ -74,6 +127,18 @@ type RelationshipReporter struct {
 	ReporterInstanceId     string `json:"reporter_instance_id"`
 }
 
+const (
+	ResourceEventType     = "resources"
+	RelationshipEventType = "resources-relationship"
+
+	// EventVersion is the version of the event contract, it suffixes the event types
+	EventVersion = "v1"
+	// ResourceDataSchema is the JSON schema of the data of the resource events
+	ResourceDataSchema = "https://raw.githubusercontent.com/project-kessel/inventory-api/main/api/kessel/inventory/events/v1/resource_data.schema.json"
+	// RelationshipDataSchema is the JSON schema of the data of the relationship events
+	RelationshipDataSchema = "https://raw.githubusercontent.com/project-kessel/inventory-api/main/api/kessel/inventory/events/v1/relationship_data.schema.json"
+)
+
 type OperationType interface {
 	OperationType() operationType
 }
@@ -90,13 +155,17 @@ func (o operationType) OperationType() operationType {
 	return o
 }
 
-func NewResourceEvent(operationType OperationType, resource *model.Resource, reportedTime time.Time) (*Event, error) {
-	const eventType = "resources"
-
-	eventId, err := uuid.NewUUID() // Todo: we need to have an stable id if we implement some re-trying logic
-	if err != nil {
-		return nil, err
+// ResourceKey is the id the events of the resource are looked up with: its inventory id, shared by the reporters of
+// the resource, or its own id when it has no inventory id.
+func ResourceKey(resource *model.Resource) uuid.UUID {
+	if resource.InventoryId != nil {
+		return *resource.InventoryId
 	}
+	return resource.ID
+}
+
+func NewResourceEvent(operationType OperationType, resource *model.Resource, reportedTime time.Time) (*Event, error) {
+	const eventType = ResourceEventType
 
 	var labels []ResourceLabel
 	for _, val := range resource.Labels {
@@ -119,14 +188,29 @@ func NewResourceEvent(operationType OperationType, resource *model.Resource, rep
 		deletedAt = &reportedTime
 	}
 
+	inventoryId := ""
+	if resource.InventoryId != nil {
+		inventoryId = resource.InventoryId.String()
+	}
+
+	reporterType := resource.ReporterType
+	if reporterType == "" {
+		reporterType = resource.Reporter.ReporterType //nolint:staticcheck
+	}
+
 	return &Event{
 		Specversion:     "1.0",
 		Type:            makeEventType(eventType, resource.ResourceType, string(operationType.OperationType())),
 		Source:          "", // Todo: inventory uri
-		Id:              eventId.String(),
+		Id:              makeEventId(resource.ID, resource.HistoryId, reportedTime, operationType),
 		Subject:         makeEventSubject(eventType, resource.ResourceType, resource.ID.String()),
 		Time:            reportedTime,
 		DataContentType: "application/json",
+		DataSchema:      ResourceDataSchema,
+		OrgId:           resource.OrgId,
+		InventoryId:     inventoryId,
+		ReporterType:    reporterType,
+		ResourceType:    resource.ResourceType,
 		Data: ResourceData{
 			Metadata: ResourceMetadata{
 				Id:           resource.ID.String(),
@@ -152,12 +236,7 @@ func NewResourceEvent(operationType OperationType, resource *model.Resource, rep
 }
 
 func NewRelationshipEvent(operationType OperationType, relationship *model.Relationship, reportedTime time.Time) (*Event, error) {
-	const eventType = "resources-relationship"
-
-	eventId, err := uuid.NewUUID() // Todo: we need to have an stable id if we implement some re-trying logic
-	if err != nil {
-		return nil, err
-	}
+	const eventType = RelationshipEventType
 
 	var createdAt *time.Time
 	var updatedAt *time.Time
@@ -176,10 +255,14 @@ func NewRelationshipEvent(operationType OperationType, relationship *model.Relat
 		Specversion:     "1.0",
 		Type:            makeEventType(eventType, relationship.RelationshipType, string(operationType.OperationType())),
 		Source:          "", // Todo: inventory uri
-		Id:              eventId.String(),
+		Id:              makeEventId(relationship.ID, relationship.HistoryId, reportedTime, operationType),
 		Subject:         makeEventSubject(eventType, relationship.RelationshipType, relationship.ID.String()),
 		Time:            reportedTime,
 		DataContentType: "application/json",
+		DataSchema:      RelationshipDataSchema,
+		OrgId:           relationship.OrgId,
+		ReporterType:    relationship.Reporter.ReporterType,
+		ResourceType:    relationship.RelationshipType,
 		Data: RelationshipData{
 			Metadata: RelationshipMetadata{
 				Id:               relationship.ID.String(),
@@ -202,7 +285,7 @@ func NewRelationshipEvent(operationType OperationType, relationship *model.Relat
 }
 
 func makeEventType(eventType, resourceType, operation string) string {
-	return fmt.Sprintf("redhat.inventory.%s.%s.%s", eventType, resourceType, operation)
+	return fmt.Sprintf("redhat.inventory.%s.%s.%s.%s", eventType, resourceType, operation, EventVersion)
 }
 
 func makeEventSubject(eventType, resourceType, resourceId string) string {
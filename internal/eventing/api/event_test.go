@@ -0,0 +1,107 @@
+package api
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/project-kessel/inventory-api/internal/biz/model"
+)
+
+func testResource() *model.Resource {
+	inventoryId := uuid.MustParse("0194d9b4-3bc5-7c4b-93a5-0b1e3cf1e7a1")
+	return &model.Resource{
+		ID:           uuid.MustParse("0194d9b4-3bc5-7c4b-93a5-0b1e3cf1e7a2"),
+		InventoryId:  &inventoryId,
+		OrgId:        "org-1",
+		ResourceType: "k8s_cluster",
+		WorkspaceId:  "workspace-1",
+		ReporterType: "ACM",
+		HistoryId:    uuid.MustParse("0194d9b4-3bc5-7c4b-93a5-0b1e3cf1e7a3"),
+	}
+}
+
+func TestNewResourceEvent_DeterministicId(t *testing.T) {
+	reportedTime := time.Date(2025, 1, 2, 3, 4, 5, 6, time.UTC)
+	resource := testResource()
+
+	first, err := NewResourceEvent(OperationTypeCreated, resource, reportedTime)
+	assert.NoError(t, err)
+	second, err := NewResourceEvent(OperationTypeCreated, resource, reportedTime.Add(time.Second))
+	assert.NoError(t, err)
+	assert.Equal(t, first.Id, second.Id, "the history row identifies the change")
+
+	updated, err := NewResourceEvent(OperationTypeUpdated, resource, reportedTime)
+	assert.NoError(t, err)
+	assert.NotEqual(t, first.Id, updated.Id)
+
+	next := testResource()
+	next.HistoryId = uuid.MustParse("0194d9b4-3bc5-7c4b-93a5-0b1e3cf1e7a4")
+	nextUpdate, err := NewResourceEvent(OperationTypeUpdated, next, reportedTime)
+	assert.NoError(t, err)
+	assert.NotEqual(t, updated.Id, nextUpdate.Id)
+
+	// without a history row, the time of the change identifies it
+	withoutHistory := testResource()
+	withoutHistory.HistoryId = uuid.Nil
+	a, err := NewResourceEvent(OperationTypeDeleted, withoutHistory, reportedTime)
+	assert.NoError(t, err)
+	b, err := NewResourceEvent(OperationTypeDeleted, withoutHistory, reportedTime)
+	assert.NoError(t, err)
+	c, err := NewResourceEvent(OperationTypeDeleted, withoutHistory, reportedTime.Add(time.Nanosecond))
+	assert.NoError(t, err)
+	assert.Equal(t, a.Id, b.Id)
+	assert.NotEqual(t, a.Id, c.Id)
+}
+
+func TestNewResourceEvent_Extensions(t *testing.T) {
+	event, err := NewResourceEvent(OperationTypeCreated, testResource(), time.Now())
+	assert.NoError(t, err)
+
+	assert.Equal(t, map[string]string{
+		"orgid":        "org-1",
+		"inventoryid":  "0194d9b4-3bc5-7c4b-93a5-0b1e3cf1e7a1",
+		"reportertype": "ACM",
+		"resourcetype": "k8s_cluster",
+	}, event.Extensions())
+}
+
+func TestNewRelationshipEvent_Extensions(t *testing.T) {
+	relationship := &model.Relationship{
+		ID:               uuid.New(),
+		OrgId:            "org-1",
+		RelationshipType: "k8s_policy_ispropagatedto_k8s_cluster",
+		HistoryId:        uuid.New(),
+		Reporter: model.RelationshipReporter{
+			Reporter: model.Reporter{ReporterType: "ACM"},
+		},
+	}
+
+	event, err := NewRelationshipEvent(OperationTypeCreated, relationship, time.Now())
+	assert.NoError(t, err)
+	again, err := NewRelationshipEvent(OperationTypeCreated, relationship, time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, event.Id, again.Id)
+
+	assert.Equal(t, map[string]string{
+		"orgid":        "org-1",
+		"reportertype": "ACM",
+		"resourcetype": "k8s_policy_ispropagatedto_k8s_cluster",
+	}, event.Extensions())
+}
+
+func TestTraceParent(t *testing.T) {
+	assert.Empty(t, TraceParent(context.Background()))
+
+	spanContext := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36},
+		SpanID:     trace.SpanID{0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7},
+		TraceFlags: trace.FlagsSampled,
+	})
+	ctx := trace.ContextWithSpanContext(context.Background(), spanContext)
+	assert.Equal(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", TraceParent(ctx))
+}
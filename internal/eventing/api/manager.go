@@ -7,10 +7,35 @@ import (
 	authnapi "github.com/project-kessel/inventory-api/internal/authn/api"
 )
 
+// Route describes the event a Producer is looked up for.
+type Route struct {
+	// EventType is either ResourceEventType or RelationshipEventType
+	EventType     string
+	ResourceType  string
+	ReporterType  string
+	OperationType OperationType
+}
+
 type Manager interface {
-	// Lookup hides the logic of figuring out which topic to send an event on.
-	Lookup(identity *authnapi.Identity, resource_type string, resource_id uuid.UUID) (Producer, error)
+	// Lookup hides the logic of figuring out which topic to send an event on.  The resource_id is the inventory id
+	// of the resource the event is about, events with the same resource_id are expected to keep their order.
+	Lookup(identity *authnapi.Identity, route Route, resource_id uuid.UUID) (Producer, error)
 
 	Errs() <-chan error
 	Shutdown(ctx context.Context) error
 }
+
+// Batcher is implemented by the managers able to send the events of one change together.
+type Batcher interface {
+	// Batch runs fn and sends the events it produces, with the context it is given, atomically.
+	Batch(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// Batch runs fn within a batch of the manager when it supports them, the events produced by fn are then sent
+// atomically.  Otherwise fn just runs and its events are sent as they are produced.
+func Batch(ctx context.Context, manager Manager, fn func(ctx context.Context) error) error {
+	if batcher, ok := manager.(Batcher); ok {
+		return batcher.Batch(ctx, fn)
+	}
+	return fn(ctx)
+}
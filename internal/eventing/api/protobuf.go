@@ -0,0 +1,136 @@
+package api
+
+import (
+	"fmt"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb "github.com/project-kessel/inventory-api/api/kessel/inventory/events/v1"
+	"github.com/project-kessel/inventory-api/internal/biz/model"
+)
+
+// ProtobufContentType is the content type of the protobuf encoded event data
+const ProtobufContentType = "application/protobuf"
+
+// ProtoData returns the data of the event as its kessel.inventory.events.v1 message.
+func (e *Event) ProtoData() (proto.Message, error) {
+	switch data := e.Data.(type) {
+	case ResourceData:
+		return resourceDataToProto(data)
+	case RelationshipData:
+		return relationshipDataToProto(data)
+	default:
+		return nil, fmt.Errorf("no protobuf message for event data of type %T", e.Data)
+	}
+}
+
+// ProtoDataSchema returns the dataschema of protobuf encoded event data, the type URL of its message.
+func ProtoDataSchema(msg proto.Message) string {
+	return "type.googleapis.com/" + string(msg.ProtoReflect().Descriptor().FullName())
+}
+
+func resourceDataToProto(data ResourceData) (*pb.ResourceData, error) {
+	resourceData, err := jsonObjectToProto(data.ResourceData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert resource_data: %w", err)
+	}
+
+	changes, err := resourceChangesToProto(data.Changes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert changes: %w", err)
+	}
+
+	var labels []*pb.ResourceLabel
+	for _, label := range data.Metadata.Labels {
+		labels = append(labels, &pb.ResourceLabel{Key: label.Key, Value: label.Value})
+	}
+
+	return &pb.ResourceData{
+		Metadata: &pb.ResourceMetadata{
+			Id:           data.Metadata.Id,
+			ResourceType: data.Metadata.ResourceType,
+			OrgId:        data.Metadata.OrgId,
+			CreatedAt:    timeToProto(data.Metadata.CreatedAt),
+			UpdatedAt:    timeToProto(data.Metadata.UpdatedAt),
+			DeletedAt:    timeToProto(data.Metadata.DeletedAt),
+			WorkspaceId:  data.Metadata.WorkspaceId,
+			Labels:       labels,
+		},
+		ReporterData: &pb.ResourceReporter{
+			ReporterInstanceId: data.ReporterData.ReporterInstanceId,
+			ReporterType:       data.ReporterData.ReporterType,
+			ConsoleHref:        data.ReporterData.ConsoleHref,
+			ApiHref:            data.ReporterData.ApiHref,
+			LocalResourceId:    data.ReporterData.LocalResourceId,
+			ReporterVersion:    data.ReporterData.ReporterVersion,
+		},
+		ResourceData: resourceData,
+		Changes:      changes,
+	}, nil
+}
+
+func resourceChangesToProto(changes *ResourceChanges) (*pb.ResourceChanges, error) {
+	if changes == nil {
+		return nil, nil
+	}
+
+	previous, err := structpb.NewStruct(changes.Previous)
+	if err != nil {
+		return nil, err
+	}
+
+	var patch []*pb.PatchOperation
+	for _, operation := range changes.Patch {
+		op := &pb.PatchOperation{Op: operation.Op, Path: operation.Path}
+		if operation.Op != PatchOperationRemove {
+			if op.Value, err = structpb.NewValue(operation.Value); err != nil {
+				return nil, err
+			}
+		}
+		patch = append(patch, op)
+	}
+
+	return &pb.ResourceChanges{Previous: previous, Patch: patch}, nil
+}
+
+func relationshipDataToProto(data RelationshipData) (*pb.RelationshipData, error) {
+	resourceData, err := jsonObjectToProto(data.ResourceData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert resource_data: %w", err)
+	}
+
+	return &pb.RelationshipData{
+		Metadata: &pb.RelationshipMetadata{
+			Id:               data.Metadata.Id,
+			RelationshipType: data.Metadata.RelationshipType,
+			CreatedAt:        timeToProto(data.Metadata.CreatedAt),
+			UpdatedAt:        timeToProto(data.Metadata.UpdatedAt),
+			DeletedAt:        timeToProto(data.Metadata.DeletedAt),
+		},
+		ReporterData: &pb.RelationshipReporter{
+			ReporterType:           data.ReporterData.ReporterType,
+			SubjectLocalResourceId: data.ReporterData.SubjectLocalResourceId,
+			ObjectLocalResourceId:  data.ReporterData.ObjectLocalResourceId,
+			ReporterVersion:        data.ReporterData.ReporterVersion,
+			ReporterInstanceId:     data.ReporterData.ReporterInstanceId,
+		},
+		ResourceData: resourceData,
+	}, nil
+}
+
+func jsonObjectToProto(object model.JsonObject) (*structpb.Struct, error) {
+	if object == nil {
+		return nil, nil
+	}
+	return structpb.NewStruct(object)
+}
+
+func timeToProto(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
@@ -2,16 +2,21 @@ package eventing
 
 import (
 	"github.com/project-kessel/inventory-api/internal/eventing/kafka"
+	"github.com/project-kessel/inventory-api/internal/eventing/webhook"
 )
 
 type Config struct {
-	Eventer string
-	Kafka   *kafka.Config
+	Eventer        string
+	IncludeChanges bool
+	Kafka          *kafka.Config
+	Webhook        *webhook.Config
 }
 
 type completedConfig struct {
-	Eventer string
-	Kafka   kafka.CompletedConfig
+	Eventer        string
+	IncludeChanges bool
+	Kafka          kafka.CompletedConfig
+	Webhook        webhook.CompletedConfig
 }
 
 type CompletedConfig struct {
@@ -20,19 +25,25 @@ type CompletedConfig struct {
 
 func NewConfig(o *Options) *Config {
 	cfg := &Config{
-		Eventer: o.Eventer,
+		Eventer:        o.Eventer,
+		IncludeChanges: o.IncludeChanges,
 	}
 
 	if o.Eventer == "kafka" {
 		cfg.Kafka = kafka.NewConfig(o.Kafka)
 	}
 
+	if o.Eventer == "webhook" {
+		cfg.Webhook = webhook.NewConfig(o.Webhook)
+	}
+
 	return cfg
 }
 
 func (c *Config) Complete() (CompletedConfig, []error) {
 	cfg := &completedConfig{
-		Eventer: c.Eventer,
+		Eventer:        c.Eventer,
+		IncludeChanges: c.IncludeChanges,
 	}
 
 	if c.Eventer == "kafka" {
@@ -43,5 +54,13 @@ func (c *Config) Complete() (CompletedConfig, []error) {
 		}
 	}
 
+	if c.Eventer == "webhook" {
+		w, err := c.Webhook.Complete()
+		if err != nil {
+			return CompletedConfig{}, []error{err}
+		}
+		cfg.Webhook = w
+	}
+
 	return CompletedConfig{cfg}, nil
 }
@@ -8,6 +8,7 @@ import (
 	"github.com/project-kessel/inventory-api/internal/eventing/api"
 	"github.com/project-kessel/inventory-api/internal/eventing/kafka"
 	"github.com/project-kessel/inventory-api/internal/eventing/stdout"
+	"github.com/project-kessel/inventory-api/internal/eventing/webhook"
 )
 
 func New(c CompletedConfig, source string, logger *log.Helper) (api.Manager, error) {
@@ -20,6 +21,12 @@ func New(c CompletedConfig, source string, logger *log.Helper) (api.Manager, err
 			return nil, fmt.Errorf("failed to create kafka manager: %w", err)
 		}
 		return km, nil
+	case "webhook":
+		wm, err := webhook.New(c.Webhook, source, logger)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create webhook manager: %w", err)
+		}
+		return wm, nil
 	}
 
 	return nil, fmt.Errorf("unrecognized eventer type: %s", c.Eventer)
@@ -1,6 +1,9 @@
 package kafka
 
 import (
+	"os"
+	"time"
+
 	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
 )
 
@@ -12,8 +15,19 @@ type Config struct {
 }
 
 type completedConfig struct {
-	DefaultTopic string
-	KafkaConfig  *kafka.ConfigMap
+	DefaultTopic     string
+	TopicRoutes      []TopicRoute
+	MessageKey       string
+	TopicMessageKeys map[string]string
+	ContentMode      string
+	SpoolDir         string
+	// SpoolRetryInterval is the interval between two replays of the spool, 0 disables the background replay
+	SpoolRetryInterval   time.Duration
+	SpoolSegmentMaxBytes int64
+	// Transactional sends the events in Kafka transactions
+	Transactional      bool
+	TransactionTimeout time.Duration
+	KafkaConfig        *kafka.ConfigMap
 }
 
 type CompletedConfig struct {
@@ -28,6 +42,7 @@ func NewConfig(o *Options) *Config {
 
 func (c *Config) Complete() (CompletedConfig, error) {
 	var config *kafka.ConfigMap
+	transactionalId := os.ExpandEnv(c.TransactionalId)
 
 	if c.KafkaConfig != nil {
 		config = c.KafkaConfig
@@ -280,10 +295,40 @@ func (c *Config) Complete() (CompletedConfig, error) {
 		if err := config.SetKey("retry.backoff.max.ms", c.RetryBackoffMaxMs); err != nil {
 			return CompletedConfig{}, err
 		}
+
+		if c.EnableIdempotence || transactionalId != "" {
+			if err := config.SetKey("enable.idempotence", true); err != nil {
+				return CompletedConfig{}, err
+			}
+			// the idempotent producer keeps the order of the messages with at most 5 requests in flight
+			if err := config.SetKey("max.in.flight.requests.per.connection", min(c.MaxInFlightRequestsPerConnection, 5)); err != nil {
+				return CompletedConfig{}, err
+			}
+			if err := config.SetKey("max.in.flight", min(c.MaxInFlight, 5)); err != nil {
+				return CompletedConfig{}, err
+			}
+		}
+		if transactionalId != "" {
+			if err := config.SetKey("transactional.id", transactionalId); err != nil {
+				return CompletedConfig{}, err
+			}
+			if err := config.SetKey("transaction.timeout.ms", c.TransactionTimeoutMs); err != nil {
+				return CompletedConfig{}, err
+			}
+		}
 	}
 
 	return CompletedConfig{&completedConfig{
-		DefaultTopic: c.DefaultTopic,
-		KafkaConfig:  config,
+		DefaultTopic:         c.DefaultTopic,
+		TopicRoutes:          c.TopicRoutes,
+		MessageKey:           c.MessageKey,
+		TopicMessageKeys:     c.TopicMessageKeys,
+		ContentMode:          c.ContentMode,
+		SpoolDir:             c.SpoolDir,
+		SpoolRetryInterval:   time.Duration(c.SpoolRetryIntervalMs) * time.Millisecond,
+		SpoolSegmentMaxBytes: c.SpoolSegmentMaxBytes,
+		Transactional:        transactionalId != "",
+		TransactionTimeout:   time.Duration(c.TransactionTimeoutMs) * time.Millisecond,
+		KafkaConfig:          config,
 	}}, nil
 }
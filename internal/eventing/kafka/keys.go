@@ -0,0 +1,37 @@
+package kafka
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// MessageKeyResourceId keys the messages with the inventory id of the resource (the subject resource for
+	// relationships), so all the events of a resource land on the same partition and keep their order.
+	MessageKeyResourceId = "resource-id"
+	// MessageKeySource keys the messages with the source of the server instance.
+	MessageKeySource = "source"
+)
+
+func validateMessageKey(key string) error {
+	switch key {
+	case MessageKeyResourceId, MessageKeySource:
+		return nil
+	default:
+		return fmt.Errorf("unknown message key %s. Options are '%s' and '%s'", key, MessageKeyResourceId, MessageKeySource)
+	}
+}
+
+// messageKeyFor returns the key of the messages sent to the topic for the given resource.
+func messageKeyFor(config CompletedConfig, topic string, source string, resourceId uuid.UUID) string {
+	key := config.MessageKey
+	if topicKey, ok := config.TopicMessageKeys[topic]; ok {
+		key = topicKey
+	}
+
+	if key == MessageKeySource || resourceId == uuid.Nil {
+		return source
+	}
+	return resourceId.String()
+}
@@ -2,8 +2,14 @@ package kafka
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
 	confluent "github.com/cloudevents/sdk-go/protocol/kafka_confluent/v2"
 	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/cloudevents/sdk-go/v2/binding"
 	cecontext "github.com/cloudevents/sdk-go/v2/context"
 	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
 	"github.com/go-kratos/kratos/v2/log"
@@ -11,6 +17,7 @@ import (
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/metric"
+	"google.golang.org/protobuf/proto"
 
 	authnapi "github.com/project-kessel/inventory-api/internal/authn/api"
 	"github.com/project-kessel/inventory-api/internal/eventing/api"
@@ -22,81 +29,219 @@ type KafkaManager struct {
 	Protocol *confluent.Protocol
 	Client   cloudevents.Client
 	Errors   <-chan error
+	// Spool keeps the events that could not be delivered, it is nil when the spool is disabled
+	Spool *Spool
+	// Transactions is nil unless the producer is transactional, the events are then sent in Kafka transactions
+	Transactions Transactor
 
 	Logger *log.Helper
+
+	stopRetry chan struct{}
+	events    chan struct{}
+
+	txMu          sync.Mutex
+	txInitialized bool
 }
 
 func New(config CompletedConfig, source string, logger *log.Helper) (*KafkaManager, error) {
 	logger.Info("Using eventing: kafka")
-	if sender, err := confluent.New(
+	producer, err := kafka.NewProducer(config.KafkaConfig)
+	if err != nil {
+		return nil, err
+	}
+	sender, err := confluent.New(
 		confluent.WithSenderTopic(config.DefaultTopic),
-		confluent.WithConfigMap(config.KafkaConfig),
-	); err != nil {
+		confluent.WithSender(producer),
+	)
+	if err != nil {
 		return nil, err
-	} else {
-		client, err := cloudevents.NewClient(sender, cloudevents.WithUUIDs())
+	}
+
+	client, err := cloudevents.NewClient(sender, cloudevents.WithUUIDs())
+	if err != nil {
+		return nil, err
+	}
+
+	var spool *Spool
+	if config.SpoolDir != "" {
+		spool, err = NewSpool(config.SpoolDir, config.SpoolSegmentMaxBytes)
 		if err != nil {
 			return nil, err
 		}
+		logger.Infof("Spooling undelivered events to %s, %d event(s) waiting", config.SpoolDir, spool.Depth())
+	}
 
-		errChan := make(chan error)
+	var transactions Transactor
+	if config.Transactional {
+		transactions = producer
+		logger.Info("Sending events in kafka transactions")
+	}
 
-		go func() {
-			eventChan, err := sender.Events()
-			if err != nil {
-				logger.Errorf("failed to get events channel for sender, %v", err)
-				errChan <- err
-			} else {
-				for e := range eventChan {
-					switch ev := e.(type) {
-					case *kafka.Message:
-						// The message delivery report, indicating success or permanent failure after retries have
-						// been exhausted. Application level retries won't help since the client is already
-						// configured to do that.
-						m := ev
-						if m.TopicPartition.Error != nil {
-							logger.Errorf("Delivery failed: %v\n", m.TopicPartition.Error)
-							errChan <- err
-						} else {
-							logger.Infof("Delivered message to topic %s [%d] at offset %v\n",
-								*m.TopicPartition.Topic, m.TopicPartition.Partition, m.TopicPartition.Offset)
-						}
-					case kafka.Error:
-						e := ev
-						if e.IsFatal() {
-							logger.Errorf("Error: %v\n", ev)
-							errChan <- e
-						} else {
-							logger.Infof("Error: %v\n", ev)
-						}
-					default:
-						logger.Infof("Ignored event: %v\n", ev)
+	errChan := make(chan error)
+	m := &KafkaManager{
+		Config:   config,
+		Source:   source,
+		Protocol: sender,
+		Client:   client,
+		Errors:   errChan,
+		Spool:    spool,
+
+		Transactions: transactions,
+
+		Logger: logger,
+
+		stopRetry: make(chan struct{}),
+		events:    make(chan struct{}),
+	}
+
+	go m.handleEvents(errChan)
+
+	if spool != nil && config.SpoolRetryInterval > 0 {
+		go m.retrySpool(config.SpoolRetryInterval)
+	}
+
+	return m, nil
+}
+
+// handleEvents reads the delivery reports and errors of the producer until it is closed.
+func (m *KafkaManager) handleEvents(errChan chan<- error) {
+	defer close(m.events)
+
+	eventChan, err := m.Protocol.Events()
+	if err != nil {
+		m.Logger.Errorf("failed to get events channel for sender, %v", err)
+		errChan <- err
+		return
+	}
+
+	for e := range eventChan {
+		switch ev := e.(type) {
+		case *kafka.Message:
+			// The message delivery report, indicating success or permanent failure after retries have
+			// been exhausted. Application level retries won't help since the client is already
+			// configured to do that.
+			msg := ev
+			if msg.TopicPartition.Error != nil {
+				m.Logger.Errorf("Delivery failed: %v\n", msg.TopicPartition.Error)
+				if m.Transactions != nil {
+					// the transaction of the message is aborted, the batch it belongs to spools or reports it
+					continue
+				}
+				if m.Spool != nil {
+					// the spool retries later, when the broker may be reachable again
+					if err := m.spoolMessage(msg); err == nil {
+						continue
+					} else {
+						m.Logger.Errorf("Failed to spool undelivered event: %v", err)
 					}
 				}
+				errChan <- msg.TopicPartition.Error
+			} else {
+				m.Logger.Infof("Delivered message to topic %s [%d] at offset %v\n",
+					*msg.TopicPartition.Topic, msg.TopicPartition.Partition, msg.TopicPartition.Offset)
+			}
+		case kafka.Error:
+			e := ev
+			if e.IsFatal() {
+				m.Logger.Errorf("Error: %v\n", ev)
+				errChan <- e
+			} else {
+				m.Logger.Infof("Error: %v\n", ev)
+			}
+		default:
+			m.Logger.Infof("Ignored event: %v\n", ev)
+		}
+	}
+}
+
+func (m *KafkaManager) spoolMessage(msg *kafka.Message) error {
+	topic := m.Config.DefaultTopic
+	if msg.TopicPartition.Topic != nil {
+		topic = *msg.TopicPartition.Topic
+	}
+
+	e, err := binding.ToEvent(context.Background(), confluent.NewMessage(msg))
+	if err != nil {
+		return fmt.Errorf("failed to decode undelivered event: %w", err)
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	return m.Spool.Append(SpoolRecord{
+		Topic:     topic,
+		Key:       string(msg.Key),
+		Event:     data,
+		SpooledAt: time.Now(),
+	})
+}
+
+// retrySpool periodically sends the spooled events until the manager shuts down.
+func (m *KafkaManager) retrySpool(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.stopRetry:
+			return
+		case <-ticker.C:
+			if m.Spool.Depth() == 0 {
+				continue
+			}
+			sent, err := m.FlushSpool(context.Background())
+			if err != nil {
+				m.Logger.Warnf("Sent %d spooled event(s), %d left: %v", sent, m.Spool.Depth(), err)
+			} else {
+				m.Logger.Infof("Sent %d spooled event(s)", sent)
 			}
-		}()
+		}
+	}
+}
+
+// FlushSpool sends the spooled events in order and returns the number of events sent.  Events failing delivery
+// again are spooled back by the delivery reports.
+func (m *KafkaManager) FlushSpool(ctx context.Context) (int, error) {
+	if m.Spool == nil {
+		return 0, fmt.Errorf("the event spool is not enabled, set eventing.kafka.spool-dir")
+	}
+	return m.Spool.Drain(ctx, func(record SpoolRecord) error {
+		e := cloudevents.NewEvent()
+		if err := json.Unmarshal(record.Event, &e); err != nil {
+			return fmt.Errorf("failed to decode spooled event: %w", err)
+		}
+		if m.Transactions != nil {
+			// the event stays in the spool when its transaction fails
+			return m.sendTransaction(ctx, []pendingEvent{{topic: record.Topic, key: record.Key, event: e}})
+		}
+		return m.send(ctx, record.Topic, record.Key, e)
+	})
+}
 
-		return &KafkaManager{
-			Config:   config,
-			Source:   source,
-			Protocol: sender,
-			Client:   client,
-			Errors:   errChan,
+func (m *KafkaManager) send(ctx context.Context, topic string, key string, e cloudevents.Event) error {
+	if m.Config.ContentMode == ContentModeBinaryProtobuf {
+		ctx = cloudevents.WithEncodingBinary(ctx)
+	} else {
+		ctx = cloudevents.WithEncodingStructured(ctx)
+	}
 
-			Logger: logger,
-		}, nil
+	ret := m.Client.Send(confluent.WithMessageKey(cecontext.WithTopic(ctx, topic), key), e)
+	if cloudevents.IsUndelivered(ret) {
+		return ret
 	}
+	return nil
 }
 
 func (m *KafkaManager) Errs() <-chan error {
 	return m.Errors
 }
 
-// Lookup figures out which topic should be used for the given identity and resource.
-func (m *KafkaManager) Lookup(identity *authnapi.Identity, resource_type string, resource_id uuid.UUID) (api.Producer, error) {
-
-	// there is no complicated topic dispatch logic... for now.
-	producer, err := NewProducer(m, m.Config.DefaultTopic, identity)
+// Lookup figures out which topic should be used for the given identity and resource, using the first
+// topic route matching the event or the default topic, and which key the messages of the resource use.
+func (m *KafkaManager) Lookup(identity *authnapi.Identity, route api.Route, resource_id uuid.UUID) (api.Producer, error) {
+	topic := topicFor(m.Config.TopicRoutes, m.Config.DefaultTopic, route)
+	producer, err := NewProducer(m, topic, messageKeyFor(m.Config, topic, m.Source, resource_id), identity)
 	if err != nil {
 		return nil, err
 	}
@@ -104,12 +249,28 @@ func (m *KafkaManager) Lookup(identity *authnapi.Identity, resource_type string,
 }
 
 func (m *KafkaManager) Shutdown(ctx context.Context) error {
-	return m.Protocol.Close(ctx)
+	close(m.stopRetry)
+
+	// flushes the pending messages, failed deliveries are still spooled until the events channel is closed
+	if err := m.Protocol.Close(ctx); err != nil {
+		return err
+	}
+
+	if m.Spool != nil {
+		select {
+		case <-m.events:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		return m.Spool.Close()
+	}
+	return nil
 }
 
 type kafkaProducer struct {
 	Manager  *KafkaManager
 	Topic    string
+	Key      string
 	Identity *authnapi.Identity
 
 	Logger        *log.Helper
@@ -121,8 +282,8 @@ func NewProducerEventsCounter(meter metric.Meter, histogramName string) (metric.
 	return meter.Int64Counter(histogramName, metric.WithUnit("{event_type}"))
 }
 
-// NewProducer produces a kafka producer that is bound to a particular topic.
-func NewProducer(manager *KafkaManager, topic string, identity *authnapi.Identity) (*kafkaProducer, error) {
+// NewProducer produces a kafka producer that is bound to a particular topic and message key.
+func NewProducer(manager *KafkaManager, topic string, key string, identity *authnapi.Identity) (*kafkaProducer, error) {
 	meter := otel.Meter("github.com/project-kessel/inventory-api/blob/main/internal/server/otel")
 	eventsCounter, err := NewProducerEventsCounter(meter, "kafka_event")
 	if err != nil {
@@ -131,6 +292,7 @@ func NewProducer(manager *KafkaManager, topic string, identity *authnapi.Identit
 	return &kafkaProducer{
 		Manager:  manager,
 		Topic:    topic,
+		Key:      key,
 		Identity: identity,
 
 		Logger:        manager.Logger,
@@ -138,28 +300,33 @@ func NewProducer(manager *KafkaManager, topic string, identity *authnapi.Identit
 	}, nil
 }
 
-// Produce creates the cloud event and sends it on the Kafka Topic
+// Produce creates the cloud event and sends it on the Kafka Topic.  With transactions, events produced within a batch
+// are sent when the batch completes, other events are sent in their own transaction.
 func (p *kafkaProducer) Produce(ctx context.Context, event *api.Event) error {
-	e := cloudevents.NewEvent()
-
-	e.SetSpecVersion(cloudevents.VersionV1)
-	e.SetType(event.Type)
-	e.SetSource(p.Manager.Source)
-	e.SetID(event.Id)
-	e.SetTime(event.Time)
+	if p.Manager.Transactions != nil && transactionFrom(ctx) == nil {
+		return p.Manager.Batch(ctx, func(ctx context.Context) error {
+			return p.Produce(ctx, event)
+		})
+	}
 
-	err := e.SetData(event.DataContentType, event.Data)
-	if err != nil {
+	e := event.CloudEvent(ctx, p.Manager.Source)
+	if err := p.setData(&e, event); err != nil {
 		return err
 	}
 
-	e.SetSubject(event.Subject)
-
-	ret := p.Manager.Client.Send(confluent.WithMessageKey(cecontext.WithTopic(cloudevents.WithEncodingStructured(ctx), p.Topic), p.Manager.Source), e)
-	if cloudevents.IsUndelivered(ret) {
-		p.Logger.Infof("Failed to send %v", ret)
+	var ret error
+	if tx := transactionFrom(ctx); tx != nil {
+		tx.events = append(tx.events, pendingEvent{topic: p.Topic, key: p.Key, event: e})
 	} else {
-		p.Logger.Infof("Kafka returned: %v", ret)
+		ret = p.Manager.send(ctx, p.Topic, p.Key, e)
+		if ret != nil {
+			p.Logger.Infof("Failed to send %v", ret)
+			if p.Manager.Spool != nil {
+				ret = p.spool(e)
+			}
+		} else {
+			p.Logger.Infof("Kafka returned: %v", ret)
+		}
 	}
 
 	p.eventsCounter.Add(
@@ -171,3 +338,35 @@ func (p *kafkaProducer) Produce(ctx context.Context, event *api.Event) error {
 	)
 	return ret
 }
+
+// setData sets the data of the cloudevent, protobuf encoded in the binary-protobuf content mode.
+func (p *kafkaProducer) setData(e *cloudevents.Event, event *api.Event) error {
+	if p.Manager.Config.ContentMode != ContentModeBinaryProtobuf {
+		e.SetDataSchema(event.DataSchema)
+		return e.SetData(event.DataContentType, event.Data)
+	}
+
+	msg, err := event.ProtoData()
+	if err != nil {
+		return err
+	}
+	data, err := proto.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to encode event data: %w", err)
+	}
+	e.SetDataSchema(api.ProtoDataSchema(msg))
+	return e.SetData(api.ProtobufContentType, data)
+}
+
+// spool keeps the event in the spool of the manager, it is sent again by the retry loop.
+func (p *kafkaProducer) spool(e cloudevents.Event) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	if err := p.Manager.Spool.Append(SpoolRecord{Topic: p.Topic, Key: p.Key, Event: data, SpooledAt: time.Now()}); err != nil {
+		return fmt.Errorf("failed to spool undelivered event: %w", err)
+	}
+	p.Logger.Infof("Spooled undelivered event %s", e.ID())
+	return nil
+}
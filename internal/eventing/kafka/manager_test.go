@@ -0,0 +1,295 @@
+package kafka
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	confluent "github.com/cloudevents/sdk-go/protocol/kafka_confluent/v2"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	cecontext "github.com/cloudevents/sdk-go/v2/context"
+	"github.com/cloudevents/sdk-go/v2/protocol"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/protobuf/proto"
+
+	pb "github.com/project-kessel/inventory-api/api/kessel/inventory/events/v1"
+	authnapi "github.com/project-kessel/inventory-api/internal/authn/api"
+	"github.com/project-kessel/inventory-api/internal/biz/model"
+	"github.com/project-kessel/inventory-api/internal/eventing/api"
+)
+
+// fakeClient records the topic, message key and event of every event sent through it, and fails with err when set.
+type fakeClient struct {
+	topics []string
+	keys   []string
+	events []cloudevents.Event
+	err    error
+}
+
+func (c *fakeClient) Send(ctx context.Context, event cloudevents.Event) protocol.Result {
+	c.topics = append(c.topics, cecontext.TopicFrom(ctx))
+	c.keys = append(c.keys, confluent.MessageKeyFrom(ctx))
+	c.events = append(c.events, event)
+	return c.err
+}
+
+func (c *fakeClient) Request(ctx context.Context, event cloudevents.Event) (*cloudevents.Event, protocol.Result) {
+	return nil, nil
+}
+
+func (c *fakeClient) StartReceiver(ctx context.Context, fn interface{}) error {
+	return nil
+}
+
+var testRoutes = []TopicRoute{
+	{ResourceType: "k8s_cluster", Operation: "deleted", Topic: "cluster-deletes"},
+	{ResourceType: "k8s_cluster", Topic: "clusters"},
+	{ReporterType: "ACM", EventType: api.ResourceEventType, Topic: "acm-resources"},
+	{EventType: api.RelationshipEventType, Topic: "relationships"},
+}
+
+func TestOptions_Validate(t *testing.T) {
+	tests := []struct {
+		name   string
+		routes []TopicRoute
+		errors []string
+	}{
+		{
+			name:   "valid routes",
+			routes: testRoutes,
+		},
+		{
+			name:   "missing topic",
+			routes: []TopicRoute{{ResourceType: "k8s_cluster"}},
+			errors: []string{"invalid topic-routes[0]: missing topic"},
+		},
+		{
+			name:   "no selector",
+			routes: []TopicRoute{{Topic: "everything"}},
+			errors: []string{"invalid topic-routes[0]: route to topic everything must set at least one of resource-type, reporter-type, event-type or operation"},
+		},
+		{
+			name: "invalid event type and operation",
+			routes: []TopicRoute{
+				{EventType: "workspaces", Topic: "workspaces"},
+				{Operation: "moved", Topic: "moves"},
+			},
+			errors: []string{
+				"invalid topic-routes[0]: invalid event-type workspaces for topic workspaces. Options are 'resources' and 'resources-relationship'",
+				"invalid topic-routes[1]: invalid operation moved for topic moves. Options are 'created', 'updated' and 'deleted'",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := NewOptions()
+			options.TopicRoutes = tt.routes
+
+			var messages []string
+			for _, err := range options.Validate() {
+				messages = append(messages, err.Error())
+			}
+			assert.Equal(t, tt.errors, messages)
+		})
+	}
+
+	options := NewOptions()
+	options.DefaultTopic = ""
+	assert.Len(t, options.Validate(), 1)
+
+	options = NewOptions()
+	options.MessageKey = "org-id"
+	options.TopicMessageKeys = map[string]string{"legacy": MessageKeySource}
+	errs := options.Validate()
+	assert.Len(t, errs, 1)
+	assert.EqualError(t, errs[0], "invalid message-key: unknown message key org-id. Options are 'resource-id' and 'source'")
+}
+
+func TestKafkaManager_MessageKey(t *testing.T) {
+	resourceId := uuid.New()
+
+	tests := []struct {
+		name       string
+		messageKey string
+		topicKeys  map[string]string
+		resourceId uuid.UUID
+		key        string
+	}{
+		{
+			name:       "resource id",
+			messageKey: MessageKeyResourceId,
+			resourceId: resourceId,
+			key:        resourceId.String(),
+		},
+		{
+			name:       "source",
+			messageKey: MessageKeySource,
+			resourceId: resourceId,
+			key:        "test",
+		},
+		{
+			name:       "topic override",
+			messageKey: MessageKeyResourceId,
+			topicKeys:  map[string]string{"kessel-inventory": MessageKeySource},
+			resourceId: resourceId,
+			key:        "test",
+		},
+		{
+			name:       "no resource id falls back to the source",
+			messageKey: MessageKeyResourceId,
+			resourceId: uuid.Nil,
+			key:        "test",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &fakeClient{}
+			manager := &KafkaManager{
+				Config: CompletedConfig{&completedConfig{DefaultTopic: "kessel-inventory", MessageKey: tt.messageKey, TopicMessageKeys: tt.topicKeys}},
+				Source: "test",
+				Client: client,
+				Logger: log.NewHelper(log.DefaultLogger),
+			}
+
+			producer, err := manager.Lookup(&authnapi.Identity{Principal: "reporter"}, api.Route{EventType: api.ResourceEventType}, tt.resourceId)
+			assert.NoError(t, err)
+			assert.NoError(t, producer.Produce(context.Background(), testEvent()))
+
+			// every event of the resource gets the same key
+			assert.NoError(t, producer.Produce(context.Background(), testEvent()))
+			assert.Equal(t, []string{tt.key, tt.key}, client.keys)
+		})
+	}
+}
+
+func testEvent() *api.Event {
+	return &api.Event{
+		Id:              uuid.NewString(),
+		Type:            "redhat.inventory.resources.k8s_cluster.created",
+		Time:            time.Now(),
+		DataContentType: "application/json",
+		Data:            map[string]string{"id": "1"},
+	}
+}
+
+func TestKafkaManager_Lookup(t *testing.T) {
+	tests := []struct {
+		name  string
+		route api.Route
+		topic string
+	}{
+		{
+			name:  "first matching route wins",
+			route: api.Route{EventType: api.ResourceEventType, ResourceType: "k8s_cluster", ReporterType: "ACM", OperationType: api.OperationTypeDeleted},
+			topic: "cluster-deletes",
+		},
+		{
+			name:  "resource type is case insensitive",
+			route: api.Route{EventType: api.ResourceEventType, ResourceType: "K8S_CLUSTER", ReporterType: "OCM", OperationType: api.OperationTypeCreated},
+			topic: "clusters",
+		},
+		{
+			name:  "reporter and event type",
+			route: api.Route{EventType: api.ResourceEventType, ResourceType: "k8s_policy", ReporterType: "acm", OperationType: api.OperationTypeUpdated},
+			topic: "acm-resources",
+		},
+		{
+			name:  "relationship event",
+			route: api.Route{EventType: api.RelationshipEventType, ResourceType: "k8s_policy_ispropagatedto_k8s_cluster", ReporterType: "ACM", OperationType: api.OperationTypeCreated},
+			topic: "relationships",
+		},
+		{
+			name:  "no route matches",
+			route: api.Route{EventType: api.ResourceEventType, ResourceType: "notifications_integration", ReporterType: "NOTIFICATIONS", OperationType: api.OperationTypeCreated},
+			topic: "kessel-inventory",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &fakeClient{}
+			manager := &KafkaManager{
+				Config: CompletedConfig{&completedConfig{DefaultTopic: "kessel-inventory", TopicRoutes: testRoutes, MessageKey: MessageKeyResourceId}},
+				Source: "test",
+				Client: client,
+				Logger: log.NewHelper(log.DefaultLogger),
+			}
+
+			producer, err := manager.Lookup(&authnapi.Identity{Principal: "reporter"}, tt.route, uuid.New())
+			assert.NoError(t, err)
+
+			assert.NoError(t, producer.Produce(context.Background(), testEvent()))
+			assert.Equal(t, []string{tt.topic}, client.topics)
+		})
+	}
+}
+
+func TestKafkaProducer_Extensions(t *testing.T) {
+	client := &fakeClient{}
+	manager := &KafkaManager{
+		Config: CompletedConfig{&completedConfig{DefaultTopic: "kessel-inventory", MessageKey: MessageKeyResourceId}},
+		Source: "test",
+		Client: client,
+		Logger: log.NewHelper(log.DefaultLogger),
+	}
+
+	producer, err := manager.Lookup(&authnapi.Identity{Principal: "reporter"}, api.Route{EventType: api.ResourceEventType}, uuid.New())
+	assert.NoError(t, err)
+
+	spanContext := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36},
+		SpanID:     trace.SpanID{0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7},
+		TraceFlags: trace.FlagsSampled,
+	})
+	ctx := trace.ContextWithSpanContext(context.Background(), spanContext)
+
+	event := testEvent()
+	event.OrgId = "org-1"
+	event.ResourceType = "k8s_cluster"
+	assert.NoError(t, producer.Produce(ctx, event))
+
+	assert.Equal(t, map[string]interface{}{
+		"orgid":        "org-1",
+		"resourcetype": "k8s_cluster",
+		"traceparent":  "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+	}, client.events[0].Extensions())
+}
+
+func TestKafkaProducer_BinaryProtobuf(t *testing.T) {
+	client := &fakeClient{}
+	manager := &KafkaManager{
+		Config: CompletedConfig{&completedConfig{DefaultTopic: "kessel-inventory", MessageKey: MessageKeyResourceId, ContentMode: ContentModeBinaryProtobuf}},
+		Source: "test",
+		Client: client,
+		Logger: log.NewHelper(log.DefaultLogger),
+	}
+
+	resource := &model.Resource{
+		ID:           uuid.New(),
+		OrgId:        "org-1",
+		ResourceType: "k8s_cluster",
+		WorkspaceId:  "workspace-1",
+		ResourceData: model.JsonObject{"external_cluster_id": "cluster-1"},
+	}
+	event, err := api.NewResourceEvent(api.OperationTypeCreated, resource, time.Now())
+	assert.NoError(t, err)
+
+	producer, err := manager.Lookup(&authnapi.Identity{Principal: "reporter"}, api.Route{EventType: api.ResourceEventType}, resource.ID)
+	assert.NoError(t, err)
+	assert.NoError(t, producer.Produce(context.Background(), event))
+
+	sent := client.events[0]
+	assert.Equal(t, api.ProtobufContentType, sent.DataContentType())
+	assert.Equal(t, "type.googleapis.com/kessel.inventory.events.v1.ResourceData", sent.DataSchema())
+	assert.Equal(t, "redhat.inventory.resources.k8s_cluster.created.v1", sent.Type())
+
+	data := &pb.ResourceData{}
+	assert.NoError(t, proto.Unmarshal(sent.Data(), data))
+	assert.Equal(t, resource.ID.String(), data.GetMetadata().GetId())
+	assert.Equal(t, "cluster-1", data.GetResourceData().GetFields()["external_cluster_id"].GetStringValue())
+}
@@ -1,13 +1,41 @@
 package kafka
 
 import (
+	"fmt"
+
 	"github.com/spf13/pflag"
 )
 
+const (
+	// ContentModeStructured writes the events as JSON encoded cloudevents.
+	ContentModeStructured = "structured"
+	// ContentModeBinaryProtobuf writes the protobuf encoded event data as the message value and the cloudevent
+	// attributes as headers.
+	ContentModeBinaryProtobuf = "binary-protobuf"
+)
+
 type Options struct {
-	DefaultTopic    string `mapstructure:"default-topic"`
-	BuiltInFeatures string `mapstructure:"builtin-features"`
-	ClientId        string `mapstructure:"client-id"`
+	DefaultTopic string `mapstructure:"default-topic"`
+	// TopicRoutes are checked in order, events matching none of them are sent to DefaultTopic
+	TopicRoutes []TopicRoute `mapstructure:"topic-routes"`
+	// MessageKey selects the key of the messages, either MessageKeyResourceId or MessageKeySource
+	MessageKey string `mapstructure:"message-key"`
+	// TopicMessageKeys overrides MessageKey for the given topics
+	TopicMessageKeys map[string]string `mapstructure:"topic-message-keys"`
+	// ContentMode is either ContentModeStructured or ContentModeBinaryProtobuf
+	ContentMode string `mapstructure:"content-mode"`
+	// SpoolDir is the directory undeliverable events are written to, the spool is disabled when empty
+	SpoolDir             string `mapstructure:"spool-dir"`
+	SpoolRetryIntervalMs int    `mapstructure:"spool-retry-interval-ms"`
+	SpoolSegmentMaxBytes int64  `mapstructure:"spool-segment-max-bytes"`
+	// EnableIdempotence makes the producer write every message exactly once and in order per partition
+	EnableIdempotence bool `mapstructure:"enable-idempotence"`
+	// TransactionalId enables the transactional producer, it must be unique per replica.  Environment variables
+	// are expanded, e.g. inventory-api-${HOSTNAME}.
+	TransactionalId      string `mapstructure:"transactional-id"`
+	TransactionTimeoutMs int    `mapstructure:"transaction-timeout-ms"`
+	BuiltInFeatures      string `mapstructure:"builtin-features"`
+	ClientId             string `mapstructure:"client-id"`
 	//MetadataBrokerList                 string `mapstructure:"metadata-broker-list"`
 	BootstrapServers                   string `mapstructure:"bootstrap-servers"`
 	MessageMaxBytes                    int    `mapstructure:"message-max-bytes"`
@@ -92,9 +120,17 @@ type Options struct {
 
 func NewOptions() *Options {
 	return &Options{
-		DefaultTopic:    "kessel-inventory",
-		BuiltInFeatures: "gzip, snappy, ssl, sasl, regex, lz4, sasl_plain, sasl_scram, plugins, zstd, sasl_oauthbearer, http, oidc",
-		ClientId:        "rdkafka",
+		DefaultTopic:         "kessel-inventory",
+		MessageKey:           MessageKeyResourceId,
+		ContentMode:          ContentModeStructured,
+		SpoolDir:             "",
+		SpoolRetryIntervalMs: 30000,
+		SpoolSegmentMaxBytes: 16 * 1024 * 1024,
+		EnableIdempotence:    false,
+		TransactionalId:      "",
+		TransactionTimeoutMs: 60000,
+		BuiltInFeatures:      "gzip, snappy, ssl, sasl, regex, lz4, sasl_plain, sasl_scram, plugins, zstd, sasl_oauthbearer, http, oidc",
+		ClientId:             "rdkafka",
 		//MetadataBrokerList:                 "",
 		BootstrapServers:                   "",
 		MessageMaxBytes:                    1000000,
@@ -184,6 +220,14 @@ func (o *Options) AddFlags(fs *pflag.FlagSet, prefix string) {
 	}
 
 	fs.StringVar(&o.DefaultTopic, prefix+"default-topic", o.DefaultTopic, "The topic to use.")
+	fs.StringVar(&o.SpoolDir, prefix+"spool-dir", o.SpoolDir, "Directory where events that can't be delivered are spooled until they are sent again.  Undelivered events are dropped when empty.")
+	fs.IntVar(&o.SpoolRetryIntervalMs, prefix+"spool-retry-interval-ms", o.SpoolRetryIntervalMs, "Interval in milliseconds between two attempts to send the spooled events.")
+	fs.BoolVar(&o.EnableIdempotence, prefix+"enable-idempotence", o.EnableIdempotence, "Make the producer write every message exactly once and in order per partition.")
+	fs.StringVar(&o.TransactionalId, prefix+"transactional-id", o.TransactionalId, "Send the events of an API call in a single Kafka transaction, using this transactional.id.  It must be unique per replica, environment variables are expanded (e.g. inventory-api-${HOSTNAME}).  Implies enable-idempotence.")
+	fs.IntVar(&o.TransactionTimeoutMs, prefix+"transaction-timeout-ms", o.TransactionTimeoutMs, "Maximum time in milliseconds a transaction may stay open before the broker aborts it.")
+	fs.Int64Var(&o.SpoolSegmentMaxBytes, prefix+"spool-segment-max-bytes", o.SpoolSegmentMaxBytes, "Maximum size in bytes of a spool segment file.")
+	fs.StringVar(&o.ContentMode, prefix+"content-mode", o.ContentMode, "How events are written to Kafka.  Either structured, a JSON encoded cloudevent, or binary-protobuf, the protobuf encoded event data with the cloudevent attributes in headers.")
+	fs.StringVar(&o.MessageKey, prefix+"message-key", o.MessageKey, "The key of the produced messages.  Either resource-id, to keep the events of a resource ordered on one partition, or source.")
 
 	fs.StringVar(&o.BuiltInFeatures, prefix+"builtin-features", o.BuiltInFeatures, "Indicates the builtin features for this build of librdkafka. An application can either query this value or attempt to set it with its list of required features to check for library support. \n*Type: CSV flags*")
 	fs.StringVar(&o.ClientId, prefix+"client-id", o.BuiltInFeatures, "Client identifier. \n*Type: string*")
@@ -272,6 +316,42 @@ func (o *Options) AddFlags(fs *pflag.FlagSet, prefix string) {
 func (o *Options) Validate() []error {
 	var errs []error
 
+	if o.DefaultTopic == "" {
+		errs = append(errs, fmt.Errorf("default-topic must be set"))
+	}
+
+	for i, route := range o.TopicRoutes {
+		if err := route.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("invalid topic-routes[%d]: %w", i, err))
+		}
+	}
+
+	if o.ContentMode != ContentModeStructured && o.ContentMode != ContentModeBinaryProtobuf {
+		errs = append(errs, fmt.Errorf("invalid content-mode %s. Options are '%s' and '%s'", o.ContentMode, ContentModeStructured, ContentModeBinaryProtobuf))
+	}
+
+	if err := validateMessageKey(o.MessageKey); err != nil {
+		errs = append(errs, fmt.Errorf("invalid message-key: %w", err))
+	}
+	if o.SpoolDir != "" {
+		if o.SpoolRetryIntervalMs <= 0 {
+			errs = append(errs, fmt.Errorf("spool-retry-interval-ms must be positive"))
+		}
+		if o.SpoolSegmentMaxBytes <= 0 {
+			errs = append(errs, fmt.Errorf("spool-segment-max-bytes must be positive"))
+		}
+	}
+
+	if o.TransactionalId != "" && o.TransactionTimeoutMs <= 0 {
+		errs = append(errs, fmt.Errorf("transaction-timeout-ms must be positive"))
+	}
+
+	for topic, key := range o.TopicMessageKeys {
+		if err := validateMessageKey(key); err != nil {
+			errs = append(errs, fmt.Errorf("invalid topic-message-keys[%s]: %w", topic, err))
+		}
+	}
+
 	return errs
 }
 
@@ -0,0 +1,71 @@
+package kafka
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/project-kessel/inventory-api/internal/eventing/api"
+)
+
+// TopicRoute sends the events matching all of its non-empty fields to Topic.
+type TopicRoute struct {
+	ResourceType string `mapstructure:"resource-type"`
+	ReporterType string `mapstructure:"reporter-type"`
+	// EventType is either "resources" or "resources-relationship"
+	EventType string `mapstructure:"event-type"`
+	// Operation is one of "created", "updated" or "deleted"
+	Operation string `mapstructure:"operation"`
+	Topic     string `mapstructure:"topic"`
+}
+
+func (r TopicRoute) Validate() error {
+	if r.Topic == "" {
+		return fmt.Errorf("missing topic")
+	}
+
+	if r.ResourceType == "" && r.ReporterType == "" && r.EventType == "" && r.Operation == "" {
+		return fmt.Errorf("route to topic %s must set at least one of resource-type, reporter-type, event-type or operation", r.Topic)
+	}
+
+	switch r.EventType {
+	case "", api.ResourceEventType, api.RelationshipEventType:
+	default:
+		return fmt.Errorf("invalid event-type %s for topic %s. Options are '%s' and '%s'", r.EventType, r.Topic, api.ResourceEventType, api.RelationshipEventType)
+	}
+
+	switch r.Operation {
+	case "", string(api.OperationTypeCreated), string(api.OperationTypeUpdated), string(api.OperationTypeDeleted):
+	default:
+		return fmt.Errorf("invalid operation %s for topic %s. Options are '%s', '%s' and '%s'", r.Operation, r.Topic, api.OperationTypeCreated, api.OperationTypeUpdated, api.OperationTypeDeleted)
+	}
+
+	return nil
+}
+
+// Matches reports whether the event described by the route is sent to the topic.
+// Resource and reporter types are compared case-insensitively.
+func (r TopicRoute) Matches(route api.Route) bool {
+	if r.ResourceType != "" && !strings.EqualFold(r.ResourceType, route.ResourceType) {
+		return false
+	}
+	if r.ReporterType != "" && !strings.EqualFold(r.ReporterType, route.ReporterType) {
+		return false
+	}
+	if r.EventType != "" && r.EventType != route.EventType {
+		return false
+	}
+	if r.Operation != "" && (route.OperationType == nil || r.Operation != string(route.OperationType.OperationType())) {
+		return false
+	}
+	return true
+}
+
+// topicFor returns the topic of the first route matching the event, or the default topic.
+func topicFor(routes []TopicRoute, defaultTopic string, route api.Route) string {
+	for _, r := range routes {
+		if r.Matches(route) {
+			return r.Topic
+		}
+	}
+	return defaultTopic
+}
@@ -0,0 +1,330 @@
+package kafka
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/metric"
+)
+
+const (
+	segmentPrefix = "segment-"
+	segmentSuffix = ".log"
+)
+
+// ErrSpoolLocked is returned when another process, e.g. a running server, has the spool directory open.
+var ErrSpoolLocked = errors.New("the event spool is in use by another process")
+
+// SpoolRecord is an event that could not be delivered to Kafka.
+type SpoolRecord struct {
+	Topic string `json:"topic"`
+	Key   string `json:"key"`
+	// Event is the structured JSON encoding of the cloudevent
+	Event     json.RawMessage `json:"event"`
+	SpooledAt time.Time       `json:"spooled_at"`
+}
+
+// Spool stores undeliverable events in append-only segment files, one JSON record per line, until they can be
+// sent again.  Segments are replayed oldest first and removed once all their records are sent.
+type Spool struct {
+	dir             string
+	segmentMaxBytes int64
+	// lock is the spool directory, opened with an exclusive flock held until the spool is closed
+	lock *os.File
+	// draining serializes the drains, which replay the segments without holding mu
+	draining sync.Mutex
+
+	mu          sync.Mutex
+	current     *os.File
+	currentSize int64
+	nextSegment int
+	depth       int64
+}
+
+// NewSpool opens the spool in the given directory, creating it if needed, and counts the records already spooled.
+// The directory is locked until the spool is closed, opening it while another process holds it fails with
+// ErrSpoolLocked.
+func NewSpool(dir string, segmentMaxBytes int64) (*Spool, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create spool directory: %w", err)
+	}
+
+	lock, err := lockDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Spool{dir: dir, segmentMaxBytes: segmentMaxBytes, lock: lock}
+
+	segments, err := s.segments()
+	if err != nil {
+		_ = lock.Close()
+		return nil, err
+	}
+	for _, segment := range segments {
+		records, err := readSegment(segment.path)
+		if err != nil {
+			_ = lock.Close()
+			return nil, err
+		}
+		s.depth += int64(len(records))
+		s.nextSegment = segment.number + 1
+	}
+
+	meter := otel.Meter("github.com/project-kessel/inventory-api/blob/main/internal/server/otel")
+	if _, err := meter.Int64ObservableGauge("kafka_spool_depth",
+		metric.WithDescription("Number of undelivered events waiting in the spool"),
+		metric.WithUnit("{event}"),
+		metric.WithInt64Callback(func(_ context.Context, o metric.Int64Observer) error {
+			o.Observe(s.Depth())
+			return nil
+		}),
+	); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// Depth returns the number of records in the spool.
+func (s *Spool) Depth() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.depth
+}
+
+// Append writes the record to the current segment, starting a new segment when the current one is full.
+func (s *Spool) Append(record SpoolRecord) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.current != nil && s.currentSize+int64(len(line)) > s.segmentMaxBytes {
+		if err := s.closeCurrent(); err != nil {
+			return err
+		}
+	}
+	if s.current == nil {
+		path := filepath.Join(s.dir, fmt.Sprintf("%s%020d%s", segmentPrefix, s.nextSegment, segmentSuffix))
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o640)
+		if err != nil {
+			return fmt.Errorf("failed to open spool segment: %w", err)
+		}
+		s.current = f
+		s.currentSize = 0
+		s.nextSegment++
+	}
+
+	if _, err := s.current.Write(line); err != nil {
+		return fmt.Errorf("failed to write spool segment: %w", err)
+	}
+	if err := s.current.Sync(); err != nil {
+		return fmt.Errorf("failed to sync spool segment: %w", err)
+	}
+	s.currentSize += int64(len(line))
+	s.depth++
+	return nil
+}
+
+// Drain sends the spooled records in order.  It stops at the first record that fails to send, keeping it and the
+// records after it in the spool, and returns the number of records sent.  Records can be appended while it sends,
+// they go to a new segment replayed by the next drain.
+func (s *Spool) Drain(ctx context.Context, send func(SpoolRecord) error) (int, error) {
+	s.draining.Lock()
+	defer s.draining.Unlock()
+
+	segments, err := s.closedSegments()
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, segment := range segments {
+		records, err := readSegment(segment.path)
+		if err != nil {
+			return sent, err
+		}
+
+		for i, record := range records {
+			err := ctx.Err()
+			if err == nil {
+				err = send(record)
+			}
+			if err != nil {
+				if rewriteErr := rewriteSegment(segment.path, records[i:]); rewriteErr != nil {
+					return sent, rewriteErr
+				}
+				return sent, err
+			}
+			sent++
+			s.mu.Lock()
+			s.depth--
+			s.mu.Unlock()
+		}
+
+		if err := os.Remove(segment.path); err != nil {
+			return sent, fmt.Errorf("failed to remove spool segment: %w", err)
+		}
+	}
+
+	return sent, nil
+}
+
+// closedSegments closes the current segment, so that new records go to a new segment, and returns the segments to
+// replay.
+func (s *Spool) closedSegments() ([]segment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.closeCurrent(); err != nil {
+		return nil, err
+	}
+	return s.segments()
+}
+
+// Close closes the current segment and releases the spool directory.
+func (s *Spool) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	err := s.closeCurrent()
+	if s.lock != nil {
+		// closing the directory releases its flock
+		if closeErr := s.lock.Close(); err == nil {
+			err = closeErr
+		}
+		s.lock = nil
+	}
+	return err
+}
+
+// lockDir opens the directory with an exclusive flock, without waiting for it.
+func lockDir(dir string) (*os.File, error) {
+	f, err := os.Open(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open spool directory: %w", err)
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		_ = f.Close()
+		if errors.Is(err, syscall.EWOULDBLOCK) {
+			return nil, fmt.Errorf("%w: %s", ErrSpoolLocked, dir)
+		}
+		return nil, fmt.Errorf("failed to lock spool directory: %w", err)
+	}
+	return f, nil
+}
+
+func (s *Spool) closeCurrent() error {
+	if s.current == nil {
+		return nil
+	}
+	err := s.current.Close()
+	s.current = nil
+	return err
+}
+
+type segment struct {
+	path   string
+	number int
+}
+
+func (s *Spool) segments() ([]segment, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read spool directory: %w", err)
+	}
+
+	var segments []segment
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, segmentPrefix) || !strings.HasSuffix(name, segmentSuffix) {
+			continue
+		}
+		var number int
+		if _, err := fmt.Sscanf(strings.TrimSuffix(strings.TrimPrefix(name, segmentPrefix), segmentSuffix), "%d", &number); err != nil {
+			continue
+		}
+		segments = append(segments, segment{path: filepath.Join(s.dir, name), number: number})
+	}
+
+	sort.Slice(segments, func(i, j int) bool { return segments[i].number < segments[j].number })
+	return segments, nil
+}
+
+func readSegment(path string) ([]SpoolRecord, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open spool segment: %w", err)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	var records []SpoolRecord
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var record SpoolRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			// a partially written last line is left behind by a crash while appending, skip it
+			continue
+		}
+		records = append(records, record)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read spool segment %s: %w", path, err)
+	}
+	return records, nil
+}
+
+// rewriteSegment replaces the segment with the given records.
+func rewriteSegment(path string, records []SpoolRecord) error {
+	tmp := path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o640)
+	if err != nil {
+		return fmt.Errorf("failed to rewrite spool segment: %w", err)
+	}
+
+	writer := bufio.NewWriter(f)
+	for _, record := range records {
+		line, err := json.Marshal(record)
+		if err != nil {
+			_ = f.Close()
+			return err
+		}
+		if _, err := writer.Write(append(line, '\n')); err != nil {
+			_ = f.Close()
+			return fmt.Errorf("failed to rewrite spool segment: %w", err)
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to rewrite spool segment: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to rewrite spool segment: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to rewrite spool segment: %w", err)
+	}
+
+	return os.Rename(tmp, path)
+}
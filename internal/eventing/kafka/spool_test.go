@@ -0,0 +1,181 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	authnapi "github.com/project-kessel/inventory-api/internal/authn/api"
+	"github.com/project-kessel/inventory-api/internal/eventing/api"
+)
+
+func spoolRecord(i int) SpoolRecord {
+	return SpoolRecord{Topic: "kessel-inventory", Key: fmt.Sprintf("key-%d", i), Event: json.RawMessage(fmt.Sprintf(`{"id":"%d"}`, i))}
+}
+
+func TestSpool_AppendAndDrain(t *testing.T) {
+	dir := t.TempDir()
+
+	// small segments, every record starts a new segment
+	spool, err := NewSpool(dir, 10)
+	assert.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, spool.Append(spoolRecord(i)))
+	}
+	assert.Equal(t, int64(3), spool.Depth())
+
+	entries, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 3)
+
+	var keys []string
+	sent, err := spool.Drain(context.Background(), func(record SpoolRecord) error {
+		keys = append(keys, record.Key)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, sent)
+	assert.Equal(t, []string{"key-0", "key-1", "key-2"}, keys)
+	assert.Equal(t, int64(0), spool.Depth())
+
+	entries, err = os.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestSpool_DrainKeepsUnsentRecords(t *testing.T) {
+	dir := t.TempDir()
+
+	spool, err := NewSpool(dir, 1024)
+	assert.NoError(t, err)
+	for i := 0; i < 4; i++ {
+		assert.NoError(t, spool.Append(spoolRecord(i)))
+	}
+
+	sent, err := spool.Drain(context.Background(), func(record SpoolRecord) error {
+		if record.Key == "key-2" {
+			return errors.New("broker unreachable")
+		}
+		return nil
+	})
+	assert.EqualError(t, err, "broker unreachable")
+	assert.Equal(t, 2, sent)
+	assert.Equal(t, int64(2), spool.Depth())
+
+	// records appended after a drain go after the unsent ones
+	assert.NoError(t, spool.Append(spoolRecord(4)))
+	assert.NoError(t, spool.Close())
+
+	// the depth survives a restart
+	spool, err = NewSpool(dir, 1024)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), spool.Depth())
+
+	var keys []string
+	sent, err = spool.Drain(context.Background(), func(record SpoolRecord) error {
+		keys = append(keys, record.Key)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, sent)
+	assert.Equal(t, []string{"key-2", "key-3", "key-4"}, keys)
+}
+
+func TestSpool_AppendWhileDraining(t *testing.T) {
+	dir := t.TempDir()
+
+	spool, err := NewSpool(dir, 1024)
+	assert.NoError(t, err)
+	for i := 0; i < 2; i++ {
+		assert.NoError(t, spool.Append(spoolRecord(i)))
+	}
+
+	// a failed delivery spools its event while the drain is sending, e.g. from the producer's event loop
+	var keys []string
+	sent, err := spool.Drain(context.Background(), func(record SpoolRecord) error {
+		keys = append(keys, record.Key)
+		if record.Key == "key-0" {
+			return spool.Append(spoolRecord(2))
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, sent)
+	assert.Equal(t, []string{"key-0", "key-1"}, keys)
+	assert.Equal(t, int64(1), spool.Depth())
+
+	// the record appended meanwhile is replayed by the next drain
+	keys = nil
+	sent, err = spool.Drain(context.Background(), func(record SpoolRecord) error {
+		keys = append(keys, record.Key)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, sent)
+	assert.Equal(t, []string{"key-2"}, keys)
+	assert.NoError(t, spool.Close())
+}
+
+func TestSpool_LocksTheDirectory(t *testing.T) {
+	dir := t.TempDir()
+
+	spool, err := NewSpool(dir, 1024)
+	assert.NoError(t, err)
+	assert.NoError(t, spool.Append(spoolRecord(0)))
+
+	// e.g. flush-spool while the server spools to the directory
+	_, err = NewSpool(dir, 1024)
+	assert.ErrorIs(t, err, ErrSpoolLocked)
+
+	assert.NoError(t, spool.Close())
+	spool, err = NewSpool(dir, 1024)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), spool.Depth())
+	assert.NoError(t, spool.Close())
+}
+
+func TestKafkaManager_SpoolsUndeliveredEvents(t *testing.T) {
+	spool, err := NewSpool(t.TempDir(), 1024*1024)
+	assert.NoError(t, err)
+
+	client := &fakeClient{err: errors.New("local queue full")}
+	manager := &KafkaManager{
+		Config: CompletedConfig{&completedConfig{DefaultTopic: "kessel-inventory", MessageKey: MessageKeyResourceId}},
+		Source: "test",
+		Client: client,
+		Spool:  spool,
+		Logger: log.NewHelper(log.DefaultLogger),
+	}
+
+	resourceId := uuid.New()
+	producer, err := manager.Lookup(&authnapi.Identity{Principal: "reporter"}, api.Route{EventType: api.ResourceEventType}, resourceId)
+	assert.NoError(t, err)
+
+	event := testEvent()
+	assert.NoError(t, producer.Produce(context.Background(), event))
+	assert.Equal(t, int64(1), spool.Depth())
+
+	// still unreachable, the event stays in the spool
+	sent, err := manager.FlushSpool(context.Background())
+	assert.Error(t, err)
+	assert.Equal(t, 0, sent)
+	assert.Equal(t, int64(1), spool.Depth())
+
+	client.err = nil
+	sent, err = manager.FlushSpool(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, sent)
+	assert.Equal(t, int64(0), spool.Depth())
+
+	assert.Equal(t, []string{"kessel-inventory", "kessel-inventory", "kessel-inventory"}, client.topics)
+	assert.Equal(t, []string{resourceId.String(), resourceId.String(), resourceId.String()}, client.keys)
+	assert.Equal(t, event.Id, client.events[2].ID())
+	assert.Equal(t, event.Type, client.events[2].Type())
+}
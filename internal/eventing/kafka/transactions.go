@@ -0,0 +1,132 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+)
+
+// commitAttempts bounds the attempts to commit a transaction failing with retriable errors
+const commitAttempts = 3
+
+// Transactor is the transactional API of the confluent producer.
+type Transactor interface {
+	InitTransactions(ctx context.Context) error
+	BeginTransaction() error
+	CommitTransaction(ctx context.Context) error
+	AbortTransaction(ctx context.Context) error
+}
+
+type transactionKey struct{}
+
+// transaction collects the events produced within a batch, they are sent when the batch completes.
+type transaction struct {
+	events []pendingEvent
+}
+
+type pendingEvent struct {
+	topic string
+	key   string
+	event cloudevents.Event
+}
+
+func transactionFrom(ctx context.Context) *transaction {
+	tx, _ := ctx.Value(transactionKey{}).(*transaction)
+	return tx
+}
+
+// Batch sends the events produced by fn in a single Kafka transaction when the producer is transactional, consumers
+// reading committed messages see all of them or none.  The events are sent once fn returns, nothing is sent when it
+// fails.  Without transactions, the events are sent as they are produced.
+func (m *KafkaManager) Batch(ctx context.Context, fn func(ctx context.Context) error) error {
+	if m.Transactions == nil || transactionFrom(ctx) != nil {
+		return fn(ctx)
+	}
+
+	tx := &transaction{}
+	if err := fn(context.WithValue(ctx, transactionKey{}, tx)); err != nil {
+		return err
+	}
+	if len(tx.events) == 0 {
+		return nil
+	}
+
+	err := m.sendTransaction(ctx, tx.events)
+	if err == nil || m.Spool == nil {
+		return err
+	}
+
+	// the spool sends the events again, each in its own transaction
+	m.Logger.Warnf("Failed to send a transaction of %d event(s), spooling them: %v", len(tx.events), err)
+	for _, pending := range tx.events {
+		data, err := json.Marshal(pending.event)
+		if err != nil {
+			return err
+		}
+		if err := m.Spool.Append(SpoolRecord{Topic: pending.topic, Key: pending.key, Event: data, SpooledAt: time.Now()}); err != nil {
+			return fmt.Errorf("failed to spool undelivered event: %w", err)
+		}
+	}
+	return nil
+}
+
+// sendTransaction sends the events in a transaction, aborted when any of them can't be sent.  A transactional
+// producer runs one transaction at a time, the transactions of concurrent calls are serialized.
+func (m *KafkaManager) sendTransaction(ctx context.Context, events []pendingEvent) error {
+	m.txMu.Lock()
+	defer m.txMu.Unlock()
+
+	// the transaction outlives a cancelled request, leaving it open would block the next ones
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), m.Config.TransactionTimeout)
+	defer cancel()
+
+	// initialized lazily, so the server starts while the brokers are unreachable
+	if !m.txInitialized {
+		if err := m.Transactions.InitTransactions(ctx); err != nil {
+			return fmt.Errorf("failed to initialize kafka transactions: %w", err)
+		}
+		m.txInitialized = true
+	}
+
+	if err := m.Transactions.BeginTransaction(); err != nil {
+		return fmt.Errorf("failed to begin kafka transaction: %w", err)
+	}
+	for _, pending := range events {
+		if err := m.send(ctx, pending.topic, pending.key, pending.event); err != nil {
+			m.abortTransaction(ctx)
+			return err
+		}
+	}
+	return m.commitTransaction(ctx)
+}
+
+func (m *KafkaManager) commitTransaction(ctx context.Context) error {
+	var err error
+	for attempt := 0; attempt < commitAttempts; attempt++ {
+		if err = m.Transactions.CommitTransaction(ctx); err == nil {
+			return nil
+		}
+
+		var kafkaErr kafka.Error
+		if !errors.As(err, &kafkaErr) || !kafkaErr.IsRetriable() {
+			break
+		}
+	}
+
+	var kafkaErr kafka.Error
+	if !errors.As(err, &kafkaErr) || kafkaErr.TxnRequiresAbort() || kafkaErr.IsRetriable() {
+		m.abortTransaction(ctx)
+	}
+	return fmt.Errorf("failed to commit kafka transaction: %w", err)
+}
+
+func (m *KafkaManager) abortTransaction(ctx context.Context) {
+	if err := m.Transactions.AbortTransaction(ctx); err != nil {
+		m.Logger.Errorf("Failed to abort kafka transaction: %v", err)
+	}
+}
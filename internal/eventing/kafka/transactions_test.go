@@ -0,0 +1,188 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	authnapi "github.com/project-kessel/inventory-api/internal/authn/api"
+	"github.com/project-kessel/inventory-api/internal/eventing/api"
+)
+
+// fakeTransactor records the transaction calls, with the number of events sent by the client at the time, and
+// fails the commits with commitErr when set.
+type fakeTransactor struct {
+	client    *fakeClient
+	calls     []string
+	commitErr error
+}
+
+func (t *fakeTransactor) record(call string) {
+	t.calls = append(t.calls, fmt.Sprintf("%s@%d", call, len(t.client.events)))
+}
+
+func (t *fakeTransactor) InitTransactions(ctx context.Context) error {
+	t.record("init")
+	return nil
+}
+
+func (t *fakeTransactor) BeginTransaction() error {
+	t.record("begin")
+	return nil
+}
+
+func (t *fakeTransactor) CommitTransaction(ctx context.Context) error {
+	t.record("commit")
+	return t.commitErr
+}
+
+func (t *fakeTransactor) AbortTransaction(ctx context.Context) error {
+	t.record("abort")
+	return nil
+}
+
+func transactionalManager(spool *Spool) (*KafkaManager, *fakeClient, *fakeTransactor) {
+	client := &fakeClient{}
+	transactor := &fakeTransactor{client: client}
+	return &KafkaManager{
+		Config:       CompletedConfig{&completedConfig{DefaultTopic: "kessel-inventory", MessageKey: MessageKeyResourceId, Transactional: true, TransactionTimeout: time.Minute}},
+		Source:       "test",
+		Client:       client,
+		Spool:        spool,
+		Transactions: transactor,
+		Logger:       log.NewHelper(log.DefaultLogger),
+	}, client, transactor
+}
+
+func produceBatch(t *testing.T, manager api.Manager, events int) error {
+	producer, err := manager.Lookup(&authnapi.Identity{Principal: "reporter"}, api.Route{EventType: api.ResourceEventType}, uuid.New())
+	assert.NoError(t, err)
+
+	return api.Batch(context.Background(), manager, func(ctx context.Context) error {
+		for i := 0; i < events; i++ {
+			if err := producer.Produce(ctx, testEvent()); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func TestKafkaManager_Batch(t *testing.T) {
+	manager, client, transactor := transactionalManager(nil)
+
+	assert.NoError(t, produceBatch(t, manager, 2))
+	assert.NoError(t, produceBatch(t, manager, 1))
+
+	assert.Len(t, client.events, 3)
+	// transactions are initialized once, every batch is a transaction
+	assert.Equal(t, []string{"init@0", "begin@0", "commit@2", "begin@2", "commit@3"}, transactor.calls)
+}
+
+func TestKafkaManager_ProduceOutsideBatch(t *testing.T) {
+	manager, client, transactor := transactionalManager(nil)
+
+	producer, err := manager.Lookup(&authnapi.Identity{Principal: "reporter"}, api.Route{EventType: api.ResourceEventType}, uuid.New())
+	assert.NoError(t, err)
+	assert.NoError(t, producer.Produce(context.Background(), testEvent()))
+
+	assert.Len(t, client.events, 1)
+	assert.Equal(t, []string{"init@0", "begin@0", "commit@1"}, transactor.calls)
+}
+
+func TestKafkaManager_BatchFailure(t *testing.T) {
+	manager, client, transactor := transactionalManager(nil)
+
+	producer, err := manager.Lookup(&authnapi.Identity{Principal: "reporter"}, api.Route{EventType: api.ResourceEventType}, uuid.New())
+	assert.NoError(t, err)
+
+	err = api.Batch(context.Background(), manager, func(ctx context.Context) error {
+		if err := producer.Produce(ctx, testEvent()); err != nil {
+			return err
+		}
+		return errors.New("failed to send the second event")
+	})
+	assert.EqualError(t, err, "failed to send the second event")
+	// nothing is sent when the batch fails
+	assert.Empty(t, client.events)
+	assert.Empty(t, transactor.calls)
+}
+
+func TestKafkaManager_CommitFailure(t *testing.T) {
+	manager, _, transactor := transactionalManager(nil)
+	transactor.commitErr = errors.New("broker unavailable")
+
+	err := produceBatch(t, manager, 2)
+	assert.ErrorContains(t, err, "failed to commit kafka transaction: broker unavailable")
+	assert.Equal(t, []string{"init@0", "begin@0", "commit@2", "abort@2"}, transactor.calls)
+}
+
+func TestKafkaManager_CommitFailureSpoolsBatch(t *testing.T) {
+	spool, err := NewSpool(t.TempDir(), 1024*1024)
+	assert.NoError(t, err)
+	defer func() { _ = spool.Close() }()
+
+	manager, client, transactor := transactionalManager(spool)
+	transactor.commitErr = errors.New("broker unavailable")
+
+	assert.NoError(t, produceBatch(t, manager, 2))
+	assert.EqualValues(t, 2, spool.Depth())
+
+	// the spooled events are sent again, each in its own transaction
+	transactor.commitErr = nil
+	transactor.calls = nil
+	client.events = nil
+	sent, err := manager.FlushSpool(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 2, sent)
+	assert.EqualValues(t, 0, spool.Depth())
+	assert.Equal(t, []string{"begin@0", "commit@1", "begin@1", "commit@2"}, transactor.calls)
+}
+
+func TestKafkaManager_BatchWithoutTransactions(t *testing.T) {
+	client := &fakeClient{}
+	manager := &KafkaManager{
+		Config: CompletedConfig{&completedConfig{DefaultTopic: "kessel-inventory", MessageKey: MessageKeyResourceId}},
+		Source: "test",
+		Client: client,
+		Logger: log.NewHelper(log.DefaultLogger),
+	}
+
+	assert.NoError(t, produceBatch(t, manager, 2))
+	assert.Len(t, client.events, 2)
+}
+
+func TestConfig_Transactional(t *testing.T) {
+	t.Setenv("HOSTNAME", "inventory-api-0")
+
+	options := NewOptions()
+	options.TransactionalId = "inventory-api-${HOSTNAME}"
+	config, err := NewConfig(options).Complete()
+	assert.NoError(t, err)
+
+	assert.True(t, config.Transactional)
+	assert.Equal(t, time.Minute, config.TransactionTimeout)
+	for key, expected := range map[string]interface{}{
+		"transactional.id":                      "inventory-api-inventory-api-0",
+		"transaction.timeout.ms":                60000,
+		"enable.idempotence":                    true,
+		"max.in.flight.requests.per.connection": 5,
+	} {
+		value, err := config.KafkaConfig.Get(key, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, value, key)
+	}
+
+	config, err = NewConfig(NewOptions()).Complete()
+	assert.NoError(t, err)
+	assert.False(t, config.Transactional)
+	value, err := config.KafkaConfig.Get("enable.idempotence", nil)
+	assert.NoError(t, err)
+	assert.Nil(t, value)
+}
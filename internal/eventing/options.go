@@ -4,17 +4,22 @@ import (
 	"errors"
 
 	"github.com/project-kessel/inventory-api/internal/eventing/kafka"
+	"github.com/project-kessel/inventory-api/internal/eventing/webhook"
 	"github.com/spf13/pflag"
 )
 
 type Options struct {
-	Kafka   *kafka.Options `mapstructure:"kafka"`
-	Eventer string         `mapstructure:"eventer"`
+	Kafka   *kafka.Options   `mapstructure:"kafka"`
+	Webhook *webhook.Options `mapstructure:"webhook"`
+	Eventer string           `mapstructure:"eventer"`
+	// IncludeChanges adds the previous values and a JSON Patch of the changed fields to the resource updated events
+	IncludeChanges bool `mapstructure:"include-changes"`
 }
 
 func NewOptions() *Options {
 	return &Options{
 		Kafka:   kafka.NewOptions(),
+		Webhook: webhook.NewOptions(),
 		Eventer: "stdout",
 	}
 }
@@ -24,9 +29,12 @@ func (o *Options) AddFlags(fs *pflag.FlagSet, prefix string) {
 		prefix = prefix + "."
 	}
 
-	fs.StringVar(&o.Eventer, prefix+"eventer", o.Eventer, "The eventing subsystem to use.  Either stdout or kafka.")
+	fs.StringVar(&o.Eventer, prefix+"eventer", o.Eventer, "The eventing subsystem to use.  Either stdout, kafka or webhook.")
+
+	fs.BoolVar(&o.IncludeChanges, prefix+"include-changes", o.IncludeChanges, "Include the previous values and a JSON Patch of the changed fields in the resource updated events.")
 
 	o.Kafka.AddFlags(fs, prefix+"kafka")
+	o.Webhook.AddFlags(fs, prefix+"webhook")
 }
 
 func (o *Options) Complete() []error {
@@ -35,13 +43,17 @@ func (o *Options) Complete() []error {
 
 func (o *Options) Validate() []error {
 	var errs []error
-	if o.Eventer != "stdout" && o.Eventer != "kafka" {
-		errs = append(errs, errors.New("eventer must be either stdout or kafka"))
+	if o.Eventer != "stdout" && o.Eventer != "kafka" && o.Eventer != "webhook" {
+		errs = append(errs, errors.New("eventer must be either stdout, kafka or webhook"))
 	}
 
 	if o.Eventer == "kafka" {
 		errs = append(errs, o.Kafka.Validate()...)
 	}
 
+	if o.Eventer == "webhook" {
+		errs = append(errs, o.Webhook.Validate()...)
+	}
+
 	return errs
 }
@@ -0,0 +1,290 @@
+package replay
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/time/rate"
+	"gorm.io/gorm"
+
+	authnapi "github.com/project-kessel/inventory-api/internal/authn/api"
+	"github.com/project-kessel/inventory-api/internal/biz/model"
+	"github.com/project-kessel/inventory-api/internal/eventing/api"
+)
+
+// Principal is the identity the replayed events are looked up for.
+const Principal = "inventory-api-replay"
+
+const defaultBatchSize = 500
+
+// Filter selects the history rows replayed.  Empty fields match every row.
+type Filter struct {
+	// Since and Until bound the time of the change, Since is inclusive and Until exclusive
+	Since        time.Time
+	Until        time.Time
+	ResourceType string
+	ReporterType string
+	OrgId        string
+	// EventType is either api.ResourceEventType or api.RelationshipEventType
+	EventType string
+}
+
+// Counts is the number of events replayed, by event type.
+type Counts map[string]int
+
+func (c Counts) Total() int {
+	total := 0
+	for _, n := range c {
+		total += n
+	}
+	return total
+}
+
+// Replayer produces the events of the changes recorded in resource_history and relationship_history again.
+type Replayer struct {
+	DB *gorm.DB
+	// Manager is not used, and can be nil, for dry runs
+	Manager api.Manager
+	// Rate is the maximum number of events produced per second, 0 disables the limit
+	Rate      int
+	BatchSize int
+	DryRun    bool
+}
+
+// Replay walks the resource changes, then the relationship changes, matching the filter in the order they were
+// recorded and produces their events.  Events keep the id they were first sent with.
+func (r *Replayer) Replay(ctx context.Context, filter Filter) (Counts, error) {
+	counts := Counts{}
+	limit := rate.Inf
+	if r.Rate > 0 {
+		limit = rate.Limit(r.Rate)
+	}
+	limiter := rate.NewLimiter(limit, 1)
+
+	emit := func(route api.Route, key uuid.UUID, event *api.Event) error {
+		if r.DryRun {
+			counts[event.Type]++
+			return nil
+		}
+		if err := limiter.Wait(ctx); err != nil {
+			return err
+		}
+		producer, err := r.Manager.Lookup(&authnapi.Identity{Principal: Principal}, route, key)
+		if err != nil {
+			return err
+		}
+		if err := producer.Produce(ctx, event); err != nil {
+			return fmt.Errorf("failed to produce event %s: %w", event.Id, err)
+		}
+		counts[event.Type]++
+		return nil
+	}
+
+	if filter.EventType == "" || filter.EventType == api.ResourceEventType {
+		if err := r.replayResources(ctx, filter, emit); err != nil {
+			return counts, err
+		}
+	}
+	if filter.EventType == "" || filter.EventType == api.RelationshipEventType {
+		if err := r.replayRelationships(ctx, filter, emit); err != nil {
+			return counts, err
+		}
+	}
+
+	return counts, nil
+}
+
+type emitFunc func(route api.Route, key uuid.UUID, event *api.Event) error
+
+func (r *Replayer) replayResources(ctx context.Context, filter Filter, emit emitFunc) error {
+	query := r.query(ctx, &model.ResourceHistory{}, filter)
+	if filter.ResourceType != "" {
+		query = query.Where("resource_type = ?", filter.ResourceType)
+	}
+
+	var batch []*model.ResourceHistory
+	// history ids are version 7 uuids, the batches walk the changes in the order they were recorded
+	result := query.FindInBatches(&batch, r.batchSize(), func(tx *gorm.DB, _ int) error {
+		inventoryIds, err := r.inventoryIds(ctx, batch)
+		if err != nil {
+			return err
+		}
+		for _, history := range batch {
+			if !matchesFold(filter.ReporterType, history.Reporter.ReporterType) {
+				continue
+			}
+
+			resource := resourceFromHistory(history)
+			if resource.InventoryId == nil {
+				if inventoryId, ok := inventoryIds[resource.ID]; ok {
+					resource.InventoryId = &inventoryId
+				}
+			}
+			operationType := operationTypeFor(history.OperationType)
+			event, err := api.NewResourceEvent(operationType, resource, changedAt(history.Timestamp))
+			if err != nil {
+				return err
+			}
+
+			route := api.Route{
+				EventType:     api.ResourceEventType,
+				ResourceType:  resource.ResourceType,
+				ReporterType:  resource.ReporterType,
+				OperationType: operationType,
+			}
+			if err := emit(route, api.ResourceKey(resource), event); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if result.Error != nil {
+		return fmt.Errorf("failed to replay resource history: %w", result.Error)
+	}
+	return nil
+}
+
+// inventoryIds returns the inventory ids of the resources of the history rows recorded without theirs, the resources
+// deleted since have none.
+func (r *Replayer) inventoryIds(ctx context.Context, batch []*model.ResourceHistory) (map[uuid.UUID]uuid.UUID, error) {
+	var resourceIds []uuid.UUID
+	for _, history := range batch {
+		if history.InventoryId == nil {
+			resourceIds = append(resourceIds, history.ResourceId)
+		}
+	}
+	inventoryIds := map[uuid.UUID]uuid.UUID{}
+	if len(resourceIds) == 0 {
+		return inventoryIds, nil
+	}
+
+	var resources []model.Resource
+	if err := r.DB.WithContext(ctx).Select("id", "inventory_id").Where("id IN ? AND inventory_id IS NOT NULL", resourceIds).Find(&resources).Error; err != nil {
+		return nil, fmt.Errorf("failed to read the inventory ids of the resources: %w", err)
+	}
+	for _, resource := range resources {
+		inventoryIds[resource.ID] = *resource.InventoryId
+	}
+	return inventoryIds, nil
+}
+
+func (r *Replayer) replayRelationships(ctx context.Context, filter Filter, emit emitFunc) error {
+	query := r.query(ctx, &model.RelationshipHistory{}, filter)
+
+	var batch []*model.RelationshipHistory
+	result := query.FindInBatches(&batch, r.batchSize(), func(tx *gorm.DB, _ int) error {
+		for _, history := range batch {
+			if !matchesFold(filter.ReporterType, history.Reporter.ReporterType) {
+				continue
+			}
+			// a relationship matches the resource type of the relationship, its subject or its object
+			if filter.ResourceType != "" &&
+				!matchesFold(filter.ResourceType, history.RelationshipType) &&
+				!matchesFold(filter.ResourceType, history.Reporter.SubjectResourceType) &&
+				!matchesFold(filter.ResourceType, history.Reporter.ObjectResourceType) {
+				continue
+			}
+
+			relationship := relationshipFromHistory(history)
+			operationType := operationTypeFor(history.OperationType)
+			event, err := api.NewRelationshipEvent(operationType, relationship, changedAt(history.Timestamp))
+			if err != nil {
+				return err
+			}
+
+			route := api.Route{
+				EventType:     api.RelationshipEventType,
+				ResourceType:  relationship.RelationshipType,
+				ReporterType:  relationship.Reporter.ReporterType,
+				OperationType: operationType,
+			}
+			if err := emit(route, relationship.SubjectId, event); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if result.Error != nil {
+		return fmt.Errorf("failed to replay relationship history: %w", result.Error)
+	}
+	return nil
+}
+
+// query selects the history rows of the model matching the time and org filters.
+func (r *Replayer) query(ctx context.Context, m interface{}, filter Filter) *gorm.DB {
+	query := r.DB.Session(&gorm.Session{}).WithContext(ctx).Model(m)
+	if !filter.Since.IsZero() {
+		query = query.Where("timestamp >= ?", filter.Since)
+	}
+	if !filter.Until.IsZero() {
+		query = query.Where("timestamp < ?", filter.Until)
+	}
+	if filter.OrgId != "" {
+		query = query.Where("org_id = ?", filter.OrgId)
+	}
+	return query
+}
+
+func (r *Replayer) batchSize() int {
+	if r.BatchSize > 0 {
+		return r.BatchSize
+	}
+	return defaultBatchSize
+}
+
+func resourceFromHistory(history *model.ResourceHistory) *model.Resource {
+	return &model.Resource{
+		ID:                 history.ResourceId,
+		HistoryId:          history.ID,
+		InventoryId:        history.InventoryId,
+		OrgId:              history.OrgId,
+		ResourceData:       history.ResourceData,
+		ResourceType:       history.ResourceType,
+		WorkspaceId:        history.WorkspaceId,
+		ConsoleHref:        history.ConsoleHref,
+		ApiHref:            history.ApiHref,
+		Labels:             history.Labels,
+		ReporterType:       history.Reporter.ReporterType,
+		ReporterResourceId: history.Reporter.LocalResourceId,
+		ReporterVersion:    history.Reporter.ReporterVersion,
+		Reporter:           history.Reporter,
+	}
+}
+
+func relationshipFromHistory(history *model.RelationshipHistory) *model.Relationship {
+	return &model.Relationship{
+		ID:               history.RelationshipId,
+		HistoryId:        history.ID,
+		OrgId:            history.OrgId,
+		RelationshipData: history.RelationshipData,
+		RelationshipType: history.RelationshipType,
+		SubjectId:        history.SubjectId,
+		ObjectId:         history.ObjectId,
+		Reporter:         history.Reporter,
+	}
+}
+
+func operationTypeFor(operationType model.OperationType) api.OperationType {
+	switch operationType {
+	case model.OperationTypeCreate:
+		return api.OperationTypeCreated
+	case model.OperationTypeDelete:
+		return api.OperationTypeDeleted
+	default:
+		return api.OperationTypeUpdated
+	}
+}
+
+func changedAt(timestamp *time.Time) time.Time {
+	if timestamp == nil {
+		return time.Time{}
+	}
+	return *timestamp
+}
+
+func matchesFold(filter, value string) bool {
+	return filter == "" || strings.EqualFold(filter, value)
+}
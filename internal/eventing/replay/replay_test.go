@@ -0,0 +1,208 @@
+package replay
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	authnapi "github.com/project-kessel/inventory-api/internal/authn/api"
+	"github.com/project-kessel/inventory-api/internal/biz/model"
+	"github.com/project-kessel/inventory-api/internal/data"
+	"github.com/project-kessel/inventory-api/internal/eventing/api"
+)
+
+// fakeManager records the routes, keys and events produced through it.
+type fakeManager struct {
+	routes []api.Route
+	keys   []uuid.UUID
+	events []*api.Event
+}
+
+func (m *fakeManager) Lookup(identity *authnapi.Identity, route api.Route, resource_id uuid.UUID) (api.Producer, error) {
+	m.routes = append(m.routes, route)
+	m.keys = append(m.keys, resource_id)
+	return m, nil
+}
+
+func (m *fakeManager) Produce(ctx context.Context, event *api.Event) error {
+	m.events = append(m.events, event)
+	return nil
+}
+
+func (m *fakeManager) Errs() <-chan error {
+	return nil
+}
+
+func (m *fakeManager) Shutdown(ctx context.Context) error {
+	return nil
+}
+
+var (
+	clusterId = uuid.New()
+	hostId    = uuid.New()
+	day       = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+)
+
+func at(hours int) *time.Time {
+	t := day.Add(time.Duration(hours) * time.Hour)
+	return &t
+}
+
+func setupHistory(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.Nil(t, err)
+	require.Nil(t, data.Migrate(db, log.NewHelper(log.DefaultLogger)))
+
+	acm := model.ResourceReporter{Reporter: model.Reporter{ReporterType: "ACM", ReporterId: "acm-1"}, LocalResourceId: "cluster-1"}
+	hbi := model.ResourceReporter{Reporter: model.Reporter{ReporterType: "HBI", ReporterId: "hbi-1"}, LocalResourceId: "host-1"}
+
+	for _, history := range []*model.ResourceHistory{
+		{ResourceId: clusterId, OrgId: "org-1", ResourceType: "k8s_cluster", Reporter: acm, OperationType: model.OperationTypeCreate, Timestamp: at(1)},
+		{ResourceId: hostId, OrgId: "org-2", ResourceType: "rhel_host", Reporter: hbi, OperationType: model.OperationTypeCreate, Timestamp: at(2)},
+		{ResourceId: clusterId, OrgId: "org-1", ResourceType: "k8s_cluster", Reporter: acm, OperationType: model.OperationTypeUpdate, Timestamp: at(3)},
+		{ResourceId: clusterId, OrgId: "org-1", ResourceType: "k8s_cluster", Reporter: acm, OperationType: model.OperationTypeDelete, Timestamp: at(4)},
+	} {
+		require.Nil(t, db.Create(history).Error)
+	}
+
+	require.Nil(t, db.Create(&model.RelationshipHistory{
+		RelationshipId:   uuid.New(),
+		OrgId:            "org-1",
+		RelationshipType: "k8spolicy_ispropagatedto_k8scluster",
+		SubjectId:        uuid.New(),
+		ObjectId:         clusterId,
+		Reporter: model.RelationshipReporter{
+			Reporter:            model.Reporter{ReporterType: "ACM"},
+			SubjectResourceType: "k8s_policy",
+			ObjectResourceType:  "k8s_cluster",
+		},
+		OperationType: model.OperationTypeCreate,
+		Timestamp:     at(5),
+	}).Error)
+
+	return db
+}
+
+func TestReplayer_Replay(t *testing.T) {
+	db := setupHistory(t)
+	manager := &fakeManager{}
+	replayer := &Replayer{DB: db, Manager: manager, BatchSize: 2}
+
+	counts, err := replayer.Replay(context.Background(), Filter{})
+	assert.NoError(t, err)
+	assert.Equal(t, 5, counts.Total())
+
+	var types []string
+	for _, event := range manager.events {
+		types = append(types, event.Type)
+	}
+	assert.Equal(t, []string{
+		"redhat.inventory.resources.k8s_cluster.created.v1",
+		"redhat.inventory.resources.rhel_host.created.v1",
+		"redhat.inventory.resources.k8s_cluster.updated.v1",
+		"redhat.inventory.resources.k8s_cluster.deleted.v1",
+		"redhat.inventory.resources-relationship.k8spolicy_ispropagatedto_k8scluster.created.v1",
+	}, types)
+
+	// events keep the id derived from the history row, and the routing and keys of the original events
+	var history model.ResourceHistory
+	assert.NoError(t, db.Where("resource_id = ? AND operation_type = ?", clusterId, model.OperationTypeCreate).First(&history).Error)
+	original, err := api.NewResourceEvent(api.OperationTypeCreated, &model.Resource{ID: clusterId, HistoryId: history.ID}, *history.Timestamp)
+	assert.NoError(t, err)
+	assert.Equal(t, original.Id, manager.events[0].Id)
+	assert.Equal(t, clusterId, manager.keys[0])
+	assert.Equal(t, api.Route{EventType: api.ResourceEventType, ResourceType: "k8s_cluster", ReporterType: "ACM", OperationType: api.OperationTypeCreated}, manager.routes[0])
+	assert.Equal(t, "/resources/k8s_cluster/"+clusterId.String(), manager.events[0].Subject)
+}
+
+func TestReplayer_KeysByInventoryId(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.Nil(t, err)
+	require.Nil(t, data.Migrate(db, log.NewHelper(log.DefaultLogger)))
+
+	inventoryResource := &model.InventoryResource{ResourceType: "k8s_cluster"}
+	require.Nil(t, db.Create(inventoryResource).Error)
+	inventoryId := inventoryResource.ID
+
+	// the cluster is reported by acm, with the inventory id in its history, and by ocm, recorded before the history
+	// kept inventory ids
+	acmId, ocmId := uuid.New(), uuid.New()
+	ocm := model.ResourceReporter{Reporter: model.Reporter{ReporterType: "OCM", ReporterId: "ocm-1"}, LocalResourceId: "cluster-1"}
+	require.Nil(t, db.Create(&model.Resource{ID: ocmId, InventoryId: &inventoryId, OrgId: "org-1", ResourceType: "k8s_cluster", ReporterType: "OCM", ReporterResourceId: "cluster-1", Reporter: ocm}).Error)
+	for _, history := range []*model.ResourceHistory{
+		{ResourceId: acmId, InventoryId: &inventoryId, OrgId: "org-1", ResourceType: "k8s_cluster", Reporter: model.ResourceReporter{Reporter: model.Reporter{ReporterType: "ACM"}}, OperationType: model.OperationTypeCreate, Timestamp: at(1)},
+		{ResourceId: ocmId, OrgId: "org-1", ResourceType: "k8s_cluster", Reporter: ocm, OperationType: model.OperationTypeCreate, Timestamp: at(2)},
+	} {
+		require.Nil(t, db.Create(history).Error)
+	}
+
+	manager := &fakeManager{}
+	_, err = (&Replayer{DB: db, Manager: manager}).Replay(context.Background(), Filter{})
+	require.NoError(t, err)
+
+	// both are keyed, and carry, the inventory id like the live events
+	assert.Equal(t, []uuid.UUID{inventoryId, inventoryId}, manager.keys)
+	require.Len(t, manager.events, 2)
+	assert.Equal(t, inventoryId.String(), manager.events[0].InventoryId)
+	assert.Equal(t, inventoryId.String(), manager.events[1].InventoryId)
+}
+
+func TestReplayer_Filters(t *testing.T) {
+	tests := []struct {
+		name     string
+		filter   Filter
+		expected int
+	}{
+		{name: "since", filter: Filter{Since: *at(3)}, expected: 3},
+		{name: "until", filter: Filter{Until: *at(3)}, expected: 2},
+		{name: "resource type matches relationships of the type", filter: Filter{ResourceType: "k8s_cluster"}, expected: 4},
+		{name: "reporter type", filter: Filter{ReporterType: "hbi"}, expected: 1},
+		{name: "org", filter: Filter{OrgId: "org-2"}, expected: 1},
+		{name: "event type", filter: Filter{EventType: api.RelationshipEventType}, expected: 1},
+	}
+
+	db := setupHistory(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager := &fakeManager{}
+			replayer := &Replayer{DB: db, Manager: manager}
+
+			counts, err := replayer.Replay(context.Background(), tt.filter)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, counts.Total())
+			assert.Len(t, manager.events, tt.expected)
+		})
+	}
+}
+
+func TestReplayer_DryRun(t *testing.T) {
+	replayer := &Replayer{DB: setupHistory(t), DryRun: true}
+
+	counts, err := replayer.Replay(context.Background(), Filter{OrgId: "org-1"})
+	assert.NoError(t, err)
+	assert.Equal(t, Counts{
+		"redhat.inventory.resources.k8s_cluster.created.v1":                                      1,
+		"redhat.inventory.resources.k8s_cluster.updated.v1":                                      1,
+		"redhat.inventory.resources.k8s_cluster.deleted.v1":                                      1,
+		"redhat.inventory.resources-relationship.k8spolicy_ispropagatedto_k8scluster.created.v1": 1,
+	}, counts)
+}
+
+func TestReplayer_RateLimit(t *testing.T) {
+	manager := &fakeManager{}
+	replayer := &Replayer{DB: setupHistory(t), Manager: manager, Rate: 100}
+
+	start := time.Now()
+	counts, err := replayer.Replay(context.Background(), Filter{})
+	assert.NoError(t, err)
+	assert.Equal(t, 5, counts.Total())
+	// the first event is produced right away, the others 10ms apart
+	assert.GreaterOrEqual(t, time.Since(start), 40*time.Millisecond)
+}
@@ -29,7 +29,11 @@ func New(logger *log.Helper) (*StdOutManager, error) {
 }
 
 func (p *StdOutManager) Produce(ctx context.Context, event *api.Event) error {
-	return p.Encoder.Encode(event)
+	e := *event
+	if e.TraceParent == "" {
+		e.TraceParent = api.TraceParent(ctx)
+	}
+	return p.Encoder.Encode(&e)
 }
 
 func (m *StdOutManager) Errs() <-chan error {
@@ -37,7 +41,7 @@ func (m *StdOutManager) Errs() <-chan error {
 }
 
 // Lookup figures out which Producer should be used for the given identity and resource.
-func (m *StdOutManager) Lookup(identity *authnapi.Identity, resource_type string, resource_id uuid.UUID) (api.Producer, error) {
+func (m *StdOutManager) Lookup(identity *authnapi.Identity, route api.Route, resource_id uuid.UUID) (api.Producer, error) {
 	return m, nil
 }
 
@@ -0,0 +1,50 @@
+package webhook
+
+import (
+	"net/http"
+	"time"
+)
+
+type Config struct {
+	*Options
+
+	// this can be set manually for testing
+	HttpClient *http.Client
+}
+
+type completedConfig struct {
+	Destinations   []Destination
+	ContentMode    string
+	QueueSize      int
+	MaxRetries     int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	HttpClient     *http.Client
+}
+
+type CompletedConfig struct {
+	*completedConfig
+}
+
+func NewConfig(o *Options) *Config {
+	return &Config{
+		Options: o,
+	}
+}
+
+func (c *Config) Complete() (CompletedConfig, error) {
+	client := c.HttpClient
+	if client == nil {
+		client = &http.Client{Timeout: time.Duration(c.TimeoutMs) * time.Millisecond}
+	}
+
+	return CompletedConfig{&completedConfig{
+		Destinations:   c.Destinations,
+		ContentMode:    c.ContentMode,
+		QueueSize:      c.QueueSize,
+		MaxRetries:     c.MaxRetries,
+		InitialBackoff: time.Duration(c.InitialBackoffMs) * time.Millisecond,
+		MaxBackoff:     time.Duration(c.MaxBackoffMs) * time.Millisecond,
+		HttpClient:     client,
+	}}, nil
+}
@@ -0,0 +1,300 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/cloudevents/sdk-go/v2/binding"
+	cehttp "github.com/cloudevents/sdk-go/v2/protocol/http"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+
+	authnapi "github.com/project-kessel/inventory-api/internal/authn/api"
+	"github.com/project-kessel/inventory-api/internal/eventing/api"
+)
+
+const (
+	// SignatureHeader carries the hex encoded HMAC-SHA256 of "<timestamp>.<body>", prefixed by "sha256="
+	SignatureHeader = "X-Kessel-Signature"
+	// TimestampHeader carries the unix time the request was signed at, receivers can use it to reject replays
+	TimestampHeader = "X-Kessel-Timestamp"
+)
+
+type delivery struct {
+	destination Destination
+	event       cloudevents.Event
+}
+
+// destinationQueue holds the deliveries waiting to be posted to a destination
+type destinationQueue struct {
+	destination Destination
+	deliveries  chan delivery
+}
+
+type WebhookManager struct {
+	Config CompletedConfig
+	Source string
+	Errors chan error
+
+	Logger *log.Helper
+
+	// mu guards the queues against producers racing with Shutdown
+	mu     sync.Mutex
+	closed bool
+	// queues has a queue per destination, in the order of the configured destinations
+	queues []*destinationQueue
+	// abort stops the retries of the pending deliveries when the shutdown deadline expires
+	abort     chan struct{}
+	abortOnce sync.Once
+	done      chan struct{}
+
+	deliveriesCounter metric.Int64Counter
+}
+
+func New(config CompletedConfig, source string, logger *log.Helper) (*WebhookManager, error) {
+	logger.Info("Using eventing: webhook")
+
+	meter := otel.Meter("github.com/project-kessel/inventory-api/blob/main/internal/server/otel")
+	deliveriesCounter, err := meter.Int64Counter("webhook_delivery", metric.WithUnit("{delivery}"))
+	if err != nil {
+		return nil, err
+	}
+
+	m := &WebhookManager{
+		Config: config,
+		Source: source,
+		Errors: make(chan error),
+
+		Logger: logger,
+
+		abort: make(chan struct{}),
+		done:  make(chan struct{}),
+
+		deliveriesCounter: deliveriesCounter,
+	}
+
+	var workers sync.WaitGroup
+	for _, destination := range config.Destinations {
+		queue := &destinationQueue{destination: destination, deliveries: make(chan delivery, config.QueueSize)}
+		m.queues = append(m.queues, queue)
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			m.run(queue)
+		}()
+	}
+	go func() {
+		workers.Wait()
+		close(m.done)
+	}()
+
+	return m, nil
+}
+
+// run posts the deliveries of a destination one at a time, which keeps its events in order.  Each destination has
+// its own worker, a slow or failing destination doesn't hold back the others.
+func (m *WebhookManager) run(queue *destinationQueue) {
+	for d := range queue.deliveries {
+		outcome := "delivered"
+		if err := m.deliver(d); err != nil {
+			outcome = "dropped"
+			m.Logger.Errorf("Dropped event %s for %s: %v", d.event.ID(), d.destination.URL, err)
+		}
+		m.deliveriesCounter.Add(
+			context.Background(),
+			1,
+			metric.WithAttributes(
+				attribute.String("event_type", d.event.Type()),
+				attribute.String("outcome", outcome),
+			),
+		)
+	}
+}
+
+// deliver posts the event to the destination, retrying with an exponential backoff on network errors, 5xx and
+// 429 responses.
+func (m *WebhookManager) deliver(d delivery) error {
+	request, body, err := m.newRequest(d)
+	if err != nil {
+		return err
+	}
+
+	backoff := m.Config.InitialBackoff
+	for attempt := 0; ; attempt++ {
+		retryable, err := m.post(request, body, d.destination.Secret)
+		if err == nil {
+			m.Logger.Infof("Delivered event %s to %s", d.event.ID(), d.destination.URL)
+			return nil
+		}
+		if !retryable || attempt >= m.Config.MaxRetries {
+			return err
+		}
+
+		m.Logger.Warnf("Failed to deliver event %s to %s, retrying in %v: %v", d.event.ID(), d.destination.URL, backoff, err)
+		select {
+		case <-time.After(backoff):
+		case <-m.abort:
+			return fmt.Errorf("shutdown before delivery: %w", err)
+		}
+		backoff = min(backoff*2, m.Config.MaxBackoff)
+	}
+}
+
+// newRequest encodes the event with the HTTP binding of the configured content mode.  The request is cloned for
+// every attempt, with the returned body.
+func (m *WebhookManager) newRequest(d delivery) (*http.Request, []byte, error) {
+	ctx := cloudevents.WithEncodingStructured(context.Background())
+	if m.Config.ContentMode == ContentModeBinary {
+		ctx = cloudevents.WithEncodingBinary(context.Background())
+	}
+
+	request, err := http.NewRequest(http.MethodPost, d.destination.URL, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := cehttp.WriteRequest(ctx, binding.ToMessage(&d.event), request); err != nil {
+		return nil, nil, fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	var body []byte
+	if request.Body != nil {
+		body, err = io.ReadAll(request.Body)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to encode event: %w", err)
+		}
+	}
+	return request, body, nil
+}
+
+// post sends a single attempt and reports whether a failure can be retried.
+func (m *WebhookManager) post(request *http.Request, body []byte, secret string) (bool, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-m.abort:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	attempt := request.Clone(ctx)
+	attempt.Body = io.NopCloser(bytes.NewReader(body))
+	attempt.ContentLength = int64(len(body))
+	if secret != "" {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		attempt.Header.Set(TimestampHeader, timestamp)
+		attempt.Header.Set(SignatureHeader, "sha256="+Sign(secret, timestamp, body))
+	}
+
+	response, err := m.Config.HttpClient.Do(attempt)
+	if err != nil {
+		return true, err
+	}
+	defer func() {
+		_ = response.Body.Close()
+	}()
+	_, _ = io.Copy(io.Discard, response.Body)
+
+	if response.StatusCode >= 200 && response.StatusCode < 300 {
+		return false, nil
+	}
+	retryable := response.StatusCode >= 500 || response.StatusCode == http.StatusTooManyRequests
+	return retryable, fmt.Errorf("unexpected response status %s", response.Status)
+}
+
+// Sign returns the hex encoded HMAC-SHA256 of the timestamp and body, as sent in the SignatureHeader.
+func Sign(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// enqueue adds the event to the queues of the destinations, to all of them or none when a queue is full.
+func (m *WebhookManager) enqueue(queues []*destinationQueue, event cloudevents.Event) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.closed {
+		return fmt.Errorf("webhook eventer is shut down")
+	}
+	for _, queue := range queues {
+		if len(queue.deliveries) >= cap(queue.deliveries) {
+			return fmt.Errorf("webhook queue of %s is full, %d deliveries waiting", queue.destination.URL, len(queue.deliveries))
+		}
+	}
+	for _, queue := range queues {
+		queue.deliveries <- delivery{destination: queue.destination, event: event}
+	}
+	return nil
+}
+
+func (m *WebhookManager) Errs() <-chan error {
+	return m.Errors
+}
+
+// Lookup returns a producer posting the events to the destinations accepting the resource type of the route.
+func (m *WebhookManager) Lookup(identity *authnapi.Identity, route api.Route, resource_id uuid.UUID) (api.Producer, error) {
+	var queues []*destinationQueue
+	for _, queue := range m.queues {
+		if queue.destination.Accepts(route.ResourceType) {
+			queues = append(queues, queue)
+		}
+	}
+	return &webhookProducer{Manager: m, Queues: queues}, nil
+}
+
+// Shutdown stops accepting events and waits for the queued deliveries to be sent.  Deliveries still pending when
+// the context is done are dropped.
+func (m *WebhookManager) Shutdown(ctx context.Context) error {
+	m.mu.Lock()
+	if !m.closed {
+		m.closed = true
+		for _, queue := range m.queues {
+			close(queue.deliveries)
+		}
+	}
+	m.mu.Unlock()
+
+	select {
+	case <-m.done:
+		return nil
+	case <-ctx.Done():
+		m.abortOnce.Do(func() { close(m.abort) })
+		<-m.done
+		return ctx.Err()
+	}
+}
+
+type webhookProducer struct {
+	Manager *WebhookManager
+	Queues  []*destinationQueue
+}
+
+// Produce creates the cloud event and queues its delivery to the destinations of the producer.
+func (p *webhookProducer) Produce(ctx context.Context, event *api.Event) error {
+	if len(p.Queues) == 0 {
+		return nil
+	}
+
+	e := event.CloudEvent(ctx, p.Manager.Source)
+	e.SetDataSchema(event.DataSchema)
+	if err := e.SetData(event.DataContentType, event.Data); err != nil {
+		return err
+	}
+	return p.Manager.enqueue(p.Queues, e)
+}
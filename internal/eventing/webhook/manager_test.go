@@ -0,0 +1,314 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	cehttp "github.com/cloudevents/sdk-go/v2/protocol/http"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	authnapi "github.com/project-kessel/inventory-api/internal/authn/api"
+	"github.com/project-kessel/inventory-api/internal/eventing/api"
+)
+
+type receivedRequest struct {
+	header http.Header
+	body   []byte
+}
+
+// recorder is an http handler recording the requests it receives, answering with the given statuses in order and
+// then with 200.
+type recorder struct {
+	mu       sync.Mutex
+	requests []receivedRequest
+	statuses []int
+}
+
+func (r *recorder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+
+	r.mu.Lock()
+	r.requests = append(r.requests, receivedRequest{header: req.Header.Clone(), body: body})
+	status := http.StatusOK
+	if len(r.statuses) > 0 {
+		status, r.statuses = r.statuses[0], r.statuses[1:]
+	}
+	r.mu.Unlock()
+
+	w.WriteHeader(status)
+}
+
+func (r *recorder) received() []receivedRequest {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]receivedRequest(nil), r.requests...)
+}
+
+func newTestManager(t *testing.T, options *Options) *WebhookManager {
+	options.InitialBackoffMs = 1
+	options.MaxBackoffMs = 5
+	assert.Empty(t, options.Validate())
+
+	config, err := NewConfig(options).Complete()
+	assert.NoError(t, err)
+	manager, err := New(config, "test", log.NewHelper(log.DefaultLogger))
+	assert.NoError(t, err)
+	return manager
+}
+
+func testEvent() *api.Event {
+	return &api.Event{
+		Specversion:     "1.0",
+		Type:            "redhat.inventory.resources.k8s_cluster.created.v1",
+		Id:              uuid.NewString(),
+		Subject:         "/resources/k8s_cluster/abc",
+		Time:            time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		DataContentType: "application/json",
+		Data:            map[string]interface{}{"resource_type": "k8s_cluster"},
+		OrgId:           "org-1",
+	}
+}
+
+func produce(t *testing.T, manager *WebhookManager, resourceType string, event *api.Event) error {
+	producer, err := manager.Lookup(&authnapi.Identity{Principal: "reporter"}, api.Route{EventType: api.ResourceEventType, ResourceType: resourceType}, uuid.New())
+	assert.NoError(t, err)
+	return producer.Produce(context.Background(), event)
+}
+
+func TestOptions_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		options func(o *Options)
+		errors  []string
+	}{
+		{
+			name:    "valid",
+			options: func(o *Options) {},
+		},
+		{
+			name:    "no destination",
+			options: func(o *Options) { o.Destinations = nil },
+			errors:  []string{"at least one destination must be set"},
+		},
+		{
+			name: "invalid urls",
+			options: func(o *Options) {
+				o.Destinations = []Destination{{URL: ""}, {URL: "/events"}, {URL: "ftp://example.com"}}
+			},
+			errors: []string{
+				"invalid destinations[0]: url must be set",
+				"invalid destinations[1]: invalid url /events, expected an absolute http or https url",
+				"invalid destinations[2]: invalid url ftp://example.com, expected an absolute http or https url",
+			},
+		},
+		{
+			name: "invalid content mode and bounds",
+			options: func(o *Options) {
+				o.ContentMode = "binary-protobuf"
+				o.QueueSize = 0
+				o.MaxBackoffMs = 100
+			},
+			errors: []string{
+				"invalid content-mode binary-protobuf. Options are 'structured' and 'binary'",
+				"queue-size must be positive",
+				"max-backoff-ms must not be less than initial-backoff-ms",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := NewOptions()
+			options.Destinations = []Destination{{URL: "https://example.com/events"}}
+			tt.options(options)
+
+			var errs []string
+			for _, err := range options.Validate() {
+				errs = append(errs, err.Error())
+			}
+			assert.Equal(t, tt.errors, errs)
+		})
+	}
+}
+
+func TestWebhookManager_ContentModes(t *testing.T) {
+	for _, contentMode := range []string{ContentModeStructured, ContentModeBinary} {
+		t.Run(contentMode, func(t *testing.T) {
+			handler := &recorder{}
+			server := httptest.NewServer(handler)
+			defer server.Close()
+
+			options := NewOptions()
+			options.ContentMode = contentMode
+			options.Destinations = []Destination{{URL: server.URL}}
+			manager := newTestManager(t, options)
+
+			event := testEvent()
+			assert.NoError(t, produce(t, manager, "k8s_cluster", event))
+			assert.NoError(t, manager.Shutdown(context.Background()))
+
+			requests := handler.received()
+			assert.Len(t, requests, 1)
+
+			if contentMode == ContentModeStructured {
+				assert.Equal(t, "application/cloudevents+json", requests[0].header.Get("Content-Type"))
+			} else {
+				assert.Equal(t, "application/json", requests[0].header.Get("Content-Type"))
+				assert.Equal(t, event.Id, requests[0].header.Get("Ce-Id"))
+			}
+
+			request := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(requests[0].body))
+			request.Header = requests[0].header
+			received, err := cehttp.NewEventFromHTTPRequest(request)
+			assert.NoError(t, err)
+			assert.Equal(t, event.Id, received.ID())
+			assert.Equal(t, event.Type, received.Type())
+			assert.Equal(t, "test", received.Source())
+			assert.Equal(t, "org-1", received.Extensions()["orgid"])
+			assert.JSONEq(t, `{"resource_type": "k8s_cluster"}`, string(received.Data()))
+		})
+	}
+}
+
+func TestWebhookManager_ResourceTypeFilter(t *testing.T) {
+	clusters, all := &recorder{}, &recorder{}
+	clusterServer := httptest.NewServer(clusters)
+	defer clusterServer.Close()
+	allServer := httptest.NewServer(all)
+	defer allServer.Close()
+
+	options := NewOptions()
+	options.Destinations = []Destination{
+		{URL: clusterServer.URL, ResourceTypes: []string{"K8S_CLUSTER"}},
+		{URL: allServer.URL},
+	}
+	manager := newTestManager(t, options)
+
+	assert.NoError(t, produce(t, manager, "k8s_cluster", testEvent()))
+	assert.NoError(t, produce(t, manager, "notifications_integration", testEvent()))
+	assert.NoError(t, manager.Shutdown(context.Background()))
+
+	assert.Len(t, clusters.received(), 1)
+	assert.Len(t, all.received(), 2)
+}
+
+func TestWebhookManager_Signature(t *testing.T) {
+	handler := &recorder{}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	options := NewOptions()
+	options.Destinations = []Destination{{URL: server.URL, Secret: "s3cr3t"}}
+	manager := newTestManager(t, options)
+
+	assert.NoError(t, produce(t, manager, "k8s_cluster", testEvent()))
+	assert.NoError(t, manager.Shutdown(context.Background()))
+
+	requests := handler.received()
+	assert.Len(t, requests, 1)
+	timestamp := requests[0].header.Get(TimestampHeader)
+	assert.NotEmpty(t, timestamp)
+	assert.Equal(t, "sha256="+Sign("s3cr3t", timestamp, requests[0].body), requests[0].header.Get(SignatureHeader))
+	assert.NotEqual(t, "sha256="+Sign("other", timestamp, requests[0].body), requests[0].header.Get(SignatureHeader))
+}
+
+func TestWebhookManager_Retries(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []int
+		requests int
+	}{
+		{name: "retried until delivered", statuses: []int{http.StatusServiceUnavailable, http.StatusTooManyRequests}, requests: 3},
+		{name: "client errors are not retried", statuses: []int{http.StatusBadRequest}, requests: 1},
+		{name: "dropped after max retries", statuses: []int{500, 500, 500, 500}, requests: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := &recorder{statuses: tt.statuses}
+			server := httptest.NewServer(handler)
+			defer server.Close()
+
+			options := NewOptions()
+			options.MaxRetries = 2
+			options.Destinations = []Destination{{URL: server.URL}}
+			manager := newTestManager(t, options)
+
+			assert.NoError(t, produce(t, manager, "k8s_cluster", testEvent()))
+			assert.NoError(t, manager.Shutdown(context.Background()))
+
+			assert.Len(t, handler.received(), tt.requests)
+		})
+	}
+}
+
+func TestWebhookManager_QueueFull(t *testing.T) {
+	// the manager is not started, nothing is taken from the queues
+	a := &destinationQueue{destination: Destination{URL: "http://localhost/a"}, deliveries: make(chan delivery, 1)}
+	b := &destinationQueue{destination: Destination{URL: "http://localhost/b"}, deliveries: make(chan delivery, 2)}
+	manager := &WebhookManager{
+		Config: CompletedConfig{&completedConfig{
+			Destinations: []Destination{a.destination, b.destination},
+		}},
+		Source: "test",
+		Logger: log.NewHelper(log.DefaultLogger),
+		queues: []*destinationQueue{a, b},
+	}
+
+	assert.NoError(t, produce(t, manager, "k8s_cluster", testEvent()))
+	assert.EqualError(t, produce(t, manager, "k8s_cluster", testEvent()), "webhook queue of http://localhost/a is full, 1 deliveries waiting")
+	// the event is queued for all of its destinations or none
+	assert.Len(t, a.deliveries, 1)
+	assert.Len(t, b.deliveries, 1)
+}
+
+func TestWebhookManager_SlowDestination(t *testing.T) {
+	release := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer slow.Close()
+	defer close(release)
+
+	handler := &recorder{}
+	fast := httptest.NewServer(handler)
+	defer fast.Close()
+
+	options := NewOptions()
+	options.Destinations = []Destination{{URL: slow.URL}, {URL: fast.URL}}
+	manager := newTestManager(t, options)
+
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, produce(t, manager, "k8s_cluster", testEvent()))
+	}
+	// the fast destination receives its events while the slow one holds its first request
+	assert.Eventually(t, func() bool { return len(handler.received()) == 3 }, time.Second, 5*time.Millisecond)
+}
+
+func TestWebhookManager_ShutdownDeadline(t *testing.T) {
+	handler := &recorder{statuses: []int{500, 500, 500, 500, 500, 500}}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	options := NewOptions()
+	options.Destinations = []Destination{{URL: server.URL}}
+	options.MaxRetries = 5
+	manager := newTestManager(t, options)
+	manager.Config.InitialBackoff = time.Hour
+	manager.Config.MaxBackoff = time.Hour
+
+	assert.NoError(t, produce(t, manager, "k8s_cluster", testEvent()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, manager.Shutdown(ctx), context.DeadlineExceeded)
+	assert.EqualError(t, produce(t, manager, "k8s_cluster", testEvent()), "webhook eventer is shut down")
+}
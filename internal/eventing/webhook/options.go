@@ -0,0 +1,131 @@
+package webhook
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/spf13/pflag"
+)
+
+const (
+	// ContentModeStructured posts the events as JSON encoded cloudevents.
+	ContentModeStructured = "structured"
+	// ContentModeBinary posts the event data as the request body and the cloudevent attributes as ce- headers.
+	ContentModeBinary = "binary"
+)
+
+// Destination is an endpoint the events are posted to.
+type Destination struct {
+	URL string `mapstructure:"url"`
+	// ResourceTypes limits the events sent to the destination, all events are sent when empty
+	ResourceTypes []string `mapstructure:"resource-types"`
+	// Secret is the key of the HMAC-SHA256 signature of the requests, requests are not signed when empty
+	Secret string `mapstructure:"secret"`
+}
+
+// Validate checks the destination has a valid absolute http(s) URL.
+func (d Destination) Validate() error {
+	if d.URL == "" {
+		return fmt.Errorf("url must be set")
+	}
+	u, err := url.Parse(d.URL)
+	if err != nil {
+		return fmt.Errorf("invalid url: %w", err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid url %s, expected an absolute http or https url", d.URL)
+	}
+	return nil
+}
+
+// Accepts reports whether the events of the resource type are sent to the destination.
+func (d Destination) Accepts(resourceType string) bool {
+	if len(d.ResourceTypes) == 0 {
+		return true
+	}
+	for _, accepted := range d.ResourceTypes {
+		if strings.EqualFold(accepted, resourceType) {
+			return true
+		}
+	}
+	return false
+}
+
+type Options struct {
+	Destinations []Destination `mapstructure:"destinations"`
+	// ContentMode is either ContentModeStructured or ContentModeBinary
+	ContentMode string `mapstructure:"content-mode"`
+	// QueueSize bounds the number of deliveries waiting to be sent to each destination, events are rejected when the
+	// queue of one of their destinations is full
+	QueueSize        int `mapstructure:"queue-size"`
+	MaxRetries       int `mapstructure:"max-retries"`
+	InitialBackoffMs int `mapstructure:"initial-backoff-ms"`
+	MaxBackoffMs     int `mapstructure:"max-backoff-ms"`
+	TimeoutMs        int `mapstructure:"timeout-ms"`
+}
+
+func NewOptions() *Options {
+	return &Options{
+		ContentMode:      ContentModeStructured,
+		QueueSize:        1000,
+		MaxRetries:       5,
+		InitialBackoffMs: 500,
+		MaxBackoffMs:     30000,
+		TimeoutMs:        10000,
+	}
+}
+
+func (o *Options) AddFlags(fs *pflag.FlagSet, prefix string) {
+	if prefix != "" {
+		prefix = prefix + "."
+	}
+
+	fs.StringVar(&o.ContentMode, prefix+"content-mode", o.ContentMode, "How events are posted.  Either structured, a JSON encoded cloudevent, or binary, the event data with the cloudevent attributes in ce- headers.")
+	fs.IntVar(&o.QueueSize, prefix+"queue-size", o.QueueSize, "Maximum number of deliveries waiting to be posted to each destination.  Events are rejected when the queue of one of their destinations is full.")
+	fs.IntVar(&o.MaxRetries, prefix+"max-retries", o.MaxRetries, "Number of times a failed delivery is retried before it is dropped.")
+	fs.IntVar(&o.InitialBackoffMs, prefix+"initial-backoff-ms", o.InitialBackoffMs, "Delay in milliseconds before the first retry, doubled after every retry.")
+	fs.IntVar(&o.MaxBackoffMs, prefix+"max-backoff-ms", o.MaxBackoffMs, "Maximum delay in milliseconds between two retries.")
+	fs.IntVar(&o.TimeoutMs, prefix+"timeout-ms", o.TimeoutMs, "Timeout in milliseconds of a single request.")
+}
+
+func (o *Options) Validate() []error {
+	var errs []error
+
+	if len(o.Destinations) == 0 {
+		errs = append(errs, fmt.Errorf("at least one destination must be set"))
+	}
+	for i, destination := range o.Destinations {
+		if err := destination.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("invalid destinations[%d]: %w", i, err))
+		}
+	}
+
+	if o.ContentMode != ContentModeStructured && o.ContentMode != ContentModeBinary {
+		errs = append(errs, fmt.Errorf("invalid content-mode %s. Options are '%s' and '%s'", o.ContentMode, ContentModeStructured, ContentModeBinary))
+	}
+
+	if o.QueueSize <= 0 {
+		errs = append(errs, fmt.Errorf("queue-size must be positive"))
+	}
+	if o.MaxRetries < 0 {
+		errs = append(errs, fmt.Errorf("max-retries must not be negative"))
+	}
+	if o.InitialBackoffMs <= 0 {
+		errs = append(errs, fmt.Errorf("initial-backoff-ms must be positive"))
+	}
+	if o.MaxBackoffMs < o.InitialBackoffMs {
+		errs = append(errs, fmt.Errorf("max-backoff-ms must not be less than initial-backoff-ms"))
+	}
+	if o.TimeoutMs <= 0 {
+		errs = append(errs, fmt.Errorf("timeout-ms must be positive"))
+	}
+
+	return errs
+}
+
+func (o *Options) Complete() []error {
+	var errs []error
+
+	return errs
+}
@@ -0,0 +1,206 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"buf.build/gen/go/bufbuild/protovalidate/protocolbuffers/go/buf/validate"
+	"github.com/bufbuild/protovalidate-go"
+	kerrors "github.com/go-kratos/kratos/v2/errors"
+	"github.com/xeipuuv/gojsonschema"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// FieldViolation describes why a single field of a request failed validation.
+type FieldViolation struct {
+	// Field is the JSON pointer of the field, using the JSON names of the request
+	Field string
+	// Rule is the protovalidate constraint id or the JSON schema keyword that failed
+	Rule    string
+	Message string
+}
+
+// SchemaValidationError is returned by ValidateJSONSchema when the data doesn't match the schema.
+// The fields of its violations are relative to the validated document.
+type SchemaValidationError struct {
+	Violations []FieldViolation
+	messages   []string
+}
+
+func (e *SchemaValidationError) Error() string {
+	return fmt.Sprintf("validation failed: %s", strings.Join(e.messages, "; "))
+}
+
+func newSchemaValidationError(result *gojsonschema.Result) *SchemaValidationError {
+	err := &SchemaValidationError{}
+	for _, desc := range result.Errors() {
+		field := ""
+		if desc.Field() != gojsonschema.STRING_ROOT_SCHEMA_PROPERTY {
+			for _, segment := range strings.Split(desc.Field(), ".") {
+				field += "/" + escapePointer(segment)
+			}
+		}
+		// required errors are reported on the parent object, point to the missing property instead
+		if property, ok := desc.Details()["property"].(string); ok && desc.Type() == "required" {
+			field += "/" + escapePointer(property)
+		}
+
+		err.Violations = append(err.Violations, FieldViolation{
+			Field:   field,
+			Rule:    desc.Type(),
+			Message: desc.Description(),
+		})
+		err.messages = append(err.messages, desc.String())
+	}
+	return err
+}
+
+// fieldError attributes a validation error to a field of the request.
+type fieldError struct {
+	field string
+	rule  string
+	err   error
+}
+
+func (e *fieldError) Error() string {
+	return e.err.Error()
+}
+
+func (e *fieldError) Unwrap() error {
+	return e.err
+}
+
+func atField(field, rule string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &fieldError{field: field, rule: rule, err: err}
+}
+
+// ValidationError is the BadRequest error returned by the Validation middleware. Its field violations are sent as
+// google.rpc.BadRequest details, both in the gRPC status and in the HTTP error body.
+type ValidationError struct {
+	status     *kerrors.Error
+	Violations []FieldViolation
+}
+
+func newValidationError(reason string, err error, violations []FieldViolation) *ValidationError {
+	return &ValidationError{
+		status:     kerrors.BadRequest(reason, err.Error()).WithCause(err),
+		Violations: violations,
+	}
+}
+
+func (e *ValidationError) Error() string {
+	return e.status.Error()
+}
+
+// Unwrap exposes the kratos error, so kratos middlewares and encoders keep the code and reason of the error.
+func (e *ValidationError) Unwrap() error {
+	return e.status
+}
+
+// BadRequest returns the field violations as google.rpc.BadRequest details.
+func (e *ValidationError) BadRequest() *errdetails.BadRequest {
+	badRequest := &errdetails.BadRequest{}
+	for _, violation := range e.Violations {
+		badRequest.FieldViolations = append(badRequest.FieldViolations, &errdetails.BadRequest_FieldViolation{
+			Field:       violation.Field,
+			Reason:      violation.Rule,
+			Description: violation.Message,
+		})
+	}
+	return badRequest
+}
+
+// GRPCStatus returns the status of the kratos error with the field violations added to its details.
+func (e *ValidationError) GRPCStatus() *status.Status {
+	s := e.status.GRPCStatus()
+	if withViolations, err := s.WithDetails(e.BadRequest()); err == nil {
+		return withViolations
+	}
+	return s
+}
+
+// protoValidationViolations converts the protovalidate violations of the request to field violations.
+func protoValidationViolations(msg protoreflect.ProtoMessage, err error) []FieldViolation {
+	var valErr *protovalidate.ValidationError
+	if !errors.As(err, &valErr) {
+		return []FieldViolation{{Message: err.Error()}}
+	}
+
+	violations := make([]FieldViolation, 0, len(valErr.Violations))
+	for _, violation := range valErr.Violations {
+		violations = append(violations, FieldViolation{
+			Field:   protoFieldPointer(msg.ProtoReflect().Descriptor(), violation.Proto.GetField()),
+			Rule:    violation.Proto.GetConstraintId(),
+			Message: violation.Proto.GetMessage(),
+		})
+	}
+	return violations
+}
+
+// jsonValidationViolations converts an error of the JSON validation of the request to field violations.
+func jsonValidationViolations(err error) []FieldViolation {
+	field, rule := "", ""
+	var fieldErr *fieldError
+	if errors.As(err, &fieldErr) {
+		field, rule = fieldErr.field, fieldErr.rule
+	}
+
+	var schemaErr *SchemaValidationError
+	if !errors.As(err, &schemaErr) {
+		return []FieldViolation{{Field: field, Rule: rule, Message: err.Error()}}
+	}
+
+	violations := make([]FieldViolation, 0, len(schemaErr.Violations))
+	for _, violation := range schemaErr.Violations {
+		violation.Field = field + violation.Field
+		violations = append(violations, violation)
+	}
+	return violations
+}
+
+// protoFieldPointer converts a protovalidate field path to a JSON pointer using the JSON names of the fields.
+func protoFieldPointer(desc protoreflect.MessageDescriptor, path *validate.FieldPath) string {
+	var pointer strings.Builder
+	for _, element := range path.GetElements() {
+		name := element.GetFieldName()
+
+		var field protoreflect.FieldDescriptor
+		if desc != nil {
+			field = desc.Fields().ByNumber(protoreflect.FieldNumber(element.GetFieldNumber()))
+		}
+		if field != nil {
+			name = field.JSONName()
+			desc = field.Message()
+			if field.IsMap() {
+				desc = field.MapValue().Message()
+			}
+		} else {
+			desc = nil
+		}
+		pointer.WriteString("/" + escapePointer(name))
+
+		switch subscript := element.GetSubscript().(type) {
+		case *validate.FieldPathElement_Index:
+			fmt.Fprintf(&pointer, "/%d", subscript.Index)
+		case *validate.FieldPathElement_BoolKey:
+			fmt.Fprintf(&pointer, "/%t", subscript.BoolKey)
+		case *validate.FieldPathElement_IntKey:
+			fmt.Fprintf(&pointer, "/%d", subscript.IntKey)
+		case *validate.FieldPathElement_UintKey:
+			fmt.Fprintf(&pointer, "/%d", subscript.UintKey)
+		case *validate.FieldPathElement_StringKey:
+			pointer.WriteString("/" + escapePointer(subscript.StringKey))
+		}
+	}
+	return pointer.String()
+}
+
+func escapePointer(segment string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(segment)
+}
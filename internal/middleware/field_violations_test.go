@@ -0,0 +1,157 @@
+package middleware_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/bufbuild/protovalidate-go"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	pbv1beta2 "github.com/project-kessel/inventory-api/api/kessel/inventory/v1beta2"
+	"github.com/project-kessel/inventory-api/internal/middleware"
+)
+
+// reportClusterRequest returns a valid k8s_cluster report, with the given resource data fields overridden.
+func reportClusterRequest(t *testing.T, overrides map[string]interface{}) *pbv1beta2.ReportResourceRequest {
+	resourceData := map[string]interface{}{
+		"external_cluster_id": "abcd-efgh-1234",
+		"cluster_status":      "READY",
+		"cluster_reason":      "All systems operational",
+		"kube_version":        "1.31",
+		"kube_vendor":         "OPENSHIFT",
+		"vendor_version":      "4.16",
+		"cloud_platform":      "AWS_UPI",
+	}
+	for field, value := range overrides {
+		if value == nil {
+			delete(resourceData, field)
+		} else {
+			resourceData[field] = value
+		}
+	}
+
+	data, err := structpb.NewStruct(resourceData)
+	assert.NoError(t, err)
+	common, err := structpb.NewStruct(map[string]interface{}{"workspace_id": "workspace-1"})
+	assert.NoError(t, err)
+
+	return &pbv1beta2.ReportResourceRequest{
+		Resource: &pbv1beta2.Resource{
+			ResourceType: "k8s_cluster",
+			ReporterData: &pbv1beta2.ReporterData{
+				ReporterType:       "OCM",
+				ReporterInstanceId: "ocm-instance",
+				LocalResourceId:    "cluster-1",
+				ApiHref:            "https://api.example.com",
+				ConsoleHref:        "https://console.example.com",
+				ResourceData:       data,
+			},
+			CommonResourceData: common,
+		},
+	}
+}
+
+func validate(t *testing.T, req interface{}) *middleware.ValidationError {
+	projectRoot, err := middleware.GetProjectRootPath()
+	assert.NoError(t, err)
+	assert.NoError(t, middleware.PreloadAllSchemasFromFilesystem(filepath.Join(projectRoot, "data", "schema", "resources")))
+
+	validator, err := protovalidate.New()
+	assert.NoError(t, err)
+
+	handler := middleware.Validation(validator)(func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, nil
+	})
+	_, err = handler(context.Background(), req)
+
+	var validationErr *middleware.ValidationError
+	assert.True(t, errors.As(err, &validationErr), "expected a validation error, got %v", err)
+	return validationErr
+}
+
+func TestValidation_ProtoFieldViolations(t *testing.T) {
+	req := reportClusterRequest(t, nil)
+	req.Resource.ResourceType = ""
+	req.Resource.ReporterData.LocalResourceId = ""
+
+	err := validate(t, req)
+
+	assert.Equal(t, []middleware.FieldViolation{
+		{Field: "/resource/resourceType", Rule: "string.min_len", Message: "value length must be at least 1 characters"},
+		{Field: "/resource/reporterData/localResourceId", Rule: "string.min_len", Message: "value length must be at least 1 characters"},
+	}, err.Violations)
+}
+
+func TestValidation_SchemaFieldViolations(t *testing.T) {
+	req := reportClusterRequest(t, map[string]interface{}{"external_cluster_id": nil, "cluster_status": "BROKEN", "kube_version": 1})
+
+	err := validate(t, req)
+
+	assert.ElementsMatch(t, []middleware.FieldViolation{
+		{Field: "/resource/reporterData/resourceData/external_cluster_id", Rule: "required", Message: "external_cluster_id is required"},
+		{Field: "/resource/reporterData/resourceData/cluster_status", Rule: "enum", Message: `cluster_status must be one of the following: "CLUSTER_STATUS_UNSPECIFIED", "CLUSTER_STATUS_OTHER", "READY", "FAILED", "OFFLINE"`},
+		{Field: "/resource/reporterData/resourceData/kube_version", Rule: "invalid_type", Message: "Invalid type. Expected: string, given: integer"},
+	}, err.Violations)
+	assert.Contains(t, err.Error(), "REPORT_RESOURCE_JSON_VALIDATOR")
+}
+
+func TestValidation_ReporterFieldViolation(t *testing.T) {
+	req := reportClusterRequest(t, nil)
+	req.Resource.ReporterData.ReporterType = "HBI"
+
+	err := validate(t, req)
+
+	assert.Len(t, err.Violations, 1)
+	assert.Equal(t, "/resource/reporterData/reporterType", err.Violations[0].Field)
+	assert.Equal(t, "resource_reporter", err.Violations[0].Rule)
+}
+
+func TestValidationError_GRPCStatus(t *testing.T) {
+	req := reportClusterRequest(t, map[string]interface{}{"cluster_status": "BROKEN"})
+
+	err := validate(t, req)
+
+	s, ok := status.FromError(err)
+	assert.True(t, ok)
+
+	var badRequest *errdetails.BadRequest
+	var errorInfo *errdetails.ErrorInfo
+	for _, detail := range s.Details() {
+		switch d := detail.(type) {
+		case *errdetails.BadRequest:
+			badRequest = d
+		case *errdetails.ErrorInfo:
+			errorInfo = d
+		}
+	}
+
+	assert.Equal(t, "REPORT_RESOURCE_JSON_VALIDATOR", errorInfo.GetReason())
+	assert.Len(t, badRequest.GetFieldViolations(), 1)
+	assert.Equal(t, "/resource/reporterData/resourceData/cluster_status", badRequest.GetFieldViolations()[0].GetField())
+	assert.Equal(t, "enum", badRequest.GetFieldViolations()[0].GetReason())
+}
+
+func TestValidateJSONSchema_Violations(t *testing.T) {
+	schema := `{
+		"type": "object",
+		"properties": {
+			"labels": { "type": "array", "items": { "type": "object", "properties": { "key": { "type": "string" } }, "required": ["key"] } }
+		}
+	}`
+
+	err := middleware.ValidateJSONSchema(schema, map[string]interface{}{
+		"labels": []interface{}{map[string]interface{}{"key": "a"}, map[string]interface{}{"value": "b"}},
+	})
+
+	var schemaErr *middleware.SchemaValidationError
+	assert.True(t, errors.As(err, &schemaErr))
+	assert.Equal(t, []middleware.FieldViolation{
+		{Field: "/labels/1/key", Rule: "required", Message: "key is required"},
+	}, schemaErr.Violations)
+	assert.EqualError(t, err, "validation failed: labels.1: key is required")
+}
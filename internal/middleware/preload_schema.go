@@ -2,31 +2,101 @@ package middleware
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
-	"github.com/go-kratos/kratos/v2/log"
-	"github.com/spf13/viper"
-	"gopkg.in/yaml.v3"
+	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
+	"strings"
 	"sync"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+
+	defaultschemas "github.com/project-kessel/inventory-api/data/schema"
+)
+
+// Schema sources selectable with resources.source
+const (
+	// SchemaSourceEmbedded loads the data/schema/resources tree embedded in the binary
+	SchemaSourceEmbedded = "embedded"
+	// SchemaSourceFilesystem loads the schema tree found at resources.schemaPath (or RESOURCE_DIR)
+	SchemaSourceFilesystem = "filesystem"
+	// SchemaSourceCache loads the cache file written by preload-schema, found at resources.cache_path
+	SchemaSourceCache = "cache"
 )
 
+const defaultSchemaCacheFile = "schema_cache.json"
+
 var schemaCache sync.Map
 
+// PreloadAllSchemas fills the schema cache from the source selected by resources.source. When no source is
+// configured, the cache file (resources.use_cache) or the schema directory (the given one, resources.schemaPath or
+// RESOURCE_DIR) is used when configured, and the embedded schemas otherwise. An error is returned when the selected or
+// configured source can't be loaded, the embedded schemas may be stale and are never a fallback.
 func PreloadAllSchemas(resourceDir string) error {
-	if viper.GetBool("resources.use_cache") {
-		if err := LoadSchemaCacheFromJSON("schema_cache.json"); err != nil {
-			log.Errorf("Failed to load schema cache from JSON: %v", err)
-			return err
+	source := viper.GetString("resources.source")
+	switch source {
+	case SchemaSourceEmbedded:
+		return preloadEmbeddedSchemas()
+	case SchemaSourceFilesystem:
+		return preloadFilesystemSchemas(resourceDir)
+	case SchemaSourceCache:
+		return preloadCachedSchemas()
+	case "":
+		if viper.GetBool("resources.use_cache") {
+			return preloadCachedSchemas()
 		}
-		log.Info("Using JSON cache based on resources directory")
-	} else {
-		if err := PreloadAllSchemasFromFilesystem(resourceDir); err != nil {
-			log.Errorf("Failed to preload schemas from filesystem: %v", err)
-			return err
+		if resourceDir != "" || viper.GetString("resources.schemaPath") != "" || os.Getenv("RESOURCE_DIR") != "" {
+			return preloadFilesystemSchemas(resourceDir)
+		}
+		return preloadEmbeddedSchemas()
+	default:
+		return fmt.Errorf("invalid resources.source %q. Options are '%s', '%s' and '%s'", source, SchemaSourceEmbedded, SchemaSourceFilesystem, SchemaSourceCache)
+	}
+}
+
+func preloadEmbeddedSchemas() error {
+	resources, err := fs.Sub(defaultschemas.Resources, "resources")
+	if err != nil {
+		return err
+	}
+	if err := PreloadAllSchemasFromFS(resources); err != nil {
+		return fmt.Errorf("failed to preload embedded schemas: %w", err)
+	}
+	log.Info("Using embedded resources schemas")
+	return nil
+}
+
+func preloadFilesystemSchemas(resourceDir string) error {
+	if resourceDir == "" {
+		resourceDir = viper.GetString("resources.schemaPath")
+		if resourceDirFilePath, exists := os.LookupEnv("RESOURCE_DIR"); exists {
+			absPath, err := filepath.Abs(resourceDirFilePath)
+			if err != nil {
+				return fmt.Errorf("failed to resolve absolute path for RESOURCE_DIR: %w", err)
+			}
+			resourceDir = absPath
 		}
-		log.Infof("Using local resources directory: %s", resourceDir)
 	}
+	if err := PreloadAllSchemasFromFilesystem(resourceDir); err != nil {
+		return fmt.Errorf("failed to preload schemas from filesystem: %w", err)
+	}
+	log.Infof("Using local resources directory: %s", resourceDir)
+	return nil
+}
+
+func preloadCachedSchemas() error {
+	cachePath := viper.GetString("resources.cache_path")
+	if cachePath == "" {
+		cachePath = defaultSchemaCacheFile
+	}
+	if err := LoadSchemaCacheFromJSON(cachePath); err != nil {
+		return fmt.Errorf("failed to load schema cache from JSON: %w", err)
+	}
+	log.Info("Using JSON cache based on resources directory")
 	return nil
 }
 
@@ -34,11 +104,17 @@ func PreloadAllSchemasFromFilesystem(resourceDir string) error {
 	if resourceDir == "" {
 		resourceDir = viper.GetString("resources.schemaPath")
 	}
-	resourceDirs, err := os.ReadDir(resourceDir)
+	return PreloadAllSchemasFromFS(os.DirFS(resourceDir))
+}
+
+// PreloadAllSchemasFromFS fills the schema cache from a resources schema tree.
+func PreloadAllSchemasFromFS(resources fs.FS) error {
+	resourceDirs, err := fs.ReadDir(resources, ".")
 	if err != nil {
 		return fmt.Errorf("no directories inside schema directory")
 	}
 
+	loaded := 0
 	for _, dir := range resourceDirs {
 		if !dir.IsDir() {
 			continue
@@ -46,25 +122,24 @@ func PreloadAllSchemasFromFilesystem(resourceDir string) error {
 		resourceType := NormalizeResourceType(dir.Name())
 
 		// Load and store common resource schema
-		commonResourceSchema, err := LoadCommonResourceDataSchema(resourceType, resourceDir)
+		commonResourceSchema, err := loadCommonResourceDataSchema(resources, resourceType)
 		if err == nil {
 			schemaCache.Store(fmt.Sprintf("common:%s", resourceType), commonResourceSchema)
 		}
 
-		_, err = loadConfigFile(resourceDir, resourceType)
+		_, err = loadConfigFile(resources, resourceType)
 		if err != nil {
 			log.Errorf("Failed to load config file for '%s': %v", resourceType, err)
 			return err
 		}
+		loaded++
 
-		reportersDir := filepath.Join(resourceDir, resourceType, "reporters")
-		if _, err := os.Stat(reportersDir); os.IsNotExist(err) {
-			continue
-		}
-
-		reporterDirs, err := os.ReadDir(reportersDir)
+		reportersDir := path.Join(resourceType, "reporters")
+		reporterDirs, err := fs.ReadDir(resources, reportersDir)
 		if err != nil {
-			log.Errorf("Failed to read reporters directory for '%s': %v", resourceType, err)
+			if !errors.Is(err, fs.ErrNotExist) {
+				log.Errorf("Failed to read reporters directory for '%s': %v", resourceType, err)
+			}
 			continue
 		}
 
@@ -73,7 +148,12 @@ func PreloadAllSchemasFromFilesystem(resourceDir string) error {
 				continue
 			}
 			reporterType := reporter.Name()
-			reporterSchema, isReporterSchemaExists, err := LoadResourceSchema(resourceType, reporterType, resourceDir)
+			if err := loadReporterConfigFile(resources, resourceType, reporterType); err != nil {
+				log.Errorf("Failed to load reporter config file for '%s:%s': %v", resourceType, reporterType, err)
+				return err
+			}
+
+			reporterSchema, isReporterSchemaExists, err := loadResourceSchema(resources, resourceType, reporterType)
 			if err == nil && isReporterSchemaExists {
 				schemaCache.Store(fmt.Sprintf("%s:%s", resourceType, reporterType), reporterSchema)
 			} else {
@@ -82,6 +162,9 @@ func PreloadAllSchemasFromFilesystem(resourceDir string) error {
 		}
 	}
 
+	if loaded == 0 {
+		return fmt.Errorf("no resource schemas found in schema directory")
+	}
 	return nil
 }
 
@@ -98,6 +181,10 @@ func LoadSchemaCacheFromJSON(filePath string) error {
 		return fmt.Errorf("failed to unmarshal schema cache JSON: %w", err)
 	}
 
+	if !hasResourceConfig(cacheMap) {
+		return fmt.Errorf("no resource schemas found in schema cache file %s", filePath)
+	}
+
 	for key, value := range cacheMap {
 		schemaCache.Store(key, value)
 	}
@@ -114,7 +201,17 @@ func getSchemaFromCache(cacheKey string) (string, error) {
 	return "", fmt.Errorf("schema not found for key '%s'", cacheKey)
 }
 
-func loadConfigFile(resourceDir string, resourceType string) (struct {
+// hasResourceConfig reports whether the cache holds the config.yaml of at least one resource type
+func hasResourceConfig(cacheMap map[string]interface{}) bool {
+	for key := range cacheMap {
+		if name, ok := strings.CutPrefix(key, "config:"); ok && !strings.Contains(name, ":") {
+			return true
+		}
+	}
+	return false
+}
+
+func loadConfigFile(resources fs.FS, resourceType string) (struct {
 	ResourceType      string   `yaml:"resource_type"`
 	ResourceReporters []string `yaml:"resource_reporters"`
 }, error) {
@@ -122,8 +219,7 @@ func loadConfigFile(resourceDir string, resourceType string) (struct {
 		ResourceType      string   `yaml:"resource_type"`
 		ResourceReporters []string `yaml:"resource_reporters"`
 	}
-	configPath := filepath.Join(resourceDir, resourceType, "config.yaml")
-	configData, err := os.ReadFile(configPath)
+	configData, err := fs.ReadFile(resources, path.Join(resourceType, "config.yaml"))
 	if err != nil {
 		return config, fmt.Errorf("failed to read config file for '%s': %w", resourceType, err)
 	}
@@ -137,3 +233,21 @@ func loadConfigFile(resourceDir string, resourceType string) (struct {
 	schemaCache.Store(fmt.Sprintf("config:%s", configResourceType), configData)
 	return config, nil
 }
+
+// loadReporterConfigFile validates and caches the optional reporters/<reporter>/config.yaml
+func loadReporterConfigFile(resources fs.FS, resourceType string, reporterType string) error {
+	configData, err := fs.ReadFile(resources, path.Join(resourceType, "reporters", reporterType, "config.yaml"))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("failed to read reporter config file: %w", err)
+	}
+
+	name := fmt.Sprintf("%s:%s", resourceType, strings.ToLower(reporterType))
+	if _, err := parseReporterConfig(name, configData); err != nil {
+		return err
+	}
+	schemaCache.Store(fmt.Sprintf("config:%s", name), configData)
+	return nil
+}
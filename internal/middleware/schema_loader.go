@@ -3,13 +3,70 @@ package middleware
 import (
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"github.com/spf13/viper"
 	"gopkg.in/yaml.v3"
+	"io/fs"
 	"os"
-	"path/filepath"
+	"path"
+	"slices"
+	"strings"
 )
 
+var ErrConfigNotFound = errors.New("config not found")
+
+// ReporterConfig describes the reporters/<reporter>/config.yaml of a resource type.
+type ReporterConfig struct {
+	ResourceType string           `yaml:"resource_type" json:"resource_type"`
+	ReporterName string           `yaml:"reporter_name" json:"reporter_name"`
+	Namespace    string           `yaml:"namespace" json:"namespace"`
+	Relations    []RelationConfig `yaml:"relations" json:"relations"`
+}
+
+// RelationConfig declares a relations tuple that is derived from the reported data and written
+// alongside the workspace tuple, e.g.
+//
+//	relations:
+//	  - relation: t_cluster
+//	    subject_namespace: acm
+//	    subject_type: k8s_cluster
+//	    subject_id_field: resource_data.cluster_id
+//
+// subject_id_field is either a field of the resource (org_id, workspace_id, reporter_id,
+// reporter_instance_id, local_resource_id) or a dot separated path inside resource_data.
+// When subject_namespace is omitted, the namespace of the resource is used.
+type RelationConfig struct {
+	Relation         string `yaml:"relation" json:"relation"`
+	SubjectNamespace string `yaml:"subject_namespace" json:"subject_namespace"`
+	SubjectType      string `yaml:"subject_type" json:"subject_type"`
+	SubjectRelation  string `yaml:"subject_relation" json:"subject_relation"`
+	SubjectIdField   string `yaml:"subject_id_field" json:"subject_id_field"`
+}
+
+// relationResourceFields are the fields of the resource a subject_id_field can name.
+var relationResourceFields = []string{"org_id", "workspace_id", "reporter_id", "reporter_instance_id", "local_resource_id"}
+
+func (r RelationConfig) Validate() error {
+	if r.Relation == "" {
+		return fmt.Errorf("missing 'relation' field in relation")
+	}
+	if r.Relation == "workspace" {
+		return fmt.Errorf("relation 'workspace' is managed by inventory and may not be declared")
+	}
+	if r.SubjectType == "" {
+		return fmt.Errorf("missing 'subject_type' field in relation '%s'", r.Relation)
+	}
+	switch {
+	case r.SubjectIdField == "":
+		return fmt.Errorf("missing 'subject_id_field' field in relation '%s'", r.Relation)
+	case strings.HasPrefix(r.SubjectIdField, "resource_data."):
+	case slices.Contains(relationResourceFields, r.SubjectIdField):
+	default:
+		return fmt.Errorf("unsupported 'subject_id_field' %s in relation '%s'", r.SubjectIdField, r.Relation)
+	}
+	return nil
+}
+
 func ValidateResourceReporterCombination(resourceType, reporterType string) error {
 	resourceReporters, err := LoadValidReporters(resourceType)
 	if err != nil {
@@ -27,19 +84,15 @@ func ValidateResourceReporterCombination(resourceType, reporterType string) erro
 
 // LoadResourceSchema finds the resources schema based on the directory structure of data/resources
 func LoadResourceSchema(resourceType string, reporterType string, dir string) (string, bool, error) {
-	schemaPath := filepath.Join(dir, resourceType, "reporters", reporterType, fmt.Sprintf("%s.json", resourceType))
+	return loadResourceSchema(os.DirFS(dir), resourceType, reporterType)
+}
 
-	// Check if file exists
-	if _, err := os.Stat(schemaPath); err != nil {
-		if os.IsNotExist(err) {
+func loadResourceSchema(resources fs.FS, resourceType string, reporterType string) (string, bool, error) {
+	data, err := fs.ReadFile(resources, path.Join(resourceType, "reporters", reporterType, fmt.Sprintf("%s.json", resourceType)))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
 			return "", false, nil
 		}
-		return "", false, fmt.Errorf("failed to check schema file for '%s': %w", resourceType, err)
-	}
-
-	// Read file
-	data, err := os.ReadFile(schemaPath)
-	if err != nil {
 		return "", false, fmt.Errorf("failed to read schema file for '%s': %w", resourceType, err)
 	}
 
@@ -48,97 +101,140 @@ func LoadResourceSchema(resourceType string, reporterType string, dir string) (s
 
 // Load Common Resource Data Schema
 func LoadCommonResourceDataSchema(resourceType string, baseSchemaDir string) (string, error) {
+	return loadCommonResourceDataSchema(os.DirFS(baseSchemaDir), resourceType)
+}
 
-	schemaPath := filepath.Join(baseSchemaDir, resourceType, "common_resource_data.json")
-
-	data, err := os.ReadFile(schemaPath)
+func loadCommonResourceDataSchema(resources fs.FS, resourceType string) (string, error) {
+	data, err := fs.ReadFile(resources, path.Join(resourceType, "common_resource_data.json"))
 	if err != nil {
 		return "", fmt.Errorf("failed to read common resource schema: %w", err)
 	}
 	return string(data), nil
 }
 
-// LoadValidReporters retrieves valid reporters for a given resource type.
-// It either loads from the cache (JSON-based) or the filesystem (YAML-based).
+// LoadValidReporters retrieves valid reporters for a given resource type from its cached config.yaml,
+// whichever source the schemas were loaded from.
 func LoadValidReporters(resourceType string) ([]string, error) {
-	if viper.GetBool("resources.use_cache") {
-		return loadFromCache(resourceType)
-	}
-	return loadFromFilesystem(resourceType)
+	return loadFromCache(resourceType)
 }
 
-// LoadValidReporters Takes the resource_type from the provided config.yaml and compares it to the defined reporter_types
-func loadFromFilesystem(resourceType string) ([]string, error) {
-	var config struct {
-		ResourceReporters []string `yaml:"resource_reporters"`
-	}
-
+func loadFromCache(resourceType string) ([]string, error) {
 	cacheKey := fmt.Sprintf("config:%s", resourceType)
+
 	cachedConfig, ok := schemaCache.Load(cacheKey)
 	if !ok {
-		return nil, fmt.Errorf("config not found for resource type '%s'", resourceType)
+		return nil, fmt.Errorf("config not found in cache for resource type '%s'", resourceType)
 	}
 
-	configData, ok := cachedConfig.([]byte)
-	if !ok {
-		return nil, fmt.Errorf("invalid config data type for resource type '%s' (expected string)", resourceType)
+	configData, err := decodeCachedConfig(resourceType, cachedConfig)
+	if err != nil {
+		return nil, err
 	}
 
+	// Parse YAML or JSON
+	var config struct {
+		ResourceReporters []string `yaml:"resource_reporters" json:"resource_reporters"`
+	}
 	if err := yaml.Unmarshal(configData, &config); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal config for '%s': %w", resourceType, err)
+		return nil, fmt.Errorf("failed to parse config for '%s': %w", resourceType, err)
 	}
 
 	if config.ResourceReporters == nil {
-		return nil, fmt.Errorf("missing 'resource_reporters' field in config for '%s'", resourceType)
+		return nil, fmt.Errorf("missing 'resource_reporters' field in cache for '%s'", resourceType)
 	}
 
 	return config.ResourceReporters, nil
 }
 
-func loadFromCache(resourceType string) ([]string, error) {
-	cacheKey := fmt.Sprintf("config:%s", resourceType)
-
-	cachedConfig, ok := schemaCache.Load(cacheKey)
-	if !ok {
-		return nil, fmt.Errorf("config not found in cache for resource type '%s'", resourceType)
-	}
-
-	var configData []byte
-
-	// Handle different cases
+// decodeCachedConfig returns the YAML (or JSON) bytes of a config entry, which is stored as raw bytes when
+// loaded from the filesystem and as a Base64 string when loaded from the JSON cache.
+func decodeCachedConfig(name string, cachedConfig interface{}) ([]byte, error) {
 	switch v := cachedConfig.(type) {
 	case string:
 		decoded, err := base64.StdEncoding.DecodeString(v)
 		if err != nil {
 			// If not Base64, assume it's plain YAML
-			configData = []byte(v)
-		} else {
-			configData = decoded
+			return []byte(v), nil
 		}
+		return decoded, nil
 	case []byte:
-		configData = v
+		return v, nil
 	case map[string]interface{}:
 		// Convert JSON object back to bytes
 		jsonData, err := json.Marshal(v)
 		if err != nil {
-			return nil, fmt.Errorf("failed to marshal JSON config for '%s': %w", resourceType, err)
+			return nil, fmt.Errorf("failed to marshal JSON config for '%s': %w", name, err)
 		}
-		configData = jsonData
+		return jsonData, nil
 	default:
-		return nil, fmt.Errorf("unexpected data type for '%s' in cache: %T", resourceType, cachedConfig)
+		return nil, fmt.Errorf("unexpected data type for '%s' in cache: %T", name, cachedConfig)
 	}
+}
 
-	// Parse YAML or JSON
-	var config struct {
-		ResourceReporters []string `yaml:"resource_reporters" json:"resource_reporters"`
+// LoadResourceTypes returns the resource types of the loaded schemas, sorted.
+func LoadResourceTypes() []string {
+	var resourceTypes []string
+	schemaCache.Range(func(key, _ interface{}) bool {
+		if name, ok := strings.CutPrefix(key.(string), "config:"); ok && !strings.Contains(name, ":") {
+			resourceTypes = append(resourceTypes, name)
+		}
+		return true
+	})
+	slices.Sort(resourceTypes)
+	return resourceTypes
+}
+
+// LoadReporterConfig retrieves the reporter config.yaml for the given resource and reporter type.
+// ErrConfigNotFound is returned when the reporter doesn't declare a config.
+func LoadReporterConfig(resourceType, reporterType string) (*ReporterConfig, error) {
+	name := fmt.Sprintf("%s:%s", NormalizeResourceType(resourceType), strings.ToLower(reporterType))
+
+	cachedConfig, ok := schemaCache.Load(fmt.Sprintf("config:%s", name))
+	if !ok {
+		return nil, fmt.Errorf("%w for '%s'", ErrConfigNotFound, name)
+	}
+
+	configData, err := decodeCachedConfig(name, cachedConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseReporterConfig(name, configData)
+}
+
+// ResolveNamespace returns the relations namespace of the resources reported by the given reporter: the
+// namespace declared in its config.yaml, or the lower-cased reporter type when it doesn't declare one.
+func ResolveNamespace(resourceType, reporterType string) (string, error) {
+	fallback := strings.ToLower(reporterType)
+	if resourceType == "" || reporterType == "" {
+		return fallback, nil
+	}
+
+	config, err := LoadReporterConfig(resourceType, reporterType)
+	if err != nil {
+		if errors.Is(err, ErrConfigNotFound) {
+			return fallback, nil
+		}
+		return "", err
 	}
+
+	if config.Namespace == "" {
+		return fallback, nil
+	}
+	return config.Namespace, nil
+}
+
+func parseReporterConfig(name string, configData []byte) (*ReporterConfig, error) {
+	var config ReporterConfig
 	if err := yaml.Unmarshal(configData, &config); err != nil {
-		return nil, fmt.Errorf("failed to parse config for '%s': %w", resourceType, err)
+		return nil, fmt.Errorf("failed to parse reporter config for '%s': %w", name, err)
 	}
 
-	if config.ResourceReporters == nil {
-		return nil, fmt.Errorf("missing 'resource_reporters' field in cache for '%s'", resourceType)
+	for _, relation := range config.Relations {
+		if err := relation.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid reporter config for '%s': %w", name, err)
+		}
 	}
 
-	return config.ResourceReporters, nil
+	return &config, nil
 }
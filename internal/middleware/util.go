@@ -157,11 +157,7 @@ func ValidateJSONSchema(schemaStr string, jsonData interface{}) error {
 		return fmt.Errorf("validation error: %w", err)
 	}
 	if !result.Valid() {
-		var errMsgs []string
-		for _, desc := range result.Errors() {
-			errMsgs = append(errMsgs, desc.String())
-		}
-		return fmt.Errorf("validation failed: %s", strings.Join(errMsgs, "; "))
+		return newSchemaValidationError(result)
 	}
 	return nil
 }
@@ -2,10 +2,12 @@ package middleware_test
 
 import (
 	pbv1beta2 "github.com/project-kessel/inventory-api/api/kessel/inventory/v1beta2"
+	"os"
 	"path/filepath"
 	"testing"
 
 	"github.com/project-kessel/inventory-api/internal/middleware"
+	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -461,3 +463,211 @@ func TestSchemaValidation(t *testing.T) {
 		})
 	}
 }
+
+func writeSchemaFile(t *testing.T, path string, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("Failed to create schema dir: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write schema file: %v", err)
+	}
+}
+
+func TestLoadReporterConfig(t *testing.T) {
+	schemaDir := t.TempDir()
+	writeSchemaFile(t, filepath.Join(schemaDir, "relations_policy", "config.yaml"), `resource_type: relations_policy
+resource_reporters:
+  - ACM
+`)
+	writeSchemaFile(t, filepath.Join(schemaDir, "relations_policy", "common_resource_data.json"), `{"type": "object"}`)
+	writeSchemaFile(t, filepath.Join(schemaDir, "relations_policy", "reporters", "acm", "config.yaml"), `resource_type: relations_policy
+reporter_name: acm
+namespace: acm
+relations:
+  - relation: t_cluster
+    subject_type: k8s_cluster
+    subject_id_field: resource_data.cluster_id
+  - relation: t_owner
+    subject_namespace: rbac
+    subject_type: principal
+    subject_id_field: reporter_id
+`)
+
+	err := middleware.PreloadAllSchemasFromFilesystem(schemaDir)
+	assert.NoError(t, err)
+
+	config, err := middleware.LoadReporterConfig("relations_policy", "ACM")
+	assert.NoError(t, err)
+	assert.Equal(t, "acm", config.Namespace)
+	assert.Equal(t, []middleware.RelationConfig{
+		{Relation: "t_cluster", SubjectType: "k8s_cluster", SubjectIdField: "resource_data.cluster_id"},
+		{Relation: "t_owner", SubjectNamespace: "rbac", SubjectType: "principal", SubjectIdField: "reporter_id"},
+	}, config.Relations)
+
+	_, err = middleware.LoadReporterConfig("relations_policy", "OCM")
+	assert.ErrorIs(t, err, middleware.ErrConfigNotFound)
+}
+
+func TestLoadReporterConfig_InvalidRelations(t *testing.T) {
+	tests := []struct {
+		name           string
+		relations      string
+		expectedErrMsg string
+	}{
+		{
+			name: "missing subject type",
+			relations: `  - relation: t_cluster
+    subject_id_field: resource_data.cluster_id
+`,
+			expectedErrMsg: "missing 'subject_type' field in relation 't_cluster'",
+		},
+		{
+			name: "unsupported subject field",
+			relations: `  - relation: t_cluster
+    subject_type: k8s_cluster
+    subject_id_field: cluster_id
+`,
+			expectedErrMsg: "unsupported 'subject_id_field' cluster_id in relation 't_cluster'",
+		},
+		{
+			name: "workspace relation",
+			relations: `  - relation: workspace
+    subject_type: workspace
+    subject_id_field: workspace_id
+`,
+			expectedErrMsg: "relation 'workspace' is managed by inventory and may not be declared",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schemaDir := t.TempDir()
+			writeSchemaFile(t, filepath.Join(schemaDir, "invalid_relations", "config.yaml"), `resource_type: invalid_relations
+resource_reporters:
+  - ACM
+`)
+			writeSchemaFile(t, filepath.Join(schemaDir, "invalid_relations", "reporters", "acm", "config.yaml"), "resource_type: invalid_relations\nrelations:\n"+tt.relations)
+
+			err := middleware.PreloadAllSchemasFromFilesystem(schemaDir)
+			assert.ErrorContains(t, err, tt.expectedErrMsg)
+		})
+	}
+}
+
+func TestResolveNamespace(t *testing.T) {
+	schemaDir := t.TempDir()
+	writeSchemaFile(t, filepath.Join(schemaDir, "namespaced_cluster", "config.yaml"), `resource_type: namespaced_cluster
+resource_reporters:
+  - ACM
+  - OCM
+  - ACS
+`)
+	writeSchemaFile(t, filepath.Join(schemaDir, "namespaced_cluster", "reporters", "acm", "config.yaml"), `resource_type: namespaced_cluster
+reporter_name: acm
+namespace: kubernetes
+`)
+	writeSchemaFile(t, filepath.Join(schemaDir, "namespaced_cluster", "reporters", "ocm", "config.yaml"), `resource_type: namespaced_cluster
+reporter_name: ocm
+`)
+
+	err := middleware.PreloadAllSchemasFromFilesystem(schemaDir)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name              string
+		resourceType      string
+		reporterType      string
+		expectedNamespace string
+	}{
+		{name: "namespace declared in reporter config", resourceType: "namespaced_cluster", reporterType: "ACM", expectedNamespace: "kubernetes"},
+		{name: "reporter config without namespace", resourceType: "namespaced_cluster", reporterType: "OCM", expectedNamespace: "ocm"},
+		{name: "reporter without config", resourceType: "namespaced_cluster", reporterType: "ACS", expectedNamespace: "acs"},
+		{name: "missing resource type", resourceType: "", reporterType: "ACM", expectedNamespace: "acm"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			namespace, err := middleware.ResolveNamespace(tt.resourceType, tt.reporterType)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedNamespace, namespace)
+		})
+	}
+}
+
+func TestPreloadAllSchemas_Sources(t *testing.T) {
+	emptyDir := t.TempDir()
+	emptyCache := filepath.Join(t.TempDir(), "schema_cache.json")
+	writeSchemaFile(t, emptyCache, `{"common:k8s_cluster": "{}"}`)
+
+	tests := []struct {
+		name           string
+		settings       map[string]interface{}
+		resourceDir    string
+		expectedErrMsg string
+	}{
+		{
+			name:     "embedded schemas",
+			settings: map[string]interface{}{"resources.source": middleware.SchemaSourceEmbedded},
+		},
+		{
+			name:           "filesystem without schemas",
+			settings:       map[string]interface{}{"resources.source": middleware.SchemaSourceFilesystem},
+			resourceDir:    emptyDir,
+			expectedErrMsg: "no resource schemas found in schema directory",
+		},
+		{
+			name:           "missing cache file",
+			settings:       map[string]interface{}{"resources.source": middleware.SchemaSourceCache, "resources.cache_path": filepath.Join(emptyDir, "missing.json")},
+			expectedErrMsg: "failed to read schema cache file",
+		},
+		{
+			name:           "cache file without resource configs",
+			settings:       map[string]interface{}{"resources.source": middleware.SchemaSourceCache, "resources.cache_path": emptyCache},
+			expectedErrMsg: "no resource schemas found in schema cache file",
+		},
+		{
+			name:           "unknown source",
+			settings:       map[string]interface{}{"resources.source": "s3"},
+			expectedErrMsg: `invalid resources.source "s3"`,
+		},
+		{
+			name:           "no source with a missing cache file",
+			settings:       map[string]interface{}{"resources.use_cache": true, "resources.cache_path": filepath.Join(emptyDir, "missing.json")},
+			expectedErrMsg: "failed to read schema cache file",
+		},
+		{
+			name:           "no source with a schema path without schemas",
+			settings:       map[string]interface{}{"resources.schemaPath": emptyDir},
+			expectedErrMsg: "no resource schemas found in schema directory",
+		},
+		{
+			name:     "nothing configured uses the embedded schemas",
+			settings: map[string]interface{}{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for key, value := range tt.settings {
+				viper.Set(key, value)
+			}
+			t.Cleanup(func() {
+				for key := range tt.settings {
+					viper.Set(key, nil)
+				}
+			})
+
+			err := middleware.PreloadAllSchemas(tt.resourceDir)
+			if tt.expectedErrMsg != "" {
+				assert.ErrorContains(t, err, tt.expectedErrMsg)
+				return
+			}
+
+			assert.NoError(t, err)
+			reporters, err := middleware.LoadValidReporters("k8s_cluster")
+			assert.NoError(t, err)
+			assert.Contains(t, reporters, "ACM")
+		})
+	}
+}
@@ -0,0 +1,33 @@
+package reconciler
+
+import "time"
+
+type Config struct {
+	*Options
+}
+
+type completedConfig struct {
+	Interval  time.Duration
+	Repair    bool
+	Rate      int
+	BatchSize int
+}
+
+type CompletedConfig struct {
+	*completedConfig
+}
+
+func NewConfig(o *Options) *Config {
+	return &Config{
+		Options: o,
+	}
+}
+
+func (c *Config) Complete() CompletedConfig {
+	return CompletedConfig{&completedConfig{
+		Interval:  time.Duration(c.IntervalMs) * time.Millisecond,
+		Repair:    c.Repair,
+		Rate:      c.Rate,
+		BatchSize: c.BatchSize,
+	}}
+}
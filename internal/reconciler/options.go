@@ -0,0 +1,59 @@
+package reconciler
+
+import (
+	"fmt"
+
+	"github.com/spf13/pflag"
+)
+
+type Options struct {
+	// IntervalMs is the time between two runs of the reconciler in the background of the server, 0 disables it
+	IntervalMs int `mapstructure:"interval-ms"`
+	// Repair fixes the drift found, otherwise it is only reported
+	Repair bool `mapstructure:"repair"`
+	// Rate is the maximum number of calls to relations-api per second, 0 disables the limit
+	Rate      int `mapstructure:"rate"`
+	BatchSize int `mapstructure:"batch-size"`
+}
+
+func NewOptions() *Options {
+	return &Options{
+		IntervalMs: 0,
+		Repair:     false,
+		Rate:       20,
+		BatchSize:  500,
+	}
+}
+
+func (o *Options) AddFlags(fs *pflag.FlagSet, prefix string) {
+	if prefix != "" {
+		prefix = prefix + "."
+	}
+
+	fs.IntVar(&o.IntervalMs, prefix+"interval-ms", o.IntervalMs, "Interval in milliseconds between two runs of the reconciler in the background of the server, 0 disables it.")
+	fs.BoolVar(&o.Repair, prefix+"repair", o.Repair, "Repair the drift between the resources and their workspace tuples, otherwise it is only reported.")
+	fs.IntVar(&o.Rate, prefix+"rate", o.Rate, "Maximum number of calls to relations-api per second, 0 disables the limit.")
+	fs.IntVar(&o.BatchSize, prefix+"batch-size", o.BatchSize, "Number of resources, and of workspace tuples, read at a time.")
+}
+
+func (o *Options) Validate() []error {
+	var errs []error
+
+	if o.IntervalMs < 0 {
+		errs = append(errs, fmt.Errorf("interval-ms must be 0 or more"))
+	}
+	if o.Rate < 0 {
+		errs = append(errs, fmt.Errorf("rate must be 0 or more"))
+	}
+	if o.BatchSize <= 0 {
+		errs = append(errs, fmt.Errorf("batch-size must be positive"))
+	}
+
+	return errs
+}
+
+func (o *Options) Complete() []error {
+	var errs []error
+
+	return errs
+}
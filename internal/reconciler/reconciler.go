@@ -0,0 +1,605 @@
+package reconciler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	kessel "github.com/project-kessel/relations-api/api/kessel/relations/v1beta1"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"golang.org/x/time/rate"
+	"google.golang.org/protobuf/proto"
+	"gorm.io/gorm"
+
+	authzapi "github.com/project-kessel/inventory-api/internal/authz/api"
+	"github.com/project-kessel/inventory-api/internal/biz/model"
+	"github.com/project-kessel/inventory-api/internal/middleware"
+	"github.com/project-kessel/inventory-api/internal/storage"
+)
+
+// ErrInProgress is returned when another replica, or the reconcile command, is reconciling.
+var ErrInProgress = errors.New("another reconciliation is in progress")
+
+// lockKey is the key of the postgres advisory lock held while reconciling
+const lockKey int64 = 0x696e765f7265636f // "inv_reco"
+
+// Kind is a kind of drift between a resource of the inventory database and its workspace tuples in relations-api.
+type Kind string
+
+const (
+	// Missing is a resource without workspace tuple
+	Missing Kind = "missing"
+	// Stale is a resource whose workspace tuples don't relate it to its workspace, or not only to it
+	Stale Kind = "stale"
+	// Orphaned is a workspace tuple of a resource which is not in the inventory database
+	Orphaned Kind = "orphaned"
+)
+
+// Drift is a resource found out of sync with relations-api.
+type Drift struct {
+	Kind         Kind   `json:"kind"`
+	Namespace    string `json:"namespace"`
+	ResourceType string `json:"resource_type"`
+	// ResourceId is the local resource id of the reporter, the id of the resource in the tuples
+	ResourceId string `json:"resource_id"`
+	// Workspace is the workspace of the resource in the inventory database, it is empty for orphaned tuples
+	Workspace string `json:"workspace,omitempty"`
+	// TupleWorkspaces are the workspaces the tuples of relations-api relate the resource to
+	TupleWorkspaces []string `json:"tuple_workspaces,omitempty"`
+	Repaired        bool     `json:"repaired"`
+	Error           string   `json:"error,omitempty"`
+}
+
+// Report is the outcome of a reconciliation.
+type Report struct {
+	// Resources is the number of resources compared to their tuples
+	Resources int `json:"resources"`
+	// Tuples is the number of workspace tuples read from relations-api
+	Tuples int     `json:"tuples"`
+	Drifts []Drift `json:"drifts"`
+}
+
+// Count returns the number of drifts of the kind.
+func (r *Report) Count(kind Kind) int {
+	count := 0
+	for _, drift := range r.Drifts {
+		if drift.Kind == kind {
+			count++
+		}
+	}
+	return count
+}
+
+// Repaired returns the number of drifts repaired.
+func (r *Report) Repaired() int {
+	count := 0
+	for _, drift := range r.Drifts {
+		if drift.Repaired {
+			count++
+		}
+	}
+	return count
+}
+
+// Reconciler compares the workspace of the resources of the inventory database with their workspace tuples in
+// relations-api.  Resources are compared by relations type: the resources and the workspace tuples of the type are
+// paged through side by side in the order of the resource ids, the tuples without resource are the ones of deleted
+// resources.  On postgres, a single reconciliation runs at a time across the replicas and the reconcile command.
+type Reconciler struct {
+	DB       *gorm.DB
+	Authz    authzapi.Authorizer
+	Logger   *log.Helper
+	Interval time.Duration
+	Repair   bool
+	// Rate is the maximum number of calls to relations-api per second, 0 disables the limit
+	Rate      int
+	BatchSize int
+
+	driftCounter         metric.Int64Counter
+	repairedCounter      metric.Int64Counter
+	repairFailureCounter metric.Int64Counter
+}
+
+func New(config CompletedConfig, db *gorm.DB, authorizer authzapi.Authorizer, logger *log.Helper) (*Reconciler, error) {
+	meter := otel.Meter("github.com/project-kessel/inventory-api/blob/main/internal/server/otel")
+
+	driftCounter, err := meter.Int64Counter("inventory_reconciler_drift")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create drift counter: %w", err)
+	}
+
+	repairedCounter, err := meter.Int64Counter("inventory_reconciler_repaired")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create repaired counter: %w", err)
+	}
+
+	repairFailureCounter, err := meter.Int64Counter("inventory_reconciler_repair_failures")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create repair failures counter: %w", err)
+	}
+
+	return &Reconciler{
+		DB:                   db,
+		Authz:                authorizer,
+		Logger:               logger,
+		Interval:             config.Interval,
+		Repair:               config.Repair,
+		Rate:                 config.Rate,
+		BatchSize:            config.BatchSize,
+		driftCounter:         driftCounter,
+		repairedCounter:      repairedCounter,
+		repairFailureCounter: repairFailureCounter,
+	}, nil
+}
+
+// Run reconciles every interval until the context is done.  A failed run is logged, the next one starts over.
+func (r *Reconciler) Run(ctx context.Context) error {
+	if r.Interval <= 0 {
+		return fmt.Errorf("the interval of the reconciler must be positive")
+	}
+	ticker := time.NewTicker(r.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		report, err := r.Reconcile(ctx)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if errors.Is(err, ErrInProgress) {
+			r.Logger.Info("Skipping the reconciliation, another one is in progress")
+			continue
+		}
+		if err != nil {
+			r.Logger.Errorf("Reconciliation failed after %d resource(s): %v", report.Resources, err)
+			continue
+		}
+		r.Logger.Infof("Reconciled %d resource(s) and %d workspace tuple(s): %d missing, %d stale, %d orphaned, %d repaired",
+			report.Resources, report.Tuples, report.Count(Missing), report.Count(Stale), report.Count(Orphaned), report.Repaired())
+	}
+}
+
+// Reconcile compares every resource with its workspace tuples, and repairs the drift when configured to.  Repairs
+// failing are recorded in the report, the reconciliation goes on.  It fails with ErrInProgress when another
+// reconciliation holds the lock.
+func (r *Reconciler) Reconcile(ctx context.Context) (*Report, error) {
+	report := &Report{Drifts: []Drift{}}
+	unlock, err := r.lock(ctx)
+	if err != nil {
+		return report, err
+	}
+	defer unlock()
+
+	limit := rate.Inf
+	if r.Rate > 0 {
+		limit = rate.Limit(r.Rate)
+	}
+	limiter := rate.NewLimiter(limit, 1)
+
+	types, err := r.resourceTypes(ctx)
+	if err != nil {
+		return report, err
+	}
+	for _, t := range types {
+		if err := r.reconcileType(ctx, t, limiter, report); err != nil {
+			return report, fmt.Errorf("failed to reconcile %s/%s: %w", t.namespace, t.name, err)
+		}
+	}
+	return report, nil
+}
+
+// lock takes the postgres advisory lock of the reconciliation on a connection of its own, held until unlock is
+// called.  Other databases aren't shared by replicas, they aren't locked.
+func (r *Reconciler) lock(ctx context.Context) (func(), error) {
+	if r.DB.Dialector.Name() != "postgres" {
+		return func() {}, nil
+	}
+	sqlDB, err := r.DB.DB()
+	if err != nil {
+		return nil, err
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock the reconciliation: %w", err)
+	}
+
+	var locked bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", lockKey).Scan(&locked); err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("failed to lock the reconciliation: %w", err)
+	}
+	if !locked {
+		_ = conn.Close()
+		return nil, ErrInProgress
+	}
+
+	return func() {
+		if _, err := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", lockKey); err != nil {
+			r.Logger.Warnf("Failed to unlock the reconciliation, the lock is released with its connection: %v", err)
+		}
+		_ = conn.Close()
+	}, nil
+}
+
+// resourceType is a relations type and the reporter types whose resources have it.
+type resourceType struct {
+	namespace     string
+	name          string
+	reporterTypes []string
+}
+
+// resourceTypes returns the relations types of the resources reported with a local resource id, by namespace and
+// name.
+func (r *Reconciler) resourceTypes(ctx context.Context) ([]*resourceType, error) {
+	var rows []struct {
+		ResourceType string
+		ReporterType string
+	}
+	err := r.DB.WithContext(ctx).Model(&model.Resource{}).
+		Distinct("resource_type", "reporter_type").
+		Where("reporter_resource_id <> ''").
+		Order("resource_type, reporter_type").
+		Find(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the resource types: %w", err)
+	}
+
+	var types []*resourceType
+	byName := map[string]*resourceType{}
+	for _, row := range rows {
+		namespace, err := middleware.ResolveNamespace(row.ResourceType, row.ReporterType)
+		if err != nil {
+			return nil, err
+		}
+		key := namespace + "/" + row.ResourceType
+		t, ok := byName[key]
+		if !ok {
+			t = &resourceType{namespace: namespace, name: row.ResourceType}
+			byName[key] = t
+			types = append(types, t)
+		}
+		t.reporterTypes = append(t.reporterTypes, row.ReporterType)
+	}
+	return types, nil
+}
+
+// reconcileType pages through the resources of the type and its workspace tuples, both in the order of the resource
+// ids, and compares the resources and the tuples of the same id.
+func (r *Reconciler) reconcileType(ctx context.Context, t *resourceType, limiter *rate.Limiter, report *Report) error {
+	tuples := &tupleCursor{reconciler: r, resourceType: t, limiter: limiter, report: report}
+	resources := &resourceCursor{reconciler: r, resourceType: t}
+
+	tupleId, tupleWorkspaces, err := tuples.next(ctx)
+	if err != nil {
+		return err
+	}
+	resourceId, rows, err := resources.next(ctx)
+	if err != nil {
+		return err
+	}
+
+	for tupleId != "" || resourceId != "" {
+		switch {
+		case tupleId != "" && (resourceId == "" || tupleId < resourceId):
+			// the tuples of a resource deleted from the inventory database, or reported after the resources were
+			// read past its id
+			reported, err := r.reported(ctx, t, tupleId)
+			if err != nil {
+				return err
+			}
+			if !reported {
+				drift := Drift{
+					Kind:            Orphaned,
+					Namespace:       t.namespace,
+					ResourceType:    t.name,
+					ResourceId:      tupleId,
+					TupleWorkspaces: tupleWorkspaces,
+				}
+				if err := r.record(ctx, drift, limiter, report); err != nil {
+					return err
+				}
+			}
+			if tupleId, tupleWorkspaces, err = tuples.next(ctx); err != nil {
+				return err
+			}
+		case resourceId != "" && (tupleId == "" || resourceId < tupleId):
+			if err := r.compare(ctx, t, rows, nil, limiter, report); err != nil {
+				return err
+			}
+			if resourceId, rows, err = resources.next(ctx); err != nil {
+				return err
+			}
+		default:
+			if err := r.compare(ctx, t, rows, tupleWorkspaces, limiter, report); err != nil {
+				return err
+			}
+			if tupleId, tupleWorkspaces, err = tuples.next(ctx); err != nil {
+				return err
+			}
+			if resourceId, rows, err = resources.next(ctx); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// reported tells whether a resource of the type has the local resource id in the inventory database now.
+func (r *Reconciler) reported(ctx context.Context, t *resourceType, id string) (bool, error) {
+	var count int64
+	err := r.DB.WithContext(ctx).Model(&model.Resource{}).
+		Where("resource_type = ? AND reporter_type IN ? AND reporter_resource_id = ?", t.name, t.reporterTypes, id).
+		Limit(1).
+		Count(&count).Error
+	if err != nil {
+		return false, fmt.Errorf("failed to read the resource %s: %w", id, err)
+	}
+	return count > 0, nil
+}
+
+// compare records the drift of the resources of an id from the workspaces their tuples relate them to.
+func (r *Reconciler) compare(ctx context.Context, t *resourceType, resources []*model.Resource, workspaces []string, limiter *rate.Limiter, report *Report) error {
+	for _, resource := range resources {
+		report.Resources++
+		drift := Drift{
+			Namespace:       t.namespace,
+			ResourceType:    t.name,
+			ResourceId:      resource.ReporterResourceId,
+			Workspace:       resource.WorkspaceId,
+			TupleWorkspaces: workspaces,
+		}
+		switch {
+		case len(workspaces) == 0 && resource.WorkspaceId == "":
+			continue
+		case len(workspaces) == 0:
+			drift.Kind = Missing
+		case len(workspaces) == 1 && workspaces[0] == resource.WorkspaceId:
+			continue
+		default:
+			drift.Kind = Stale
+		}
+		if err := r.record(ctx, drift, limiter, report); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// tupleCursor reads the workspace tuples of a type a page at a time, in the order of their resource ids.
+type tupleCursor struct {
+	reconciler   *Reconciler
+	resourceType *resourceType
+	limiter      *rate.Limiter
+	report       *Report
+
+	page  []*kessel.Relationship
+	token string
+	done  bool
+	last  string
+}
+
+// next returns the next resource id and the workspaces its tuples relate it to, the id is empty once every tuple is
+// read.
+func (c *tupleCursor) next(ctx context.Context) (string, []string, error) {
+	tuple, err := c.peek(ctx)
+	if err != nil || tuple == nil {
+		return "", nil, err
+	}
+	id := tuple.GetResource().GetId()
+	if c.last != "" && id <= c.last {
+		return "", nil, fmt.Errorf("the workspace tuples are not read in the order of their resource ids: %q after %q", id, c.last)
+	}
+	c.last = id
+
+	var workspaces []string
+	for tuple != nil && tuple.GetResource().GetId() == id {
+		workspaces = append(workspaces, tuple.GetSubject().GetSubject().GetId())
+		c.page = c.page[1:]
+		if tuple, err = c.peek(ctx); err != nil {
+			return "", nil, err
+		}
+	}
+	sort.Strings(workspaces)
+	return id, workspaces, nil
+}
+
+// peek returns the next tuple without consuming it, reading the next page when needed.
+func (c *tupleCursor) peek(ctx context.Context) (*kessel.Relationship, error) {
+	if len(c.page) == 0 && !c.done {
+		if err := c.read(ctx); err != nil {
+			return nil, err
+		}
+	}
+	if len(c.page) == 0 {
+		return nil, nil
+	}
+	return c.page[0], nil
+}
+
+func (c *tupleCursor) read(ctx context.Context) error {
+	if err := c.limiter.Wait(ctx); err != nil {
+		return err
+	}
+	batchSize := c.reconciler.batchSize()
+	pagination := &kessel.RequestPagination{Limit: uint32(batchSize)}
+	if c.token != "" {
+		pagination.ContinuationToken = proto.String(c.token)
+	}
+	stream, err := c.reconciler.Authz.ReadTuples(ctx, &kessel.ReadTuplesRequest{
+		Filter:     workspaceFilter(c.resourceType.namespace, c.resourceType.name, nil, nil),
+		Pagination: pagination,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to read the workspace tuples: %w", err)
+	}
+
+	read := 0
+	for {
+		resp, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read the workspace tuples: %w", err)
+		}
+		read++
+		c.page = append(c.page, resp.GetTuple())
+		c.token = resp.GetPagination().GetContinuationToken()
+	}
+	c.report.Tuples += read
+	c.done = read < batchSize || c.token == ""
+	return nil
+}
+
+// resourceCursor reads the resources of a type a page at a time, in the order of their local resource ids.
+type resourceCursor struct {
+	reconciler   *Reconciler
+	resourceType *resourceType
+
+	page []*model.Resource
+	done bool
+	last *model.Resource
+}
+
+// next returns the next local resource id and its resources, one per reporter, the id is empty once every resource
+// is read.
+func (c *resourceCursor) next(ctx context.Context) (string, []*model.Resource, error) {
+	if err := c.fill(ctx); err != nil || len(c.page) == 0 {
+		return "", nil, err
+	}
+	id := c.page[0].ReporterResourceId
+
+	var resources []*model.Resource
+	for len(c.page) > 0 && c.page[0].ReporterResourceId == id {
+		resources = append(resources, c.page[0])
+		c.page = c.page[1:]
+		if err := c.fill(ctx); err != nil {
+			return "", nil, err
+		}
+	}
+	return id, resources, nil
+}
+
+// fill reads the page after the last resource read when the current one is consumed.
+func (c *resourceCursor) fill(ctx context.Context) error {
+	if len(c.page) > 0 || c.done {
+		return nil
+	}
+	db := c.reconciler.DB
+	column := storage.BinaryOrder(db, "reporter_resource_id")
+	query := db.WithContext(ctx).Model(&model.Resource{}).
+		Select("id", "reporter_resource_id", "workspace_id").
+		Where("resource_type = ? AND reporter_type IN ? AND reporter_resource_id <> ''", c.resourceType.name, c.resourceType.reporterTypes).
+		Order(column + ", id").
+		Limit(c.reconciler.batchSize())
+	if c.last != nil {
+		query = query.Where("("+column+" > ? OR (reporter_resource_id = ? AND id > ?))", c.last.ReporterResourceId, c.last.ReporterResourceId, c.last.ID)
+	}
+
+	var page []*model.Resource
+	if err := query.Find(&page).Error; err != nil {
+		return fmt.Errorf("failed to read the resources: %w", err)
+	}
+	c.done = len(page) < c.reconciler.batchSize()
+	if len(page) > 0 {
+		c.last = page[len(page)-1]
+	}
+	c.page = page
+	return nil
+}
+
+// record reports the drift, and repairs it when configured to.
+func (r *Reconciler) record(ctx context.Context, drift Drift, limiter *rate.Limiter, report *Report) error {
+	attrs := metric.WithAttributes(attribute.String("kind", string(drift.Kind)), attribute.String("resource_type", drift.Namespace+"/"+drift.ResourceType))
+	r.driftCounter.Add(context.Background(), 1, attrs)
+	r.Logger.Warnf("Resource %s/%s:%s is %s, workspace %q, workspace tuples %v",
+		drift.Namespace, drift.ResourceType, drift.ResourceId, drift.Kind, drift.Workspace, drift.TupleWorkspaces)
+
+	if r.Repair {
+		if err := r.repair(ctx, drift, limiter); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			r.Logger.Errorf("Failed to repair resource %s/%s:%s: %v", drift.Namespace, drift.ResourceType, drift.ResourceId, err)
+			r.repairFailureCounter.Add(context.Background(), 1, attrs)
+			drift.Error = err.Error()
+		} else {
+			r.repairedCounter.Add(context.Background(), 1, attrs)
+			drift.Repaired = true
+		}
+	}
+
+	report.Drifts = append(report.Drifts, drift)
+	return nil
+}
+
+// repair relates the resource to its workspace first, then deletes the tuples of other workspaces, so that the
+// resource stays in a workspace while it is repaired.  Repairs can be applied again, a resource changed meanwhile is
+// fixed by the next reconciliation.
+func (r *Reconciler) repair(ctx context.Context, drift Drift, limiter *rate.Limiter) error {
+	if drift.Kind == Orphaned {
+		if err := limiter.Wait(ctx); err != nil {
+			return err
+		}
+		_, err := r.Authz.UnsetWorkspace(ctx, drift.ResourceId, drift.Namespace, drift.ResourceType)
+		return err
+	}
+
+	if drift.Workspace != "" && !slices.Contains(drift.TupleWorkspaces, drift.Workspace) {
+		if err := limiter.Wait(ctx); err != nil {
+			return err
+		}
+		if _, err := r.Authz.SetWorkspace(ctx, drift.ResourceId, drift.Workspace, drift.Namespace, drift.ResourceType, true); err != nil {
+			return err
+		}
+	}
+	for _, workspace := range drift.TupleWorkspaces {
+		if workspace == drift.Workspace {
+			continue
+		}
+		if err := limiter.Wait(ctx); err != nil {
+			return err
+		}
+		_, err := r.Authz.DeleteTuples(ctx, &kessel.DeleteTuplesRequest{
+			Filter: workspaceFilter(drift.Namespace, drift.ResourceType, proto.String(drift.ResourceId), proto.String(workspace)),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Reconciler) batchSize() int {
+	if r.BatchSize > 0 {
+		return r.BatchSize
+	}
+	return NewOptions().BatchSize
+}
+
+// workspaceFilter matches the workspace tuples of the type, of a resource and of a workspace when they aren't nil.
+func workspaceFilter(namespace, name string, resourceId, workspace *string) *kessel.RelationTupleFilter {
+	return &kessel.RelationTupleFilter{
+		ResourceNamespace: proto.String(namespace),
+		ResourceType:      proto.String(name),
+		ResourceId:        resourceId,
+		Relation:          proto.String("workspace"),
+		SubjectFilter: &kessel.SubjectFilter{
+			SubjectNamespace: proto.String("rbac"),
+			SubjectType:      proto.String("workspace"),
+			SubjectId:        workspace,
+		},
+	}
+}
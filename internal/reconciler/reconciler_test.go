@@ -0,0 +1,215 @@
+package reconciler
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/google/uuid"
+	kessel "github.com/project-kessel/relations-api/api/kessel/relations/v1beta1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"github.com/project-kessel/inventory-api/internal/authz/local"
+	"github.com/project-kessel/inventory-api/internal/biz/model"
+	"github.com/project-kessel/inventory-api/internal/data"
+)
+
+func setupReconciler(t *testing.T, repair bool) (*Reconciler, *local.LocalAuthz) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, data.Migrate(db, log.NewHelper(log.DefaultLogger)))
+
+	authzConfig, errs := local.NewConfig(&local.Options{SchemaFile: "../../deploy/schema.zed"}).Complete()
+	require.Empty(t, errs)
+	authz, err := local.New(authzConfig, db, log.NewHelper(log.DefaultLogger))
+	require.NoError(t, err)
+
+	options := NewOptions()
+	options.Repair = repair
+	options.Rate = 0
+	// pages through the tuples and resources of the tests
+	options.BatchSize = 2
+	reconciler, err := New(NewConfig(options).Complete(), db, authz, log.NewHelper(log.DefaultLogger))
+	require.NoError(t, err)
+	return reconciler, authz
+}
+
+func createHost(t *testing.T, db *gorm.DB, id, workspace string) {
+	require.NoError(t, db.Create(&model.Resource{
+		ID:                 uuid.New(),
+		ResourceType:       "host",
+		ReporterType:       "HBI",
+		ReporterResourceId: id,
+		ReporterInstanceId: "hbi-1",
+		WorkspaceId:        workspace,
+	}).Error)
+}
+
+// setupDrift creates hosts in sync with their tuples, a host without tuple, a host moved to ws-2 still related to
+// ws-1 and the tuple of a deleted host.
+func setupDrift(t *testing.T, reconciler *Reconciler, authz *local.LocalAuthz) {
+	ctx := context.Background()
+	for _, id := range []string{"host-1", "host-2", "host-3"} {
+		createHost(t, reconciler.DB, id, "ws-1")
+		_, err := authz.SetWorkspace(ctx, id, "ws-1", "hbi", "host", true)
+		require.NoError(t, err)
+	}
+	createHost(t, reconciler.DB, "host-no-workspace", "")
+	createHost(t, reconciler.DB, "host-missing", "ws-1")
+	createHost(t, reconciler.DB, "host-moved", "ws-2")
+	for _, id := range []string{"host-moved", "host-deleted"} {
+		_, err := authz.SetWorkspace(ctx, id, "ws-1", "hbi", "host", true)
+		require.NoError(t, err)
+	}
+}
+
+func workspaces(t *testing.T, authz *local.LocalAuthz) map[string][]string {
+	stream, err := authz.ReadTuples(context.Background(), &kessel.ReadTuplesRequest{Filter: workspaceFilter("hbi", "host", nil, nil)})
+	require.NoError(t, err)
+
+	tuples := map[string][]string{}
+	for {
+		resp, err := stream.Recv()
+		if err == io.EOF {
+			return tuples
+		}
+		require.NoError(t, err)
+		id := resp.GetTuple().GetResource().GetId()
+		tuples[id] = append(tuples[id], resp.GetTuple().GetSubject().GetSubject().GetId())
+	}
+}
+
+func TestReconcile_ReportsDrift(t *testing.T) {
+	reconciler, authz := setupReconciler(t, false)
+	setupDrift(t, reconciler, authz)
+	before := workspaces(t, authz)
+
+	report, err := reconciler.Reconcile(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, 6, report.Resources)
+	assert.Equal(t, 5, report.Tuples)
+	// the resources are paged through in the order of their ids
+	assert.ElementsMatch(t, []Drift{
+		{Kind: Missing, Namespace: "hbi", ResourceType: "host", ResourceId: "host-missing", Workspace: "ws-1"},
+		{Kind: Stale, Namespace: "hbi", ResourceType: "host", ResourceId: "host-moved", Workspace: "ws-2", TupleWorkspaces: []string{"ws-1"}},
+		{Kind: Orphaned, Namespace: "hbi", ResourceType: "host", ResourceId: "host-deleted", TupleWorkspaces: []string{"ws-1"}},
+	}, report.Drifts)
+	assert.Equal(t, 0, report.Repaired())
+
+	// the drift is only reported
+	assert.Equal(t, before, workspaces(t, authz))
+}
+
+func TestReconcile_Repairs(t *testing.T) {
+	reconciler, authz := setupReconciler(t, true)
+	setupDrift(t, reconciler, authz)
+
+	report, err := reconciler.Reconcile(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, report.Drifts, 3)
+	assert.Equal(t, 3, report.Repaired())
+
+	assert.Equal(t, map[string][]string{
+		"host-1":       {"ws-1"},
+		"host-2":       {"ws-1"},
+		"host-3":       {"ws-1"},
+		"host-missing": {"ws-1"},
+		"host-moved":   {"ws-2"},
+	}, workspaces(t, authz))
+
+	report, err = reconciler.Reconcile(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, report.Drifts)
+}
+
+func TestReconcile_PagesThroughTuplesAndResourcesTogether(t *testing.T) {
+	reconciler, authz := setupReconciler(t, false)
+	ctx := context.Background()
+
+	// host-a is related to two workspaces, its tuples span two pages of tuples
+	createHost(t, reconciler.DB, "host-a", "ws-2")
+	for _, workspace := range []string{"ws-1", "ws-2"} {
+		_, err := authz.CreateTuples(ctx, &kessel.CreateTuplesRequest{Upsert: true, Tuples: []*kessel.Relationship{workspaceTuple("host-a", workspace)}})
+		require.NoError(t, err)
+	}
+	// host-b is reported by two instances of the reporter
+	createHost(t, reconciler.DB, "host-b", "ws-1")
+	require.NoError(t, reconciler.DB.Create(&model.Resource{
+		ID:                 uuid.New(),
+		ResourceType:       "host",
+		ReporterType:       "HBI",
+		ReporterResourceId: "host-b",
+		ReporterInstanceId: "hbi-2",
+		WorkspaceId:        "ws-1",
+	}).Error)
+	_, err := authz.SetWorkspace(ctx, "host-b", "ws-1", "hbi", "host", true)
+	require.NoError(t, err)
+	// host-c was deleted, host-d has no tuple
+	_, err = authz.SetWorkspace(ctx, "host-c", "ws-1", "hbi", "host", true)
+	require.NoError(t, err)
+	createHost(t, reconciler.DB, "host-d", "ws-1")
+
+	report, err := reconciler.Reconcile(ctx)
+	require.NoError(t, err)
+
+	assert.Equal(t, 4, report.Resources)
+	assert.Equal(t, 4, report.Tuples)
+	assert.Equal(t, []Drift{
+		{Kind: Stale, Namespace: "hbi", ResourceType: "host", ResourceId: "host-a", Workspace: "ws-2", TupleWorkspaces: []string{"ws-1", "ws-2"}},
+		{Kind: Orphaned, Namespace: "hbi", ResourceType: "host", ResourceId: "host-c", TupleWorkspaces: []string{"ws-1"}},
+		{Kind: Missing, Namespace: "hbi", ResourceType: "host", ResourceId: "host-d", Workspace: "ws-1"},
+	}, report.Drifts)
+}
+
+// reportingAuthz runs report before reading the second page of tuples
+type reportingAuthz struct {
+	*local.LocalAuthz
+	reads  int
+	report func()
+}
+
+func (a *reportingAuthz) ReadTuples(ctx context.Context, r *kessel.ReadTuplesRequest) (grpc.ServerStreamingClient[kessel.ReadTuplesResponse], error) {
+	a.reads++
+	if a.reads == 2 {
+		a.report()
+	}
+	return a.LocalAuthz.ReadTuples(ctx, r)
+}
+
+func TestReconcile_KeepsTheTuplesOfResourcesReportedMeanwhile(t *testing.T) {
+	reconciler, authz := setupReconciler(t, true)
+	ctx := context.Background()
+
+	createHost(t, reconciler.DB, "host-a", "ws-1")
+	createHost(t, reconciler.DB, "host-c", "ws-1")
+	for _, id := range []string{"host-a", "host-b", "host-c"} {
+		_, err := authz.SetWorkspace(ctx, id, "ws-1", "hbi", "host", true)
+		require.NoError(t, err)
+	}
+	// host-b is reported once the resources were read past its id, before its tuple is compared
+	reconciler.Authz = &reportingAuthz{LocalAuthz: authz, report: func() {
+		createHost(t, reconciler.DB, "host-b", "ws-1")
+	}}
+
+	report, err := reconciler.Reconcile(ctx)
+	require.NoError(t, err)
+
+	assert.Empty(t, report.Drifts)
+	assert.Equal(t, []string{"ws-1"}, workspaces(t, authz)["host-b"])
+}
+
+func workspaceTuple(id, workspace string) *kessel.Relationship {
+	return &kessel.Relationship{
+		Resource: &kessel.ObjectReference{Type: &kessel.ObjectType{Namespace: "hbi", Name: "host"}, Id: id},
+		Relation: "workspace",
+		Subject: &kessel.SubjectReference{
+			Subject: &kessel.ObjectReference{Type: &kessel.ObjectType{Namespace: "rbac", Name: "workspace"}, Id: workspace},
+		},
+	}
+}
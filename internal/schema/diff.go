@@ -0,0 +1,364 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+type Severity string
+
+const (
+	Compatible Severity = "compatible"
+	Breaking   Severity = "breaking"
+)
+
+// Change is a single difference between two schema trees.
+type Change struct {
+	Severity Severity `json:"severity"`
+	// Location is the resource type, optionally followed by the reporter type and the schema (common or resource_data)
+	Location string `json:"location"`
+	// Path is the JSON pointer of the changed field inside the schema, empty for config changes
+	Path    string `json:"path,omitempty"`
+	Message string `json:"message"`
+}
+
+func (c Change) String() string {
+	if c.Path == "" {
+		return fmt.Sprintf("[%s] %s: %s", c.Severity, c.Location, c.Message)
+	}
+	return fmt.Sprintf("[%s] %s %s: %s", c.Severity, c.Location, c.Path, c.Message)
+}
+
+// Report lists the changes found by Diff.
+type Report struct {
+	Changes []Change `json:"changes"`
+}
+
+// Breaking returns the changes that break existing reporters or stored data.
+func (r *Report) Breaking() []Change {
+	var breaking []Change
+	for _, change := range r.Changes {
+		if change.Severity == Breaking {
+			breaking = append(breaking, change)
+		}
+	}
+	return breaking
+}
+
+func (r *Report) add(severity Severity, location, path, format string, args ...interface{}) {
+	r.Changes = append(r.Changes, Change{
+		Severity: severity,
+		Location: location,
+		Path:     path,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// Diff compares the resource types, allowed reporters and JSON schemas of two schema trees and classifies every change.
+func Diff(old, new *Tree) (*Report, error) {
+	report := &Report{}
+
+	for _, resourceType := range sortedKeys(old.Resources, new.Resources) {
+		oldResource, inOld := old.Resources[resourceType]
+		newResource, inNew := new.Resources[resourceType]
+
+		switch {
+		case !inNew:
+			report.add(Breaking, resourceType, "", "resource type removed")
+		case !inOld:
+			report.add(Compatible, resourceType, "", "resource type added")
+		default:
+			if err := diffResource(report, oldResource, newResource); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return report, nil
+}
+
+func diffResource(report *Report, old, new *ResourceSchema) error {
+	for _, reporter := range old.Reporters {
+		if !containsFold(new.Reporters, reporter) {
+			report.add(Breaking, old.ResourceType, "", "reporter %s removed", reporter)
+		}
+	}
+	for _, reporter := range new.Reporters {
+		if !containsFold(old.Reporters, reporter) {
+			report.add(Compatible, old.ResourceType, "", "reporter %s added", reporter)
+		}
+	}
+
+	location := fmt.Sprintf("%s common", old.ResourceType)
+	if err := diffSchemaDocuments(report, location, old.CommonSchema, new.CommonSchema); err != nil {
+		return err
+	}
+
+	for _, reporter := range sortedKeys(old.ReporterSchemas, new.ReporterSchemas) {
+		location := fmt.Sprintf("%s:%s resource_data", old.ResourceType, reporter)
+		if err := diffSchemaDocuments(report, location, old.ReporterSchemas[reporter], new.ReporterSchemas[reporter]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func diffSchemaDocuments(report *Report, location, old, new string) error {
+	switch {
+	case old == "" && new == "":
+		return nil
+	case new == "":
+		report.add(Breaking, location, "", "schema removed")
+		return nil
+	case old == "":
+		report.add(Compatible, location, "", "schema added")
+		return nil
+	}
+
+	oldSchema, err := parseSchema(old)
+	if err != nil {
+		return fmt.Errorf("failed to parse old schema of %s: %w", location, err)
+	}
+	newSchema, err := parseSchema(new)
+	if err != nil {
+		return fmt.Errorf("failed to parse new schema of %s: %w", location, err)
+	}
+
+	diffSchemaNode(report, location, "", oldSchema, newSchema)
+	return nil
+}
+
+func parseSchema(schema string) (map[string]interface{}, error) {
+	var node map[string]interface{}
+	if err := json.Unmarshal([]byte(schema), &node); err != nil {
+		return nil, err
+	}
+	return node, nil
+}
+
+var (
+	// lowerBoundKeywords are tightened when their value increases
+	lowerBoundKeywords = []string{"minimum", "exclusiveMinimum", "minLength", "minItems", "minProperties"}
+	// upperBoundKeywords are tightened when their value decreases
+	upperBoundKeywords = []string{"maximum", "exclusiveMaximum", "maxLength", "maxItems", "maxProperties"}
+	// exactKeywords are tightened by any change of their value
+	exactKeywords = []string{"pattern", "format", "const"}
+)
+
+func diffSchemaNode(report *Report, location, path string, old, new map[string]interface{}) {
+	diffTypes(report, location, path, old, new)
+	diffEnum(report, location, path, old, new)
+
+	for _, keyword := range lowerBoundKeywords {
+		diffBound(report, location, path, keyword, old, new, func(o, n float64) bool { return n > o })
+	}
+	for _, keyword := range upperBoundKeywords {
+		diffBound(report, location, path, keyword, old, new, func(o, n float64) bool { return n < o })
+	}
+	for _, keyword := range exactKeywords {
+		oldValue, inOld := old[keyword]
+		newValue, inNew := new[keyword]
+		switch {
+		case inOld && !inNew:
+			report.add(Compatible, location, pointer(path), "%s removed", keyword)
+		case !inOld && inNew:
+			report.add(Breaking, location, pointer(path), "%s %v added", keyword, newValue)
+		case inOld && fmt.Sprint(oldValue) != fmt.Sprint(newValue):
+			report.add(Breaking, location, pointer(path), "%s changed from %v to %v", keyword, oldValue, newValue)
+		}
+	}
+
+	diffProperties(report, location, path, old, new)
+
+	oldItems, oldOk := old["items"].(map[string]interface{})
+	newItems, newOk := new["items"].(map[string]interface{})
+	if oldOk && newOk {
+		diffSchemaNode(report, location, path+"/items", oldItems, newItems)
+	}
+}
+
+func diffTypes(report *Report, location, path string, old, new map[string]interface{}) {
+	oldTypes := stringList(old["type"])
+	newTypes := stringList(new["type"])
+
+	switch {
+	case len(oldTypes) == 0 && len(newTypes) == 0:
+	case len(newTypes) == 0:
+		report.add(Compatible, location, pointer(path), "type constraint removed")
+	case len(oldTypes) == 0:
+		report.add(Breaking, location, pointer(path), "type constraint %s added", strings.Join(newTypes, "|"))
+	default:
+		for _, t := range oldTypes {
+			if !slices.Contains(newTypes, t) {
+				report.add(Breaking, location, pointer(path), "type changed from %s to %s", strings.Join(oldTypes, "|"), strings.Join(newTypes, "|"))
+				return
+			}
+		}
+		if len(newTypes) > len(oldTypes) {
+			report.add(Compatible, location, pointer(path), "type widened from %s to %s", strings.Join(oldTypes, "|"), strings.Join(newTypes, "|"))
+		}
+	}
+}
+
+func diffEnum(report *Report, location, path string, old, new map[string]interface{}) {
+	oldEnum, inOld := old["enum"].([]interface{})
+	newEnum, inNew := new["enum"].([]interface{})
+
+	switch {
+	case !inOld && !inNew:
+		return
+	case !inNew:
+		report.add(Compatible, location, pointer(path), "enum removed")
+		return
+	case !inOld:
+		report.add(Breaking, location, pointer(path), "enum added")
+		return
+	}
+
+	oldValues := stringList(oldEnum)
+	newValues := stringList(newEnum)
+
+	var removed, added []string
+	for _, value := range oldValues {
+		if !slices.Contains(newValues, value) {
+			removed = append(removed, value)
+		}
+	}
+	for _, value := range newValues {
+		if !slices.Contains(oldValues, value) {
+			added = append(added, value)
+		}
+	}
+
+	if len(removed) > 0 {
+		report.add(Breaking, location, pointer(path), "enum narrowed, removed %s", strings.Join(removed, ", "))
+	}
+	if len(added) > 0 {
+		report.add(Compatible, location, pointer(path), "enum widened, added %s", strings.Join(added, ", "))
+	}
+}
+
+func diffBound(report *Report, location, path, keyword string, old, new map[string]interface{}, tightened func(old, new float64) bool) {
+	oldValue, inOld := old[keyword].(float64)
+	newValue, inNew := new[keyword].(float64)
+
+	switch {
+	case !inOld && !inNew:
+	case !inNew:
+		report.add(Compatible, location, pointer(path), "%s removed", keyword)
+	case !inOld:
+		report.add(Breaking, location, pointer(path), "%s %v added", keyword, newValue)
+	case tightened(oldValue, newValue):
+		report.add(Breaking, location, pointer(path), "%s tightened from %v to %v", keyword, oldValue, newValue)
+	case oldValue != newValue:
+		report.add(Compatible, location, pointer(path), "%s relaxed from %v to %v", keyword, oldValue, newValue)
+	}
+}
+
+func diffProperties(report *Report, location, path string, old, new map[string]interface{}) {
+	oldProperties, _ := old["properties"].(map[string]interface{})
+	newProperties, _ := new["properties"].(map[string]interface{})
+	oldRequired := stringList(old["required"])
+	newRequired := stringList(new["required"])
+
+	oldClosed := old["additionalProperties"] == false
+	newClosed := new["additionalProperties"] == false
+	switch {
+	case !oldClosed && newClosed:
+		report.add(Breaking, location, pointer(path), "additional properties no longer allowed")
+	case oldClosed && !newClosed:
+		report.add(Compatible, location, pointer(path), "additional properties allowed")
+	}
+
+	for _, name := range sortedKeys(oldProperties, newProperties) {
+		fieldPath := path + "/" + name
+		oldField, inOld := oldProperties[name]
+		newField, inNew := newProperties[name]
+
+		switch {
+		case !inNew:
+			if newClosed {
+				report.add(Breaking, location, fieldPath, "field removed")
+			} else {
+				report.add(Compatible, location, fieldPath, "field removed, it is no longer validated")
+			}
+		case !inOld:
+			if slices.Contains(newRequired, name) {
+				report.add(Breaking, location, fieldPath, "required field added")
+			} else {
+				report.add(Compatible, location, fieldPath, "optional field added")
+			}
+		default:
+			oldNode, oldOk := oldField.(map[string]interface{})
+			newNode, newOk := newField.(map[string]interface{})
+			if oldOk && newOk {
+				diffSchemaNode(report, location, fieldPath, oldNode, newNode)
+			}
+		}
+	}
+
+	for _, name := range newRequired {
+		if _, inNew := newProperties[name]; inNew && oldProperties[name] == nil {
+			// already reported as a new required field
+			continue
+		}
+		if !slices.Contains(oldRequired, name) {
+			report.add(Breaking, location, path+"/"+name, "field became required")
+		}
+	}
+	for _, name := range oldRequired {
+		if !slices.Contains(newRequired, name) {
+			report.add(Compatible, location, path+"/"+name, "field is no longer required")
+		}
+	}
+}
+
+func pointer(path string) string {
+	if path == "" {
+		return "/"
+	}
+	return path
+}
+
+func stringList(value interface{}) []string {
+	switch v := value.(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			values = append(values, fmt.Sprint(item))
+		}
+		return values
+	default:
+		return nil
+	}
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedKeys[V any](maps ...map[string]V) []string {
+	seen := map[string]bool{}
+	var keys []string
+	for _, m := range maps {
+		for key := range m {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,186 @@
+package schema
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const clusterSchema = `{
+  "$schema": "http://json-schema.org/draft-07/schema#",
+  "type": "object",
+  "properties": {
+    "external_cluster_id": { "type": "string" },
+    "cluster_status": { "type": "string", "enum": ["READY", "FAILED", "OFFLINE"] },
+    "kube_version": { "type": "string", "maxLength": 20 },
+    "nodes": {
+      "type": "array",
+      "items": {
+        "type": "object",
+        "properties": { "name": { "type": "string" } }
+      }
+    }
+  },
+  "required": ["external_cluster_id"]
+}`
+
+func resourceTree(reporters []string, schema string) *Tree {
+	return &Tree{Resources: map[string]*ResourceSchema{
+		"k8s_cluster": {
+			ResourceType:    "k8s_cluster",
+			Reporters:       reporters,
+			CommonSchema:    `{"type": "object", "properties": {"workspace_id": {"type": "string"}}, "required": ["workspace_id"]}`,
+			ReporterSchemas: map[string]string{"acm": schema},
+		},
+	}}
+}
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name      string
+		reporters []string
+		schema    string
+		expected  []Change
+	}{
+		{
+			name:      "no changes",
+			reporters: []string{"ACM", "OCM"},
+			schema:    clusterSchema,
+		},
+		{
+			name:      "reporter added and removed",
+			reporters: []string{"ACM", "ACS"},
+			schema:    clusterSchema,
+			expected: []Change{
+				{Severity: Breaking, Location: "k8s_cluster", Message: "reporter OCM removed"},
+				{Severity: Compatible, Location: "k8s_cluster", Message: "reporter ACS added"},
+			},
+		},
+		{
+			name:      "optional and required fields added",
+			reporters: []string{"ACM", "OCM"},
+			schema: `{
+  "type": "object",
+  "properties": {
+    "external_cluster_id": { "type": "string" },
+    "cluster_status": { "type": "string", "enum": ["READY", "FAILED", "OFFLINE"] },
+    "kube_version": { "type": "string", "maxLength": 20 },
+    "nodes": { "type": "array", "items": { "type": "object", "properties": { "name": { "type": "string" } } } },
+    "region": { "type": "string" },
+    "zone": { "type": "string" }
+  },
+  "required": ["external_cluster_id", "zone"]
+}`,
+			expected: []Change{
+				{Severity: Compatible, Location: "k8s_cluster:acm resource_data", Path: "/region", Message: "optional field added"},
+				{Severity: Breaking, Location: "k8s_cluster:acm resource_data", Path: "/zone", Message: "required field added"},
+			},
+		},
+		{
+			name:      "narrowed enum, tightened bound, changed type and existing field required",
+			reporters: []string{"ACM", "OCM"},
+			schema: `{
+  "type": "object",
+  "properties": {
+    "external_cluster_id": { "type": "string" },
+    "cluster_status": { "type": "string", "enum": ["READY", "FAILED", "UNKNOWN"] },
+    "kube_version": { "type": "string", "maxLength": 10 },
+    "nodes": { "type": "array", "items": { "type": "object", "properties": { "name": { "type": "integer" } } } }
+  },
+  "required": ["external_cluster_id", "kube_version"]
+}`,
+			expected: []Change{
+				{Severity: Breaking, Location: "k8s_cluster:acm resource_data", Path: "/cluster_status", Message: "enum narrowed, removed OFFLINE"},
+				{Severity: Compatible, Location: "k8s_cluster:acm resource_data", Path: "/cluster_status", Message: "enum widened, added UNKNOWN"},
+				{Severity: Breaking, Location: "k8s_cluster:acm resource_data", Path: "/kube_version", Message: "maxLength tightened from 20 to 10"},
+				{Severity: Breaking, Location: "k8s_cluster:acm resource_data", Path: "/nodes/items/name", Message: "type changed from string to integer"},
+				{Severity: Breaking, Location: "k8s_cluster:acm resource_data", Path: "/kube_version", Message: "field became required"},
+			},
+		},
+		{
+			name:      "relaxed schema",
+			reporters: []string{"ACM", "OCM"},
+			schema: `{
+  "type": "object",
+  "properties": {
+    "external_cluster_id": { "type": ["string", "null"] },
+    "cluster_status": { "type": "string" },
+    "kube_version": { "type": "string", "maxLength": 40 },
+    "nodes": { "type": "array", "items": { "type": "object", "properties": { "name": { "type": "string" } } } }
+  }
+}`,
+			expected: []Change{
+				{Severity: Compatible, Location: "k8s_cluster:acm resource_data", Path: "/cluster_status", Message: "enum removed"},
+				{Severity: Compatible, Location: "k8s_cluster:acm resource_data", Path: "/external_cluster_id", Message: "type widened from string to string|null"},
+				{Severity: Compatible, Location: "k8s_cluster:acm resource_data", Path: "/kube_version", Message: "maxLength relaxed from 20 to 40"},
+				{Severity: Compatible, Location: "k8s_cluster:acm resource_data", Path: "/external_cluster_id", Message: "field is no longer required"},
+			},
+		},
+		{
+			name:      "schema removed",
+			reporters: []string{"ACM", "OCM"},
+			schema:    "",
+			expected: []Change{
+				{Severity: Breaking, Location: "k8s_cluster:acm resource_data", Message: "schema removed"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			old := resourceTree([]string{"ACM", "OCM"}, clusterSchema)
+			new := resourceTree(tt.reporters, tt.schema)
+			if tt.schema == "" {
+				delete(new.Resources["k8s_cluster"].ReporterSchemas, "acm")
+			}
+
+			report, err := Diff(old, new)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, report.Changes)
+		})
+	}
+}
+
+func TestDiff_ResourceTypes(t *testing.T) {
+	old := resourceTree([]string{"ACM"}, clusterSchema)
+	new := &Tree{Resources: map[string]*ResourceSchema{
+		"host": {ResourceType: "host", Reporters: []string{"HBI"}},
+	}}
+
+	report, err := Diff(old, new)
+	assert.NoError(t, err)
+	assert.Equal(t, []Change{
+		{Severity: Compatible, Location: "host", Message: "resource type added"},
+		{Severity: Breaking, Location: "k8s_cluster", Message: "resource type removed"},
+	}, report.Changes)
+	assert.Len(t, report.Breaking(), 1)
+}
+
+func TestLoadTree(t *testing.T) {
+	root, err := os.Getwd()
+	assert.NoError(t, err)
+
+	tree, err := LoadTree(filepath.Join(root, "..", "..", "data", "schema", "resources"))
+	assert.NoError(t, err)
+
+	assert.Contains(t, tree.Resources, "notifications_integration")
+	cluster := tree.Resources["k8s_cluster"]
+	assert.Equal(t, []string{"ACM", "ACS", "OCM"}, cluster.Reporters)
+	assert.Contains(t, cluster.ReporterSchemas, "acm")
+	assert.NotEmpty(t, cluster.CommonSchema)
+
+	report, err := Diff(tree, tree)
+	assert.NoError(t, err)
+	assert.Empty(t, report.Changes)
+}
+
+func TestValidateResourceData(t *testing.T) {
+	tree := resourceTree([]string{"ACM"}, clusterSchema)
+
+	assert.NoError(t, tree.ValidateResourceData("k8s_cluster", "ACM", "workspace-1", map[string]interface{}{"external_cluster_id": "abc"}))
+	assert.ErrorContains(t, tree.ValidateResourceData("k8s_cluster", "ACM", "workspace-1", map[string]interface{}{"cluster_status": "READY"}), "external_cluster_id is required")
+	assert.ErrorContains(t, tree.ValidateResourceData("k8s_cluster", "OCM", "workspace-1", nil), "invalid reporter_type: OCM")
+	assert.ErrorContains(t, tree.ValidateResourceData("host", "HBI", "workspace-1", nil), "unknown resource_type: host")
+}
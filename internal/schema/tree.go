@@ -0,0 +1,141 @@
+package schema
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/project-kessel/inventory-api/internal/middleware"
+)
+
+// Tree is the content of a resources schema directory (e.g. data/schema/resources).
+type Tree struct {
+	Resources map[string]*ResourceSchema
+}
+
+// ResourceSchema holds the config and schemas of a single resource type.
+type ResourceSchema struct {
+	ResourceType string
+	// Reporters allowed to report the resource type, as declared in its config.yaml
+	Reporters []string
+	// CommonSchema is the common_resource_data.json schema, empty when absent
+	CommonSchema string
+	// ReporterSchemas maps the lower-cased reporter type to its resource_data schema
+	ReporterSchemas map[string]string
+}
+
+// LoadTree reads every resource type found in the given schema directory.
+func LoadTree(dir string) (*Tree, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema directory: %w", err)
+	}
+
+	tree := &Tree{Resources: map[string]*ResourceSchema{}}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		resource, err := loadResourceSchema(filepath.Join(dir, entry.Name()), entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		tree.Resources[resource.ResourceType] = resource
+	}
+
+	return tree, nil
+}
+
+func loadResourceSchema(dir string, name string) (*ResourceSchema, error) {
+	var config struct {
+		ResourceType      string   `yaml:"resource_type"`
+		ResourceReporters []string `yaml:"resource_reporters"`
+	}
+
+	configData, err := os.ReadFile(filepath.Join(dir, "config.yaml"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file for '%s': %w", name, err)
+	}
+	if err := yaml.Unmarshal(configData, &config); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal config for '%s': %w", name, err)
+	}
+
+	resource := &ResourceSchema{
+		ResourceType:    middleware.NormalizeResourceType(config.ResourceType),
+		Reporters:       config.ResourceReporters,
+		ReporterSchemas: map[string]string{},
+	}
+	if resource.ResourceType == "" {
+		resource.ResourceType = middleware.NormalizeResourceType(name)
+	}
+
+	if data, err := os.ReadFile(filepath.Join(dir, "common_resource_data.json")); err == nil {
+		resource.CommonSchema = string(data)
+	} else if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read common resource schema for '%s': %w", name, err)
+	}
+
+	reporterDirs, err := os.ReadDir(filepath.Join(dir, "reporters"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return resource, nil
+		}
+		return nil, fmt.Errorf("failed to read reporters directory for '%s': %w", name, err)
+	}
+
+	for _, reporter := range reporterDirs {
+		if !reporter.IsDir() {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, "reporters", reporter.Name(), fmt.Sprintf("%s.json", name)))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to read schema file for '%s:%s': %w", name, reporter.Name(), err)
+		}
+		resource.ReporterSchemas[strings.ToLower(reporter.Name())] = string(data)
+	}
+
+	return resource, nil
+}
+
+// ValidateResourceData validates stored resource data against the schemas of the tree, following the
+// same rules the Validation middleware applies to reported resources.
+func (t *Tree) ValidateResourceData(resourceType, reporterType, workspaceId string, resourceData map[string]interface{}) error {
+	resource, ok := t.Resources[middleware.NormalizeResourceType(resourceType)]
+	if !ok {
+		return fmt.Errorf("unknown resource_type: %s", resourceType)
+	}
+
+	if !containsFold(resource.Reporters, reporterType) {
+		return fmt.Errorf("invalid reporter_type: %s for resource_type: %s", reporterType, resourceType)
+	}
+
+	if resource.CommonSchema != "" {
+		if err := middleware.ValidateJSONSchema(resource.CommonSchema, map[string]interface{}{"workspace_id": workspaceId}); err != nil {
+			return fmt.Errorf("commonResourceData validation failed: %w", err)
+		}
+	}
+
+	schema, ok := resource.ReporterSchemas[strings.ToLower(reporterType)]
+	if !ok {
+		if len(resourceData) > 0 {
+			return fmt.Errorf("no schema found for '%s:%s', but 'resourceData' is stored", resourceType, strings.ToLower(reporterType))
+		}
+		return nil
+	}
+
+	if len(resourceData) == 0 {
+		return nil
+	}
+
+	if err := middleware.ValidateJSONSchema(schema, resourceData); err != nil {
+		return fmt.Errorf("resourceData validation failed: %w", err)
+	}
+	return nil
+}
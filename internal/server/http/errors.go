@@ -0,0 +1,60 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	nethttp "net/http"
+
+	"github.com/go-kratos/kratos/v2/encoding"
+	kerrors "github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/transport/http"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	m "github.com/project-kessel/inventory-api/internal/middleware"
+)
+
+// ErrorEncoder encodes errors like the kratos default encoder, adding the google.rpc.BadRequest details of
+// validation errors to the body, the same way they are attached to the gRPC status.
+func ErrorEncoder(w nethttp.ResponseWriter, r *nethttp.Request, err error) {
+	var validationErr *m.ValidationError
+	codec, _ := http.CodecForRequest(r, "Accept")
+	if !errors.As(err, &validationErr) || codec.Name() != "json" {
+		http.DefaultErrorEncoder(w, r, err)
+		return
+	}
+
+	se := kerrors.FromError(validationErr)
+	body, err := validationErrorBody(codec, se, validationErr)
+	if err != nil {
+		w.WriteHeader(nethttp.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(int(se.Code))
+	_, _ = w.Write(body)
+}
+
+func validationErrorBody(codec encoding.Codec, se *kerrors.Error, validationErr *m.ValidationError) ([]byte, error) {
+	statusBody, err := codec.Marshal(se)
+	if err != nil {
+		return nil, err
+	}
+
+	var body map[string]json.RawMessage
+	if err := json.Unmarshal(statusBody, &body); err != nil {
+		return nil, err
+	}
+
+	badRequest, err := anypb.New(validationErr.BadRequest())
+	if err != nil {
+		return nil, err
+	}
+	details, err := protojson.Marshal(badRequest)
+	if err != nil {
+		return nil, err
+	}
+	body["details"] = json.RawMessage("[" + string(details) + "]")
+
+	return json.Marshal(body)
+}
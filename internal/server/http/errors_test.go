@@ -0,0 +1,63 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	nethttp "net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bufbuild/protovalidate-go"
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/stretchr/testify/assert"
+
+	pbv1beta2 "github.com/project-kessel/inventory-api/api/kessel/inventory/v1beta2"
+	m "github.com/project-kessel/inventory-api/internal/middleware"
+)
+
+func TestErrorEncoder_ValidationError(t *testing.T) {
+	validator, err := protovalidate.New()
+	assert.NoError(t, err)
+
+	handler := m.Validation(validator)(func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, nil
+	})
+	_, err = handler(context.Background(), &pbv1beta2.DeleteResourceRequest{ReporterType: "HBI"})
+	assert.Error(t, err)
+
+	w := httptest.NewRecorder()
+	ErrorEncoder(w, httptest.NewRequest(nethttp.MethodDelete, "/api/inventory/v1beta2/resources", nil), err)
+
+	assert.Equal(t, nethttp.StatusBadRequest, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+
+	var body struct {
+		Code    int    `json:"code"`
+		Reason  string `json:"reason"`
+		Details []struct {
+			Type            string `json:"@type"`
+			FieldViolations []struct {
+				Field       string `json:"field"`
+				Reason      string `json:"reason"`
+				Description string `json:"description"`
+			} `json:"fieldViolations"`
+		} `json:"details"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+
+	assert.Equal(t, 400, body.Code)
+	assert.Equal(t, "VALIDATOR", body.Reason)
+	assert.Len(t, body.Details, 1)
+	assert.Equal(t, "type.googleapis.com/google.rpc.BadRequest", body.Details[0].Type)
+	assert.Len(t, body.Details[0].FieldViolations, 1)
+	assert.Equal(t, "/localResourceId", body.Details[0].FieldViolations[0].Field)
+	assert.Equal(t, "string.min_len", body.Details[0].FieldViolations[0].Reason)
+}
+
+func TestErrorEncoder_OtherErrors(t *testing.T) {
+	w := httptest.NewRecorder()
+	ErrorEncoder(w, httptest.NewRequest(nethttp.MethodGet, "/", nil), errors.NotFound("NOT_FOUND", "resource not found"))
+
+	assert.Equal(t, nethttp.StatusNotFound, w.Code)
+	assert.NotContains(t, w.Body.String(), "details")
+}
@@ -43,6 +43,7 @@ func New(c CompletedConfig, authn middleware.Middleware, meter metric.Meter, log
 				authn,
 			).Match(NewWhiteListMatcher).Build(),
 		),
+		http.ErrorEncoder(ErrorEncoder),
 	}
 	opts = append(opts, c.ServerOptions...)
 	srv := http.NewServer(opts...)
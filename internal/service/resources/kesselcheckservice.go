@@ -72,12 +72,21 @@ func (s *KesselCheckServiceServiceV1beta2) Check(ctx context.Context, req *pbv1b
 		return nil, err
 	}
 
+	namespace, err := middleware.ResolveNamespace(req.Object.GetResourceType(), req.Object.GetReporter().GetType())
+	if err != nil {
+		return nil, err
+	}
+	subjectNamespace, err := middleware.ResolveNamespace(req.GetSubject().Resource.GetResourceType(), req.GetSubject().Resource.GetReporter().GetType())
+	if err != nil {
+		return nil, err
+	}
+
 	if resource, err := authzFromRequestV1beta2(identity, req.Object); err == nil {
-		if resp, err := s.Ctl.Check(ctx, req.GetRelation(), req.Object.Reporter.GetType(), &v1beta1.SubjectReference{
+		if resp, err := s.Ctl.Check(ctx, req.GetRelation(), namespace, &v1beta1.SubjectReference{
 			Relation: req.GetSubject().Relation,
 			Subject: &v1beta1.ObjectReference{
 				Type: &v1beta1.ObjectType{
-					Namespace: req.GetSubject().Resource.GetReporter().GetType(),
+					Namespace: subjectNamespace,
 					Name:      req.GetSubject().Resource.GetResourceType(),
 				},
 				Id: req.GetSubject().Resource.GetResourceId(),
@@ -124,12 +133,21 @@ func (s *KesselCheckServiceServiceV1beta2) CheckForUpdate(ctx context.Context, r
 		return nil, err
 	}
 
+	namespace, err := middleware.ResolveNamespace(req.Object.GetResourceType(), req.Object.GetReporter().GetType())
+	if err != nil {
+		return nil, err
+	}
+	subjectNamespace, err := middleware.ResolveNamespace(req.GetSubject().Resource.GetResourceType(), req.GetSubject().Resource.GetReporter().GetType())
+	if err != nil {
+		return nil, err
+	}
+
 	if resource, err := authzFromRequestV1beta2(identity, req.Object); err == nil {
-		if resp, err := s.Ctl.CheckForUpdate(ctx, req.GetRelation(), req.Object.Reporter.GetType(), &v1beta1.SubjectReference{
+		if resp, err := s.Ctl.CheckForUpdate(ctx, req.GetRelation(), namespace, &v1beta1.SubjectReference{
 			Relation: req.GetSubject().Relation,
 			Subject: &v1beta1.ObjectReference{
 				Type: &v1beta1.ObjectType{
-					Namespace: req.GetSubject().Resource.GetReporter().GetType(),
+					Namespace: subjectNamespace,
 					Name:      req.GetSubject().Resource.GetResourceType(),
 				},
 				Id: req.GetSubject().Resource.GetResourceId(),
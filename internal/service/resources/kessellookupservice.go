@@ -4,6 +4,7 @@ import (
 	"fmt"
 	pbv1beta2 "github.com/project-kessel/inventory-api/api/kessel/inventory/v1beta2"
 	"github.com/project-kessel/inventory-api/internal/biz/resources"
+	"github.com/project-kessel/inventory-api/internal/middleware"
 	kessel "github.com/project-kessel/relations-api/api/kessel/relations/v1beta1"
 	"io"
 )
@@ -24,7 +25,12 @@ func (s *KesselLookupService) LookupResources(
 	stream pbv1beta2.KesselStreamedListService_StreamedListObjectsServer,
 ) error {
 	ctx := stream.Context()
-	clientStream, err := s.Ctl.LookupResources(ctx, toLookupResourceRequest(req))
+	lookupRequest, err := toLookupResourceRequest(req)
+	if err != nil {
+		return err
+	}
+
+	clientStream, err := s.Ctl.LookupResources(ctx, lookupRequest)
 	if err != nil {
 		return fmt.Errorf("failed to retrieve resources: %w", err)
 	}
@@ -41,15 +47,54 @@ func (s *KesselLookupService) LookupResources(
 		}
 
 		// Convert and send the response to the client
-		if err := stream.Send(toLookupResourceResponse(resp)); err != nil {
+		if err := stream.Send(toLookupResourceResponse(resp, req.ObjectType.GetReporterType())); err != nil {
 			return fmt.Errorf("error sending resource to client: %w", err)
 		}
 	}
 }
 
-func toLookupResourceRequest(request *pbv1beta2.StreamedListObjectsRequest) *kessel.LookupResourcesRequest {
+// StreamedListSubjects streams the subjects having the relation to the resource, e.g. who can view a cluster.
+func (s *KesselLookupService) StreamedListSubjects(
+	req *pbv1beta2.StreamedListSubjectsRequest,
+	stream pbv1beta2.KesselStreamedListService_StreamedListSubjectsServer,
+) error {
+	ctx := stream.Context()
+	lookupRequest, err := toLookupSubjectsRequest(req)
+	if err != nil {
+		return err
+	}
+
+	clientStream, err := s.Ctl.LookupSubjects(ctx, lookupRequest)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve subjects: %w", err)
+	}
+
+	for {
+		resp, err := clientStream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("error receiving subject: %w", err)
+		}
+
+		if err := stream.Send(toLookupSubjectsResponse(resp, req.SubjectType.GetReporterType())); err != nil {
+			return fmt.Errorf("error sending subject to client: %w", err)
+		}
+	}
+}
+
+func toLookupResourceRequest(request *pbv1beta2.StreamedListObjectsRequest) (*kessel.LookupResourcesRequest, error) {
 	if request == nil {
-		return nil
+		return nil, nil
+	}
+	namespace, err := middleware.ResolveNamespace(request.ObjectType.GetResourceType(), request.ObjectType.GetReporterType())
+	if err != nil {
+		return nil, err
+	}
+	subjectNamespace, err := middleware.ResolveNamespace(request.Subject.Resource.GetResourceType(), request.Subject.Resource.GetReporter().GetType())
+	if err != nil {
+		return nil, err
 	}
 	var pagination *kessel.RequestPagination
 	if request.Pagination != nil {
@@ -60,7 +105,7 @@ func toLookupResourceRequest(request *pbv1beta2.StreamedListObjectsRequest) *kes
 	}
 	return &kessel.LookupResourcesRequest{
 		ResourceType: &kessel.ObjectType{
-			Namespace: request.ObjectType.GetReporterType(),
+			Namespace: namespace,
 			Name:      request.ObjectType.GetResourceType(),
 		},
 		Relation: request.Relation,
@@ -69,20 +114,22 @@ func toLookupResourceRequest(request *pbv1beta2.StreamedListObjectsRequest) *kes
 			Subject: &kessel.ObjectReference{
 				Type: &kessel.ObjectType{
 					Name:      request.Subject.Resource.GetResourceType(),
-					Namespace: request.Subject.Resource.GetReporter().GetType(),
+					Namespace: subjectNamespace,
 				},
 				Id: request.Subject.Resource.GetResourceId(),
 			},
 		},
 		Pagination: pagination,
-	}
+	}, nil
 }
 
-func toLookupResourceResponse(response *kessel.LookupResourcesResponse) *pbv1beta2.StreamedListObjectsResponse {
+// toLookupResourceResponse reports the resources with the reporter type of the request, as their relations
+// namespace can differ from it.
+func toLookupResourceResponse(response *kessel.LookupResourcesResponse, reporterType string) *pbv1beta2.StreamedListObjectsResponse {
 	return &pbv1beta2.StreamedListObjectsResponse{
 		Object: &pbv1beta2.ResourceReference{
 			Reporter: &pbv1beta2.ReporterReference{
-				Type: response.Resource.Type.Namespace,
+				Type: reporterType,
 			},
 			ResourceId: response.Resource.Id,
 		},
@@ -91,3 +138,79 @@ func toLookupResourceResponse(response *kessel.LookupResourcesResponse) *pbv1bet
 		},
 	}
 }
+
+func toLookupSubjectsRequest(request *pbv1beta2.StreamedListSubjectsRequest) (*kessel.LookupSubjectsRequest, error) {
+	if request == nil {
+		return nil, nil
+	}
+	namespace, err := middleware.ResolveNamespace(request.Resource.GetResourceType(), request.Resource.GetReporter().GetType())
+	if err != nil {
+		return nil, err
+	}
+	subjectNamespace, err := middleware.ResolveNamespace(request.SubjectType.GetResourceType(), request.SubjectType.GetReporterType())
+	if err != nil {
+		return nil, err
+	}
+	var pagination *kessel.RequestPagination
+	if request.Pagination != nil {
+		pagination = &kessel.RequestPagination{
+			Limit:             request.Pagination.Limit,
+			ContinuationToken: request.Pagination.ContinuationToken,
+		}
+	}
+	return &kessel.LookupSubjectsRequest{
+		Resource: &kessel.ObjectReference{
+			Type: &kessel.ObjectType{
+				Namespace: namespace,
+				Name:      request.Resource.GetResourceType(),
+			},
+			Id: request.Resource.GetResourceId(),
+		},
+		Relation: request.Relation,
+		SubjectType: &kessel.ObjectType{
+			Namespace: subjectNamespace,
+			Name:      request.SubjectType.GetResourceType(),
+		},
+		SubjectRelation: request.SubjectRelation,
+		Pagination:      pagination,
+		Consistency:     toConsistency(request.Consistency),
+	}, nil
+}
+
+// toConsistency returns the relations consistency of a request, nil lets relations-api pick it
+func toConsistency(consistency *pbv1beta2.Consistency) *kessel.Consistency {
+	if token := consistency.GetAtLeastAsFresh(); token != nil {
+		return &kessel.Consistency{Requirement: &kessel.Consistency_AtLeastAsFresh{
+			AtLeastAsFresh: &kessel.ConsistencyToken{Token: token.GetToken()},
+		}}
+	}
+	if consistency.GetMinimizeLatency() {
+		return &kessel.Consistency{Requirement: &kessel.Consistency_MinimizeLatency{MinimizeLatency: true}}
+	}
+	return nil
+}
+
+// toLookupSubjectsResponse reports the subjects with the reporter type of the request, as their relations namespace
+// can differ from it.
+func toLookupSubjectsResponse(response *kessel.LookupSubjectsResponse, reporterType string) *pbv1beta2.StreamedListSubjectsResponse {
+	subject := response.GetSubject()
+	resp := &pbv1beta2.StreamedListSubjectsResponse{
+		Subject: &pbv1beta2.SubjectReference{
+			Relation: subject.Relation,
+			Resource: &pbv1beta2.ResourceReference{
+				ResourceType: subject.GetSubject().GetType().GetName(),
+				ResourceId:   subject.GetSubject().GetId(),
+			},
+		},
+		Pagination: &pbv1beta2.ResponsePagination{
+			ContinuationToken: response.GetPagination().GetContinuationToken(),
+		},
+	}
+	if reporterType != "" {
+		resp.Subject.Resource.Reporter = &pbv1beta2.ReporterReference{Type: reporterType}
+	}
+	if token := response.GetConsistencyToken(); token != nil {
+		resp.ConsistencyToken = &pbv1beta2.ConsistencyToken{Token: token.GetToken()}
+	}
+	return resp
+}
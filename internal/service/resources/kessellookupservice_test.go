@@ -0,0 +1,178 @@
+package resources
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	kessel "github.com/project-kessel/relations-api/api/kessel/relations/v1beta1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	pbv1beta2 "github.com/project-kessel/inventory-api/api/kessel/inventory/v1beta2"
+	"github.com/project-kessel/inventory-api/internal/middleware"
+)
+
+// preloadNamespacedCluster loads a namespaced_cluster type whose ACM reporter maps to the kubernetes namespace
+func preloadNamespacedCluster(t *testing.T) {
+	schemaDir := t.TempDir()
+	files := map[string]string{
+		"namespaced_cluster/config.yaml":               "resource_type: namespaced_cluster\nresource_reporters:\n  - ACM\n",
+		"namespaced_cluster/reporters/acm/config.yaml": "resource_type: namespaced_cluster\nreporter_name: acm\nnamespace: kubernetes\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(schemaDir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+	require.NoError(t, middleware.PreloadAllSchemasFromFilesystem(schemaDir))
+}
+
+func TestToConsistency(t *testing.T) {
+	tests := []struct {
+		name        string
+		consistency *pbv1beta2.Consistency
+		expected    *kessel.Consistency
+	}{
+		{
+			name:        "unset",
+			consistency: nil,
+			expected:    nil,
+		},
+		{
+			name: "at least as fresh",
+			consistency: &pbv1beta2.Consistency{Requirement: &pbv1beta2.Consistency_AtLeastAsFresh{
+				AtLeastAsFresh: &pbv1beta2.ConsistencyToken{Token: "token-1"},
+			}},
+			expected: &kessel.Consistency{Requirement: &kessel.Consistency_AtLeastAsFresh{
+				AtLeastAsFresh: &kessel.ConsistencyToken{Token: "token-1"},
+			}},
+		},
+		{
+			name:        "minimize latency",
+			consistency: &pbv1beta2.Consistency{Requirement: &pbv1beta2.Consistency_MinimizeLatency{MinimizeLatency: true}},
+			expected:    &kessel.Consistency{Requirement: &kessel.Consistency_MinimizeLatency{MinimizeLatency: true}},
+		},
+		{
+			name:        "minimize latency off",
+			consistency: &pbv1beta2.Consistency{Requirement: &pbv1beta2.Consistency_MinimizeLatency{MinimizeLatency: false}},
+			expected:    nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.True(t, proto.Equal(tt.expected, toConsistency(tt.consistency)), "got %v", toConsistency(tt.consistency))
+		})
+	}
+}
+
+func TestToLookupSubjectsRequest(t *testing.T) {
+	preloadNamespacedCluster(t)
+
+	request := &pbv1beta2.StreamedListSubjectsRequest{
+		Resource: &pbv1beta2.ResourceReference{
+			ResourceType: "namespaced_cluster",
+			ResourceId:   "cluster-1",
+			Reporter:     &pbv1beta2.ReporterReference{Type: "ACM"},
+		},
+		Relation:        "view",
+		SubjectType:     &pbv1beta2.RepresentationType{ResourceType: "principal", ReporterType: proto.String("RBAC")},
+		SubjectRelation: proto.String("member"),
+		Pagination:      &pbv1beta2.RequestPagination{Limit: 10, ContinuationToken: proto.String("page-2")},
+		Consistency: &pbv1beta2.Consistency{Requirement: &pbv1beta2.Consistency_AtLeastAsFresh{
+			AtLeastAsFresh: &pbv1beta2.ConsistencyToken{Token: "token-1"},
+		}},
+	}
+
+	lookup, err := toLookupSubjectsRequest(request)
+	require.NoError(t, err)
+
+	expected := &kessel.LookupSubjectsRequest{
+		Resource: &kessel.ObjectReference{
+			Type: &kessel.ObjectType{Namespace: "kubernetes", Name: "namespaced_cluster"},
+			Id:   "cluster-1",
+		},
+		Relation:        "view",
+		SubjectType:     &kessel.ObjectType{Namespace: "rbac", Name: "principal"},
+		SubjectRelation: proto.String("member"),
+		Pagination:      &kessel.RequestPagination{Limit: 10, ContinuationToken: proto.String("page-2")},
+		Consistency: &kessel.Consistency{Requirement: &kessel.Consistency_AtLeastAsFresh{
+			AtLeastAsFresh: &kessel.ConsistencyToken{Token: "token-1"},
+		}},
+	}
+	assert.True(t, proto.Equal(expected, lookup), "got %v", lookup)
+}
+
+func TestToLookupSubjectsRequest_NoPaginationOrConsistency(t *testing.T) {
+	request := &pbv1beta2.StreamedListSubjectsRequest{
+		Resource: &pbv1beta2.ResourceReference{
+			ResourceType: "host",
+			ResourceId:   "host-1",
+			Reporter:     &pbv1beta2.ReporterReference{Type: "HBI"},
+		},
+		Relation:    "view",
+		SubjectType: &pbv1beta2.RepresentationType{ResourceType: "principal", ReporterType: proto.String("RBAC")},
+	}
+
+	lookup, err := toLookupSubjectsRequest(request)
+	require.NoError(t, err)
+	assert.Equal(t, "hbi", lookup.GetResource().GetType().GetNamespace())
+	assert.Nil(t, lookup.Pagination)
+	assert.Nil(t, lookup.Consistency)
+	assert.Nil(t, lookup.SubjectRelation)
+}
+
+func TestToLookupSubjectsRequest_Nil(t *testing.T) {
+	lookup, err := toLookupSubjectsRequest(nil)
+	assert.NoError(t, err)
+	assert.Nil(t, lookup)
+}
+
+func TestToLookupSubjectsResponse(t *testing.T) {
+	response := &kessel.LookupSubjectsResponse{
+		Subject: &kessel.SubjectReference{
+			Relation: proto.String("member"),
+			Subject: &kessel.ObjectReference{
+				Type: &kessel.ObjectType{Namespace: "rbac", Name: "principal"},
+				Id:   "alice",
+			},
+		},
+		Pagination:       &kessel.ResponsePagination{ContinuationToken: "page-3"},
+		ConsistencyToken: &kessel.ConsistencyToken{Token: "token-2"},
+	}
+
+	expected := &pbv1beta2.StreamedListSubjectsResponse{
+		Subject: &pbv1beta2.SubjectReference{
+			Relation: proto.String("member"),
+			Resource: &pbv1beta2.ResourceReference{
+				ResourceType: "principal",
+				ResourceId:   "alice",
+				Reporter:     &pbv1beta2.ReporterReference{Type: "RBAC"},
+			},
+		},
+		Pagination:       &pbv1beta2.ResponsePagination{ContinuationToken: "page-3"},
+		ConsistencyToken: &pbv1beta2.ConsistencyToken{Token: "token-2"},
+	}
+	got := toLookupSubjectsResponse(response, "RBAC")
+	assert.True(t, proto.Equal(expected, got), "got %v", got)
+}
+
+func TestToLookupSubjectsResponse_NoReporterOrToken(t *testing.T) {
+	response := &kessel.LookupSubjectsResponse{
+		Subject: &kessel.SubjectReference{
+			Subject: &kessel.ObjectReference{
+				Type: &kessel.ObjectType{Namespace: "rbac", Name: "principal"},
+				Id:   "alice",
+			},
+		},
+		Pagination: &kessel.ResponsePagination{},
+	}
+
+	got := toLookupSubjectsResponse(response, "")
+	assert.Nil(t, got.GetSubject().GetResource().Reporter)
+	assert.Nil(t, got.ConsistencyToken)
+	assert.Nil(t, got.GetSubject().Relation)
+	assert.Equal(t, "alice", got.GetSubject().GetResource().GetResourceId())
+}
@@ -38,3 +38,12 @@ func New(c CompletedConfig, logger *log.Helper) (*gorm.DB, error) {
 
 	return db, nil
 }
+
+// BinaryOrder returns the column to order by the bytes of its values, the order of the ids in relations-api.  Postgres
+// orders text by the collation of the database, SQLite by its bytes already.
+func BinaryOrder(db *gorm.DB, column string) string {
+	if db.Dialector.Name() == "postgres" {
+		return column + ` COLLATE "C"`
+	}
+	return column
+}
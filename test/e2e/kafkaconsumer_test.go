@@ -23,16 +23,16 @@ const inventoryEventSchema = `{
 			"enum": ["1.0"]
 		},
 		"type": {
-			"description": "We use a string comprised of redhat.inventory.(resources|resources_relationship).{resource_type}.(created|updated|deleted)",
+			"description": "We use a string comprised of redhat.inventory.(resources|resources_relationship).{resource_type}.(created|updated|deleted).{version}",
 			"type": "string",
-			"pattern": "^redhat\\.inventory\\.(resources|resources_relationship)\\.[a-zA-Z0-9_-]+\\.(created|updated|deleted)$",
+			"pattern": "^redhat\\.inventory\\.(resources|resources_relationship)\\.[a-zA-Z0-9_-]+\\.(created|updated|deleted)\\.v1$",
 			"examples": [
-				"redhat.inventory.resources.k8s_cluster.created",
-				"redhat.inventory.resources.k8s_cluster.updated",
-				"redhat.inventory.resources.k8s_cluster.deleted",
-				"redhat.inventory.resources_relationship.k8spolicy_ispropagatedto_k8scluster.created",
-				"redhat.inventory.resources_relationship.k8spolicy_ispropagatedto_k8scluster.updated",
-				"redhat.inventory.resources_relationship.k8spolicy_ispropagatedto_k8scluster.deleted"
+				"redhat.inventory.resources.k8s_cluster.created.v1",
+				"redhat.inventory.resources.k8s_cluster.updated.v1",
+				"redhat.inventory.resources.k8s_cluster.deleted.v1",
+				"redhat.inventory.resources_relationship.k8spolicy_ispropagatedto_k8scluster.created.v1",
+				"redhat.inventory.resources_relationship.k8spolicy_ispropagatedto_k8scluster.updated.v1",
+				"redhat.inventory.resources_relationship.k8spolicy_ispropagatedto_k8scluster.deleted.v1"
 			]
 		},
 		"source": {